@@ -68,7 +68,13 @@ type SimulatedBackend struct {
 // NewSimulatedBackendWithDatabase creates a new binding backend based on the given database
 // and uses a simulated blockchain for testing purposes.
 func NewSimulatedBackendWithDatabase(database ethdb.Database, alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBackend {
-	genesis := core.Genesis{Config: params.AllScryptProtocolChanges, GasLimit: gasLimit, Alloc: alloc}
+	return NewSimulatedBackendWithConfig(database, params.AllScryptProtocolChanges, alloc, gasLimit)
+}
+
+// NewSimulatedBackendWithConfig creates a new binding backend of the given chain config, so that
+// more than one simulated chain with different chain ids can be run together.
+func NewSimulatedBackendWithConfig(database ethdb.Database, config *params.ChainConfig, alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBackend {
+	genesis := core.Genesis{Config: config, GasLimit: gasLimit, Alloc: alloc}
 	genesis.MustCommit(database)
 	blockchain, _ := core.NewBlockChain(database, nil, genesis.Config, ethash.NewFaker(), vm.Config{}, nil)
 
@@ -40,6 +40,7 @@ const (
 	MimetypeTypedData         = "data/typed"
 	MimetypeClique            = "application/x-clique-header"
 	MimetypeDPoS              = "application/x-dpos-header"
+	MimetypeCrossTransaction  = "application/x-cross-transaction"
 	MimetypeTextPlain         = "text/plain"
 )
 
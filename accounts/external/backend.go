@@ -26,6 +26,7 @@ import (
 	"github.com/simplechain-org/go-simplechain/common"
 	"github.com/simplechain-org/go-simplechain/common/hexutil"
 	"github.com/simplechain-org/go-simplechain/core/types"
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
 	"github.com/simplechain-org/go-simplechain/event"
 	"github.com/simplechain-org/go-simplechain/internal/ethapi"
 	"github.com/simplechain-org/go-simplechain/log"
@@ -196,6 +197,7 @@ func (api *ExternalSigner) SignTx(account accounts.Account, tx *types.Transactio
 		GasPrice: hexutil.Big(*tx.GasPrice()),
 		To:       to,
 		From:     common.NewMixedcaseAddress(account.Address),
+		ChainID:  (*hexutil.Big)(chainID),
 	}
 	if err := api.client.Call(&res, "account_signTransaction", args); err != nil {
 		return nil, err
@@ -203,6 +205,28 @@ func (api *ExternalSigner) SignTx(account accounts.Account, tx *types.Transactio
 	return res.Tx, nil
 }
 
+// SignCrossTransaction signs the hash of a cross chain transaction made in chain chainID by the anchor account
+func (api *ExternalSigner) SignCrossTransaction(account accounts.Account, ctx *cc.CrossTransaction, chainID *big.Int) ([]byte, error) {
+	var res hexutil.Bytes
+	var signAddress = common.NewMixedcaseAddress(account.Address)
+	args := &core.SendCtxArgs{
+		CtxId:            ctx.ID(),
+		TxHash:           ctx.Data.TxHash,
+		BlockHash:        ctx.Data.BlockHash,
+		From:             common.NewMixedcaseAddress(ctx.Data.From),
+		To:               common.NewMixedcaseAddress(ctx.Data.To),
+		Value:            hexutil.Big(*ctx.Data.Value),
+		ChainId:          hexutil.Big(*chainID),
+		DestinationId:    hexutil.Big(*ctx.Data.DestinationId),
+		DestinationValue: hexutil.Big(*ctx.Data.DestinationValue),
+		Input:            ctx.Data.Input,
+	}
+	if err := api.client.Call(&res, "account_signCrossTransaction", &signAddress, args); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
 func (api *ExternalSigner) SignTextWithPassphrase(account accounts.Account, passphrase string, text []byte) ([]byte, error) {
 	return []byte{}, fmt.Errorf("password-operations not supported on external signers")
 }
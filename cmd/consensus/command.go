@@ -29,7 +29,7 @@ var dposCommand = cli.Command{
 			Action: func(ctx *cli.Context) error {
 				return generate(DPOS, int(ctx.Uint(nFlag.Name)), ctx.StringSlice(ipFlag.Name),
 					ctx.IntSlice(portFlag.Name), ctx.IntSlice(discportFlag.Name), ctx.IntSlice(raftportFlag.Name),
-					ctx.String(nodeDirFlag.Name), ctx.String(genesisFlag.Name))
+					ctx.String(nodeDirFlag.Name), ctx.String(genesisFlag.Name), false, "")
 			},
 		},
 	},
@@ -48,7 +48,7 @@ var raftCommand = cli.Command{
 			Action: func(ctx *cli.Context) error {
 				return generate(RAFT, int(ctx.Uint(nFlag.Name)), ctx.StringSlice(ipFlag.Name),
 					ctx.IntSlice(portFlag.Name), ctx.IntSlice(discportFlag.Name), ctx.IntSlice(raftportFlag.Name),
-					ctx.String(nodeDirFlag.Name), ctx.String(genesisFlag.Name))
+					ctx.String(nodeDirFlag.Name), ctx.String(genesisFlag.Name), false, "")
 			},
 		},
 	},
@@ -63,11 +63,13 @@ var pbftCommand = cli.Command{
 			Usage: "generate pbft init file & static-nodes",
 			Flags: []cli.Flag{
 				nFlag, ipFlag, portFlag, discportFlag, raftportFlag, nodeDirFlag, genesisFlag,
+				validatorContractFlag, adminFlag,
 			},
 			Action: func(ctx *cli.Context) error {
 				return generate(PBFT, int(ctx.Uint(nFlag.Name)), ctx.StringSlice(ipFlag.Name),
 					ctx.IntSlice(portFlag.Name), ctx.IntSlice(discportFlag.Name), ctx.IntSlice(raftportFlag.Name),
-					ctx.String(nodeDirFlag.Name), ctx.String(genesisFlag.Name))
+					ctx.String(nodeDirFlag.Name), ctx.String(genesisFlag.Name),
+					ctx.Bool(validatorContractFlag.Name), ctx.String(adminFlag.Name))
 			},
 		},
 		{
@@ -131,4 +133,14 @@ var (
 		Usage: "genesis file path",
 		Value: "genesis_raft.json",
 	}
+
+	validatorContractFlag = cli.BoolFlag{
+		Name:  "validatorcontract",
+		Usage: "preallocate the validator management contract in genesis",
+	}
+
+	adminFlag = cli.StringFlag{
+		Name:  "admin",
+		Usage: "admin address of the validator management contract (default = the first validator)",
+	}
 )
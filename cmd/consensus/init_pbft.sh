@@ -8,16 +8,18 @@ ips=()
 ports=()
 discports=()
 validators=()
+contract=
 
 function usage() {
   echo ""
   echo "Usage:"
-  echo "    $0 [--numNodes numberOfNodes --ip ipList --port portList"
+  echo "    $0 [--numNodes numberOfNodes --ip ipList --port portList --validatorContract"
   echo ""
   echo "Where:"
   echo "    numberOfNodes is the number of nodes to initialise (default = $numNodes)"
   echo "    ip is the ipList of nodes (default = 127.0.0.1 127.0.0.1 127.0.0.1 ...)"
   echo "    port is the portList of nodes (default = 21001 21002 21003 ...)"
+  echo "    validatorContract preallocates the validator management contract in genesis"
   echo ""
   exit 0
 }
@@ -68,6 +70,10 @@ while (("$#")); do
     done
     shift 1
     ;;
+  --validatorContract)
+    contract="--validatorcontract"
+    shift 1
+    ;;
   --validator)
     for i in $(seq 1 "${numNodes}"); do
       shift 1
@@ -107,7 +113,7 @@ echo "$numNodes" >${dir}/numberOfNodes
 
 go build
 
-cmd="./consensus pbft generate --nodedir=${dir}/nodekey --n=${numNodes} --genesis=genesis_pbft.json"
+cmd="./consensus pbft generate --nodedir=${dir}/nodekey --n=${numNodes} --genesis=genesis_pbft.json ${contract}"
 
 for i in $(seq 1 "${numNodes}"); do
   cmd="${cmd} --ip=${ips[i]} --port=${ports[i]}"
@@ -15,6 +15,7 @@ import (
 	"github.com/simplechain-org/go-simplechain/accounts"
 	"github.com/simplechain-org/go-simplechain/accounts/keystore"
 	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/consensus/istanbul/validator"
 	"github.com/simplechain-org/go-simplechain/core"
 	"github.com/simplechain-org/go-simplechain/core/types"
 	"github.com/simplechain-org/go-simplechain/crypto"
@@ -43,9 +44,9 @@ func main() {
 	}
 }
 
-func generate(consensus ConsensusType, n int, ips []string, ports []int, discports []int, raftports []int, nodeDir, genesis string) (e error) {
+func generate(consensus ConsensusType, n int, ips []string, ports []int, discports []int, raftports []int, nodeDir, genesis string, validatorContract bool, admin string) (e error) {
 	fmt.Println("generate nodekey", "consensus", consensus, "n", n, "ips", ips, "ports", ports,
-		"discports", discports, "raftports", raftports, "nodedir", nodeDir, "genesis", genesis)
+		"discports", discports, "raftports", raftports, "nodedir", nodeDir, "genesis", genesis, "validatorcontract", validatorContract)
 
 	switch consensus {
 	case RAFT:
@@ -119,7 +120,7 @@ func generate(consensus ConsensusType, n int, ips []string, ports []int, discpor
 		return err
 	}
 
-	if err := writeGenesis(consensus, accs, nodeDir, genesis); err != nil {
+	if err := writeGenesis(consensus, accs, nodeDir, genesis, validatorContract, admin); err != nil {
 		return err
 	}
 
@@ -200,7 +201,7 @@ func writeFile(dir, name string, content []byte) error {
 	return file.Close()
 }
 
-func writeGenesis(consensus ConsensusType, addresses []accounts.Account, dir, file string) error {
+func writeGenesis(consensus ConsensusType, addresses []accounts.Account, dir, file string, validatorContract bool, admin string) error {
 	gf, err := os.Open(file)
 	if err != nil {
 		return fmt.Errorf("open genesis file failed, %s", err.Error())
@@ -229,6 +230,11 @@ func writeGenesis(consensus ConsensusType, addresses []accounts.Account, dir, fi
 	case PBFT:
 		GenesisFile = GenesisPbftFile
 		genesis.ExtraData, err = makeIstanbulExtra(addresses)
+		if validatorContract {
+			if err := makeValidatorContract(&genesis, addresses, admin); err != nil {
+				return err
+			}
+		}
 	case DPOS:
 		GenesisFile = GenesisDPoSFile
 		makeDPoSSigners(&genesis, addresses)
@@ -273,6 +279,41 @@ func makeDPoSSigners(genesis *core.Genesis, accs []accounts.Account) {
 	}
 }
 
+// makeValidatorContract preallocates the validator management contract with the validators of accs,
+// the contract is managed by admin or the first validator if admin is empty
+func makeValidatorContract(genesis *core.Genesis, accs []accounts.Account, admin string) error {
+	if genesis.Config == nil || genesis.Config.Istanbul == nil {
+		return fmt.Errorf("istanbul config is required for validator contract")
+	}
+	if len(accs) == 0 {
+		return fmt.Errorf("validator contract requires at least 1 validator")
+	}
+	owner := accs[0].Address
+	if admin != "" {
+		if !common.IsHexAddress(admin) {
+			return fmt.Errorf("invalid admin address %s", admin)
+		}
+		owner = common.HexToAddress(admin)
+	}
+	if genesis.Config.Istanbul.ValidatorContract == nil {
+		address := validator.DefaultContractAddress
+		genesis.Config.Istanbul.ValidatorContract = &address
+	}
+	validators := make([]common.Address, 0, len(accs))
+	for _, acc := range accs {
+		validators = append(validators, acc.Address)
+	}
+	if genesis.Alloc == nil {
+		genesis.Alloc = make(core.GenesisAlloc)
+	}
+	genesis.Alloc[*genesis.Config.Istanbul.ValidatorContract] = core.GenesisAccount{
+		Code:    validator.ContractCode,
+		Storage: validator.ContractStorage(owner, validators),
+		Balance: new(big.Int),
+	}
+	return nil
+}
+
 func makeIstanbulExtra(accs []accounts.Account) ([]byte, error) {
 	var buf bytes.Buffer
 	var extra []byte
@@ -156,6 +156,18 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 			cfg.Eth.CrossConfig.SubContract = address
 		}
 	}
+	if ctx.GlobalIsSet(utils.AnchorExpireFlag.Name) {
+		cfg.Eth.CrossConfig.ExpireNumber = ctx.GlobalUint64(utils.AnchorExpireFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.AnchorBLSKeyFlag.Name) {
+		cfg.Eth.CrossConfig.BLSKey = ctx.GlobalString(utils.AnchorBLSKeyFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.AnchorStoreFlag.Name) {
+		cfg.Eth.CrossConfig.StoreEngine = ctx.GlobalString(utils.AnchorStoreFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.AnchorClaimPeriodFlag.Name) {
+		cfg.Eth.CrossConfig.ClaimPeriod = ctx.GlobalUint64(utils.AnchorClaimPeriodFlag.Name)
+	}
 
 	return stack, cfg
 }
@@ -221,6 +233,7 @@ func RegisterRaftService(stack *node.Node, ctx *cli.Context, cfg gethConfig, sub
 	joinExistingId := ctx.GlobalInt(utils.RaftJoinExistingFlag.Name)
 
 	raftPort := uint16(ctx.GlobalInt(utils.RaftPortFlag.Name))
+	autoPromote := ctx.GlobalBool(utils.RaftAutoPromoteFlag.Name)
 
 	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 		privkey := cfg.Node.NodeKey()
@@ -256,7 +269,7 @@ func RegisterRaftService(stack *node.Node, ctx *cli.Context, cfg gethConfig, sub
 		}
 
 		ethereum := <-subChan
-		return raftBackend.New(ctx, myId, raftPort, joinExisting, ethereum, peers, datadir)
+		return raftBackend.New(ctx, myId, raftPort, joinExisting, autoPromote, ethereum, peers, datadir)
 	}); err != nil {
 		utils.Fatalf("Failed to register the Raft service: %v", err)
 	}
@@ -0,0 +1,199 @@
+// Copyright 2016 The go-simplechain Authors
+// This file is part of go-simplechain.
+//
+// go-simplechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-simplechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-simplechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/simplechain-org/go-simplechain/cmd/utils"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/cross"
+	"github.com/simplechain-org/go-simplechain/cross/audit"
+	crossBackend "github.com/simplechain-org/go-simplechain/cross/backend"
+	cdb "github.com/simplechain-org/go-simplechain/cross/database"
+	"github.com/simplechain-org/go-simplechain/cross/trigger/simpletrigger"
+	"github.com/simplechain-org/go-simplechain/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	auditDiffFlag = cli.StringFlag{
+		Name:  "diff",
+		Usage: "File to write the machine-readable diff (JSON)",
+	}
+	auditPlanFlag = cli.StringFlag{
+		Name:  "plan",
+		Usage: "File to write the fix plan (JSON)",
+	}
+	auditDepthFlag = cli.Uint64Flag{
+		Name:  "depth",
+		Usage: "Number of blocks to confirm a cross contract log",
+		Value: uint64(simpletrigger.DefaultConfirmDepth),
+	}
+
+	crossCommand = cli.Command{
+		Name:      "cross",
+		Usage:     "Manage cross chain stores",
+		ArgsUsage: "",
+		Category:  "CROSS CHAIN COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:      "audit",
+				Usage:     "Reconcile cross stores with the cross contract logs",
+				ArgsUsage: " ",
+				Action:    utils.MigrateFlags(auditCross),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					configFileFlag,
+					utils.ContractMainFlag,
+					utils.ContractSubFlag,
+					auditDiffFlag,
+					auditPlanFlag,
+					auditDepthFlag,
+				},
+				Description: `
+    sipe cross audit [--diff diff.json] [--plan plan.json]
+
+opens the crossdata, chaindata and subchaindata of a stopped node, replays
+MakerTx, TakerTx, TakerPartial, MakerFinish, message and cancel logs of the cross contracts, and
+reports every ctx whose stored status, block number, data or signatures
+disagree with the chain. Pairs with a remote chain followed over RPC are
+skipped. The diff and the fix plan are written as JSON if requested.`,
+			},
+			{
+				Name:      "migrate",
+				Usage:     "Copy cross stores from storm to leveldb",
+				ArgsUsage: " ",
+				Action:    utils.MigrateFlags(migrateCross),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					configFileFlag,
+				},
+				Description: `
+    sipe cross migrate
+
+copies ctxs and evidences of every chain and pair namespace in the storm
+store of a stopped node to the leveldb store. Ctxs which are already copied
+are kept, so an interrupted migration can be run again. Start the node with
+--anchor.store leveldb to use the migrated store.`,
+			},
+		},
+	}
+)
+
+func auditCross(ctx *cli.Context) error {
+	stack, cfg := makeConfigNode(ctx)
+	defer stack.Close()
+
+	depth := ctx.Uint64(auditDepthFlag.Name)
+	crossCfg := cfg.Eth.CrossConfig.Sanitize()
+
+	mainDb, err := stack.OpenDatabaseWithFreezer(common.MainchainData, 0, 0, "", "")
+	if err != nil {
+		utils.Fatalf("Failed to open main chain database: %v", err)
+	}
+	defer mainDb.Close()
+	subDb, err := stack.OpenDatabaseWithFreezer(common.SubchainData, 0, 0, "", "")
+	if err != nil {
+		utils.Fatalf("Failed to open sub chain database: %v", err)
+	}
+	defer subDb.Close()
+
+	store, err := crossBackend.NewCrossStore(stack, crossCfg.StoreEngine, crossBackend.StoreName(crossCfg.StoreEngine))
+	if err != nil {
+		utils.Fatalf("Failed to open cross store, is the node stopped? %v", err)
+	}
+	defer store.Close()
+
+	mainChain, err := audit.Replay(mainDb, crossCfg.MainContract, depth)
+	if err != nil {
+		utils.Fatalf("Failed to replay main chain: %v", err)
+	}
+	subChainID, err := audit.ReadChainID(subDb)
+	if err != nil {
+		utils.Fatalf("Failed to read sub chain: %v", err)
+	}
+
+	report := new(audit.Report)
+	for _, remote := range crossCfg.RemoteChains(subChainID.Uint64()) {
+		if remote.ChainID != subChainID.Uint64() {
+			log.Warn("Skip cross chain without local database", "chainID", remote.ChainID)
+			continue
+		}
+		subChain, err := audit.Replay(subDb, remote.Contract, depth)
+		if err != nil {
+			utils.Fatalf("Failed to replay sub chain: %v", err)
+		}
+		pairStore := store.Namespace(crossBackend.StoreNamespace(remote.ChainID, crossCfg.LegacyPair(remote)))
+		mainStore, _ := pairStore.GetStore(mainChain.ChainID)
+		subStore, _ := pairStore.GetStore(subChain.ChainID)
+		anchors := crossCfg.PairConfig(remote).Anchors
+
+		report.Merge(audit.Audit(mainStore, mainChain, subChain, anchors))
+		report.Merge(audit.Audit(subStore, subChain, mainChain, anchors))
+	}
+
+	for _, issue := range report.Issues {
+		fmt.Println(issue)
+	}
+	fmt.Printf("checked %d ctx, %d issues, %d fixes\n", report.Checked, len(report.Issues), len(report.Fixes))
+
+	if file := ctx.String(auditDiffFlag.Name); file != "" {
+		if err := writeJSON(file, report.Issues); err != nil {
+			utils.Fatalf("Failed to write diff: %v", err)
+		}
+	}
+	if file := ctx.String(auditPlanFlag.Name); file != "" {
+		if err := writeJSON(file, report.Fixes); err != nil {
+			utils.Fatalf("Failed to write fix plan: %v", err)
+		}
+	}
+	return nil
+}
+
+func migrateCross(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	src, err := cdb.OpenStormDB(stack, cross.DataDir)
+	if err != nil {
+		utils.Fatalf("Failed to open storm store, is the node stopped? %v", err)
+	}
+	defer src.Close()
+	dst, err := cdb.OpenEngine(stack, cdb.LevelDBEngine, cross.IndexDir)
+	if err != nil {
+		utils.Fatalf("Failed to open leveldb store: %v", err)
+	}
+	defer dst.Close()
+
+	ctxs, evidences, err := cdb.MigrateStorm(src, dst)
+	if err != nil {
+		utils.Fatalf("Failed to migrate cross store: %v", err)
+	}
+	fmt.Printf("migrated %d ctx, %d evidences\n", ctxs, evidences)
+	return nil
+}
+
+func writeJSON(file string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
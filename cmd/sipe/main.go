@@ -159,11 +159,16 @@ var (
 		utils.RaftModeFlag,
 		utils.RaftJoinExistingFlag,
 		utils.RaftPortFlag,
+		utils.RaftAutoPromoteFlag,
 		utils.IstanbulRequestTimeoutFlag,
 		utils.IstanbulBlockPeriodFlag,
 		utils.AnchorSignerFlag,
 		utils.ConfirmDepthFlag,
 		utils.AnchorMaxGasPriceFlag,
+		utils.AnchorExpireFlag,
+		utils.AnchorBLSKeyFlag,
+		utils.AnchorStoreFlag,
+		utils.AnchorClaimPeriodFlag,
 	}
 
 	rpcFlags = []cli.Flag{
@@ -255,6 +260,8 @@ func init() {
 		dumpConfigCommand,
 		// See retesteth.go
 		retestethCommand,
+		// See crosscmd.go
+		crossCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
@@ -86,6 +86,10 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.AnchorSignerFlag,
 			utils.ConfirmDepthFlag,
 			utils.AnchorMaxGasPriceFlag,
+			utils.AnchorExpireFlag,
+			utils.AnchorBLSKeyFlag,
+			utils.AnchorStoreFlag,
+			utils.AnchorClaimPeriodFlag,
 		},
 	},
 	{
@@ -150,6 +154,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RaftModeFlag,
 			utils.RaftJoinExistingFlag,
 			utils.RaftPortFlag,
+			utils.RaftAutoPromoteFlag,
 		},
 	},
 	{
@@ -1,46 +1,193 @@
 package utils
 
 import (
+	"fmt"
+	"math/big"
+
 	"github.com/simplechain-org/go-simplechain/common"
 	"github.com/simplechain-org/go-simplechain/cross"
 	crossBackend "github.com/simplechain-org/go-simplechain/cross/backend"
+	"github.com/simplechain-org/go-simplechain/cross/trigger"
+	"github.com/simplechain-org/go-simplechain/cross/trigger/rpctrigger"
+	rpcexecutor "github.com/simplechain-org/go-simplechain/cross/trigger/rpctrigger/executor"
+	rpcretriever "github.com/simplechain-org/go-simplechain/cross/trigger/rpctrigger/retriever"
 	"github.com/simplechain-org/go-simplechain/cross/trigger/simpletrigger"
 	"github.com/simplechain-org/go-simplechain/cross/trigger/simpletrigger/executor"
 	"github.com/simplechain-org/go-simplechain/cross/trigger/simpletrigger/retriever"
 	"github.com/simplechain-org/go-simplechain/cross/trigger/simpletrigger/subscriber"
 	"github.com/simplechain-org/go-simplechain/eth"
 	"github.com/simplechain-org/go-simplechain/node"
+	"github.com/simplechain-org/go-simplechain/params"
 	"github.com/simplechain-org/go-simplechain/sub"
 )
 
 func RegisterCrossChainService(stack *node.Node, cfg cross.Config, mainCh chan *eth.Ethereum, subCh chan *sub.Ethereum) {
+	if err := cfg.Validate(); err != nil {
+		Fatalf("Invalid cross chain config: %v", err)
+	}
 	err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 		mainNode := <-mainCh
 		subNode := <-subCh
 		defer close(mainCh)
 		defer close(subCh)
-		mainCtx, err := newSimpleChainContext(mainNode, cfg, cfg.MainContract, ctx.ResolvePath("mainChain_unconfirmed.rlp"))
-		if err != nil {
-			return nil, err
+
+		mainChainID := simpletrigger.NewSimpleProtocolChain(mainNode).ChainID().Uint64()
+		subChainID := simpletrigger.NewSimpleProtocolChain(subNode).ChainID().Uint64()
+		if err := cfg.ValidateChains(mainChainID, subChainID); err != nil {
+			return nil, fmt.Errorf("invalid cross chain config: %v", err)
 		}
-		subCtx, err := newSimpleChainContext(subNode, cfg, cfg.SubContract, ctx.ResolvePath("subChain_unconfirmed.rlp"))
+
+		// subscriber and executor are shared by every pair of the chain
+		mainChain, err := newSimpleChain(mainNode, cfg, cfg.MainContract, uint64(simpletrigger.DefaultConfirmDepth),
+			ctx.ResolvePath("mainChain_unconfirmed.rlp"), ctx.ResolvePath("mainChain_executor.rlp"))
 		if err != nil {
 			return nil, err
 		}
-		return crossBackend.NewCrossService(ctx, mainCtx, subCtx, cfg)
+		chains := map[uint64]crossChain{}
+
+		var pairs []cross.ChainPair
+		for _, remote := range cfg.RemoteChains(subChainID) {
+			remoteChain, ok := chains[remote.ChainID]
+			if !ok {
+				switch {
+				case remote.RPC != "":
+					journal := ctx.ResolvePath(fmt.Sprintf("chain%d_unconfirmed.rlp", remote.ChainID))
+					queue := ctx.ResolvePath(fmt.Sprintf("chain%d_executor.rlp", remote.ChainID))
+					checkpoint := ctx.ResolvePath(fmt.Sprintf("chain%d_checkpoint.json", remote.ChainID))
+					if remoteChain, err = newRPCChain(remote, journal, queue, checkpoint, mainChain.executor, cfg.Signer); err != nil {
+						return nil, err
+					}
+				default: // the sub chain, checked by ValidateChains
+					if remoteChain, err = newSimpleChain(subNode, cfg, remote.Contract, confirmDepth(remote),
+						ctx.ResolvePath("subChain_unconfirmed.rlp"), ctx.ResolvePath("subChain_executor.rlp")); err != nil {
+						return nil, err
+					}
+				}
+				chains[remote.ChainID] = remoteChain
+			}
+			pairCfg := cfg.PairConfig(remote)
+			pairs = append(pairs, cross.ChainPair{
+				Main:   mainChain.newServiceContext(pairCfg),
+				Sub:    remoteChain.newServiceContext(pairCfg),
+				Legacy: cfg.LegacyPair(remote),
+			})
+		}
+		return crossBackend.NewCrossService(ctx, pairs, cfg)
 	})
 	if err != nil {
 		Fatalf("Failed to register the CrossChain service: %v", err)
 	}
 }
 
-func newSimpleChainContext(chain simpletrigger.SimpleChain, config cross.Config, contract common.Address, journal string) (ctx *cross.ServiceContext, err error) {
-	ctx = &cross.ServiceContext{ProtocolChain: simpletrigger.NewSimpleProtocolChain(chain), Config: &config}
-	ctx.Executor, err = executor.NewSimpleExecutor(chain, config.Signer, contract)
+// crossChain creates service context of a chain for every pair it joins in
+type crossChain interface {
+	newServiceContext(config cross.Config) *cross.ServiceContext
+}
+
+type simpleChain struct {
+	chain      simpletrigger.SimpleChain
+	protocol   cross.ProtocolChain
+	contract   common.Address
+	finality   simpletrigger.Finality
+	executor   *executor.SimpleExecutor
+	subscriber trigger.Subscriber
+}
+
+func newSimpleChain(chain simpletrigger.SimpleChain, config cross.Config, contract common.Address, depth uint64, journal, queue string) (*simpleChain, error) {
+	exec, err := executor.NewSimpleExecutor(chain, config.Signer, contract, queue)
+	if err != nil {
+		return nil, err
+	}
+	finality := simpletrigger.NewFinality(chain.ChainConfig(), depth)
+	return &simpleChain{
+		chain:      chain,
+		protocol:   simpletrigger.NewSimpleProtocolChain(chain),
+		contract:   contract,
+		finality:   finality,
+		executor:   exec,
+		subscriber: subscriber.NewSimpleSubscriber(contract, chain.BlockChain(), journal, finality),
+	}, nil
+}
+
+// newServiceContext creates context of the chain for a pair, config and retriever are owned by the pair
+func (c *simpleChain) newServiceContext(config cross.Config) *cross.ServiceContext {
+	ctx := &cross.ServiceContext{
+		ProtocolChain: c.protocol,
+		Config:        &config,
+		Executor:      c.executor,
+		Subscriber:    c.subscriber,
+	}
+	ctx.Retriever = retriever.NewSimpleRetriever(c.chain.BlockChain(), c.chain.ProtocolManager(), c.contract, ctx.Config, c.chain.ChainConfig(), c.finality)
+	return ctx
+}
+
+// rpcChain is a remote chain followed over websocket JSON-RPC
+type rpcChain struct {
+	chain      *rpctrigger.RPCChain
+	finality   simpletrigger.Finality
+	executor   trigger.Executor
+	subscriber trigger.Subscriber
+}
+
+func newRPCChain(remote cross.ChainConfig, journal, queue, checkpoint string, signer trigger.AnchorSigner, anchor common.Address) (*rpcChain, error) {
+	consensus, err := consensusConfig(remote)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := rpctrigger.Dial(remote.RPC, remote.ChainID, remote.Contract, checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("dial cross chain %d failed: %v", remote.ChainID, err)
+	}
+	exec, err := rpcexecutor.NewRPCExecutor(chain, anchor, signer, queue)
 	if err != nil {
 		return nil, err
 	}
-	ctx.Retriever = retriever.NewSimpleRetriever(chain.BlockChain(), chain.ProtocolManager(), contract, ctx.Config, chain.ChainConfig())
-	ctx.Subscriber = subscriber.NewSimpleSubscriber(contract, chain.BlockChain(), journal)
-	return ctx, nil
+	finality := simpletrigger.NewFinality(consensus, confirmDepth(remote))
+	return &rpcChain{
+		chain:      chain,
+		finality:   finality,
+		executor:   exec,
+		subscriber: subscriber.NewSimpleSubscriber(remote.Contract, chain, journal, finality),
+	}, nil
+}
+
+func (c *rpcChain) newServiceContext(config cross.Config) *cross.ServiceContext {
+	ctx := &cross.ServiceContext{
+		ProtocolChain: c.chain,
+		Config:        &config,
+		Executor:      c.executor,
+		Subscriber:    c.subscriber,
+	}
+	ctx.Retriever = rpcretriever.NewRPCRetriever(c.chain, ctx.Config, c.finality)
+	return ctx
+}
+
+// confirmDepth returns the depth to confirm blocks of the remote PoW chain
+func confirmDepth(remote cross.ChainConfig) uint64 {
+	if remote.ConfirmDepth > 0 {
+		return remote.ConfirmDepth
+	}
+	return uint64(simpletrigger.DefaultConfirmDepth)
+}
+
+// consensusConfig makes the consensus config of a chain followed over rpc, which can't be read from the chain
+func consensusConfig(remote cross.ChainConfig) (*params.ChainConfig, error) {
+	config := &params.ChainConfig{ChainID: new(big.Int).SetUint64(remote.ChainID)}
+	switch remote.Consensus {
+	case "", "ethash":
+		config.Ethash = new(params.EthashConfig)
+	case "scrypt":
+		config.Scrypt = new(params.ScryptConfig)
+	case "clique":
+		config.Clique = new(params.CliqueConfig)
+	case "dpos":
+		config.DPoS = new(params.DPoSConfig) // confirmed by the depth if signer count is unknown
+	case "raft":
+		config.Raft = true
+	case "istanbul":
+		config.Istanbul = new(params.IstanbulConfig)
+	default:
+		return nil, fmt.Errorf("unknown consensus %q of cross chain %d", remote.Consensus, remote.ChainID)
+	}
+	return config, nil
 }
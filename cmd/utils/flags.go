@@ -794,6 +794,10 @@ var (
 		Usage: "The port to bind for the raft transport",
 		Value: 50400,
 	}
+	RaftAutoPromoteFlag = cli.BoolFlag{
+		Name:  "raftautopromote",
+		Usage: "If enabled, the raft leader promotes learners to voters once they are caught up",
+	}
 
 	// Istanbul settings
 	IstanbulRequestTimeoutFlag = cli.Uint64Flag{
@@ -889,6 +893,23 @@ var (
 		Usage: "anchor's max gasprice(GWei) for cross chain txs",
 		Value: 100,
 	}
+	AnchorExpireFlag = cli.Uint64Flag{
+		Name:  "anchor.expire",
+		Usage: "blocks after which an untaken cross chain tx can be refunded (0 = never expired)",
+	}
+	AnchorBLSKeyFlag = cli.StringFlag{
+		Name:  "anchor.blskey",
+		Usage: "file of anchor's BLS key share for aggregate signatures",
+	}
+	AnchorStoreFlag = cli.StringFlag{
+		Name:  "anchor.store",
+		Usage: "storage engine of cross chain stores (storm, leveldb)",
+		Value: "storm",
+	}
+	AnchorClaimPeriodFlag = cli.Uint64Flag{
+		Name:  "anchor.claimperiod",
+		Usage: "seconds between claiming relay fees from cross contracts (0 = never claimed)",
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -63,6 +63,7 @@ var (
 	minerRewardPerThousand           = uint64(618)                                           // Default reward for miner in each block from block reward (618/1000)
 	candidateNeedPD                  = false                                                 // is new candidate need Proposal & Declare process
 	proposalDeposit                  = new(big.Int).Mul(big.NewInt(1e+18), big.NewInt(1e+4)) // default current proposalDeposit
+	defaultUnbondingLoopCnt          = uint64(10000)                                         // Default loop count for the stake of devote to unlock, About one week if period = 3 & 21 super nodes
 )
 
 // Various error messages to mark blocks invalid. These should be private to
@@ -231,6 +232,9 @@ func New(config *params.DPoSConfig, db ethdb.Database) *DPoS {
 	if conf.MaxSignerCount == 0 {
 		conf.MaxSignerCount = defaultMaxSignerCount
 	}
+	if conf.UnbondingLoops == 0 {
+		conf.UnbondingLoops = defaultUnbondingLoopCnt
+	}
 	if conf.MinVoterBalance.Uint64() > 0 {
 		minVoterBalance = conf.MinVoterBalance
 	}
@@ -646,6 +650,7 @@ func (d *DPoS) Finalize(chain consensus.ChainReader, header *types.Header, state
 		return err
 	}
 	currentHeaderExtra = mcCurrentHeaderExtra
+	d.installGovernance(state, number)
 	currentHeaderExtra.ConfirmedBlockNumber = snap.getLastConfirmedBlockNumber(currentHeaderExtra.CurrentBlockConfirmations).Uint64()
 	// write signerQueue in first header, from self vote signers in genesis block
 	if number == 1 {
@@ -857,6 +862,11 @@ func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header
 		state.AddBalance(proposer, refund)
 	}
 
+	// unlock the stake which finishes unbonding
+	for voter, unbonded := range snap.calculateUnbonded() {
+		state.AddBalance(voter, unbonded)
+	}
+
 	// refund gas for custom txs (confirm event)
 	for sender, gas := range refundGas {
 		state.AddBalance(sender, gas)
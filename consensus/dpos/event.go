@@ -88,15 +88,18 @@ type RefundPair struct {
 type RefundHash map[common.Hash]RefundPair
 
 // Vote :
-// vote come from custom tx which data like "dpos:1:event:vote"
+// vote come from custom tx which data like "dpos:1:event:vote" or "dpos:1:event:vote:stake:200"
 // Sender of tx is Voter, the tx.to is Candidate
-// Stake is the balance of Voter when create this vote
+// Stake is locked from the balance of Voter, it is minVoterBalance or the bonded stake if not given (count in 1e+18),
+// a lower stake than the bonded one starts unbonding the difference, devote starts unbonding all
 type Vote struct {
 	Voter     common.Address
 	Candidate common.Address
 	Stake     *big.Int
 }
 
+// PredecessorVoter :
+// stake of voter modified by its balance, only in blocks before bonding
 type PredecessorVoter struct {
 	Voter common.Address
 	Stake *big.Int
@@ -183,6 +186,18 @@ func decodeHeaderExtra(b []byte, val *HeaderExtra) error {
 	return rlp.DecodeBytes(b, val)
 }
 
+// ConfirmedBlockNumber returns the last block number confirmed by signers when the header is sealed
+func ConfirmedBlockNumber(header *types.Header) (uint64, error) {
+	if len(header.Extra) < extraVanity+extraSeal {
+		return 0, errMissingSignature
+	}
+	var headerExtra HeaderExtra
+	if err := decodeHeaderExtra(header.Extra[extraVanity:len(header.Extra)-extraSeal], &headerExtra); err != nil {
+		return 0, err
+	}
+	return headerExtra.ConfirmedBlockNumber, nil
+}
+
 // Calculate Votes from transaction in this block, write into header.Extra
 func (d *DPoS) processTxEvent(headerExtra HeaderExtra, chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, receipts []*types.Receipt) (HeaderExtra, RefundGas, error) {
 	// if predecessor voter make transaction and vote in this block,
@@ -202,7 +217,12 @@ func (d *DPoS) processTxEvent(headerExtra HeaderExtra, chain consensus.ChainRead
 		}
 	}
 
-	for _, tx := range txs {
+	// string payloads are replaced by calls of the governance contract since the fork block
+	legacyTxs := txs
+	if d.config.IsGovernance(header.Number) {
+		legacyTxs = nil
+	}
+	for _, tx := range legacyTxs {
 
 		txSender, err := types.Sender(types.NewEIP155Signer(tx.ChainId()), tx)
 		if err != nil {
@@ -219,8 +239,12 @@ func (d *DPoS) processTxEvent(headerExtra HeaderExtra, chain consensus.ChainRead
 						if txDataInfo[pCategory] == dposCategoryEvent {
 							if len(txDataInfo) > dposMinSplitLen {
 								// check is vote or not
-								if txDataInfo[pEventVote] == dposEventVote && (!candidateNeedPD || snap.isCandidate(*tx.To())) && state.GetBalance(txSender).Cmp(snap.MinVB) > 0 {
-									headerExtra.CurrentBlockVotes = d.processEventVote(headerExtra.CurrentBlockVotes, state, tx, txSender)
+								if txDataInfo[pEventVote] == dposEventVote && (!candidateNeedPD || snap.isCandidate(*tx.To())) {
+									if !d.config.IsBonding(header.Number) {
+										headerExtra.CurrentBlockVotes = d.processLegacyVote(headerExtra.CurrentBlockVotes, state, *tx.To(), txSender, snap)
+									} else if stake, ok := parseVoteStake(txDataInfo); ok {
+										headerExtra.CurrentBlockVotes = d.processEventVote(headerExtra.CurrentBlockVotes, stake, state, *tx.To(), txSender, snap)
+									}
 
 								} else if txDataInfo[pEventVote] == dposEventDeVote && snap.isVoter(txSender) {
 									headerExtra.CurrentBlockVotes = d.processEventDeVote(headerExtra.CurrentBlockVotes, txSender)
@@ -244,12 +268,19 @@ func (d *DPoS) processTxEvent(headerExtra HeaderExtra, chain consensus.ChainRead
 				}
 			}
 		}
-		// check each address
-		if number > 1 {
-			// process predecessor voter
+	}
+	if d.config.IsGovernance(header.Number) {
+		headerExtra, refundHash = d.processGovernanceEvent(headerExtra, chain, number, state, txs, receipts, snap, refundHash)
+	}
+	// the stake of voters follows their balance before bonding
+	if number > 1 && !d.config.IsBonding(header.Number) {
+		for _, tx := range txs {
+			txSender, err := types.Sender(types.NewEIP155Signer(tx.ChainId()), tx)
+			if err != nil {
+				continue
+			}
 			headerExtra.ModifyPredecessorVotes = d.processPredecessorVoter(headerExtra.ModifyPredecessorVotes, state, tx, txSender, snap)
 		}
-
 	}
 
 	for _, receipt := range receipts {
@@ -278,19 +309,7 @@ func (d *DPoS) processEventProposal(currentBlockProposals []Proposal, txDataInfo
 		return currentBlockProposals
 	}
 
-	proposal := Proposal{
-		Hash:                   tx.Hash(),
-		ReceivedNumber:         big.NewInt(0),
-		CurrentDeposit:         proposalDeposit, // for all type of deposit
-		ValidationLoopCnt:      defaultValidationLoopCnt,
-		ProposalType:           proposalTypeCandidateAdd,
-		Proposer:               proposer,
-		TargetAddress:          common.Address{},
-		MinerRewardPerThousand: minerRewardPerThousand,
-		Declares:               []*Declare{},
-		MinVoterBalance:        new(big.Int).Div(minVoterBalance, big.NewInt(1e+18)).Uint64(),
-		ProposalDeposit:        new(big.Int).Div(proposalDeposit, big.NewInt(1e+18)).Uint64(), // default value
-	}
+	proposal := newProposal(tx.Hash(), proposer)
 
 	for i := 0; i < len(txDataInfo[pEventProposal+1:])/2; i++ {
 		k, v := txDataInfo[pEventProposal+1+i*2], txDataInfo[pEventProposal+2+i*2]
@@ -334,7 +353,29 @@ func (d *DPoS) processEventProposal(currentBlockProposals []Proposal, txDataInfo
 			}
 		}
 	}
-	// now the proposal is built
+	return d.depositProposal(currentBlockProposals, proposal, state)
+}
+
+// newProposal creates a proposal of the tx with default values
+func newProposal(hash common.Hash, proposer common.Address) Proposal {
+	return Proposal{
+		Hash:                   hash,
+		ReceivedNumber:         big.NewInt(0),
+		CurrentDeposit:         proposalDeposit, // for all type of deposit
+		ValidationLoopCnt:      defaultValidationLoopCnt,
+		ProposalType:           proposalTypeCandidateAdd,
+		Proposer:               proposer,
+		TargetAddress:          common.Address{},
+		MinerRewardPerThousand: minerRewardPerThousand,
+		Declares:               []*Declare{},
+		MinVoterBalance:        new(big.Int).Div(minVoterBalance, big.NewInt(1e+18)).Uint64(),
+		ProposalDeposit:        new(big.Int).Div(proposalDeposit, big.NewInt(1e+18)).Uint64(), // default value
+	}
+}
+
+// depositProposal collects the deposit of the built proposal from the balance of proposer
+func (d *DPoS) depositProposal(currentBlockProposals []Proposal, proposal Proposal, state *state.StateDB) []Proposal {
+	proposer := proposal.Proposer
 	currentProposalPay := new(big.Int).Set(proposalDeposit)
 	// check enough balance for deposit
 	if state.GetBalance(proposer).Cmp(currentProposalPay) < 0 {
@@ -374,15 +415,74 @@ func (d *DPoS) processEventDeclare(currentBlockDeclares []Declare, txDataInfo []
 	return append(currentBlockDeclares, declare)
 }
 
-func (d *DPoS) processEventVote(currentBlockVotes []Vote, state *state.StateDB, tx *types.Transaction, voter common.Address) []Vote {
+// parseVoteStake returns the stake of "dpos:1:event:vote:stake:200", or nil if the stake is not given
+func parseVoteStake(txDataInfo []string) (*big.Int, bool) {
+	var stake *big.Int
+	for i := 0; i < len(txDataInfo[pEventVote+1:])/2; i++ {
+		k, v := txDataInfo[pEventVote+1+i*2], txDataInfo[pEventVote+2+i*2]
+		switch k {
+		case "stake":
+			if amount, err := strconv.ParseUint(v, 10, 64); err != nil {
+				return nil, false
+			} else {
+				stake = new(big.Int).Mul(new(big.Int).SetUint64(amount), big.NewInt(1e+18))
+			}
+		}
+	}
+	return stake, true
+}
+
+// processLegacyVote votes with the balance of voter, which must be more than minVoterBalance,
+// only in blocks before bonding
+func (d *DPoS) processLegacyVote(currentBlockVotes []Vote, state *state.StateDB, candidate common.Address, voter common.Address, snap *Snapshot) []Vote {
 	d.lock.RLock()
 	stake := state.GetBalance(voter)
 	d.lock.RUnlock()
+	if stake.Cmp(snap.MinVB) <= 0 {
+		return currentBlockVotes
+	}
+	return append(currentBlockVotes, Vote{
+		Voter:     voter,
+		Candidate: candidate,
+		Stake:     new(big.Int).Set(stake),
+	})
+}
+
+// processEventVote locks the stake of vote from the balance of voter, minVoterBalance or the bonded stake
+// is voted if stake is nil, since the bonding block
+func (d *DPoS) processEventVote(currentBlockVotes []Vote, stake *big.Int, state *state.StateDB, candidate common.Address, voter common.Address, snap *Snapshot) []Vote {
+	// the stake bonded by the latest vote of voter, which may be in current block
+	bonded := snap.bondedStake(voter)
+	for i := len(currentBlockVotes) - 1; i >= 0; i-- {
+		if currentBlockVotes[i].Voter == voter {
+			bonded = currentBlockVotes[i].Stake
+			break
+		}
+	}
+	if stake == nil {
+		stake = new(big.Int).Set(snap.MinVB)
+		if bonded.Cmp(stake) > 0 {
+			stake.Set(bonded)
+		}
+	}
+	if stake.Cmp(snap.MinVB) < 0 {
+		return currentBlockVotes
+	}
+	// lock the increased stake from the balance of voter
+	if locked := new(big.Int).Sub(stake, bonded); locked.Sign() > 0 {
+		d.lock.RLock()
+		balance := state.GetBalance(voter)
+		d.lock.RUnlock()
+		if balance.Cmp(locked) < 0 {
+			return currentBlockVotes
+		}
+		state.SubBalance(voter, locked)
+	}
 
 	return append(currentBlockVotes, Vote{
 		Voter:     voter,
-		Candidate: *tx.To(),
-		Stake:     stake,
+		Candidate: candidate,
+		Stake:     new(big.Int).Set(stake),
 	})
 }
 
@@ -396,40 +496,49 @@ func (d *DPoS) processEventDeVote(currentBlockVotes []Vote, voter common.Address
 func (d *DPoS) processEventConfirm(currentBlockConfirmations []Confirmation, chain consensus.ChainReader, txDataInfo []string, number uint64, tx *types.Transaction, confirmer common.Address, refundHash RefundHash) ([]Confirmation, RefundHash) {
 	if len(txDataInfo) > pEventConfirmNumber {
 		confirmedBlockNumber := new(big.Int)
-		err := confirmedBlockNumber.UnmarshalText([]byte(txDataInfo[pEventConfirmNumber]))
-		if err != nil || number-confirmedBlockNumber.Uint64() > d.config.MaxSignerCount || number-confirmedBlockNumber.Uint64() < 0 {
-			return currentBlockConfirmations, refundHash
-		}
-		// check if the voter is in block
-		confirmedHeader := chain.GetHeaderByNumber(confirmedBlockNumber.Uint64())
-		if confirmedHeader == nil {
-			//log.Info("Fail to get confirmedHeader")
-			return currentBlockConfirmations, refundHash
-		}
-		confirmedHeaderExtra := HeaderExtra{}
-		if extraVanity+extraSeal > len(confirmedHeader.Extra) {
-			return currentBlockConfirmations, refundHash
-		}
-		err = decodeHeaderExtra(confirmedHeader.Extra[extraVanity:len(confirmedHeader.Extra)-extraSeal], &confirmedHeaderExtra)
-		if err != nil {
-			log.Info("Fail to decode parent header", "err", err)
+		if err := confirmedBlockNumber.UnmarshalText([]byte(txDataInfo[pEventConfirmNumber])); err != nil {
 			return currentBlockConfirmations, refundHash
 		}
-		for _, s := range confirmedHeaderExtra.SignerQueue {
-			if s == confirmer {
-				currentBlockConfirmations = append(currentBlockConfirmations, Confirmation{
-					Signer:      confirmer,
-					BlockNumber: new(big.Int).Set(confirmedBlockNumber),
-				})
-				refundHash[tx.Hash()] = RefundPair{confirmer, tx.GasPrice()}
-				break
-			}
+		var confirmed bool
+		if currentBlockConfirmations, confirmed = d.confirmBlock(currentBlockConfirmations, chain, confirmedBlockNumber, number, confirmer); confirmed {
+			refundHash[tx.Hash()] = RefundPair{confirmer, tx.GasPrice()}
 		}
 	}
 
 	return currentBlockConfirmations, refundHash
 }
 
+// confirmBlock adds the confirmation if confirmer is in the signer queue of the confirmed block
+func (d *DPoS) confirmBlock(currentBlockConfirmations []Confirmation, chain consensus.ChainReader, confirmedBlockNumber *big.Int, number uint64, confirmer common.Address) ([]Confirmation, bool) {
+	if !confirmedBlockNumber.IsUint64() || confirmedBlockNumber.Uint64() > number || number-confirmedBlockNumber.Uint64() > d.config.MaxSignerCount {
+		return currentBlockConfirmations, false
+	}
+	// check if the voter is in block
+	confirmedHeader := chain.GetHeaderByNumber(confirmedBlockNumber.Uint64())
+	if confirmedHeader == nil {
+		//log.Info("Fail to get confirmedHeader")
+		return currentBlockConfirmations, false
+	}
+	confirmedHeaderExtra := HeaderExtra{}
+	if extraVanity+extraSeal > len(confirmedHeader.Extra) {
+		return currentBlockConfirmations, false
+	}
+	err := decodeHeaderExtra(confirmedHeader.Extra[extraVanity:len(confirmedHeader.Extra)-extraSeal], &confirmedHeaderExtra)
+	if err != nil {
+		log.Info("Fail to decode parent header", "err", err)
+		return currentBlockConfirmations, false
+	}
+	for _, s := range confirmedHeaderExtra.SignerQueue {
+		if s == confirmer {
+			return append(currentBlockConfirmations, Confirmation{
+				Signer:      confirmer,
+				BlockNumber: new(big.Int).Set(confirmedBlockNumber),
+			}), true
+		}
+	}
+	return currentBlockConfirmations, false
+}
+
 func (d *DPoS) processPredecessorVoter(modifyPredecessorVotes []PredecessorVoter, state *state.StateDB, tx *types.Transaction, voter common.Address, snap *Snapshot) []PredecessorVoter {
 	// process normal transaction which relate to voter
 	if snap.isVoter(voter) {
@@ -0,0 +1,226 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package dpos implements the delegated-proof-of-stake consensus engine.
+
+package dpos
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/simplechain-org/go-simplechain/accounts/abi"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/consensus"
+	"github.com/simplechain-org/go-simplechain/core/state"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/log"
+)
+
+// GovernanceABI is the interface of the governance system contract, which replaces the
+// "dpos:1:event:*" payloads since config.GovernanceBlock
+//
+// vote(candidate, stake): vote for candidate with stake in wei, minVoterBalance or the bonded stake if stake is 0
+// devote(): cancel the vote of sender
+// confirm(number): confirm the block by signer
+// proposal(proposalType, candidate, validationLoopCnt, minerRewardPerThousand, minVoterBalance, proposalDeposit):
+// propose with the deposit, default values are used for zero validationLoopCnt, minerRewardPerThousand, minVoterBalance and proposalDeposit
+// declare(proposalHash, decision): declare on the proposal by candidate
+const GovernanceABI = `[
+	{"type":"function","name":"vote","stateMutability":"nonpayable","inputs":[{"name":"candidate","type":"address"},{"name":"stake","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"devote","stateMutability":"nonpayable","inputs":[],"outputs":[]},
+	{"type":"function","name":"confirm","stateMutability":"nonpayable","inputs":[{"name":"number","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"proposal","stateMutability":"nonpayable","inputs":[{"name":"proposalType","type":"uint256"},{"name":"candidate","type":"address"},{"name":"validationLoopCnt","type":"uint256"},{"name":"minerRewardPerThousand","type":"uint256"},{"name":"minVoterBalance","type":"uint256"},{"name":"proposalDeposit","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"declare","stateMutability":"nonpayable","inputs":[{"name":"proposalHash","type":"bytes32"},{"name":"decision","type":"bool"}],"outputs":[]},
+	{"type":"event","name":"Governance","anonymous":false,"inputs":[{"name":"sender","type":"address","indexed":true},{"name":"input","type":"bytes","indexed":false}]}
+]`
+
+// GovernanceAddress is the address of the governance system contract, the contract is installed by
+// the engine in the block before config.GovernanceBlock
+var GovernanceAddress = common.HexToAddress("0x0000000000000000000000000000000000002000")
+
+var (
+	governanceABI, _ = abi.JSON(strings.NewReader(GovernanceABI))
+
+	// governanceCode is the runtime code of the governance contract, it rejects value and logs
+	// Governance(msg.sender, msg.data) for every call, the methods are executed by the engine in Finalize.
+	//
+	//   CALLVALUE ISZERO PUSH1 0x09 JUMPI PUSH1 0 DUP1 REVERT JUMPDEST
+	//   PUSH1 0x20 PUSH1 0 MSTORE                                         ; offset of input
+	//   CALLDATASIZE PUSH1 0x20 MSTORE                                    ; length of input
+	//   CALLDATASIZE PUSH1 0 PUSH1 0x40 CALLDATACOPY                      ; input
+	//   CALLER PUSH32 <topic of Governance>
+	//   CALLDATASIZE PUSH1 0x1f ADD PUSH1 0x1f NOT AND PUSH1 0x40 ADD PUSH1 0 LOG2 STOP
+	governanceCode = common.FromHex("0x34156009576000" + "80fd5b" +
+		"6020600052" +
+		"3660205236" + "6000604037" +
+		"337f" + governanceABI.Events["Governance"].ID().Hex()[2:] +
+		"36601f01601f19166040016000a200")
+
+	errInvalidGovernanceLog = errors.New("invalid governance log")
+)
+
+// installGovernance sets the code of governance contract in the block before the fork block,
+// so the contract is callable since config.GovernanceBlock
+func (d *DPoS) installGovernance(state *state.StateDB, number uint64) {
+	if d.config.GovernanceBlock == nil || number+1 < d.config.GovernanceBlock.Uint64() {
+		return
+	}
+	if state.GetCodeSize(GovernanceAddress) == 0 {
+		state.SetCode(GovernanceAddress, governanceCode)
+	}
+}
+
+// decodeGovernanceLog returns the sender, method and arguments of the governance call logged by l
+func decodeGovernanceLog(l *types.Log) (common.Address, *abi.Method, []interface{}, error) {
+	event := governanceABI.Events["Governance"]
+	if l.Address != GovernanceAddress || len(l.Topics) != 2 || l.Topics[0] != event.ID() {
+		return common.Address{}, nil, nil, errInvalidGovernanceLog
+	}
+	values, err := event.Inputs.NonIndexed().UnpackValues(l.Data)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	input, ok := values[0].([]byte)
+	if !ok || len(input) < 4 {
+		return common.Address{}, nil, nil, errInvalidGovernanceLog
+	}
+	method, err := governanceABI.MethodById(input[:4])
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	args, err := method.Inputs.UnpackValues(input[4:])
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return common.BytesToAddress(l.Topics[1].Bytes()), method, args, nil
+}
+
+// processGovernanceEvent calculates votes, confirmations, proposals and declares from the logs of governance contract,
+// calls from failed transactions are reverted with their logs
+func (d *DPoS) processGovernanceEvent(headerExtra HeaderExtra, chain consensus.ChainReader, number uint64, state *state.StateDB, txs []*types.Transaction, receipts []*types.Receipt, snap *Snapshot, refundHash RefundHash) (HeaderExtra, RefundHash) {
+	if snap == nil || len(txs) != len(receipts) {
+		return headerExtra, refundHash
+	}
+	for i, receipt := range receipts {
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			continue
+		}
+		tx := txs[i]
+		for _, l := range receipt.Logs {
+			if l.Address != GovernanceAddress {
+				continue
+			}
+			sender, method, args, err := decodeGovernanceLog(l)
+			if err != nil {
+				log.Debug("Skip governance log", "tx", receipt.TxHash, "err", err)
+				continue
+			}
+			switch method.Name {
+			case "vote":
+				candidate, stake := args[0].(common.Address), args[1].(*big.Int)
+				if stake.Sign() == 0 {
+					stake = nil
+				}
+				if candidateNeedPD && !snap.isCandidate(candidate) {
+					continue
+				}
+				if !d.config.IsBonding(new(big.Int).SetUint64(number)) {
+					headerExtra.CurrentBlockVotes = d.processLegacyVote(headerExtra.CurrentBlockVotes, state, candidate, sender, snap)
+				} else {
+					headerExtra.CurrentBlockVotes = d.processEventVote(headerExtra.CurrentBlockVotes, stake, state, candidate, sender, snap)
+				}
+
+			case "devote":
+				if snap.isVoter(sender) {
+					headerExtra.CurrentBlockVotes = d.processEventDeVote(headerExtra.CurrentBlockVotes, sender)
+				}
+
+			case "confirm":
+				if !snap.isCandidate(sender) {
+					continue
+				}
+				var confirmed bool
+				headerExtra.CurrentBlockConfirmations, confirmed = d.confirmBlock(headerExtra.CurrentBlockConfirmations, chain, args[0].(*big.Int), number, sender)
+				// only gas of the confirmation sent by signer directly is refunded
+				if confirmed && tx.To() != nil && *tx.To() == GovernanceAddress {
+					if txSender, err := types.Sender(types.NewEIP155Signer(tx.ChainId()), tx); err == nil && txSender == sender {
+						refundHash[tx.Hash()] = RefundPair{sender, tx.GasPrice()}
+					}
+				}
+
+			case "proposal":
+				if proposal, ok := governanceProposal(tx.Hash(), sender, args); ok {
+					headerExtra.CurrentBlockProposals = d.depositProposal(headerExtra.CurrentBlockProposals, proposal, state)
+				}
+
+			case "declare":
+				if snap.isCandidate(sender) {
+					headerExtra.CurrentBlockDeclares = append(headerExtra.CurrentBlockDeclares, Declare{
+						ProposalHash: common.Hash(args[0].([32]byte)),
+						Declarer:     sender,
+						Decision:     args[1].(bool),
+					})
+				}
+			}
+		}
+	}
+	return headerExtra, refundHash
+}
+
+// governanceProposal builds the proposal of the governance call, values are checked as "dpos:1:event:proposal"
+func governanceProposal(hash common.Hash, proposer common.Address, args []interface{}) (Proposal, bool) {
+	var (
+		proposalType           = args[0].(*big.Int)
+		candidate              = args[1].(common.Address)
+		validationLoopCnt      = args[2].(*big.Int)
+		minerRewardPerThousand = args[3].(*big.Int)
+		minVoterBalance        = args[4].(*big.Int)
+		deposit                = args[5].(*big.Int)
+	)
+	proposal := newProposal(hash, proposer)
+	if !proposalType.IsUint64() {
+		return proposal, false
+	}
+	proposal.ProposalType = proposalType.Uint64()
+	proposal.TargetAddress = candidate
+	if validationLoopCnt.Sign() > 0 {
+		if validationLoopCnt.Cmp(big.NewInt(minValidationLoopCnt)) < 0 || validationLoopCnt.Cmp(big.NewInt(maxValidationLoopCnt)) > 0 {
+			return proposal, false
+		}
+		proposal.ValidationLoopCnt = validationLoopCnt.Uint64()
+	}
+	if minerRewardPerThousand.Sign() > 0 {
+		if minerRewardPerThousand.Cmp(big.NewInt(1000)) > 0 {
+			return proposal, false
+		}
+		proposal.MinerRewardPerThousand = minerRewardPerThousand.Uint64()
+	}
+	if minVoterBalance.Sign() > 0 {
+		if !minVoterBalance.IsUint64() {
+			return proposal, false
+		}
+		proposal.MinVoterBalance = minVoterBalance.Uint64()
+	}
+	if deposit.Sign() > 0 {
+		if deposit.Cmp(big.NewInt(maxProposalDeposit)) > 0 {
+			return proposal, false
+		}
+		proposal.ProposalDeposit = deposit.Uint64()
+	}
+	return proposal, true
+}
@@ -0,0 +1,130 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dpos
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/core/rawdb"
+	"github.com/simplechain-org/go-simplechain/core/state"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/params"
+)
+
+// newGovernanceLog builds the log emitted by the governance contract for the call of sender
+func newGovernanceLog(t *testing.T, sender common.Address, method string, args ...interface{}) *types.Log {
+	input, err := governanceABI.Pack(method, args...)
+	if err != nil {
+		t.Fatalf("pack %s failed: %v", method, err)
+	}
+	event := governanceABI.Events["Governance"]
+	data, err := event.Inputs.NonIndexed().Pack(input)
+	if err != nil {
+		t.Fatalf("pack log failed: %v", err)
+	}
+	return &types.Log{
+		Address: GovernanceAddress,
+		Topics:  []common.Hash{event.ID(), common.BytesToHash(sender.Bytes())},
+		Data:    data,
+	}
+}
+
+// Tests that the calls logged by the governance contract are decoded.
+func TestDecodeGovernanceLog(t *testing.T) {
+	accounts := newTesterAccountPool()
+	sender, candidate := accounts.address("A"), accounts.address("B")
+
+	l := newGovernanceLog(t, sender, "vote", candidate, big.NewInt(1e+18))
+	from, method, args, err := decodeGovernanceLog(l)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if from != sender || method.Name != "vote" {
+		t.Fatalf("call mismatch: have %x %s, want %x vote", from, method.Name, sender)
+	}
+	if args[0].(common.Address) != candidate || args[1].(*big.Int).Cmp(big.NewInt(1e+18)) != 0 {
+		t.Fatalf("args mismatch: %v", args)
+	}
+
+	l = newGovernanceLog(t, sender, "declare", [32]byte{1}, true)
+	if _, method, args, err = decodeGovernanceLog(l); err != nil || method.Name != "declare" || !args[1].(bool) {
+		t.Fatalf("declare mismatch: %v %v", args, err)
+	}
+
+	// logs of other contracts are rejected
+	l.Address = common.HexToAddress("0x1")
+	if _, _, _, err := decodeGovernanceLog(l); err != errInvalidGovernanceLog {
+		t.Fatalf("error mismatch: have %v, want %v", err, errInvalidGovernanceLog)
+	}
+	// unknown method is rejected
+	l = newGovernanceLog(t, sender, "devote")
+	l.Data[len(l.Data)-32] ^= 0xff
+	if _, _, _, err := decodeGovernanceLog(l); err == nil {
+		t.Fatalf("unknown method decoded")
+	}
+}
+
+// Tests that the values of governance proposal are checked as the legacy payload.
+func TestGovernanceProposal(t *testing.T) {
+	accounts := newTesterAccountPool()
+	proposer, candidate := accounts.address("A"), accounts.address("B")
+	zero := new(big.Int)
+
+	tests := []struct {
+		args []interface{}
+		ok   bool
+	}{
+		{[]interface{}{big.NewInt(proposalTypeCandidateAdd), candidate, zero, zero, zero, zero}, true},
+		{[]interface{}{big.NewInt(proposalTypeMinerRewardDistributionModify), candidate, zero, big.NewInt(500), zero, zero}, true},
+		{[]interface{}{big.NewInt(proposalTypeMinerRewardDistributionModify), candidate, zero, big.NewInt(1001), zero, zero}, false},
+		{[]interface{}{big.NewInt(proposalTypeCandidateAdd), candidate, big.NewInt(maxValidationLoopCnt + 1), zero, zero, zero}, false},
+		{[]interface{}{big.NewInt(proposalTypeCandidateAdd), candidate, zero, zero, zero, big.NewInt(maxProposalDeposit + 1)}, false},
+		{[]interface{}{new(big.Int).Lsh(big.NewInt(1), 64), candidate, zero, zero, zero, zero}, false},
+	}
+	for i, tt := range tests {
+		proposal, ok := governanceProposal(common.Hash{byte(i)}, proposer, tt.args)
+		if ok != tt.ok {
+			t.Errorf("test %d: result mismatch: have %v, want %v", i, ok, tt.ok)
+			continue
+		}
+		if ok && (proposal.Proposer != proposer || proposal.TargetAddress != candidate) {
+			t.Errorf("test %d: proposal mismatch: %v", i, proposal)
+		}
+	}
+}
+
+// Tests that the governance contract is installed in the block before the fork block.
+func TestInstallGovernance(t *testing.T) {
+	d := &DPoS{config: &params.DPoSConfig{GovernanceBlock: big.NewInt(10)}}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+
+	d.installGovernance(statedb, 8)
+	if statedb.GetCodeSize(GovernanceAddress) != 0 {
+		t.Fatalf("governance installed before fork")
+	}
+	d.installGovernance(statedb, 9)
+	if !bytes.Equal(statedb.GetCode(GovernanceAddress), governanceCode) {
+		t.Fatalf("governance not installed")
+	}
+	topic := governanceABI.Events["Governance"].ID()
+	if !bytes.Contains(governanceCode, topic.Bytes()) {
+		t.Fatalf("governance code misses the event topic")
+	}
+}
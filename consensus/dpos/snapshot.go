@@ -44,7 +44,7 @@ const (
 	defaultOfficialThirdLevelCount  = 30    // official third level, 40% in signer queue
 	defaultOfficialMaxValidCount    = 50    // official max valid candidate count, sort by vote
 
-	maxUncheckBalanceVoteCount = 10000 // not check current balance when calculate expired
+	maxUncheckBalanceVoteCount = 10000 // not check current balance when calculate expired, only in blocks before bonding
 	// the credit of one signer is at least minCalSignerQueueCredit
 	candidateStateNormal           = 1
 	candidateMaxLen                = 500 // if candidateNeedPD is false and candidate is more than candidateMaxLen, then minimum tickets candidates will be remove in each LCRS*loop
@@ -81,6 +81,15 @@ type Snapshot struct {
 	ProposalRefund  map[uint64]map[common.Address]*big.Int `json:"proposalRefund"`  // Refund proposal deposit
 	MinerReward     uint64                                 `json:"minerReward"`     // miner reward per thousand
 	MinVB           *big.Int                               `json:"minVoterBalance"` // min voter balance
+	Bonded          map[common.Address]*big.Int            `json:"bonded"`          // Stake locked by each voter
+	Unbonding       map[common.Address][]*Unbond           `json:"unbonding"`       // Stake of each voter waiting for unlock
+}
+
+// Unbond is the stake released by a devote, a lowered vote or an expired vote,
+// it returns to the balance of voter in the block after Release
+type Unbond struct {
+	Amount  *big.Int `json:"amount"`
+	Release uint64   `json:"release"` // Block number when the stake unlocks
 }
 
 // newSnapshot creates a new snapshot with the specified startup parameters. only ever use if for
@@ -108,6 +117,8 @@ func newSnapshot(config *params.DPoSConfig, sigcache *lru.ARCCache, hash common.
 		ProposalRefund:  make(map[uint64]map[common.Address]*big.Int),
 		MinerReward:     minerRewardPerThousand,
 		MinVB:           config.MinVoterBalance,
+		Bonded:          make(map[common.Address]*big.Int),
+		Unbonding:       make(map[common.Address][]*Unbond),
 	}
 	snap.HistoryHash = append(snap.HistoryHash, hash)
 
@@ -160,6 +171,13 @@ func loadSnapshot(config *params.DPoSConfig, sigcache *lru.ARCCache, db ethdb.Da
 	if snap.MinVB == nil {
 		snap.MinVB = new(big.Int).Set(minVoterBalance)
 	}
+	// snapshots stored before bonding
+	if snap.Bonded == nil {
+		snap.Bonded = make(map[common.Address]*big.Int)
+	}
+	if snap.Unbonding == nil {
+		snap.Unbonding = make(map[common.Address][]*Unbond)
+	}
 	return snap, nil
 }
 
@@ -199,6 +217,8 @@ func (s *Snapshot) copy() *Snapshot {
 
 		MinerReward: s.MinerReward,
 		MinVB:       nil,
+		Bonded:      make(map[common.Address]*big.Int),
+		Unbonding:   make(map[common.Address][]*Unbond),
 	}
 	copy(cpy.HistoryHash, s.HistoryHash)
 	copy(cpy.Signers, s.Signers)
@@ -235,6 +255,15 @@ func (s *Snapshot) copy() *Snapshot {
 			cpy.ProposalRefund[number][proposer] = new(big.Int).Set(deposit)
 		}
 	}
+	for voter, bonded := range s.Bonded {
+		cpy.Bonded[voter] = new(big.Int).Set(bonded)
+	}
+	for voter, unbonds := range s.Unbonding {
+		cpy.Unbonding[voter] = make([]*Unbond, len(unbonds))
+		for i, unbond := range unbonds {
+			cpy.Unbonding[voter][i] = &Unbond{Amount: new(big.Int).Set(unbond.Amount), Release: unbond.Release}
+		}
+	}
 	// miner reward per thousand proposal must larger than 0
 	// so minerReward is zeron only when update the program
 	if s.MinerReward == 0 {
@@ -297,13 +326,16 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 		}
 		snap.HistoryHash = append(snap.HistoryHash, header.Hash())
 
+		// drop the stake unlocked by the parent block
+		snap.updateSnapshotForUnbonded(header.Number)
+
 		// deal the new confirmation in this block
 		snap.updateSnapshotByConfirmations(headerExtra.CurrentBlockConfirmations)
 
 		// deal the new vote from voter
 		snap.updateSnapshotByVotes(headerExtra.CurrentBlockVotes, header.Number)
 
-		// deal the voter which balance modified
+		// deal the voter which balance modified, only in blocks before bonding
 		snap.updateSnapshotByMPVotes(headerExtra.ModifyPredecessorVotes)
 
 		// deal the snap related with punished
@@ -475,10 +507,11 @@ func (s *Snapshot) updateSnapshotByProposals(proposals []Proposal, headerNumber
 
 func (s *Snapshot) updateSnapshotForExpired(headerNumber *big.Int) {
 
-	// deal the expired vote
+	// deal the expired vote, the balance of voter is not checked since bonding as the stake is locked
 	var expiredVotes []*Vote
+	bonding := s.config.IsBonding(headerNumber)
 	checkBalance := false
-	if len(s.Voters) > maxUncheckBalanceVoteCount {
+	if !bonding && len(s.Voters) > maxUncheckBalanceVoteCount {
 		checkBalance = true
 	}
 
@@ -501,6 +534,9 @@ func (s *Snapshot) updateSnapshotForExpired(headerNumber *big.Int) {
 			}
 			delete(s.Votes, expiredVote.Voter)
 			delete(s.Voters, expiredVote.Voter)
+			if bonding {
+				s.bond(expiredVote.Voter, devoteStake, headerNumber)
+			}
 		}
 	}
 
@@ -545,6 +581,10 @@ func (s *Snapshot) updateSnapshotByVotes(votes []Vote, headerNumber *big.Int) {
 		if lastVote, ok := s.Votes[vote.Voter]; ok {
 			s.Tally[lastVote.Candidate].Sub(s.Tally[lastVote.Candidate], lastVote.Stake)
 		}
+		// the stake of vote is locked by processEventVote since bonding
+		if s.config.IsBonding(headerNumber) {
+			s.bond(vote.Voter, vote.Stake, headerNumber)
+		}
 		if vote.Stake.Cmp(devoteStake) == 0 {
 			delete(s.Votes, vote.Voter)
 			delete(s.Voters, vote.Voter)
@@ -565,6 +605,47 @@ func (s *Snapshot) updateSnapshotByVotes(votes []Vote, headerNumber *big.Int) {
 	}
 }
 
+// bond sets the locked stake of voter, the decreased part starts unbonding at the header
+func (s *Snapshot) bond(voter common.Address, stake *big.Int, headerNumber *big.Int) {
+	bonded := s.bondedStake(voter)
+	if released := new(big.Int).Sub(bonded, stake); released.Sign() > 0 {
+		s.Unbonding[voter] = append(s.Unbonding[voter], &Unbond{
+			Amount:  released,
+			Release: headerNumber.Uint64() + s.config.UnbondingLoops*s.config.MaxSignerCount,
+		})
+	}
+	if stake.Sign() > 0 {
+		s.Bonded[voter] = new(big.Int).Set(stake)
+	} else {
+		delete(s.Bonded, voter)
+	}
+}
+
+// bondedStake returns the stake locked by voter, stake of votes in genesis is not locked
+func (s *Snapshot) bondedStake(voter common.Address) *big.Int {
+	if bonded, ok := s.Bonded[voter]; ok {
+		return bonded
+	}
+	return new(big.Int)
+}
+
+// updateSnapshotForUnbonded removes the unbonding stake which is released before the header
+func (s *Snapshot) updateSnapshotForUnbonded(headerNumber *big.Int) {
+	for voter, unbonds := range s.Unbonding {
+		var remains []*Unbond
+		for _, unbond := range unbonds {
+			if unbond.Release >= headerNumber.Uint64() {
+				remains = append(remains, unbond)
+			}
+		}
+		if len(remains) > 0 {
+			s.Unbonding[voter] = remains
+		} else {
+			delete(s.Unbonding, voter)
+		}
+	}
+}
+
 func (s *Snapshot) updateSnapshotByMPVotes(votes []PredecessorVoter) {
 	for _, txVote := range votes {
 		if lastVote, ok := s.Votes[txVote.Voter]; ok {
@@ -693,6 +774,23 @@ func (s *Snapshot) calculateProposalRefund() map[common.Address]*big.Int {
 	return make(map[common.Address]*big.Int)
 }
 
+// calculateUnbonded returns the stake released at the snapshot block, which is unlocked in the next block
+func (s *Snapshot) calculateUnbonded() map[common.Address]*big.Int {
+	unbonded := make(map[common.Address]*big.Int)
+	for voter, unbonds := range s.Unbonding {
+		for _, unbond := range unbonds {
+			if unbond.Release != s.Number {
+				continue
+			}
+			if _, ok := unbonded[voter]; !ok {
+				unbonded[voter] = new(big.Int)
+			}
+			unbonded[voter].Add(unbonded[voter], unbond.Amount)
+		}
+	}
+	return unbonded
+}
+
 func (s *Snapshot) calculateVoteReward(coinbase common.Address, votersReward *big.Int) (map[common.Address]*big.Int, error) {
 	rewards := make(map[common.Address]*big.Int)
 	allStake := big.NewInt(0)
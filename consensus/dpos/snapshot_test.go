@@ -22,11 +22,13 @@ import (
 	"bytes"
 	"crypto/ecdsa"
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/simplechain-org/go-simplechain/common"
 	"github.com/simplechain-org/go-simplechain/core"
 	"github.com/simplechain-org/go-simplechain/core/rawdb"
+	"github.com/simplechain-org/go-simplechain/core/state"
 	"github.com/simplechain-org/go-simplechain/core/types"
 	"github.com/simplechain-org/go-simplechain/crypto"
 	"github.com/simplechain-org/go-simplechain/ethdb"
@@ -1138,3 +1140,135 @@ func TestVoting(t *testing.T) {
 
 	}
 }
+
+// Tests that the stake of votes is bonded, and unlocked after the unbonding period.
+func TestBonding(t *testing.T) {
+	accounts := newTesterAccountPool()
+	voter, candidate := accounts.address("A"), accounts.address("B")
+	config := &params.DPoSConfig{MaxSignerCount: 3, Epoch: 30000, UnbondingLoops: 2, MinVoterBalance: big.NewInt(50), BondingBlock: big.NewInt(0)}
+	snap := newSnapshot(config, nil, common.Hash{}, nil, 1)
+	release := uint64(1 + 2*3)
+
+	// lower the stake, the difference starts unbonding
+	snap.updateSnapshotByVotes([]Vote{{voter, candidate, big.NewInt(100)}}, big.NewInt(1))
+	snap.updateSnapshotByVotes([]Vote{{voter, candidate, big.NewInt(60)}}, big.NewInt(1))
+	if snap.Bonded[voter].Cmp(big.NewInt(60)) != 0 || snap.Tally[candidate].Cmp(big.NewInt(60)) != 0 {
+		t.Fatalf("bonded %v, tally %v, want 60", snap.Bonded[voter], snap.Tally[candidate])
+	}
+	// devote, all the bonded stake starts unbonding
+	snap.updateSnapshotByVotes([]Vote{{voter, common.Address{}, devoteStake}}, big.NewInt(2))
+	if _, ok := snap.Bonded[voter]; ok || snap.isVoter(voter) {
+		t.Fatalf("voter is still bonded after devote")
+	}
+	if len(snap.Unbonding[voter]) != 2 || snap.Unbonding[voter][0].Release != release || snap.Unbonding[voter][1].Release != release+1 {
+		t.Fatalf("unbonding mismatch: %v", snap.Unbonding[voter])
+	}
+
+	// unlock in the block after release
+	cpy := snap.copy()
+	cpy.Number = release - 1
+	if unbonded := cpy.calculateUnbonded(); len(unbonded) != 0 {
+		t.Fatalf("unlocked before release: %v", unbonded)
+	}
+	cpy.Number = release
+	if unbonded := cpy.calculateUnbonded(); unbonded[voter].Cmp(big.NewInt(40)) != 0 {
+		t.Fatalf("unlocked %v, want 40", unbonded[voter])
+	}
+	cpy.updateSnapshotForUnbonded(new(big.Int).SetUint64(release + 1))
+	if len(cpy.Unbonding[voter]) != 1 || cpy.Unbonding[voter][0].Amount.Cmp(big.NewInt(60)) != 0 {
+		t.Fatalf("unbonding mismatch after unlock: %v", cpy.Unbonding[voter])
+	}
+	cpy.updateSnapshotForUnbonded(new(big.Int).SetUint64(release + 2))
+	if len(cpy.Unbonding) != 0 {
+		t.Fatalf("unbonding is not removed: %v", cpy.Unbonding)
+	}
+	// the copy is deep
+	if len(snap.Unbonding[voter]) != 2 {
+		t.Fatalf("unbonding of origin snapshot is modified: %v", snap.Unbonding[voter])
+	}
+}
+
+// Tests that vote transactions lock the stake from the balance of voter.
+func TestProcessEventVote(t *testing.T) {
+	accounts := newTesterAccountPool()
+	voter, candidate := accounts.address("A"), accounts.address("B")
+	config := &params.DPoSConfig{MaxSignerCount: 3, Epoch: 30000, UnbondingLoops: 2, MinVoterBalance: big.NewInt(1e+18), BondingBlock: big.NewInt(0)}
+	snap := newSnapshot(config, nil, common.Hash{}, nil, 1)
+	d := &DPoS{config: config}
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	statedb.SetBalance(voter, new(big.Int).Mul(big.NewInt(10), big.NewInt(1e+18)))
+	vote := func(votes []Vote, data string) []Vote {
+		stake, _ := parseVoteStake(strings.Split(data, ":"))
+		return d.processEventVote(votes, stake, statedb, candidate, voter, snap)
+	}
+
+	// minVoterBalance is bonded by default
+	votes := vote(nil, "dpos:1:event:vote")
+	if len(votes) != 1 || votes[0].Stake.Cmp(snap.MinVB) != 0 {
+		t.Fatalf("votes mismatch: %v", votes)
+	}
+	// raise the stake in the same block, only the increase is locked
+	votes = vote(votes, "dpos:1:event:vote:stake:4")
+	if len(votes) != 2 || votes[1].Stake.Cmp(new(big.Int).Mul(big.NewInt(4), big.NewInt(1e+18))) != 0 {
+		t.Fatalf("votes mismatch: %v", votes)
+	}
+	if balance := statedb.GetBalance(voter); balance.Cmp(new(big.Int).Mul(big.NewInt(6), big.NewInt(1e+18))) != 0 {
+		t.Fatalf("balance %v, want 6e+18", balance)
+	}
+	// stake beyond the balance or below minVoterBalance is ignored
+	if votes = vote(votes, "dpos:1:event:vote:stake:20"); len(votes) != 2 {
+		t.Fatalf("vote beyond the balance is accepted")
+	}
+	if votes = vote(votes, "dpos:1:event:vote:stake:0"); len(votes) != 2 {
+		t.Fatalf("vote below minVoterBalance is accepted")
+	}
+}
+
+// Tests that votes follow the balance of voter before the bonding block, and the stake is bonded since it.
+func TestBondingFork(t *testing.T) {
+	accounts := newTesterAccountPool()
+	voter, candidate := accounts.address("A"), accounts.address("B")
+	config := &params.DPoSConfig{MaxSignerCount: 3, Epoch: 30000, UnbondingLoops: 2, MinVoterBalance: big.NewInt(1e+18), BondingBlock: big.NewInt(5)}
+	snap := newSnapshot(config, nil, common.Hash{}, nil, 1)
+	d := &DPoS{config: config}
+	if config.IsBonding(big.NewInt(4)) || !config.IsBonding(big.NewInt(5)) {
+		t.Fatalf("bonding block mismatch")
+	}
+
+	balance := new(big.Int).Mul(big.NewInt(10), big.NewInt(1e+18))
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	statedb.SetBalance(voter, balance)
+
+	// the balance is voted without locking before the bonding block
+	votes := d.processLegacyVote(nil, statedb, candidate, voter, snap)
+	if len(votes) != 1 || votes[0].Stake.Cmp(balance) != 0 {
+		t.Fatalf("votes mismatch: %v", votes)
+	}
+	if statedb.GetBalance(voter).Cmp(balance) != 0 {
+		t.Fatalf("balance %v, want %v", statedb.GetBalance(voter), balance)
+	}
+	snap.updateSnapshotByVotes(votes, big.NewInt(4))
+	if _, ok := snap.Bonded[voter]; ok || snap.Tally[candidate].Cmp(balance) != 0 {
+		t.Fatalf("bonded %v, tally %v before bonding", snap.Bonded[voter], snap.Tally[candidate])
+	}
+	// balance not above minVoterBalance is not voted
+	statedb.SetBalance(accounts.address("C"), snap.MinVB)
+	if votes := d.processLegacyVote(nil, statedb, candidate, accounts.address("C"), snap); len(votes) != 0 {
+		t.Fatalf("vote of minVoterBalance is accepted: %v", votes)
+	}
+
+	// the stake is locked and bonded since the bonding block
+	stake, _ := parseVoteStake(strings.Split("dpos:1:event:vote:stake:4", ":"))
+	votes = d.processEventVote(nil, stake, statedb, candidate, voter, snap)
+	if len(votes) != 1 {
+		t.Fatalf("votes mismatch: %v", votes)
+	}
+	snap.updateSnapshotByVotes(votes, big.NewInt(5))
+	if snap.Bonded[voter].Cmp(stake) != 0 || snap.Tally[candidate].Cmp(stake) != 0 {
+		t.Fatalf("bonded %v, tally %v, want %v", snap.Bonded[voter], snap.Tally[candidate], stake)
+	}
+	if want := new(big.Int).Sub(balance, stake); statedb.GetBalance(voter).Cmp(want) != 0 {
+		t.Fatalf("balance %v, want %v", statedb.GetBalance(voter), want)
+	}
+}
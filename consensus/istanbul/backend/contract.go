@@ -0,0 +1,103 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/simplechain-org/go-simplechain/accounts/abi"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/consensus"
+	"github.com/simplechain-org/go-simplechain/consensus/istanbul/validator"
+	"github.com/simplechain-org/go-simplechain/core"
+	"github.com/simplechain-org/go-simplechain/core/state"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/core/vm"
+	"github.com/simplechain-org/go-simplechain/log"
+)
+
+// validatorContractGas is the gas allowance for reading the validators from the contract
+const validatorContractGas uint64 = 10000000
+
+var (
+	validatorABI, _ = abi.JSON(strings.NewReader(validator.ContractABI))
+
+	// errEmptyValidators is returned if the validator contract returns no validators.
+	errEmptyValidators = errors.New("empty validators")
+)
+
+// readValidators calls getValidators of the validator contract on the state after the
+// transactions of header, the validators are returned in ascending order as in the snapshot.
+func (sb *backend) readValidators(chain consensus.ChainReader, header *types.Header, statedb *state.StateDB) ([]common.Address, error) {
+	input, err := validatorABI.Pack("getValidators")
+	if err != nil {
+		return nil, err
+	}
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash: func(n uint64) common.Hash {
+			if h := chain.GetHeaderByNumber(n); h != nil {
+				return h.Hash()
+			}
+			return common.Hash{}
+		},
+		GasPrice:    new(big.Int),
+		Nonce:       new(big.Int),
+		Coinbase:    header.Coinbase,
+		GasLimit:    header.GasLimit,
+		BlockNumber: new(big.Int).Set(header.Number),
+		Time:        new(big.Int).SetUint64(header.Time),
+		Difficulty:  new(big.Int).Set(header.Difficulty),
+	}
+	evm := vm.NewEVM(context, statedb.Copy(), chain.Config(), vm.Config{})
+	ret, _, err := evm.StaticCall(vm.AccountRef(common.Address{}), *sb.config.ValidatorContract, input, validatorContractGas)
+	if err != nil {
+		return nil, err
+	}
+	var validators []common.Address
+	if err := validatorABI.Unpack(&validators, "getValidators", ret); err != nil {
+		return nil, err
+	}
+	if len(validators) == 0 {
+		return nil, errEmptyValidators
+	}
+	sort.Slice(validators, func(i, j int) bool {
+		return bytes.Compare(validators[i][:], validators[j][:]) < 0
+	})
+	return validators, nil
+}
+
+// contractValidators returns the validators after the block of header, which are read from the
+// validator contract. The validators of parent are kept if the contract fails or returns none.
+func (sb *backend) contractValidators(chain consensus.ChainReader, header *types.Header, statedb *state.StateDB) ([]common.Address, error) {
+	validators, err := sb.readValidators(chain, header, statedb)
+	if err == nil {
+		return validators, nil
+	}
+	log.Warn("Failed to read validators from contract, keep the current ones", "number", header.Number, "contract", sb.config.ValidatorContract, "err", err)
+
+	snap, err := sb.snapshot(chain, header.Number.Uint64()-1, header.ParentHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	return snap.validators(), nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/consensus/istanbul"
+	"github.com/simplechain-org/go-simplechain/consensus/istanbul/validator"
+	"github.com/simplechain-org/go-simplechain/core"
+	"github.com/simplechain-org/go-simplechain/core/rawdb"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/core/vm"
+	"github.com/simplechain-org/go-simplechain/crypto"
+)
+
+func TestContractValidators(t *testing.T) {
+	genesis, nodeKeys := getGenesisAndKeys(1)
+	var (
+		contract = validator.DefaultContractAddress
+		addr     = crypto.PubkeyToAddress(nodeKeys[0].PublicKey)
+		other    = common.HexToAddress("0x01")
+	)
+	genesis.Alloc = core.GenesisAlloc{
+		contract: {
+			Code:    validator.ContractCode,
+			Storage: validator.ContractStorage(addr, []common.Address{addr, other}),
+			Balance: new(big.Int),
+		},
+	}
+	config := *istanbul.DefaultConfig
+	config.ValidatorContract = &contract
+	config.ValidatorPerBlock = true
+
+	db := rawdb.NewMemoryDatabase()
+	engine := New(&config, nodeKeys[0], db).(*backend)
+	genesis.MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, genesis.Config, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+
+	block := makeBlockWithoutSeal(chain, engine, chain.Genesis())
+	if block == nil {
+		t.Fatalf("failed to assemble block")
+	}
+	istanbulExtra, err := types.ExtractIstanbulExtra(block.Header())
+	if err != nil {
+		t.Fatalf("failed to extract istanbul extra: %v", err)
+	}
+	if want := []common.Address{other, addr}; !reflect.DeepEqual(istanbulExtra.Validators, want) {
+		t.Errorf("validators mismatch: have %x, want %x", istanbulExtra.Validators, want)
+	}
+
+	state, _ := chain.StateAt(chain.Genesis().Root())
+	header := block.Header()
+	if err := engine.Finalize(chain, header, state, nil, nil, nil); err != nil {
+		t.Errorf("error mismatch: have %v, want nil", err)
+	}
+	header.Extra, _ = prepareExtra(header, []common.Address{addr})
+	if err := engine.Finalize(chain, header, state, nil, nil, nil); err != errInvalidValidators {
+		t.Errorf("error mismatch: have %v, want %v", err, errInvalidValidators)
+	}
+
+	// Validators of parent are kept if the contract returns nothing
+	config.ValidatorContract = &common.Address{}
+	header.Extra, _ = prepareExtra(header, []common.Address{addr})
+	if err := engine.Finalize(chain, header, state, nil, nil, nil); err != nil {
+		t.Errorf("error mismatch: have %v, want nil", err)
+	}
+}
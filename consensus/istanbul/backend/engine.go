@@ -82,6 +82,9 @@ var (
 	errEmptyCommittedSeals = errors.New("zero committed seals")
 	// errMismatchTxhashes is returned if the TxHash in header is mismatch.
 	errMismatchTxhashes = errors.New("mismatch transactions hashes")
+	// errInvalidValidators is returned if the validators in extra-data differ from the ones
+	// read from the validator contract.
+	errInvalidValidators = errors.New("invalid validators")
 )
 var (
 	defaultDifficulty = big.NewInt(1)
@@ -355,12 +358,12 @@ func (sb *backend) Prepare(chain consensus.ChainReader, header *types.Header) er
 		return err
 	}
 
-	// get valid candidate list
+	// get valid candidate list, validators are managed by the contract instead of votes if configured
 	sb.candidatesLock.RLock()
 	var addresses []common.Address
 	var authorizes []bool
 	for address, authorize := range sb.candidates {
-		if snap.checkVote(address, authorize) {
+		if sb.config.ValidatorContract == nil && snap.checkVote(address, authorize) {
 			addresses = append(addresses, address)
 			authorizes = append(authorizes, authorize)
 		}
@@ -404,6 +407,26 @@ func (sb *backend) Finalize(chain consensus.ChainReader, header *types.Header, s
 	// No block rewards in Istanbul, so the state remains as is and uncles are dropped
 	header.Root = state.IntermediateRoot(true)
 	header.UncleHash = nilUncleHash
+
+	// Ensure the validators in extra-data are the ones read from the validator contract
+	if sb.config.IsValidatorUpdate(header.Number.Uint64()) {
+		validators, err := sb.contractValidators(chain, header, state)
+		if err != nil {
+			return err
+		}
+		istanbulExtra, err := types.ExtractIstanbulExtra(header)
+		if err != nil {
+			return err
+		}
+		if len(istanbulExtra.Validators) != len(validators) {
+			return errInvalidValidators
+		}
+		for i, val := range validators {
+			if istanbulExtra.Validators[i] != val {
+				return errInvalidValidators
+			}
+		}
+	}
 	return nil
 }
 
@@ -413,6 +436,17 @@ func (sb *backend) FinalizeAndAssemble(chain consensus.ChainReader, header *type
 	header.Root = state.IntermediateRoot(true)
 	header.UncleHash = nilUncleHash
 
+	// Replace the validators in extra-data by the ones read from the validator contract
+	if sb.config.IsValidatorUpdate(header.Number.Uint64()) {
+		validators, err := sb.contractValidators(chain, header, state)
+		if err != nil {
+			return nil, err
+		}
+		if header.Extra, err = prepareExtra(header, validators); err != nil {
+			return nil, err
+		}
+	}
+
 	// Assemble and return the final block for sealing
 	return types.NewBlock(header, txs, nil, receipts), nil
 }
@@ -613,7 +647,12 @@ func (sb *backend) snapshot(chain consensus.ChainReader, number uint64, hash com
 	for i := 0; i < len(headers)/2; i++ {
 		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
 	}
-	snap, err := snap.apply(headers)
+	var err error
+	if sb.config.ValidatorContract != nil {
+		snap, err = snap.applyContract(headers, sb.config.IsValidatorUpdate)
+	} else {
+		snap, err = snap.apply(headers)
+	}
 	if err != nil {
 		return nil, err
 	}
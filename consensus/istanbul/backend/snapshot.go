@@ -166,13 +166,8 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 		return s, nil
 	}
 	// Sanity check that the headers can be applied
-	for i := 0; i < len(headers)-1; i++ {
-		if headers[i+1].Number.Uint64() != headers[i].Number.Uint64()+1 {
-			return nil, errInvalidVotingChain
-		}
-	}
-	if headers[0].Number.Uint64() != s.Number+1 {
-		return nil, errInvalidVotingChain
+	if err := s.checkChain(headers); err != nil {
+		return nil, err
 	}
 	// Iterate through the headers and create a new snapshot
 	snap := s.copy()
@@ -258,6 +253,64 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 	return snap, nil
 }
 
+// applyContract creates a new authorization snapshot by applying the given headers
+// to the original one, while the validators are managed by the validator contract.
+// The votes in headers are ignored, and the validators are replaced by the ones in
+// extra-data of the headers updating validators.
+func (s *Snapshot) applyContract(headers []*types.Header, update func(number uint64) bool) (*Snapshot, error) {
+	// Allow passing in no headers for cleaner code
+	if len(headers) == 0 {
+		return s, nil
+	}
+	// Sanity check that the headers can be applied
+	if err := s.checkChain(headers); err != nil {
+		return nil, err
+	}
+	// Iterate through the headers and create a new snapshot
+	snap := s.copy()
+	snap.Votes = nil
+	snap.Tally = make(map[common.Address]Tally)
+
+	for _, header := range headers {
+		// Resolve the authorization key and check against validators
+		signer, err := ecrecover(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, v := snap.ValSet.GetByAddress(signer); v == nil {
+			return nil, errUnauthorized
+		}
+		if !update(header.Number.Uint64()) {
+			continue
+		}
+		istanbulExtra, err := types.ExtractIstanbulExtra(header)
+		if err != nil {
+			return nil, err
+		}
+		if len(istanbulExtra.Validators) == 0 {
+			return nil, errEmptyValidators
+		}
+		snap.ValSet = validator.NewSet(istanbulExtra.Validators, snap.ValSet.Policy())
+	}
+	snap.Number += uint64(len(headers))
+	snap.Hash = headers[len(headers)-1].Hash()
+
+	return snap, nil
+}
+
+// checkChain checks that the headers are contiguous on top of the snapshot.
+func (s *Snapshot) checkChain(headers []*types.Header) error {
+	for i := 0; i < len(headers)-1; i++ {
+		if headers[i+1].Number.Uint64() != headers[i].Number.Uint64()+1 {
+			return errInvalidVotingChain
+		}
+	}
+	if headers[0].Number.Uint64() != s.Number+1 {
+		return errInvalidVotingChain
+	}
+	return nil
+}
+
 // validators retrieves the list of authorized validators in ascending order.
 func (s *Snapshot) validators() []common.Address {
 	validators := make([]common.Address, 0, s.ValSet.Size())
@@ -406,6 +406,58 @@ func TestVoting(t *testing.T) {
 	}
 }
 
+func TestApplyContract(t *testing.T) {
+	accounts := newTesterAccountPool()
+	update := func(number uint64) bool { return number%2 == 0 }
+
+	snap := newSnapshot(istanbul.DefaultConfig.Epoch, 0, common.Hash{}, validator.NewSet([]common.Address{
+		accounts.address("A"), accounts.address("B"),
+	}, istanbul.RoundRobin))
+
+	// B votes C in, but only the validators in extra-data of block 2 are applied
+	signers := []string{"A", "B", "C"}
+	validators := [][]common.Address{
+		{accounts.address("A"), accounts.address("B")},
+		{accounts.address("C")},
+		{accounts.address("A")},
+	}
+	headers := make([]*types.Header, len(signers))
+	for i, signer := range signers {
+		headers[i] = &types.Header{
+			Number:     big.NewInt(int64(i) + 1),
+			Coinbase:   accounts.address("C"),
+			Difficulty: defaultDifficulty,
+			MixDigest:  types.IstanbulDigest,
+		}
+		copy(headers[i].Nonce[:], nonceAuthVote)
+		headers[i].Extra, _ = prepareExtra(headers[i], validators[i])
+		if i > 0 {
+			headers[i].ParentHash = headers[i-1].Hash()
+		}
+		accounts.sign(headers[i], signer)
+	}
+	result, err := snap.applyContract(headers, update)
+	if err != nil {
+		t.Fatalf("failed to apply headers: %v", err)
+	}
+	if have, want := result.validators(), []common.Address{accounts.address("C")}; !reflect.DeepEqual(have, want) {
+		t.Errorf("validators mismatch: have %x, want %x", have, want)
+	}
+	if len(result.Votes) != 0 || len(result.Tally) != 0 {
+		t.Errorf("votes mismatch: have %v, %v, want none", result.Votes, result.Tally)
+	}
+	if result.Number != 3 || result.Hash != headers[2].Hash() {
+		t.Errorf("snapshot mismatch: have %d %x, want %d %x", result.Number, result.Hash, 3, headers[2].Hash())
+	}
+
+	// A is not authorized after block 2
+	headers[2].Extra, _ = prepareExtra(headers[2], validators[2])
+	accounts.sign(headers[2], "A")
+	if _, err := snap.applyContract(headers, update); err != errUnauthorized {
+		t.Errorf("error mismatch: have %v, want %v", err, errUnauthorized)
+	}
+}
+
 func TestSaveAndLoad(t *testing.T) {
 	snap := &Snapshot{
 		Epoch:  5,
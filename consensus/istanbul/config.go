@@ -16,6 +16,12 @@
 
 package istanbul
 
+import (
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain/common"
+)
+
 type ProposerPolicy uint64
 
 const (
@@ -28,6 +34,10 @@ type Config struct {
 	BlockPeriod    uint64         `toml:",omitempty"` // Default minimum difference between two consecutive block's timestamps in second
 	ProposerPolicy ProposerPolicy `toml:",omitempty"` // The policy for proposer selection
 	Epoch          uint64         `toml:",omitempty"` // The number of blocks after which to checkpoint and reset the pending votes
+	QBFTBlock      *big.Int       `toml:",omitempty"` // The block from which the QBFT round change is used
+
+	ValidatorContract *common.Address `toml:",omitempty"` // The contract managing validators, validators are voted by headers if nil
+	ValidatorPerBlock bool            `toml:",omitempty"` // Whether validators are read from the contract every block instead of each epoch
 }
 
 var DefaultConfig = &Config{
@@ -36,3 +46,18 @@ var DefaultConfig = &Config{
 	ProposerPolicy: RoundRobin,
 	Epoch:          30000,
 }
+
+// IsQBFT returns whether the sequence runs the QBFT round change, which justifies the
+// ROUND CHANGE and PRE-PREPARE messages with prepared certificates instead of locking the proposal.
+func (c *Config) IsQBFT(sequence *big.Int) bool {
+	return c.QBFTBlock != nil && sequence != nil && c.QBFTBlock.Cmp(sequence) <= 0
+}
+
+// IsValidatorUpdate returns whether the block of number replaces the validators by the
+// ones read from the validator contract after its transactions.
+func (c *Config) IsValidatorUpdate(number uint64) bool {
+	if c.ValidatorContract == nil || number == 0 {
+		return false
+	}
+	return c.ValidatorPerBlock || (c.Epoch > 0 && number%c.Epoch == 0)
+}
@@ -19,6 +19,7 @@ package core
 import (
 	"github.com/simplechain-org/go-simplechain/common/prque"
 	"github.com/simplechain-org/go-simplechain/consensus/istanbul"
+	"github.com/simplechain-org/go-simplechain/rlp"
 )
 
 var (
@@ -93,20 +94,8 @@ func (c *core) storeBacklog(msg *message, src istanbul.Validator) {
 	if backlog == nil {
 		backlog = prque.New(nil)
 	}
-	switch msg.Code {
-	case msgPreprepare:
-		var p *istanbul.Preprepare
-		err := msg.Decode(&p)
-		if err == nil {
-			backlog.Push(msg, toPriority(msg.Code, p.View))
-		}
-		// for msgRoundChange, msgPrepare and msgCommit cases
-	default:
-		var p *istanbul.Subject
-		err := msg.Decode(&p)
-		if err == nil {
-			backlog.Push(msg, toPriority(msg.Code, p.View))
-		}
+	if view := messageView(msg); view != nil {
+		backlog.Push(msg, toPriority(msg.Code, view))
 	}
 	c.backlogs[src.Address()] = backlog
 }
@@ -134,22 +123,7 @@ func (c *core) processBacklog() {
 		for !(backlog.Empty() || isFuture) {
 			m, prio := backlog.Pop()
 			msg := m.(*message)
-			var view *istanbul.View
-			switch msg.Code {
-			case msgPreprepare:
-				var m *istanbul.Preprepare
-				err := msg.Decode(&m)
-				if err == nil {
-					view = m.View
-				}
-				// for msgRoundChange, msgPrepare and msgCommit cases
-			default:
-				var sub *istanbul.Subject
-				err := msg.Decode(&sub)
-				if err == nil {
-					view = sub.View
-				}
-			}
+			view := messageView(msg)
 			if view == nil {
 				logger.Debug("Nil view", "msg", msg)
 				continue
@@ -176,6 +150,28 @@ func (c *core) processBacklog() {
 	}
 }
 
+// messageView returns the view of the message, nil if the message is malformed.
+// The ROUND CHANGE of QBFT carries the prepared certificate after the view.
+func messageView(msg *message) *istanbul.View {
+	switch msg.Code {
+	case msgPreprepare:
+		var p *istanbul.Preprepare
+		if err := msg.Decode(&p); err == nil {
+			return p.View
+		}
+		// for msgRoundChange, msgPrepare and msgCommit cases
+	default:
+		var sub struct {
+			View *istanbul.View
+			Rest []rlp.RawValue `rlp:"tail"`
+		}
+		if err := msg.Decode(&sub); err == nil {
+			return sub.View
+		}
+	}
+	return nil
+}
+
 func toPriority(msgCode uint64, view *istanbul.View) int64 {
 	if msgCode == msgRoundChange {
 		// For msgRoundChange, set the message priority based on its sequence
@@ -74,7 +74,9 @@ func (c *core) handleCommit(msg *message, src istanbul.Validator) error {
 	// by committing the proposal without PREPARE messages.
 	if c.current.Commits.Size() >= c.Confirmations() && c.state.Cmp(StateCommitted) < 0 {
 		// Still need to call LockHash here since state can skip Prepared state and jump directly to the Committed state.
-		c.current.LockHash()
+		if !c.config.IsQBFT(commit.View.Sequence) {
+			c.current.LockHash()
+		}
 		c.commit()
 	}
 
@@ -241,8 +241,12 @@ func (c *core) startNewRound(round *big.Int) {
 
 	// Update logger
 	logger = logger.New("old_proposer", c.valSet.GetProposer())
-	// Clear invalid ROUND CHANGE messages
-	c.roundChangeSet = newRoundChangeSet(c.valSet)
+	// Clear invalid ROUND CHANGE messages, QBFT keeps those of the new round to justify the PRE-PREPARE
+	if roundChange && c.config.IsQBFT(newView.Sequence) {
+		c.roundChangeSet.Clear(newView.Round)
+	} else {
+		c.roundChangeSet = newRoundChangeSet(c.valSet)
+	}
 	// New snapshot for new round
 	c.updateRoundState(newView, c.valSet, roundChange)
 	// Calculate new proposer
@@ -252,7 +256,12 @@ func (c *core) startNewRound(round *big.Int) {
 	if roundChange && c.IsProposer() && c.current != nil {
 		// If it is locked, propose the old proposal
 		// If we have pending request, propose pending request
-		if c.current.IsHashLocked() {
+		// QBFT proposes the highest prepared block of the ROUND CHANGE messages instead of the locked one
+		if c.config.IsQBFT(newView.Sequence) {
+			if r := c.justifiedRequest(); r != nil {
+				c.sendPreprepare(r)
+			}
+		} else if c.current.IsHashLocked() {
 			r := &istanbul.Request{
 				Proposal: c.current.Proposal(), //c.current.Proposal would be the locked proposal by previous proposer, see updateRoundState
 			}
@@ -286,11 +295,13 @@ func (c *core) catchUpRound(view *istanbul.View) {
 func (c *core) updateRoundState(view *istanbul.View, validatorSet istanbul.ValidatorSet, roundChange bool) {
 	// Lock only if both roundChange is true and it is locked
 	if roundChange && c.current != nil {
+		prev := c.current
 		if c.current.IsHashLocked() {
 			c.current = newRoundState(view, validatorSet, c.current.GetLockedHash(), c.current.Preprepare, c.current.pendingRequest, c.backend.HasBadProposal)
 		} else {
 			c.current = newRoundState(view, validatorSet, common.Hash{}, nil, c.current.pendingRequest, c.backend.HasBadProposal)
 		}
+		c.current.inheritPrepared(prev)
 	} else {
 		c.current = newRoundState(view, validatorSet, common.Hash{}, nil, nil, c.backend.HasBadProposal)
 	}
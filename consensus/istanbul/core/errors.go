@@ -45,4 +45,7 @@ var (
 	// errFailedDecodeMessageSet = errors.New("failed to decode message set")
 	// errInvalidSigner is returned when the message is signed by a validator different than message sender
 	errInvalidSigner = errors.New("message not signed by the sender")
+	// errInvalidJustification is returned when the prepared certificate of ROUND CHANGE or
+	// the round changes of PRE-PREPARE can not justify the message in QBFT.
+	errInvalidJustification = errors.New("invalid justification")
 )
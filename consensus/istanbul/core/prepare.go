@@ -59,6 +59,18 @@ func (c *core) handlePrepare(msg *message, src istanbul.Validator) error {
 
 	c.acceptPrepare(msg, src)
 
+	// QBFT records the prepared certificate instead of locking the proposal
+	if c.config.IsQBFT(prepare.View.Sequence) {
+		if c.current.Prepares.Size() >= c.Confirmations() && c.state.Cmp(StatePrepared) < 0 {
+			if err := c.current.SetPrepared(c.current.Prepares.Values()); err != nil {
+				return err
+			}
+			c.setState(StatePrepared)
+			c.sendCommit()
+		}
+		return nil
+	}
+
 	// Change to Prepared state if we've received enough PREPARE messages or it is locked
 	// and we are in earlier state before Prepared state.
 	if ((c.current.IsHashLocked() && prepare.Digest == c.current.GetLockedHash()) || c.current.GetPrepareOrCommitSize() >= c.Confirmations()) &&
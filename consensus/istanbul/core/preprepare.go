@@ -29,10 +29,18 @@ func (c *core) sendPreprepare(request *istanbul.Request) {
 	// If I'm the proposer and I have the same sequence with the proposal
 	if c.current.Sequence().Cmp(request.Proposal.Number()) == 0 && c.IsProposer() {
 		curView := c.currentView()
-		preprepare, err := Encode(&istanbul.Preprepare{
+		pp := &istanbul.Preprepare{
 			View:     curView,
 			Proposal: request.Proposal,
-		})
+		}
+		// The PRE-PREPARE of QBFT after round 0 is justified by the ROUND CHANGE messages
+		if c.config.IsQBFT(curView.Sequence) && curView.Round.Sign() > 0 {
+			if err := c.justifyPreprepare(pp); err != nil {
+				logger.Warn("Failed to justify preprepare", "view", curView, "err", err)
+				return
+			}
+		}
+		preprepare, err := Encode(pp)
 		if err != nil {
 			logger.Error("Failed to encode", "view", curView)
 			return
@@ -79,6 +87,14 @@ func (c *core) handlePreprepare(msg *message, src istanbul.Validator) error {
 		return errNotFromProposer
 	}
 
+	// QBFT accepts the PRE-PREPARE after round 0 only if it is justified by ROUND CHANGE messages
+	if c.config.IsQBFT(preprepare.View.Sequence) && preprepare.View.Round.Sign() > 0 {
+		if err := c.verifyJustification(preprepare); err != nil {
+			logger.Warn("Ignore unjustified preprepare", "err", err)
+			return err
+		}
+	}
+
 	// Verify the proposal we received
 	if duration, err := c.backend.Verify(preprepare.Proposal); err != nil {
 		// if it's a future block, we will handle it again after the duration
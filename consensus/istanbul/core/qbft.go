@@ -0,0 +1,159 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/consensus/istanbul"
+)
+
+// QBFT replaces the locked proposal of IBFT with justifications: the ROUND CHANGE message
+// piggybacks the prepared certificate of its sender, and the PRE-PREPARE after round 0 carries
+// a quorum of ROUND CHANGE messages, which forces the highest prepared block to be proposed.
+
+// newRoundChange builds the ROUND CHANGE message of QBFT with the prepared certificate of current sequence
+func (c *core) newRoundChange(view *istanbul.View) *istanbul.RoundChange {
+	rc := &istanbul.RoundChange{
+		View:          view,
+		PreparedRound: new(big.Int),
+	}
+	if round, block, cert := c.current.Prepared(); block != nil {
+		rc.PreparedRound, rc.PreparedBlock, rc.Prepares = round, block, cert
+	}
+	return rc
+}
+
+// signedMessages decodes the payloads signed by distinct validators with the code, invalid ones are skipped
+func (c *core) signedMessages(payloads [][]byte, code uint64) []*message {
+	var (
+		msgs []*message
+		seen = make(map[common.Address]bool)
+	)
+	for _, payload := range payloads {
+		msg := new(message)
+		if err := msg.FromPayload(payload, c.validateFn); err != nil || msg.Code != code || seen[msg.Address] {
+			continue
+		}
+		if _, v := c.valSet.GetByAddress(msg.Address); v == nil {
+			continue
+		}
+		seen[msg.Address] = true
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+// verifyRoundChange verifies the prepared certificate piggybacked by the ROUND CHANGE message,
+// which is a quorum of PREPARE messages on the prepared block in an earlier round
+func (c *core) verifyRoundChange(rc *istanbul.RoundChange) error {
+	if rc.PreparedBlock == nil {
+		return nil
+	}
+	if rc.PreparedRound == nil || rc.PreparedRound.Cmp(rc.View.Round) >= 0 || rc.PreparedBlock.Number().Cmp(rc.View.Sequence) != 0 {
+		return errInvalidJustification
+	}
+	prepared := &istanbul.View{
+		Round:    rc.PreparedRound,
+		Sequence: rc.View.Sequence,
+	}
+	count := 0
+	for _, msg := range c.signedMessages(rc.Prepares, msgPrepare) {
+		var prepare *istanbul.Subject
+		if err := msg.Decode(&prepare); err != nil || prepare.View == nil || prepare.View.Round == nil || prepare.View.Sequence == nil {
+			continue
+		}
+		if prepare.View.Cmp(prepared) == 0 && prepare.Digest == rc.PreparedBlock.Hash() {
+			count++
+		}
+	}
+	if count < c.Confirmations() {
+		return errInvalidJustification
+	}
+	return nil
+}
+
+// highestPrepared returns the block prepared in the highest round by the valid ROUND CHANGE messages
+func (c *core) highestPrepared(msgs []*message) istanbul.Proposal {
+	var (
+		round *big.Int
+		block istanbul.Proposal
+	)
+	for _, msg := range msgs {
+		var rc *istanbul.RoundChange
+		if err := msg.Decode(&rc); err != nil || rc.View == nil || rc.PreparedBlock == nil || c.verifyRoundChange(rc) != nil {
+			continue
+		}
+		if round == nil || rc.PreparedRound.Cmp(round) > 0 {
+			round, block = rc.PreparedRound, rc.PreparedBlock
+		}
+	}
+	return block
+}
+
+// justifiedRequest returns the request to propose after the round change, which is the highest prepared
+// block of the ROUND CHANGE messages, or the pending request if no block is prepared
+func (c *core) justifiedRequest() *istanbul.Request {
+	if prepared := c.highestPrepared(c.roundChangeSet.Values(c.current.Round())); prepared != nil {
+		return &istanbul.Request{Proposal: prepared}
+	}
+	return c.current.pendingRequest
+}
+
+// justifyPreprepare attaches the ROUND CHANGE messages of the round to the PRE-PREPARE,
+// the proposal is replaced by the highest prepared block of them
+func (c *core) justifyPreprepare(preprepare *istanbul.Preprepare) error {
+	msgs := c.roundChangeSet.Values(preprepare.View.Round)
+	if len(msgs) < c.Confirmations() {
+		return errInvalidJustification
+	}
+	if prepared := c.highestPrepared(msgs); prepared != nil {
+		preprepare.Proposal = prepared
+	}
+	preprepare.RoundChanges = make([][]byte, 0, len(msgs))
+	for _, msg := range msgs {
+		payload, err := msg.Payload()
+		if err != nil {
+			return err
+		}
+		preprepare.RoundChanges = append(preprepare.RoundChanges, payload)
+	}
+	return nil
+}
+
+// verifyJustification verifies the PRE-PREPARE after round 0 is justified by a quorum of ROUND CHANGE
+// messages of its view, and it proposes the highest prepared block of them if any
+func (c *core) verifyJustification(preprepare *istanbul.Preprepare) error {
+	var msgs []*message
+	for _, msg := range c.signedMessages(preprepare.RoundChanges, msgRoundChange) {
+		var rc *istanbul.RoundChange
+		if err := msg.Decode(&rc); err != nil || rc.View == nil || rc.View.Round == nil || rc.View.Sequence == nil {
+			continue
+		}
+		if rc.View.Cmp(preprepare.View) == 0 {
+			msgs = append(msgs, msg)
+		}
+	}
+	if len(msgs) < c.Confirmations() {
+		return errInvalidJustification
+	}
+	if prepared := c.highestPrepared(msgs); prepared != nil && prepared.Hash() != preprepare.Proposal.Hash() {
+		return errInvalidJustification
+	}
+	return nil
+}
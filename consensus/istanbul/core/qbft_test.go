@@ -0,0 +1,291 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/consensus/istanbul"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/rlp"
+)
+
+// newQBFTSystem returns a test system switching to QBFT at the block
+func newQBFTSystem(n uint64, qbftBlock int64, requestTimeout uint64) *testSystem {
+	sys := NewTestSystemWithBackend(n, 0)
+	for _, backend := range sys.backends {
+		config := *istanbul.DefaultConfig
+		config.QBFTBlock = big.NewInt(qbftBlock)
+		config.RequestTimeout = requestTimeout
+		backend.engine.(*core).config = &config
+	}
+	return sys
+}
+
+// makeQBFTBlock makes blocks of the same number with different hashes
+func makeQBFTBlock(number int64, time uint64) *types.Block {
+	header := &types.Header{
+		Difficulty: big.NewInt(0),
+		Number:     big.NewInt(number),
+		Time:       time,
+	}
+	block := &types.Block{}
+	return block.WithSeal(header)
+}
+
+// signedPayload returns the payload signed by the signer, which is recovered by the signature of test backend
+func signedPayload(t *testing.T, code uint64, val interface{}, signer common.Address) []byte {
+	m, err := Encode(val)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	msg := &message{
+		Code:          code,
+		Msg:           m,
+		Address:       signer,
+		Signature:     signer.Bytes(),
+		CommittedSeal: []byte{},
+	}
+	payload, err := msg.Payload()
+	if err != nil {
+		t.Fatalf("failed to encode message: %v", err)
+	}
+	return payload
+}
+
+// prepareCert returns the PREPARE messages of the validators on the digest
+func prepareCert(t *testing.T, vals []istanbul.Validator, view *istanbul.View, digest common.Hash) [][]byte {
+	var cert [][]byte
+	for _, v := range vals {
+		cert = append(cert, signedPayload(t, msgPrepare, &istanbul.Subject{View: view, Digest: digest}, v.Address()))
+	}
+	return cert
+}
+
+func TestVerifyRoundChange(t *testing.T) {
+	sys := newQBFTSystem(4, 0, 10000)
+	c := sys.backends[0].engine.(*core)
+	vals := c.valSet.List()
+
+	block := makeQBFTBlock(1, 1)
+	view := &istanbul.View{Round: big.NewInt(2), Sequence: big.NewInt(1)}
+	prepared := &istanbul.View{Round: big.NewInt(1), Sequence: big.NewInt(1)}
+
+	testCases := []struct {
+		rc  *istanbul.RoundChange
+		err error
+	}{
+		{
+			// not prepared
+			&istanbul.RoundChange{View: view, PreparedRound: new(big.Int)},
+			nil,
+		},
+		{
+			// prepared by a quorum
+			&istanbul.RoundChange{View: view, PreparedRound: big.NewInt(1), PreparedBlock: block, Prepares: prepareCert(t, vals[:3], prepared, block.Hash())},
+			nil,
+		},
+		{
+			// less than a quorum
+			&istanbul.RoundChange{View: view, PreparedRound: big.NewInt(1), PreparedBlock: block, Prepares: prepareCert(t, vals[:2], prepared, block.Hash())},
+			errInvalidJustification,
+		},
+		{
+			// duplicated prepares
+			&istanbul.RoundChange{View: view, PreparedRound: big.NewInt(1), PreparedBlock: block, Prepares: prepareCert(t, []istanbul.Validator{vals[0], vals[0], vals[0]}, prepared, block.Hash())},
+			errInvalidJustification,
+		},
+		{
+			// prepares of another block
+			&istanbul.RoundChange{View: view, PreparedRound: big.NewInt(1), PreparedBlock: block, Prepares: prepareCert(t, vals, prepared, makeQBFTBlock(1, 2).Hash())},
+			errInvalidJustification,
+		},
+		{
+			// prepared in the round of the change
+			&istanbul.RoundChange{View: view, PreparedRound: big.NewInt(2), PreparedBlock: block, Prepares: prepareCert(t, vals, view, block.Hash())},
+			errInvalidJustification,
+		},
+	}
+	for i, test := range testCases {
+		payload, err := Encode(test.rc)
+		if err != nil {
+			t.Fatalf("test %d: failed to encode: %v", i, err)
+		}
+		var rc *istanbul.RoundChange
+		if err := rlp.DecodeBytes(payload, &rc); err != nil {
+			t.Fatalf("test %d: failed to decode: %v", i, err)
+		}
+		if err := c.verifyRoundChange(rc); err != test.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+}
+
+func TestJustifyPreprepare(t *testing.T) {
+	sys := newQBFTSystem(4, 0, 10000)
+	c := sys.backends[1].engine.(*core)
+	vals := c.valSet.List()
+
+	view := &istanbul.View{Round: big.NewInt(2), Sequence: big.NewInt(1)}
+	c.current = newTestRoundState(view, c.valSet)
+	c.roundChangeSet = newRoundChangeSet(c.valSet)
+
+	pending, low, high := makeQBFTBlock(1, 1), makeQBFTBlock(1, 2), makeQBFTBlock(1, 3)
+	rcs := []*istanbul.RoundChange{
+		{View: view, PreparedRound: new(big.Int)},
+		{View: view, PreparedRound: big.NewInt(0), PreparedBlock: low, Prepares: prepareCert(t, vals[:3], &istanbul.View{Round: big.NewInt(0), Sequence: big.NewInt(1)}, low.Hash())},
+		{View: view, PreparedRound: big.NewInt(1), PreparedBlock: high, Prepares: prepareCert(t, vals[1:], &istanbul.View{Round: big.NewInt(1), Sequence: big.NewInt(1)}, high.Hash())},
+	}
+
+	pp := &istanbul.Preprepare{View: view, Proposal: pending}
+	for i, rc := range rcs {
+		// not justified before a quorum of ROUND CHANGE messages
+		if err := c.justifyPreprepare(pp); err != errInvalidJustification {
+			t.Fatalf("error mismatch: have %v, want %v", err, errInvalidJustification)
+		}
+		msg := new(message)
+		if err := msg.FromPayload(signedPayload(t, msgRoundChange, rc, vals[i].Address()), nil); err != nil {
+			t.Fatalf("failed to decode message: %v", err)
+		}
+		if _, err := c.roundChangeSet.Add(view.Round, msg); err != nil {
+			t.Fatalf("failed to add round change: %v", err)
+		}
+	}
+
+	// the highest prepared block is proposed instead of the pending one
+	if err := c.justifyPreprepare(pp); err != nil {
+		t.Fatalf("error mismatch: have %v, want nil", err)
+	}
+	if pp.Proposal.Hash() != high.Hash() {
+		t.Errorf("proposal mismatch: have %v, want %v", pp.Proposal.Hash(), high.Hash())
+	}
+	if len(pp.RoundChanges) != len(rcs) {
+		t.Errorf("the number of round changes mismatch: have %v, want %v", len(pp.RoundChanges), len(rcs))
+	}
+
+	// the justification survives the encoding
+	payload, err := Encode(pp)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	var decoded *istanbul.Preprepare
+	if err := rlp.DecodeBytes(payload, &decoded); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if err := c.verifyJustification(decoded); err != nil {
+		t.Errorf("error mismatch: have %v, want nil", err)
+	}
+
+	// the lower prepared block is rejected
+	decoded.Proposal = low
+	if err := c.verifyJustification(decoded); err != errInvalidJustification {
+		t.Errorf("error mismatch: have %v, want %v", err, errInvalidJustification)
+	}
+	// round changes less than a quorum are rejected
+	decoded.Proposal = high
+	decoded.RoundChanges = decoded.RoundChanges[:2]
+	if err := c.verifyJustification(decoded); err != errInvalidJustification {
+		t.Errorf("error mismatch: have %v, want %v", err, errInvalidJustification)
+	}
+	// round changes of another view are rejected
+	decoded.RoundChanges = pp.RoundChanges
+	decoded.View = &istanbul.View{Round: big.NewInt(3), Sequence: big.NewInt(1)}
+	if err := c.verifyJustification(decoded); err != errInvalidJustification {
+		t.Errorf("error mismatch: have %v, want %v", err, errInvalidJustification)
+	}
+}
+
+// TestQBFTMigration commits a block by IBFT before the fork, then the proposer of round 0 misses
+// the request at the fork block and the block is committed after a QBFT round change.
+func TestQBFTMigration(t *testing.T) {
+	sys := newQBFTSystem(4, 2, 500)
+
+	close := sys.Run(true)
+	defer close()
+
+	// replica 0 is the proposer of round 0
+	request1 := makeBlock(1)
+	sys.backends[0].NewRequest(request1)
+
+	<-time.After(1 * time.Second)
+
+	// replica 1 is the proposer of round 1
+	request2 := makeBlock(2)
+	sys.backends[1].NewRequest(request2)
+
+	<-time.After(2 * time.Second)
+
+	for i, backend := range sys.backends {
+		if len(backend.committedMsgs) != 2 {
+			t.Fatalf("replica %d: the number of executed requests mismatch: have %v, want 2", i, len(backend.committedMsgs))
+		}
+		if hash := backend.committedMsgs[0].commitProposal.Hash(); hash != request1.Hash() {
+			t.Errorf("replica %d: the committed request mismatch: have %v, want %v", i, hash, request1.Hash())
+		}
+		if hash := backend.committedMsgs[1].commitProposal.Hash(); hash != request2.Hash() {
+			t.Errorf("replica %d: the committed request mismatch: have %v, want %v", i, hash, request2.Hash())
+		}
+	}
+}
+
+// TestQBFTMigrationPrepared prepares a block in round 0 of the fork block, then the proposer of round 0
+// goes silent and the COMMIT messages are lost, the block is re-proposed after the round change instead
+// of the pending request of the next proposer.
+func TestQBFTMigrationPrepared(t *testing.T) {
+	sys := newQBFTSystem(4, 2, 500)
+	faulty := sys.backends[0].address
+	sys.drop = func(msg *message) bool {
+		view := messageView(msg)
+		if view == nil || view.Sequence.Cmp(big.NewInt(2)) != 0 || view.Round.Sign() != 0 {
+			return false
+		}
+		return msg.Code == msgCommit || (msg.Address == faulty && msg.Code != msgPreprepare)
+	}
+
+	close := sys.Run(true)
+	defer close()
+
+	// replica 0 is the proposer of round 0
+	request1 := makeBlock(1)
+	sys.backends[0].NewRequest(request1)
+
+	// the requests of the fork block are sent before its round 0 times out
+	<-time.After(200 * time.Millisecond)
+
+	// replica 1 is the proposer of round 1 with its own pending request
+	prepared, pending := makeQBFTBlock(2, 1), makeQBFTBlock(2, 2)
+	sys.backends[1].NewRequest(pending)
+	<-time.After(100 * time.Millisecond)
+	sys.backends[0].NewRequest(prepared)
+
+	<-time.After(3 * time.Second)
+
+	for i, backend := range sys.backends {
+		if len(backend.committedMsgs) != 2 {
+			t.Fatalf("replica %d: the number of executed requests mismatch: have %v, want 2", i, len(backend.committedMsgs))
+		}
+		if hash := backend.committedMsgs[0].commitProposal.Hash(); hash != request1.Hash() {
+			t.Errorf("replica %d: the committed request mismatch: have %v, want %v", i, hash, request1.Hash())
+		}
+		if hash := backend.committedMsgs[1].commitProposal.Hash(); hash != prepared.Hash() {
+			t.Errorf("replica %d: the committed request mismatch: have %v, want %v", i, hash, prepared.Hash())
+		}
+	}
+}
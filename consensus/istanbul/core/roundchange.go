@@ -48,10 +48,14 @@ func (c *core) sendRoundChange(round *big.Int) {
 
 	// Now we have the new round number and sequence number
 	cv = c.currentView()
-	rc := &istanbul.Subject{
+	var rc interface{} = &istanbul.Subject{
 		View:   cv,
 		Digest: common.Hash{},
 	}
+	// The ROUND CHANGE of QBFT piggybacks the prepared certificate
+	if c.config.IsQBFT(cv.Sequence) {
+		rc = c.newRoundChange(cv)
+	}
 
 	payload, err := Encode(rc)
 	if err != nil {
@@ -69,18 +73,35 @@ func (c *core) handleRoundChange(msg *message, src istanbul.Validator) error {
 	logger := c.logger.New("state", c.state, "from", src.Address().Hex())
 
 	// Decode ROUND CHANGE message
-	var rc *istanbul.Subject
-	if err := msg.Decode(&rc); err != nil {
-		logger.Error("Failed to decode ROUND CHANGE", "err", err)
-		return errInvalidMessage
-	}
-
-	if err := c.checkMessage(msgRoundChange, rc.View); err != nil {
-		return err
+	var roundView *istanbul.View
+	if view := messageView(msg); view != nil && c.config.IsQBFT(view.Sequence) {
+		var rc *istanbul.RoundChange
+		if err := msg.Decode(&rc); err != nil {
+			logger.Error("Failed to decode ROUND CHANGE", "err", err)
+			return errInvalidMessage
+		}
+		if err := c.checkMessage(msgRoundChange, rc.View); err != nil {
+			return err
+		}
+		// Verify the prepared certificate before it justifies the PRE-PREPARE of the round
+		if err := c.verifyRoundChange(rc); err != nil {
+			logger.Warn("Invalid prepared certificate in ROUND CHANGE", "rc", rc, "err", err)
+			return err
+		}
+		roundView = rc.View
+	} else {
+		var rc *istanbul.Subject
+		if err := msg.Decode(&rc); err != nil {
+			logger.Error("Failed to decode ROUND CHANGE", "err", err)
+			return errInvalidMessage
+		}
+		if err := c.checkMessage(msgRoundChange, rc.View); err != nil {
+			return err
+		}
+		roundView = rc.View
 	}
 
 	cv := c.currentView()
-	roundView := rc.View
 
 	// Add the ROUND CHANGE message to its message set and return how many
 	// messages we've got with the same round number and sequence number.
@@ -154,6 +175,17 @@ func (rcs *roundChangeSet) Clear(round *big.Int) {
 	}
 }
 
+// Values returns the ROUND CHANGE messages of the round
+func (rcs *roundChangeSet) Values(r *big.Int) []*message {
+	rcs.mu.Lock()
+	defer rcs.mu.Unlock()
+
+	if rms := rcs.roundChanges[r.Uint64()]; rms != nil {
+		return rms.Values()
+	}
+	return nil
+}
+
 // MaxRound returns the max round which the number of messages is equal or larger than num
 func (rcs *roundChangeSet) MaxRound(num int) *big.Int {
 	rcs.mu.Lock()
@@ -53,6 +53,11 @@ type roundState struct {
 	lockedHash     common.Hash
 	pendingRequest *istanbul.Request
 
+	// prepared certificate of QBFT, it is kept across the rounds of a sequence
+	preparedRound *big.Int
+	preparedBlock istanbul.Proposal
+	preparedCert  [][]byte
+
 	mu             *sync.RWMutex
 	hasBadProposal func(hash common.Hash) bool
 }
@@ -168,6 +173,46 @@ func (s *roundState) GetLockedHash() common.Hash {
 	return s.lockedHash
 }
 
+// SetPrepared records the PREPARE messages of current proposal as the prepared certificate
+func (s *roundState) SetPrepared(prepares []*message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Preprepare == nil {
+		return errInvalidMessage
+	}
+	cert := make([][]byte, 0, len(prepares))
+	for _, m := range prepares {
+		payload, err := m.Payload()
+		if err != nil {
+			return err
+		}
+		cert = append(cert, payload)
+	}
+	s.preparedRound = new(big.Int).Set(s.round)
+	s.preparedBlock = s.Preprepare.Proposal
+	s.preparedCert = cert
+	return nil
+}
+
+// Prepared returns the latest prepared certificate, the proposal is nil if not prepared
+func (s *roundState) Prepared() (*big.Int, istanbul.Proposal, [][]byte) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.preparedRound, s.preparedBlock, s.preparedCert
+}
+
+// inheritPrepared keeps the prepared certificate of the previous round in the same sequence
+func (s *roundState) inheritPrepared(prev *roundState) {
+	round, block, cert := prev.Prepared()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.preparedRound, s.preparedBlock, s.preparedCert = round, block, cert
+}
+
 // The DecodeRLP method should read one value from the given
 // Stream. It is not forbidden to read less or more, but it might
 // be confusing.
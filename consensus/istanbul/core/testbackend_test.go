@@ -169,6 +169,8 @@ type testSystem struct {
 
 	queuedMessage chan istanbul.MessageEvent
 	quit          chan struct{}
+
+	drop func(msg *message) bool // messages lost in the network, set before Run
 }
 
 func newTestSystem(n uint64) *testSystem {
@@ -234,6 +236,9 @@ func (t *testSystem) listen() {
 			return
 		case queuedMessage := <-t.queuedMessage:
 			testLogger.Info("consuming a queue message...")
+			if msg := new(message); t.drop != nil && msg.FromPayload(queuedMessage.Payload, nil) == nil && t.drop(msg) {
+				continue
+			}
 			for _, backend := range t.backends {
 				go backend.EventMux().Post(queuedMessage)
 			}
@@ -96,28 +96,80 @@ func (v *View) Cmp(y *View) int {
 type Preprepare struct {
 	View     *View
 	Proposal Proposal
+
+	// RoundChanges are the signed ROUND CHANGE messages justifying the proposal of QBFT after round 0,
+	// they are appended to the encoding of IBFT only if present.
+	RoundChanges [][]byte
 }
 
 // EncodeRLP serializes b into the Ethereum RLP format.
 func (b *Preprepare) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, []interface{}{b.View, b.Proposal})
+	fields := []interface{}{b.View, b.Proposal}
+	for _, rc := range b.RoundChanges {
+		fields = append(fields, rc)
+	}
+	return rlp.Encode(w, fields)
 }
 
 // DecodeRLP implements rlp.Decoder, and load the consensus fields from a RLP stream.
 func (b *Preprepare) DecodeRLP(s *rlp.Stream) error {
 	var preprepare struct {
-		View     *View
-		Proposal *types.Block
+		View         *View
+		Proposal     *types.Block
+		RoundChanges [][]byte `rlp:"tail"`
 	}
 
 	if err := s.Decode(&preprepare); err != nil {
 		return err
 	}
 	b.View, b.Proposal = preprepare.View, preprepare.Proposal
+	if len(preprepare.RoundChanges) > 0 {
+		b.RoundChanges = preprepare.RoundChanges
+	}
+
+	return nil
+}
+
+// RoundChange is the ROUND CHANGE message of QBFT, it piggybacks the prepared certificate of the sender.
+// PreparedBlock is nil if the sender has not prepared any block in the sequence.
+type RoundChange struct {
+	View          *View
+	PreparedRound *big.Int
+	PreparedBlock Proposal
+	Prepares      [][]byte // signed PREPARE messages of the prepared block
+}
+
+// EncodeRLP serializes b into the Ethereum RLP format.
+func (b *RoundChange) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, []interface{}{b.View, b.PreparedRound, b.PreparedBlock, b.Prepares})
+}
 
+// DecodeRLP implements rlp.Decoder, and load the consensus fields from a RLP stream.
+func (b *RoundChange) DecodeRLP(s *rlp.Stream) error {
+	var rc struct {
+		View          *View
+		PreparedRound *big.Int
+		PreparedBlock *types.Block `rlp:"nil"`
+		Prepares      [][]byte
+	}
+
+	if err := s.Decode(&rc); err != nil {
+		return err
+	}
+	b.View, b.PreparedRound, b.PreparedBlock, b.Prepares = rc.View, rc.PreparedRound, nil, rc.Prepares
+	if rc.PreparedBlock != nil {
+		b.PreparedBlock = rc.PreparedBlock
+	}
 	return nil
 }
 
+func (b *RoundChange) String() string {
+	if b.PreparedBlock == nil {
+		return fmt.Sprintf("{View: %v}", b.View)
+	}
+	return fmt.Sprintf("{View: %v, PreparedRound: %d, PreparedDigest: %v}", b.View, b.PreparedRound.Uint64(), b.PreparedBlock.Hash().String())
+}
+
 type Subject struct {
 	View   *View
 	Digest common.Hash
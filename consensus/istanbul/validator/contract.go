@@ -0,0 +1,80 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package validator
+
+import (
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/crypto"
+)
+
+// ContractABI is the interface of the validator management contract, Istanbul reads
+// getValidators at the blocks updating validators if config.ValidatorContract is set
+//
+// getValidators(): the current validators
+// addValidator(validator): authorize the validator, only by admin
+// removeValidator(validator): deauthorize the validator, only by admin and the last validator is kept
+// admin(): the account managing validators
+const ContractABI = `[
+	{"type":"function","name":"getValidators","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address[]"}]},
+	{"type":"function","name":"addValidator","stateMutability":"nonpayable","inputs":[{"name":"validator","type":"address"}],"outputs":[]},
+	{"type":"function","name":"removeValidator","stateMutability":"nonpayable","inputs":[{"name":"validator","type":"address"}],"outputs":[]},
+	{"type":"function","name":"admin","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]}
+]`
+
+// DefaultContractAddress is the address where the validator management contract is preallocated in genesis
+var DefaultContractAddress = common.HexToAddress("0x0000000000000000000000000000000000001000")
+
+// ContractCode is the runtime code of the validator management contract preallocated in genesis,
+// it rejects value and unknown methods. The admin is kept at slot 0, the number of validators at slot 1,
+// the validators in order from slot keccak256(1) and the index+1 of each validator at keccak256(validator, 2).
+//
+//	CALLVALUE revert JUMPI  PUSH1 0 CALLDATALOAD PUSH1 0xe0 SHR          ; dispatch the selector
+//	get:    return abi.encode(slot[keccak256(1)+i] for i < slot[1])
+//	admin:  return slot[0]
+//	add:    require(CALLER == slot[0] && validator != 0); if index[validator] == 0, append it
+//	remove: require(CALLER == slot[0]); if index[validator] != 0, require(slot[1] > 1),
+//	        move the last validator to its index and pop
+var ContractCode = common.FromHex("0x346100375760003560e01c8063b7ab4db51461003c5780634d238c8e1461008957806340a141ff146100e3578063f851a4401461007d575b600080fd" +
+	"5b6020600052600154806020526001604052602060402060005b8281101561007157808201548160200260400152600101610055565b50506020026040016000f3" +
+	"5b60005460005260206000f3" +
+	"5b3360005414156100375760043573ffffffffffffffffffffffffffffffffffffffff168015610037578060005260026020526040600020805461016057" +
+	"600154806001018060015582556001600052602060002001829055005b" +
+	"3360005414156100375760043573ffffffffffffffffffffffffffffffffffffffff1680600052600260205260406000208054801561016057" +
+	"600154600281106100375760016000526020600020600182038101805460018503830181905560005260406000208490556000905550600190036001555060009055005b00")
+
+// ContractStorage returns the genesis storage of ContractCode managed by admin with the validators
+func ContractStorage(admin common.Address, validators []common.Address) map[common.Hash]common.Hash {
+	var (
+		storage = make(map[common.Hash]common.Hash)
+		base    = crypto.Keccak256Hash(common.BigToHash(big.NewInt(1)).Bytes()).Big()
+		count   int64
+	)
+	storage[common.Hash{}] = admin.Hash()
+	for _, val := range validators {
+		index := crypto.Keccak256Hash(val.Hash().Bytes(), common.BigToHash(big.NewInt(2)).Bytes())
+		if _, ok := storage[index]; ok || val == (common.Address{}) {
+			continue
+		}
+		storage[common.BigToHash(new(big.Int).Add(base, big.NewInt(count)))] = val.Hash()
+		count++
+		storage[index] = common.BigToHash(big.NewInt(count))
+	}
+	storage[common.BigToHash(big.NewInt(1))] = common.BigToHash(big.NewInt(count))
+	return storage
+}
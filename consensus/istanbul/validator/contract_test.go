@@ -0,0 +1,102 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package validator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/accounts/abi"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/core/rawdb"
+	"github.com/simplechain-org/go-simplechain/core/state"
+	"github.com/simplechain-org/go-simplechain/core/vm/runtime"
+)
+
+func TestContract(t *testing.T) {
+	contractABI, err := abi.JSON(strings.NewReader(ContractABI))
+	if err != nil {
+		t.Fatalf("failed to parse abi: %v", err)
+	}
+	var (
+		admin = common.HexToAddress("0xad")
+		addr1 = common.HexToAddress("0x01")
+		addr2 = common.HexToAddress("0x02")
+		addr3 = common.HexToAddress("0x03")
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	statedb.SetCode(DefaultContractAddress, ContractCode)
+	for key, value := range ContractStorage(admin, []common.Address{addr1, addr2, addr1}) {
+		statedb.SetState(DefaultContractAddress, key, value)
+	}
+
+	call := func(from common.Address, method string, args ...interface{}) ([]byte, error) {
+		input, err := contractABI.Pack(method, args...)
+		if err != nil {
+			t.Fatalf("failed to pack %s: %v", method, err)
+		}
+		ret, _, err := runtime.Call(DefaultContractAddress, input, &runtime.Config{State: statedb, Origin: from})
+		return ret, err
+	}
+	validators := func() []common.Address {
+		ret, err := call(addr1, "getValidators")
+		if err != nil {
+			t.Fatalf("failed to get validators: %v", err)
+		}
+		var vals []common.Address
+		if err := contractABI.Unpack(&vals, "getValidators", ret); err != nil {
+			t.Fatalf("failed to unpack validators: %v", err)
+		}
+		return vals
+	}
+
+	if have, want := validators(), []common.Address{addr1, addr2}; !reflect.DeepEqual(have, want) {
+		t.Errorf("genesis validators mismatch: have %x, want %x", have, want)
+	}
+	ret, err := call(addr1, "admin")
+	if err != nil || common.BytesToAddress(ret) != admin {
+		t.Errorf("admin mismatch: have %x, %v, want %x", ret, err, admin)
+	}
+	if _, err := call(addr1, "addValidator", addr3); err == nil {
+		t.Errorf("expected revert of adding validator by non-admin")
+	}
+	if _, err := call(admin, "addValidator", addr3); err != nil {
+		t.Errorf("failed to add validator: %v", err)
+	}
+	if _, err := call(admin, "addValidator", addr3); err != nil {
+		t.Errorf("failed to add validator again: %v", err)
+	}
+	if have, want := validators(), []common.Address{addr1, addr2, addr3}; !reflect.DeepEqual(have, want) {
+		t.Errorf("validators mismatch after add: have %x, want %x", have, want)
+	}
+	if _, err := call(admin, "removeValidator", addr1); err != nil {
+		t.Errorf("failed to remove validator: %v", err)
+	}
+	if have, want := validators(), []common.Address{addr3, addr2}; !reflect.DeepEqual(have, want) {
+		t.Errorf("validators mismatch after remove: have %x, want %x", have, want)
+	}
+	if _, err := call(admin, "removeValidator", addr2); err != nil {
+		t.Errorf("failed to remove validator: %v", err)
+	}
+	if _, err := call(admin, "removeValidator", addr3); err == nil {
+		t.Errorf("expected revert of removing the last validator")
+	}
+	if have, want := validators(), []common.Address{addr3}; !reflect.DeepEqual(have, want) {
+		t.Errorf("validators mismatch after removing the last: have %x, want %x", have, want)
+	}
+}
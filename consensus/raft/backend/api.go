@@ -8,6 +8,7 @@ type RaftNodeInfo struct {
 	Address        *raft.Address   `json:"address"`
 	PeerAddresses  []*raft.Address `json:"peerAddresses"`
 	RemovedPeerIds []uint16        `json:"removedPeerIds"`
+	LearnerIds     []uint16        `json:"learnerIds"`
 	AppliedIndex   uint64          `json:"appliedIndex"`
 	SnapshotIndex  uint64          `json:"snapshotIndex"`
 }
@@ -25,7 +26,17 @@ func (s *PublicRaftAPI) Role() string {
 }
 
 func (s *PublicRaftAPI) AddPeer(enodeId string) (uint16, error) {
-	return s.raftService.raftProtocolManager.ProposeNewPeer(enodeId)
+	return s.raftService.raftProtocolManager.ProposeNewPeer(enodeId, false)
+}
+
+// AddLearner adds the node as a non-voting learner, which is promoted to a voter manually
+// by PromoteToPeer or automatically by the leader with --raftautopromote once caught up.
+func (s *PublicRaftAPI) AddLearner(enodeId string) (uint16, error) {
+	return s.raftService.raftProtocolManager.ProposeNewPeer(enodeId, true)
+}
+
+func (s *PublicRaftAPI) PromoteToPeer(raftId uint16) (bool, error) {
+	return s.raftService.raftProtocolManager.PromoteToPeer(raftId)
 }
 
 func (s *PublicRaftAPI) RemovePeer(raftId uint16) {
@@ -37,7 +37,7 @@ type RaftService struct {
 	nodeKey  *ecdsa.PrivateKey
 }
 
-func New(ctx *node.ServiceContext, raftId, raftPort uint16, joinExisting bool, e *sub.Ethereum, startPeers []*enode.Node, datadir string) (*RaftService, error) {
+func New(ctx *node.ServiceContext, raftId, raftPort uint16, joinExisting, autoPromote bool, e *sub.Ethereum, startPeers []*enode.Node, datadir string) (*RaftService, error) {
 	service := &RaftService{
 		eventMux:       ctx.EventMux,
 		chainDb:        e.ChainDb(),
@@ -58,7 +58,7 @@ func New(ctx *node.ServiceContext, raftId, raftPort uint16, joinExisting bool, e
 	service.minter = miner.New(service, &e.Config().Miner, e.ChainConfig(), service.eventMux, engine, nil)
 
 	var err error
-	if service.raftProtocolManager, err = NewProtocolManager(raftId, raftPort, service.blockchain, service.eventMux, startPeers, joinExisting, datadir, service.minter, service.downloader); err != nil {
+	if service.raftProtocolManager, err = NewProtocolManager(raftId, raftPort, service.blockchain, service.eventMux, startPeers, joinExisting, autoPromote, datadir, service.minter, service.downloader); err != nil {
 		return nil, err
 	}
 
@@ -36,6 +36,11 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 )
 
+// confChangeAddLearnerNode adds a non-voting learner. The pinned etcd raft has no learners and applies
+// ConfChangeUpdateNode without changing its ConfState, so the learners are tracked and replicated to
+// by the ProtocolManager instead.
+const confChangeAddLearnerNode = raftpb.ConfChangeUpdateNode
+
 type ProtocolManager struct {
 	mu       sync.RWMutex // For protecting concurrent JS access to "local peer" and "remote peer" state
 	quitSync chan struct{}
@@ -43,6 +48,7 @@ type ProtocolManager struct {
 
 	// Static configuration
 	joinExisting   bool // Whether to join an existing cluster when a WAL doesn't already exist
+	autoPromote    bool // Whether to promote the caught-up learners to voters when leading the cluster
 	bootstrapNodes []*enode.Node
 	raftId         uint16
 	raftPort       uint16
@@ -57,6 +63,9 @@ type ProtocolManager struct {
 	leader       uint16
 	peers        map[uint16]*raft.Peer
 	removedPeers mapset.Set // *Permanently removed* peers
+	learners     mapset.Set // Non-voting peers catching up before promotion
+
+	learnerProgress map[uint16]*learnerProgress // Log replication progress of the learners, only when leading
 
 	// P2P transport
 	p2pServer *p2p.Server // Initialized in start()
@@ -98,7 +107,7 @@ type ProtocolManager struct {
 // Public interface
 //
 
-func NewProtocolManager(raftId uint16, raftPort uint16, blockchain *core.BlockChain, mux *event.TypeMux, bootstrapNodes []*enode.Node, joinExisting bool, datadir string, minter *miner.Miner, downloader *downloader.Downloader) (*ProtocolManager, error) {
+func NewProtocolManager(raftId uint16, raftPort uint16, blockchain *core.BlockChain, mux *event.TypeMux, bootstrapNodes []*enode.Node, joinExisting, autoPromote bool, datadir string, minter *miner.Miner, downloader *downloader.Downloader) (*ProtocolManager, error) {
 	waldir := fmt.Sprintf("%s/raft-wal", datadir)
 	snapdir := fmt.Sprintf("%s/raft-snap", datadir)
 	raftDbLoc := fmt.Sprintf("%s/raft-state", datadir)
@@ -108,7 +117,10 @@ func NewProtocolManager(raftId uint16, raftPort uint16, blockchain *core.BlockCh
 		peers:               make(map[uint16]*raft.Peer),
 		leader:              uint16(etcdRaft.None),
 		removedPeers:        mapset.NewSet(),
+		learners:            mapset.NewSet(),
+		learnerProgress:     make(map[uint16]*learnerProgress),
 		joinExisting:        joinExisting,
+		autoPromote:         autoPromote,
 		blockchain:          blockchain,
 		eventMux:            mux,
 		blockProposalC:      make(chan *types.Block),
@@ -190,6 +202,8 @@ func (pm *ProtocolManager) NodeInfo() *RaftNodeInfo {
 	var roleDescription string
 	if pm.role == raft.MinterRole {
 		roleDescription = "minter"
+	} else if pm.learners.Contains(pm.raftId) {
+		roleDescription = "learner"
 	} else {
 		roleDescription = "verifier"
 	}
@@ -209,6 +223,11 @@ func (pm *ProtocolManager) NodeInfo() *RaftNodeInfo {
 		i++
 	}
 
+	learnerIds := make([]uint16, 0, pm.learners.Cardinality())
+	for learnerIface := range pm.learners.Iterator().C {
+		learnerIds = append(learnerIds, learnerIface.(uint16))
+	}
+
 	//
 	// NOTE: before exposing any new fields here, make sure that the underlying
 	// ProtocolManager members are protected from concurrent access by pm.mu!
@@ -219,6 +238,7 @@ func (pm *ProtocolManager) NodeInfo() *RaftNodeInfo {
 		Address:        pm.address,
 		PeerAddresses:  peerAddresses,
 		RemovedPeerIds: removedPeerIds,
+		LearnerIds:     learnerIds,
 		AppliedIndex:   pm.appliedIndex,
 		SnapshotIndex:  pm.snapshotIndex,
 	}
@@ -268,6 +288,13 @@ func (pm *ProtocolManager) isRaftIdRemoved(id uint16) bool {
 	return pm.removedPeers.Contains(id)
 }
 
+func (pm *ProtocolManager) isLearner(id uint16) bool {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	return pm.learners.Contains(id)
+}
+
 func (pm *ProtocolManager) isRaftIdUsed(raftId uint16) bool {
 	if pm.raftId == raftId || pm.isRaftIdRemoved(raftId) {
 		return true
@@ -303,7 +330,9 @@ func (pm *ProtocolManager) isNodeAlreadyInCluster(node *enode.Node) error {
 	return nil
 }
 
-func (pm *ProtocolManager) ProposeNewPeer(enodeId string) (uint16, error) {
+// ProposeNewPeer proposes to add the node as a voter, or as a non-voting learner
+// which is not counted in the quorum until it is promoted.
+func (pm *ProtocolManager) ProposeNewPeer(enodeId string, isLearner bool) (uint16, error) {
 	node, err := enode.ParseV4(enodeId)
 	if err != nil {
 		return 0, err
@@ -324,8 +353,13 @@ func (pm *ProtocolManager) ProposeNewPeer(enodeId string) (uint16, error) {
 	raftId := pm.nextRaftId()
 	address := raft.NewAddress(raftId, node.RaftPort(), node)
 
+	confChangeType := raftpb.ConfChangeAddNode
+	if isLearner {
+		confChangeType = confChangeAddLearnerNode
+	}
+
 	pm.confChangeProposalC <- raftpb.ConfChange{
-		Type:    raftpb.ConfChangeAddNode,
+		Type:    confChangeType,
 		NodeID:  uint64(raftId),
 		Context: address.ToBytes(),
 	}
@@ -333,6 +367,29 @@ func (pm *ProtocolManager) ProposeNewPeer(enodeId string) (uint16, error) {
 	return raftId, nil
 }
 
+// PromoteToPeer proposes to promote the learner to a voter.
+func (pm *ProtocolManager) PromoteToPeer(raftId uint16) (bool, error) {
+	if !pm.isLearner(raftId) {
+		return false, fmt.Errorf("%d is not a learner in the cluster", raftId)
+	}
+
+	pm.mu.RLock()
+	peer := pm.peers[raftId]
+	pm.mu.RUnlock()
+
+	if peer == nil {
+		return false, fmt.Errorf("learner %d is not connected", raftId)
+	}
+
+	pm.confChangeProposalC <- raftpb.ConfChange{
+		Type:    raftpb.ConfChangeAddNode,
+		NodeID:  uint64(raftId),
+		Context: peer.Address.ToBytes(),
+	}
+
+	return true, nil
+}
+
 func (pm *ProtocolManager) ProposePeerRemoval(raftId uint16) {
 	pm.confChangeProposalC <- raftpb.ConfChange{
 		Type:   raftpb.ConfChangeRemoveNode,
@@ -357,6 +414,11 @@ func (pm *ProtocolManager) WriteMsg(msg p2p.Msg) error {
 //
 
 func (pm *ProtocolManager) Process(ctx context.Context, m raftpb.Message) error {
+	// Raft doesn't know the learners, so their responses only advance the replication to learners.
+	if m.Type == raftpb.MsgAppResp && pm.isLearner(uint16(m.From)) {
+		pm.updateLearnerProgress(uint16(m.From), m)
+		return nil
+	}
 	return pm.rawNode().Step(ctx, m)
 }
 
@@ -377,6 +439,10 @@ func (pm *ProtocolManager) ReportSnapshot(id uint64, status etcdRaft.SnapshotSta
 		log.Info("finished sending snapshot", "raft peer", id)
 	}
 
+	if pm.isLearner(uint16(id)) {
+		pm.reportLearnerSnapshot(uint16(id), status)
+		return
+	}
 	pm.rawNode().ReportSnapshot(id, status)
 }
 
@@ -500,6 +566,11 @@ func (pm *ProtocolManager) startRaft() {
 	go pm.serveLocalProposals()
 	go pm.eventLoop()
 	go pm.handleRoleChange(pm.rawNode().RoleChan().Out())
+	go pm.replicateToLearnersLoop()
+
+	if pm.autoPromote {
+		go pm.promoteLearnersLoop()
+	}
 }
 
 func (pm *ProtocolManager) setLocalAddress(addr *raft.Address) {
@@ -563,6 +634,75 @@ func (pm *ProtocolManager) handleRoleChange(roleC <-chan interface{}) {
 	}
 }
 
+// promoteLearnersLoop periodically proposes to promote the learners whose logs have caught up
+// with the committed index, only the leader tracks the progress of the learners.
+func (pm *ProtocolManager) promoteLearnersLoop() {
+	ticker := time.NewTicker(raft.PromotionCheckMS * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			pm.mu.RLock()
+			isLeader := pm.role == raft.MinterRole
+			pm.mu.RUnlock()
+
+			if !isLeader {
+				continue
+			}
+
+			status := pm.rawNode().Status()
+			for _, raftId := range pm.learnerIds() {
+				match, ok := pm.learnerMatch(raftId)
+				if !ok || match+raft.LearnerPromotionGap < status.Commit {
+					continue
+				}
+				log.Info("promoting caught-up learner", "raft id", raftId, "match", match, "commit", status.Commit)
+
+				if _, err := pm.PromoteToPeer(raftId); err != nil {
+					log.Warn("failed to promote learner", "raft id", raftId, "err", err)
+				}
+			}
+
+		case <-pm.quitSync:
+			return
+		}
+	}
+}
+
+// replicateToLearnersLoop sends the committed entries to the learners when leading the cluster, or the
+// latest snapshot if the entries are compacted, as raft does for the voters.
+func (pm *ProtocolManager) replicateToLearnersLoop() {
+	ticker := time.NewTicker(raft.TickerMS * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			pm.mu.RLock()
+			isLeader := pm.role == raft.MinterRole
+			pm.mu.RUnlock()
+
+			if !isLeader {
+				pm.resetLearnerProgress()
+				continue
+			}
+
+			status := pm.rawNode().Status()
+			var msgs []raftpb.Message
+			for _, raftId := range pm.learnerIds() {
+				if msg, ok := pm.learnerMessage(raftId, status); ok {
+					msgs = append(msgs, msg)
+				}
+			}
+			pm.transport.Send(msgs)
+
+		case <-pm.quitSync:
+			return
+		}
+	}
+}
+
 func (pm *ProtocolManager) minedBroadcastLoop() {
 	for obj := range pm.minedBlockSub.Chan() {
 		switch ev := obj.Data.(type) {
@@ -663,6 +803,28 @@ func (pm *ProtocolManager) addPeer(address *raft.Address) {
 	pm.peers[raftId] = &raft.Peer{Address: address, P2pNode: p2pNode}
 }
 
+// addLearner connects to the learner like any peer, and marks it non-voting until promotion.
+func (pm *ProtocolManager) addLearner(address *raft.Address) {
+	pm.mu.Lock()
+	pm.learners.Add(address.RaftId)
+	pm.mu.Unlock()
+
+	if address.RaftId == pm.raftId {
+		// A newcomer replaying the log learns its own Address here.
+		pm.setLocalAddress(address)
+		return
+	}
+	pm.addPeer(address)
+}
+
+func (pm *ProtocolManager) promoteLearner(raftId uint16) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.learners.Remove(raftId)
+	delete(pm.learnerProgress, raftId)
+}
+
 func (pm *ProtocolManager) disconnectFromPeer(raftId uint16, peer *raft.Peer) {
 	pm.p2pServer.RemovePeer(peer.P2pNode)
 	pm.transport.RemovePeer(raftTypes.ID(raftId))
@@ -678,6 +840,9 @@ func (pm *ProtocolManager) removePeer(raftId uint16) {
 		delete(pm.peers, raftId)
 	}
 
+	pm.learners.Remove(raftId)
+	delete(pm.learnerProgress, raftId)
+
 	// This is only necessary sometimes, but it's idempotent. Also, we *always*
 	// do this, and not just when there's still a peer in the map, because we
 	// need to do it for our *own* raft ID before we get booted from the cluster
@@ -756,7 +921,10 @@ func (pm *ProtocolManager) eventLoop() {
 					cc.Unmarshal(entry.Data)
 					raftId := uint16(cc.NodeID)
 
-					pm.confState = *pm.rawNode().ApplyConfChange(cc)
+					confState := pm.rawNode().ApplyConfChange(cc)
+					pm.mu.Lock()
+					pm.confState = *confState
+					pm.mu.Unlock()
 
 					forceSnapshot := false
 
@@ -764,6 +932,11 @@ func (pm *ProtocolManager) eventLoop() {
 					case raftpb.ConfChangeAddNode:
 						if pm.isRaftIdRemoved(raftId) {
 							log.Info("ignoring ConfChangeAddNode for permanently-removed peer", "raft id", raftId)
+						} else if pm.isLearner(raftId) {
+							log.Info("promoting learner to voter due to ConfChangeAddNode", "raft id", raftId)
+
+							forceSnapshot = true
+							pm.promoteLearner(raftId)
 						} else if pm.isRaftIdUsed(raftId) && raftId <= uint16(len(pm.bootstrapNodes)) {
 							// See initial cluster logic in startRaft() for more information.
 							log.Info("ignoring expected ConfChangeAddNode for initial peer", "raft id", raftId)
@@ -779,6 +952,18 @@ func (pm *ProtocolManager) eventLoop() {
 							pm.addPeer(raft.BytesToAddress(cc.Context))
 						}
 
+					case confChangeAddLearnerNode:
+						if pm.isRaftIdRemoved(raftId) {
+							log.Info("ignoring learner ConfChangeUpdateNode for permanently-removed peer", "raft id", raftId)
+						} else if raftId != pm.raftId && pm.isRaftIdUsed(raftId) {
+							log.Info("ignoring learner ConfChangeUpdateNode for already-used raft ID", "raft id", raftId)
+						} else {
+							log.Info("adding learner due to ConfChangeUpdateNode", "raft id", raftId)
+
+							forceSnapshot = true
+							pm.addLearner(raft.BytesToAddress(cc.Context))
+						}
+
 					case raftpb.ConfChangeRemoveNode:
 						if pm.isRaftIdRemoved(raftId) {
 							log.Info("ignoring ConfChangeRemoveNode for already-removed peer", "raft id", raftId)
@@ -793,11 +978,6 @@ func (pm *ProtocolManager) eventLoop() {
 
 							pm.removePeer(raftId)
 						}
-
-					case raftpb.ConfChangeUpdateNode:
-						// NOTE: remember to forceSnapshot in this case, if we add support
-						// for this.
-						raft.Fatalf("not yet handled: ConfChangeUpdateNode")
 					}
 
 					if forceSnapshot {
@@ -0,0 +1,153 @@
+package backend
+
+import (
+	"sort"
+
+	"github.com/simplechain-org/go-simplechain/consensus/raft"
+	"github.com/simplechain-org/go-simplechain/log"
+
+	etcdRaft "github.com/coreos/etcd/raft"
+	"github.com/coreos/etcd/raft/raftpb"
+)
+
+// Learners
+
+// learnerProgress is the log replication progress of a learner, which raft doesn't track as the
+// learners are not in its ConfState.
+type learnerProgress struct {
+	match, next     uint64
+	pendingSnapshot uint64 // Index of the snapshot in flight, no entries are sent until it is reported
+}
+
+// learnerIds returns the learners other than this node in ascending order.
+func (pm *ProtocolManager) learnerIds() []uint16 {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	learnerIds := make([]uint16, 0, pm.learners.Cardinality())
+	for learnerIface := range pm.learners.Iterator().C {
+		if raftId := learnerIface.(uint16); raftId != pm.raftId {
+			learnerIds = append(learnerIds, raftId)
+		}
+	}
+	sort.Slice(learnerIds, func(i, j int) bool { return learnerIds[i] < learnerIds[j] })
+	return learnerIds
+}
+
+// learnerMatch returns the last index acknowledged by the learner, false if it hasn't acknowledged any.
+func (pm *ProtocolManager) learnerMatch(raftId uint16) (uint64, bool) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	pr := pm.learnerProgress[raftId]
+	if pr == nil || pr.match == 0 {
+		return 0, false
+	}
+	return pr.match, true
+}
+
+func (pm *ProtocolManager) resetLearnerProgress() {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if len(pm.learnerProgress) > 0 {
+		pm.learnerProgress = make(map[uint16]*learnerProgress)
+	}
+}
+
+// learnerMessage builds the message appending the committed entries after the progress of the
+// learner, or the snapshot if they are compacted. Entries are resent until acknowledged.
+func (pm *ProtocolManager) learnerMessage(raftId uint16, status etcdRaft.Status) (raftpb.Message, bool) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pr := pm.learnerProgress[raftId]
+	if pr == nil {
+		// Probe with the last committed entry, a lagging learner rejects it with its last index.
+		pr = &learnerProgress{next: status.Commit}
+		if pr.next == 0 {
+			pr.next = 1
+		}
+		pm.learnerProgress[raftId] = pr
+	}
+	if pr.pendingSnapshot != 0 || pr.next > status.Commit {
+		return raftpb.Message{}, false
+	}
+
+	msg := raftpb.Message{
+		To:   uint64(raftId),
+		From: uint64(pm.raftId),
+		Term: status.Term,
+	}
+	logTerm, errTerm := pm.raftStorage.Term(pr.next - 1)
+	entries, errEntries := pm.raftStorage.Entries(pr.next, status.Commit+1, raft.LearnerMaxSizePerMsg)
+	if errTerm == nil && errEntries == nil {
+		msg.Type = raftpb.MsgApp
+		msg.Index = pr.next - 1
+		msg.LogTerm = logTerm
+		msg.Entries = entries
+		msg.Commit = status.Commit
+		return msg, true
+	}
+	if errTerm != etcdRaft.ErrCompacted && errEntries != etcdRaft.ErrCompacted {
+		log.Warn("failed to read entries for learner", "raft id", raftId, "next", pr.next, "term err", errTerm, "entries err", errEntries)
+		return raftpb.Message{}, false
+	}
+
+	snapshot, err := pm.raftStorage.Snapshot()
+	if err != nil || etcdRaft.IsEmptySnap(snapshot) {
+		log.Warn("failed to read snapshot for learner", "raft id", raftId, "err", err)
+		return raftpb.Message{}, false
+	}
+	log.Info("sending snapshot to learner", "raft id", raftId, "next", pr.next, "snapshot index", snapshot.Metadata.Index)
+
+	pr.pendingSnapshot = snapshot.Metadata.Index
+	msg.Type = raftpb.MsgSnap
+	msg.Snapshot = snapshot
+	return msg, true
+}
+
+// updateLearnerProgress advances the progress of learner by its response to the appended entries,
+// or goes back to its last index if it rejected them.
+func (pm *ProtocolManager) updateLearnerProgress(raftId uint16, m raftpb.Message) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pr := pm.learnerProgress[raftId]
+	if pr == nil {
+		return
+	}
+	pr.pendingSnapshot = 0
+
+	if m.Reject {
+		pr.next = m.Index
+		if hint := m.RejectHint + 1; hint < pr.next {
+			pr.next = hint
+		}
+		if pr.next < 1 {
+			pr.next = 1
+		}
+		if pr.match >= pr.next {
+			pr.match = pr.next - 1
+		}
+		return
+	}
+	if m.Index > pr.match {
+		pr.match = m.Index
+	}
+	pr.next = pr.match + 1
+}
+
+// reportLearnerSnapshot resends the snapshot to the learner if it failed to send.
+func (pm *ProtocolManager) reportLearnerSnapshot(raftId uint16, status etcdRaft.SnapshotStatus) {
+	if status != etcdRaft.SnapshotFailure {
+		return
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if pr := pm.learnerProgress[raftId]; pr != nil {
+		pr.pendingSnapshot = 0
+	}
+}
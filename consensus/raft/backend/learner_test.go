@@ -0,0 +1,74 @@
+package backend
+
+import (
+	"testing"
+
+	etcdRaft "github.com/coreos/etcd/raft"
+	"github.com/coreos/etcd/raft/raftpb"
+	mapset "github.com/deckarep/golang-set"
+)
+
+func TestReplicateToLearner(t *testing.T) {
+	pm := &ProtocolManager{
+		raftId:          1,
+		learners:        mapset.NewSet(),
+		learnerProgress: make(map[uint16]*learnerProgress),
+		raftStorage:     etcdRaft.NewMemoryStorage(),
+	}
+	pm.learners.Add(uint16(2))
+	pm.learners.Add(uint16(1)) // a learner never replicates to itself
+	if ids := pm.learnerIds(); len(ids) != 1 || ids[0] != 2 {
+		t.Fatalf("learner ids mismatch: have %v, want [2]", ids)
+	}
+
+	for i := uint64(1); i <= 5; i++ {
+		pm.raftStorage.Append([]raftpb.Entry{{Index: i, Term: 1}})
+	}
+	status := etcdRaft.Status{HardState: raftpb.HardState{Term: 1, Commit: 5}}
+
+	// Probe with the last committed entry
+	msg, ok := pm.learnerMessage(2, status)
+	if !ok || msg.Type != raftpb.MsgApp || msg.Index != 4 || len(msg.Entries) != 1 || msg.Commit != 5 {
+		t.Fatalf("probe mismatch: %v", msg)
+	}
+	// A lagging learner rejects, the entries after its last index are resent until acknowledged
+	pm.updateLearnerProgress(2, raftpb.Message{Type: raftpb.MsgAppResp, Index: 4, Reject: true, RejectHint: 1})
+	for i := 0; i < 2; i++ {
+		if msg, ok = pm.learnerMessage(2, status); !ok || msg.Index != 1 || msg.LogTerm != 1 || len(msg.Entries) != 4 {
+			t.Fatalf("append mismatch: %v", msg)
+		}
+	}
+	if _, ok := pm.learnerMatch(2); ok {
+		t.Fatalf("learner matched before acknowledging")
+	}
+	pm.updateLearnerProgress(2, raftpb.Message{Type: raftpb.MsgAppResp, Index: 5})
+	if match, ok := pm.learnerMatch(2); !ok || match != 5 {
+		t.Fatalf("match mismatch: have %d, want 5", match)
+	}
+	if msg, ok = pm.learnerMessage(2, status); ok {
+		t.Fatalf("caught-up learner is sent %v", msg)
+	}
+
+	// The snapshot is sent once if the entries are compacted
+	if _, err := pm.raftStorage.CreateSnapshot(3, &raftpb.ConfState{Nodes: []uint64{1}}, nil); err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	if err := pm.raftStorage.Compact(3); err != nil {
+		t.Fatalf("failed to compact: %v", err)
+	}
+	pm.updateLearnerProgress(2, raftpb.Message{Type: raftpb.MsgAppResp, Index: 5, Reject: true, RejectHint: 1})
+	if msg, ok = pm.learnerMessage(2, status); !ok || msg.Type != raftpb.MsgSnap || msg.Snapshot.Metadata.Index != 3 {
+		t.Fatalf("snapshot mismatch: %v", msg)
+	}
+	if msg, ok = pm.learnerMessage(2, status); ok {
+		t.Fatalf("message is sent with a snapshot in flight: %v", msg)
+	}
+	pm.reportLearnerSnapshot(2, etcdRaft.SnapshotFailure)
+	if msg, ok = pm.learnerMessage(2, status); !ok || msg.Type != raftpb.MsgSnap {
+		t.Fatalf("failed snapshot is not resent: %v", msg)
+	}
+	pm.updateLearnerProgress(2, raftpb.Message{Type: raftpb.MsgAppResp, Index: 3})
+	if msg, ok = pm.learnerMessage(2, status); !ok || msg.Type != raftpb.MsgApp || msg.Index != 3 || len(msg.Entries) != 2 {
+		t.Fatalf("append after snapshot mismatch: %v", msg)
+	}
+}
@@ -26,6 +26,7 @@ type Snapshot struct {
 	addresses      []raft.Address
 	removedRaftIds []uint16 // Raft IDs for permanently removed peers
 	headBlockHash  common.Hash
+	learnerRaftIds []uint16 // Raft IDs for non-voting peers, absent in snapshots before learners
 }
 
 type ByRaftId []raft.Address
@@ -38,20 +39,30 @@ func (pm *ProtocolManager) buildSnapshot() *Snapshot {
 	pm.mu.RLock()
 	defer pm.mu.RUnlock()
 
-	numNodes := len(pm.confState.Nodes)
+	// Learners are not in the ConfState of raft
+	learnerRaftIds := make([]uint16, 0, pm.learners.Cardinality())
+	for learnerIface := range pm.learners.Iterator().C {
+		learnerRaftIds = append(learnerRaftIds, learnerIface.(uint16))
+	}
+	sort.Slice(learnerRaftIds, func(i, j int) bool { return learnerRaftIds[i] < learnerRaftIds[j] })
+
+	members := make([]uint16, 0, len(pm.confState.Nodes)+len(learnerRaftIds))
+	for _, rawRaftId := range pm.confState.Nodes {
+		members = append(members, uint16(rawRaftId))
+	}
+	members = append(members, learnerRaftIds...)
 	numRemovedNodes := pm.removedPeers.Cardinality()
 
 	snapshot := &Snapshot{
-		addresses:      make([]raft.Address, numNodes),
+		addresses:      make([]raft.Address, len(members)),
 		removedRaftIds: make([]uint16, numRemovedNodes),
 		headBlockHash:  pm.blockchain.CurrentBlock().Hash(),
+		learnerRaftIds: learnerRaftIds,
 	}
 
 	// Populate addresses
 
-	for i, rawRaftId := range pm.confState.Nodes {
-		raftId := uint16(rawRaftId)
-
+	for i, raftId := range members {
 		if raftId == pm.raftId {
 			snapshot.addresses[i] = *pm.address
 		} else {
@@ -110,10 +121,13 @@ func confStateIdSet(confState raftpb.ConfState) mapset.Set {
 	return set
 }
 
-func (pm *ProtocolManager) updateClusterMembership(newConfState raftpb.ConfState, addresses []raft.Address, removedRaftIds []uint16) {
+func (pm *ProtocolManager) updateClusterMembership(newConfState raftpb.ConfState, addresses []raft.Address, removedRaftIds, learnerRaftIds []uint16) {
 	log.Info("updating cluster membership per raft snapshot")
 
 	prevConfState := pm.confState
+	pm.mu.RLock()
+	prevLearners := pm.learners
+	pm.mu.RUnlock()
 
 	// Update tombstones for permanently removed peers. For simplicity we do not
 	// allow the re-use of peer IDs once a peer is removed.
@@ -122,14 +136,20 @@ func (pm *ProtocolManager) updateClusterMembership(newConfState raftpb.ConfState
 	for _, removedRaftId := range removedRaftIds {
 		removedPeers.Add(removedRaftId)
 	}
+	learners := mapset.NewSet()
+	for _, learnerRaftId := range learnerRaftIds {
+		learners.Add(learnerRaftId)
+	}
+
 	pm.mu.Lock()
 	pm.removedPeers = removedPeers
+	pm.learners = learners
 	pm.mu.Unlock()
 
 	// Remove old peers that we're still connected to
 
-	prevIds := confStateIdSet(prevConfState)
-	newIds := confStateIdSet(newConfState)
+	prevIds := confStateIdSet(prevConfState).Union(prevLearners)
+	newIds := confStateIdSet(newConfState).Union(learners)
 	idsToRemove := prevIds.Difference(newIds)
 	for idIfaceToRemove := range idsToRemove.Iterator().C {
 		raftId := idIfaceToRemove.(uint16)
@@ -213,7 +233,7 @@ func bytesToSnapshot(bytes []byte) *Snapshot {
 }
 
 func (snapshot *Snapshot) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, []interface{}{snapshot.addresses, snapshot.removedRaftIds, snapshot.headBlockHash})
+	return rlp.Encode(w, []interface{}{snapshot.addresses, snapshot.removedRaftIds, snapshot.headBlockHash, snapshot.learnerRaftIds})
 }
 
 func (snapshot *Snapshot) DecodeRLP(s *rlp.Stream) error {
@@ -222,14 +242,20 @@ func (snapshot *Snapshot) DecodeRLP(s *rlp.Stream) error {
 		Addresses      []raft.Address
 		RemovedRaftIds []uint16
 		HeadBlockHash  common.Hash
+		Rest           []rlp.RawValue `rlp:"tail"` // learner IDs, if any
 	}
 
 	if err := s.Decode(&temp); err != nil {
 		return err
-	} else {
-		snapshot.addresses, snapshot.removedRaftIds, snapshot.headBlockHash = temp.Addresses, temp.RemovedRaftIds, temp.HeadBlockHash
-		return nil
 	}
+	var learnerRaftIds []uint16
+	if len(temp.Rest) > 0 {
+		if err := rlp.DecodeBytes(temp.Rest[0], &learnerRaftIds); err != nil {
+			return err
+		}
+	}
+	snapshot.addresses, snapshot.removedRaftIds, snapshot.headBlockHash, snapshot.learnerRaftIds = temp.Addresses, temp.RemovedRaftIds, temp.HeadBlockHash, learnerRaftIds
+	return nil
 }
 
 // Raft snapshot
@@ -269,7 +295,7 @@ func (pm *ProtocolManager) applyRaftSnapshot(raftSnapshot raftpb.Snapshot) {
 
 	latestBlockHash := snapshot.headBlockHash
 
-	pm.updateClusterMembership(raftSnapshot.Metadata.ConfState, snapshot.addresses, snapshot.removedRaftIds)
+	pm.updateClusterMembership(raftSnapshot.Metadata.ConfState, snapshot.addresses, snapshot.removedRaftIds, snapshot.learnerRaftIds)
 
 	preSyncHead := pm.blockchain.CurrentBlock()
 
@@ -0,0 +1,39 @@
+package backend
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/consensus/raft"
+	"github.com/simplechain-org/go-simplechain/p2p/enr"
+	"github.com/simplechain-org/go-simplechain/rlp"
+)
+
+func TestSnapshotRLP(t *testing.T) {
+	snapshot := &Snapshot{
+		addresses: []raft.Address{
+			{RaftId: 1, Ip: net.IPv4(127, 0, 0, 1).To4(), P2pPort: enr.TCP(21001), RaftPort: enr.RaftPort(50401)},
+			{RaftId: 3, Ip: net.IPv4(127, 0, 0, 1).To4(), P2pPort: enr.TCP(21003), RaftPort: enr.RaftPort(50403)},
+		},
+		removedRaftIds: []uint16{2},
+		headBlockHash:  common.HexToHash("0x1234"),
+		learnerRaftIds: []uint16{3},
+	}
+	decoded := bytesToSnapshot(snapshot.toBytes())
+	if !reflect.DeepEqual(decoded, snapshot) {
+		t.Errorf("snapshot mismatch: have %+v, want %+v", decoded, snapshot)
+	}
+
+	// Snapshots before learners have no learner IDs
+	legacy, err := rlp.EncodeToBytes([]interface{}{snapshot.addresses, snapshot.removedRaftIds, snapshot.headBlockHash})
+	if err != nil {
+		t.Fatalf("failed to encode legacy snapshot: %v", err)
+	}
+	decoded = bytesToSnapshot(legacy)
+	if !reflect.DeepEqual(decoded.addresses, snapshot.addresses) || !reflect.DeepEqual(decoded.removedRaftIds, snapshot.removedRaftIds) ||
+		decoded.headBlockHash != snapshot.headBlockHash || len(decoded.learnerRaftIds) != 0 {
+		t.Errorf("legacy snapshot mismatch: have %+v", decoded)
+	}
+}
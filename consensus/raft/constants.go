@@ -4,6 +4,10 @@ const (
 	MinterRole     = 1
 	TickerMS       = 100 // Raft's ticker interval
 	SnapshotPeriod = 250 // Snapshot after this many raft messages
+
+	PromotionCheckMS     = 1000       // Interval for the leader to check the learners for automatic promotion
+	LearnerPromotionGap  = 10         // Promote a learner once its log is within this many entries of the committed index
+	LearnerMaxSizePerMsg = 256 * 1024 // Size limit of the entries replicated to a learner in one message
 )
 
 var (
@@ -0,0 +1,310 @@
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/simplechain-org/go-simplechain/common"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+	cdb "github.com/simplechain-org/go-simplechain/cross/database"
+)
+
+type IssueKind string
+
+const (
+	IssueMissing     IssueKind = "missing"     // ctx confirmed in chain but not in store
+	IssueOrphan      IssueKind = "orphan"      // ctx in store but not made in chain
+	IssueData        IssueKind = "data"        // ctx fields disagree with the maker log
+	IssueSignature   IssueKind = "signature"   // signature is invalid or not signed by anchors
+	IssueStatus      IssueKind = "status"      // status disagrees with taker, finish and cancel logs
+	IssueBlockNumber IssueKind = "blockNumber" // block number disagrees with the confirmed log
+)
+
+type FixAction string
+
+const (
+	FixUpdate FixAction = "update" // set status and block number of the ctx
+	FixRemove FixAction = "remove" // remove the ctx from store
+	FixResync FixAction = "resync" // fetch the ctx from anchors by cross.syncStore
+)
+
+// Issue is a disagreement between store and chain
+type Issue struct {
+	ChainID  uint64      `json:"chainId"`
+	CtxID    common.Hash `json:"ctxId"`
+	Kind     IssueKind   `json:"kind"`
+	Stored   string      `json:"stored,omitempty"`
+	Expected string      `json:"expected,omitempty"`
+}
+
+func (i *Issue) String() string {
+	return fmt.Sprintf("chain=%d ctx=%s %s stored=%q expected=%q", i.ChainID, i.CtxID.String(), i.Kind, i.Stored, i.Expected)
+}
+
+// Fix is a step of the fix plan
+type Fix struct {
+	Action      FixAction     `json:"action"`
+	ChainID     uint64        `json:"chainId"`
+	CtxID       common.Hash   `json:"ctxId"`
+	Status      *cc.CtxStatus `json:"status,omitempty"`
+	BlockNumber *uint64       `json:"blockNumber,omitempty"`
+}
+
+type Report struct {
+	Checked int      `json:"checked"` // number of ctx in store
+	Issues  []*Issue `json:"issues"`
+	Fixes   []*Fix   `json:"fixes"`
+}
+
+func (r *Report) Merge(other *Report) {
+	r.Checked += other.Checked
+	r.Issues = append(r.Issues, other.Issues...)
+	r.Fixes = append(r.Fixes, other.Fixes...)
+}
+
+func (r *Report) issue(chainID uint64, id common.Hash, kind IssueKind, stored, expected string) {
+	r.Issues = append(r.Issues, &Issue{ChainID: chainID, CtxID: id, Kind: kind, Stored: stored, Expected: expected})
+}
+
+func (r *Report) fix(chainID uint64, id common.Hash, action FixAction) *Fix {
+	fix := &Fix{Action: action, ChainID: chainID, CtxID: id}
+	r.Fixes = append(r.Fixes, fix)
+	return fix
+}
+
+// expectation is the store state of a ctx derived from chain logs
+type expectation struct {
+	status      cc.CtxStatus   // status to fix with
+	accept      []cc.CtxStatus // statuses agreed with chain, unconfirmed logs allow intermediate statuses
+	number      uint64
+	checkNumber bool
+}
+
+func (e expectation) accepts(status cc.CtxStatus) bool {
+	for _, s := range e.accept {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// removable reports whether the ctx may be moved from store to the finished log
+func (e expectation) removable() bool {
+	return e.status == cc.CtxStatusFinished || e.status == cc.CtxStatusReceipted || e.status == cc.CtxStatusCancelled
+}
+
+// expect derives the store state of a ctx made in source chain and taken in dest chain,
+// following the status transitions of the cross handler.
+func expect(id common.Hash, maker *Maker, source, dest *Chain) expectation {
+	exp := expectOrder(id, maker, source, dest)
+	if kind := maker.Tx.Kind(); kind != cc.CtxKindOrder { // messages are delivered and receipted in steps of orders
+		exp.status = exp.status.Of(kind)
+		accept := make([]cc.CtxStatus, len(exp.accept))
+		for i, status := range exp.accept {
+			accept[i] = status.Of(kind)
+		}
+		exp.accept = accept
+	}
+	return exp
+}
+
+func expectOrder(id common.Hash, maker *Maker, source, dest *Chain) expectation {
+	makerNumber := maker.Number + source.Depth // makers are stored at confirmed number
+	if finish := source.Finishes[id]; finish != nil {
+		if source.confirmed(finish.Number) {
+			return expectation{cc.CtxStatusFinished, []cc.CtxStatus{cc.CtxStatusFinished}, finish.Number + source.Depth, true}
+		}
+		return expectation{status: cc.CtxStatusFinishing, accept: []cc.CtxStatus{cc.CtxStatusExecuted, cc.CtxStatusFinishing}}
+	}
+	if refund := source.MakerCancels[id]; refund != nil {
+		if source.confirmed(refund.Number) {
+			return expectation{cc.CtxStatusCancelled, []cc.CtxStatus{cc.CtxStatusCancelled}, refund.Number + source.Depth, true}
+		}
+		return expectation{cc.CtxStatusCancelling, []cc.CtxStatus{cc.CtxStatusCancelling}, makerNumber, true}
+	}
+	if taker := dest.Takers[id]; taker != nil {
+		if dest.confirmed(taker.Number) {
+			return expectation{cc.CtxStatusExecuted, []cc.CtxStatus{cc.CtxStatusExecuted}, makerNumber, true}
+		}
+		return expectation{cc.CtxStatusExecuting,
+			[]cc.CtxStatus{cc.CtxStatusWaiting, cc.CtxStatusIllegal, cc.CtxStatusExecuting}, makerNumber, true}
+	}
+	partial := dest.Partials[id]
+	// a partially filled ctx is also cancelled after expired
+	if cancel := dest.TakerCancels[id]; cancel != nil {
+		if dest.confirmed(cancel.Number) {
+			return expectation{cc.CtxStatusCancelling, []cc.CtxStatus{cc.CtxStatusCancelling}, makerNumber, true}
+		}
+		open := cc.CtxStatusWaiting
+		if partial != nil {
+			open = cc.CtxStatusPartialFilled
+		}
+		return expectation{cc.CtxStatusCancelling, []cc.CtxStatus{open, cc.CtxStatusCancelling}, makerNumber, true}
+	}
+	// partial fills are applied to store by new logs, the filled ctx is executing until maker finished
+	if partial != nil {
+		if partial.Filled.Cmp(partial.DestValue) >= 0 {
+			return expectation{cc.CtxStatusExecuting, []cc.CtxStatus{cc.CtxStatusExecuting}, makerNumber, true}
+		}
+		return expectation{cc.CtxStatusPartialFilled, []cc.CtxStatus{cc.CtxStatusPartialFilled}, makerNumber, true}
+	}
+	return expectation{cc.CtxStatusWaiting,
+		[]cc.CtxStatus{cc.CtxStatusPending, cc.CtxStatusWaiting, cc.CtxStatusIllegal}, makerNumber, true}
+}
+
+// diffData returns names of ctx fields which disagree with the maker log
+func diffData(stored *cc.CrossTransactionWithSignatures, made *cc.CrossTransaction) []string {
+	var fields []string
+	eqBig := func(a, b *big.Int) bool {
+		if a == nil || b == nil {
+			return a == b
+		}
+		return a.Cmp(b) == 0
+	}
+	if !eqBig(stored.Data.Value, made.Data.Value) {
+		fields = append(fields, "value")
+	}
+	if !eqBig(stored.Data.DestinationValue, made.Data.DestinationValue) {
+		fields = append(fields, "destinationValue")
+	}
+	if !eqBig(stored.Data.DestinationId, made.Data.DestinationId) {
+		fields = append(fields, "destinationId")
+	}
+	if stored.Data.From != made.Data.From {
+		fields = append(fields, "from")
+	}
+	if stored.Data.To != made.Data.To {
+		fields = append(fields, "to")
+	}
+	if stored.Data.TxHash != made.Data.TxHash {
+		fields = append(fields, "txHash")
+	}
+	if stored.Data.BlockHash != made.Data.BlockHash {
+		fields = append(fields, "blockHash")
+	}
+	if string(stored.Data.Input) != string(made.Data.Input) {
+		fields = append(fields, "input")
+	}
+	return fields
+}
+
+// checkSignatures recovers signers of the stored ctx, anchors are not checked if empty
+func checkSignatures(stored *cc.CrossTransactionWithSignatures, chainID *big.Int, anchors []common.Address) error {
+	if len(stored.Data.V) != len(stored.Data.R) || len(stored.Data.V) != len(stored.Data.S) {
+		return fmt.Errorf("%d v, %d r, %d s", len(stored.Data.V), len(stored.Data.R), len(stored.Data.S))
+	}
+	signer := cc.NewEIP155CtxSigner(chainID)
+	signed := make(map[common.Address]bool)
+	for i, ctx := range stored.Resolution() {
+		addr, err := cc.CtxSender(signer, ctx)
+		if err != nil {
+			return fmt.Errorf("signature %d: %v", i, err)
+		}
+		if signed[addr] {
+			return fmt.Errorf("signature %d: duplicate signer %s", i, addr.String())
+		}
+		if len(anchors) > 0 && !containsAddress(anchors, addr) {
+			return fmt.Errorf("signature %d: %s is not anchor", i, addr.String())
+		}
+		signed[addr] = true
+	}
+	return nil
+}
+
+func containsAddress(addrs []common.Address, addr common.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func joinStatus(statuses []cc.CtxStatus) string {
+	s := make([]string, len(statuses))
+	for i, status := range statuses {
+		s[i] = status.String()
+	}
+	return strings.Join(s, "|")
+}
+
+// Audit checks the store of ctx made in source chain and taken in dest chain.
+// Finished and cancelled ctx may have been moved to the finished log, they are not reported as missing.
+func Audit(store cdb.CtxDB, source, dest *Chain, anchors []common.Address) *Report {
+	var (
+		report  = new(Report)
+		chainID = source.ChainID.Uint64()
+		checked = make(map[common.Hash]bool)
+	)
+	for _, stored := range store.Query(0, 0, nil, false) {
+		id := stored.ID()
+		checked[id] = true
+		report.Checked++
+
+		maker := source.Makers[id]
+		if maker == nil {
+			report.issue(chainID, id, IssueOrphan, stored.Status.String(), "")
+			report.fix(chainID, id, FixRemove)
+			continue
+		}
+		if fields := diffData(stored, maker.Tx); len(fields) > 0 {
+			report.issue(chainID, id, IssueData, strings.Join(fields, ","), "maker log at #"+fmt.Sprint(maker.Number))
+			report.fix(chainID, id, FixRemove)
+			report.fix(chainID, id, FixResync)
+			continue
+		}
+		// signatures of illegal ctx are made by removed anchors
+		if stored.Status != cc.CtxStatusIllegal {
+			if err := checkSignatures(stored, source.ChainID, anchors); err != nil {
+				report.issue(chainID, id, IssueSignature, err.Error(), "")
+				report.fix(chainID, id, FixRemove)
+				report.fix(chainID, id, FixResync)
+				continue
+			}
+		}
+
+		exp := expect(id, maker, source, dest)
+		var fix *Fix
+		if !exp.accepts(stored.Status) {
+			report.issue(chainID, id, IssueStatus, stored.Status.String(), joinStatus(exp.accept))
+			fix = report.fix(chainID, id, FixUpdate)
+			status := exp.status
+			fix.Status = &status
+		}
+		if exp.checkNumber && stored.BlockNum != exp.number {
+			report.issue(chainID, id, IssueBlockNumber, fmt.Sprint(stored.BlockNum), fmt.Sprint(exp.number))
+			if fix == nil {
+				fix = report.fix(chainID, id, FixUpdate)
+			}
+			number := exp.number
+			fix.BlockNumber = &number
+		}
+	}
+
+	var missing []*Maker
+	for id, maker := range source.Makers {
+		if checked[id] || maker.Tx.DestinationId().Cmp(dest.ChainID) != 0 || !source.confirmed(maker.Number) {
+			continue
+		}
+		if exp := expect(id, maker, source, dest); !exp.removable() {
+			missing = append(missing, maker)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		if missing[i].Number != missing[j].Number {
+			return missing[i].Number < missing[j].Number
+		}
+		return bytes.Compare(missing[i].Tx.ID().Bytes(), missing[j].Tx.ID().Bytes()) < 0
+	})
+	for _, maker := range missing {
+		id := maker.Tx.ID()
+		report.issue(chainID, id, IssueMissing, "", expect(id, maker, source, dest).status.String())
+		report.fix(chainID, id, FixResync)
+	}
+	return report
+}
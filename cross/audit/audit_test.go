@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/core/rawdb"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/crypto"
+	"github.com/simplechain-org/go-simplechain/ethdb"
+	"github.com/simplechain-org/go-simplechain/params"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+	cdb "github.com/simplechain-org/go-simplechain/cross/database"
+
+	"github.com/asdine/storm/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+var contract = common.HexToAddress("0xcc")
+
+// newChainDB writes a canonical chain of n blocks, logs of block i are made by logs(i)
+func newChainDB(chainID int64, n uint64, logs func(number uint64) []*types.Log) ethdb.Database {
+	db := rawdb.NewMemoryDatabase()
+	parent := common.Hash{}
+	for number := uint64(0); number <= n; number++ {
+		var (
+			txs      []*types.Transaction
+			receipts []*types.Receipt
+		)
+		for i, l := range logs(number) {
+			txs = append(txs, types.NewTransaction(number*100+uint64(i), contract, common.Big0, 0, common.Big0, nil))
+			receipts = append(receipts, &types.Receipt{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{l}})
+		}
+		block := types.NewBlock(&types.Header{ParentHash: parent, Number: new(big.Int).SetUint64(number)}, txs, nil, receipts)
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteReceipts(db, block.Hash(), number, receipts)
+		rawdb.WriteCanonicalHash(db, block.Hash(), number)
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		if number == 0 {
+			rawdb.WriteChainConfig(db, block.Hash(), &params.ChainConfig{ChainID: big.NewInt(chainID)})
+		}
+		parent = block.Hash()
+	}
+	return db
+}
+
+func makerLog(id common.Hash, dest int64, value int64) *types.Log {
+	data := make([]byte, common.HashLength*6)
+	copy(data[:common.HashLength], common.LeftPadBytes(common.HexToAddress("0x02").Bytes(), 32))
+	copy(data[common.HashLength:], common.LeftPadBytes(big.NewInt(dest).Bytes(), 32))
+	copy(data[common.HashLength*2:], common.LeftPadBytes(big.NewInt(value).Bytes(), 32))
+	copy(data[common.HashLength*3:], common.LeftPadBytes(big.NewInt(value*2).Bytes(), 32))
+	return &types.Log{Address: contract, Topics: []common.Hash{params.MakerTopic, id, common.HexToHash("0x01")}, Data: data}
+}
+
+func idLog(topic, id common.Hash) *types.Log {
+	return &types.Log{Address: contract, Topics: []common.Hash{topic, id, common.HexToHash("0x02")}, Data: make([]byte, common.HashLength*4)}
+}
+
+func TestAudit(t *testing.T) {
+	var (
+		depth   = uint64(3)
+		anchor  = crypto.ToECDSAUnsafe(common.FromHex("0x0000000000000000000000000000000000000000000000000000000000000011"))
+		other   = crypto.ToECDSAUnsafe(common.FromHex("0x0000000000000000000000000000000000000000000000000000000000000022"))
+		anchors = []common.Address{crypto.PubkeyToAddress(anchor.PublicKey)}
+		ids     = make([]common.Hash, 7)
+	)
+	for i := range ids {
+		ids[i] = common.BigToHash(big.NewInt(int64(i + 1)))
+	}
+
+	mainDB := newChainDB(1, 20, func(number uint64) (logs []*types.Log) {
+		switch number {
+		case 1:
+			for _, id := range ids[:6] {
+				logs = append(logs, makerLog(id, 2, 100))
+			}
+		case 5:
+			logs = append(logs, idLog(params.MakerFinishTopic, ids[0]))
+		case 19: // unconfirmed
+			logs = append(logs, makerLog(ids[6], 2, 100))
+		}
+		return logs
+	})
+	subDB := newChainDB(2, 20, func(number uint64) (logs []*types.Log) {
+		if number == 2 {
+			logs = append(logs, idLog(params.TakerTopic, ids[0]), idLog(params.TakerTopic, ids[1]))
+		}
+		return logs
+	})
+
+	main, err := Replay(mainDB, contract, depth)
+	assert.NoError(t, err)
+	sub, err := Replay(subDB, contract, depth)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20), main.Head)
+	assert.Len(t, main.Makers, 7)
+	assert.Len(t, main.Finishes, 1)
+	assert.Len(t, sub.Takers, 2)
+	assert.Equal(t, common.HexToAddress("0x01"), main.Makers[ids[0]].Tx.Data.From)
+	assert.Equal(t, big.NewInt(2), main.Makers[ids[0]].Tx.DestinationId())
+
+	signer := cc.NewEIP155CtxSigner(main.ChainID)
+	stored := func(i int, key *ecdsa.PrivateKey, status cc.CtxStatus, number uint64) *cc.CrossTransactionWithSignatures {
+		tx, err := cc.SignCtx(main.Makers[ids[i]].Tx, signer, func(hash []byte) ([]byte, error) { return crypto.Sign(hash, key) })
+		assert.NoError(t, err)
+		cws := cc.NewCrossTransactionWithSignatures(tx, number)
+		cws.Status = status
+		return cws
+	}
+	dir, err := ioutil.TempDir("", "cross-audit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	stormDB, err := storm.Open(filepath.Join(dir, "crossdata"))
+	assert.NoError(t, err)
+	defer stormDB.Close()
+	store := cdb.NewIndexDB(main.ChainID, stormDB, 16)
+
+	modified := stored(2, anchor, cc.CtxStatusWaiting, 4)
+	modified.Data.Value = big.NewInt(1)
+	orphan := stored(3, anchor, cc.CtxStatusWaiting, 4)
+	orphan.Data.CTxId = common.HexToHash("0xff")
+	assert.NoError(t, store.Writes([]*cc.CrossTransactionWithSignatures{
+		stored(0, anchor, cc.CtxStatusExecuted, 4), // finished at #5
+		stored(1, anchor, cc.CtxStatusExecuted, 4), // agreed
+		modified,
+		orphan,
+		stored(4, other, cc.CtxStatusIllegal, 4), // signed by removed anchor
+		stored(5, other, cc.CtxStatusWaiting, 4), // not signed by anchor
+		// ids[3] is missing, ids[6] is not confirmed
+	}, false))
+
+	report := Audit(store, main, sub, anchors)
+	assert.Equal(t, 6, report.Checked)
+
+	issues := make(map[common.Hash][]IssueKind)
+	for _, issue := range report.Issues {
+		issues[issue.CtxID] = append(issues[issue.CtxID], issue.Kind)
+	}
+	assert.Equal(t, []IssueKind{IssueStatus, IssueBlockNumber}, issues[ids[0]])
+	assert.Nil(t, issues[ids[1]])
+	assert.Equal(t, []IssueKind{IssueData}, issues[ids[2]])
+	assert.Equal(t, []IssueKind{IssueMissing}, issues[ids[3]])
+	assert.Nil(t, issues[ids[4]])
+	assert.Equal(t, []IssueKind{IssueOrphan}, issues[common.HexToHash("0xff")])
+	assert.Equal(t, []IssueKind{IssueSignature}, issues[ids[5]])
+	assert.Nil(t, issues[ids[6]])
+
+	var fix *Fix
+	for _, f := range report.Fixes {
+		if f.CtxID == ids[0] {
+			fix = f
+		}
+	}
+	assert.Equal(t, FixUpdate, fix.Action)
+	assert.Equal(t, cc.CtxStatusFinished, *fix.Status)
+	assert.Equal(t, uint64(5)+depth, *fix.BlockNumber)
+}
@@ -0,0 +1,166 @@
+// Package audit checks cross stores of a stopped node against the cross contract logs
+// of the local chain databases.
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/core/rawdb"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/ethdb"
+	"github.com/simplechain-org/go-simplechain/log"
+	"github.com/simplechain-org/go-simplechain/params"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+	"github.com/simplechain-org/go-simplechain/cross/trigger/simpletrigger/subscriber"
+)
+
+var (
+	ErrNoHeadBlock   = errors.New("head block not found")
+	ErrNoChainConfig = errors.New("chain config not found")
+)
+
+// Maker is a ctx made in the chain
+type Maker struct {
+	Tx     *cc.CrossTransaction
+	Number uint64
+}
+
+// Event is a log of taker, finish or cancel
+type Event struct {
+	Number uint64
+	TxHash common.Hash
+}
+
+// Partial is the latest TakerPartial log of a ctx
+type Partial struct {
+	Event
+	Filled    *big.Int // destination value filled in total
+	DestValue *big.Int
+}
+
+// Chain is the state of the cross contract replayed from logs of canonical blocks
+type Chain struct {
+	ChainID  *big.Int
+	Contract common.Address
+	Head     uint64
+	Depth    uint64 // blocks to confirm a log
+
+	Makers       map[common.Hash]*Maker   // made in the chain, keyed by ctxID
+	Takers       map[common.Hash]*Event   // taken in the chain
+	Partials     map[common.Hash]*Partial // partially filled in the chain
+	Finishes     map[common.Hash]*Event   // maker finished in the chain
+	TakerCancels map[common.Hash]*Event   // taking cancelled in the chain
+	MakerCancels map[common.Hash]*Event   // maker refunded in the chain
+}
+
+func newChain(chainID *big.Int, contract common.Address, head, depth uint64) *Chain {
+	return &Chain{
+		ChainID:      chainID,
+		Contract:     contract,
+		Head:         head,
+		Depth:        depth,
+		Makers:       make(map[common.Hash]*Maker),
+		Takers:       make(map[common.Hash]*Event),
+		Partials:     make(map[common.Hash]*Partial),
+		Finishes:     make(map[common.Hash]*Event),
+		TakerCancels: make(map[common.Hash]*Event),
+		MakerCancels: make(map[common.Hash]*Event),
+	}
+}
+
+// ReadChainID returns the chainID in the genesis config of the database
+func ReadChainID(db ethdb.Reader) (*big.Int, error) {
+	config := rawdb.ReadChainConfig(db, rawdb.ReadCanonicalHash(db, 0))
+	if config == nil || config.ChainID == nil {
+		return nil, ErrNoChainConfig
+	}
+	return config.ChainID, nil
+}
+
+// Replay reads logs of the contract in every canonical block of the database
+func Replay(db ethdb.Reader, contract common.Address, depth uint64) (*Chain, error) {
+	chainID, err := ReadChainID(db)
+	if err != nil {
+		return nil, err
+	}
+	head := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadBlockHash(db))
+	if head == nil {
+		return nil, ErrNoHeadBlock
+	}
+	chain := newChain(chainID, contract, *head, depth)
+	logger := log.New("X-module", "audit", "chainID", chainID)
+
+	for number := uint64(0); number <= *head; number++ {
+		hash := rawdb.ReadCanonicalHash(db, number)
+		header := rawdb.ReadHeader(db, hash, number)
+		if header == nil {
+			return nil, fmt.Errorf("header #%d not found", number)
+		}
+		if !types.BloomLookup(header.Bloom, contract) {
+			continue
+		}
+		body := rawdb.ReadBody(db, hash, number)
+		receipts := rawdb.ReadRawReceipts(db, hash, number)
+		if body == nil || len(receipts) != len(body.Transactions) {
+			return nil, fmt.Errorf("receipts of block #%d not found", number)
+		}
+		for i, receipt := range receipts {
+			for _, l := range receipt.Logs {
+				l.TxHash, l.BlockHash, l.BlockNumber = body.Transactions[i].Hash(), hash, number
+				chain.apply(l)
+			}
+		}
+		if number%100000 == 0 {
+			logger.Info("Replaying cross logs", "number", number, "head", *head, "makers", len(chain.Makers))
+		}
+	}
+	logger.Info("Replayed cross logs", "head", *head, "makers", len(chain.Makers), "takers", len(chain.Takers), "partials", len(chain.Partials),
+		"finishes", len(chain.Finishes), "takerCancels", len(chain.TakerCancels), "makerCancels", len(chain.MakerCancels))
+	return chain, nil
+}
+
+func (c *Chain) apply(l *types.Log) {
+	if l.Address != c.Contract || len(l.Topics) < 3 {
+		return
+	}
+	event := &Event{Number: l.BlockNumber, TxHash: l.TxHash}
+	switch l.Topics[0] {
+	case params.MakerTopic:
+		if ctx := subscriber.DecodeMakerLog(l); ctx != nil {
+			c.Makers[ctx.ID()] = &Maker{Tx: ctx, Number: l.BlockNumber}
+		}
+	case params.TakerTopic:
+		if len(l.Data) >= common.HashLength*4 {
+			c.Takers[l.Topics[1]] = event
+		}
+	case params.MessageDeliveredTopic: // messages are delivered and receipted like orders are taken and finished
+		if len(l.Data) >= common.HashLength*3 {
+			c.Takers[l.Topics[1]] = event
+		}
+	case params.TakerPartialTopic:
+		if len(l.Data) >= common.HashLength*5 {
+			filled := common.BytesToHash(l.Data[common.HashLength*3 : common.HashLength*4]).Big()
+			if last := c.Partials[l.Topics[1]]; last == nil || filled.Cmp(last.Filled) > 0 {
+				c.Partials[l.Topics[1]] = &Partial{*event, filled,
+					common.BytesToHash(l.Data[common.HashLength*4 : common.HashLength*5]).Big()}
+			}
+		}
+	case params.MakerFinishTopic, params.MessageReceiptTopic:
+		c.Finishes[l.Topics[1]] = event
+	case params.TakerCancelTopic:
+		if len(l.Data) >= common.HashLength {
+			c.TakerCancels[l.Topics[1]] = event
+		}
+	case params.MakerCancelTopic:
+		c.MakerCancels[l.Topics[1]] = event
+	}
+}
+
+// confirmed reports whether a log at number is confirmed by the head
+func (c *Chain) confirmed(number uint64) bool {
+	return number+c.Depth <= c.Head
+}
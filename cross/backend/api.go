@@ -1,15 +1,20 @@
 package backend
 
 import (
+	"context"
 	"fmt"
+	"math/big"
 
 	"github.com/simplechain-org/go-simplechain/common"
 	"github.com/simplechain-org/go-simplechain/common/hexutil"
+	"github.com/simplechain-org/go-simplechain/event"
 	"github.com/simplechain-org/go-simplechain/log"
 	"github.com/simplechain-org/go-simplechain/rlp"
+	"github.com/simplechain-org/go-simplechain/rpc"
 
 	cc "github.com/simplechain-org/go-simplechain/cross/core"
 	cdb "github.com/simplechain-org/go-simplechain/cross/database"
+	"github.com/simplechain-org/go-simplechain/cross/trigger"
 )
 
 type PrivateCrossAdminAPI struct {
@@ -20,30 +25,48 @@ func NewPrivateCrossAdminAPI(service *CrossService) *PrivateCrossAdminAPI {
 	return &PrivateCrossAdminAPI{service}
 }
 
-func (s *PrivateCrossAdminAPI) Anchors() map[uint64][]common.Address {
-	return map[uint64][]common.Address{
-		s.service.main.chainID: s.service.main.handler.config.Anchors,
-		s.service.sub.chainID:  s.service.sub.handler.config.Anchors,
+// Anchors returns anchors of every pair, keyed by remote chainID
+func (s *PrivateCrossAdminAPI) Anchors() map[uint64]map[uint64][]common.Address {
+	anchors := make(map[uint64]map[uint64][]common.Address, len(s.service.pairs))
+	for _, pair := range s.service.pairs {
+		anchors[pair.sub.chainID] = map[uint64][]common.Address{
+			pair.main.chainID: pair.main.handler.config.Anchors,
+			pair.sub.chainID:  pair.sub.handler.config.Anchors,
+		}
 	}
+	return anchors
 }
 
 func (s *PrivateCrossAdminAPI) SyncPending() (bool, error) {
-	for _, peer := range s.service.peers.peers {
-		go s.service.syncPending(peer)
+	var synced bool
+	for _, pair := range s.service.pairs {
+		for _, peer := range pair.peers.peers {
+			go pair.syncPending(peer)
+		}
+		synced = synced || pair.peers.Len() > 0
 	}
-	return s.service.peers.Len() > 0, nil
+	return synced, nil
 }
 
 func (s *PrivateCrossAdminAPI) SyncStore() (bool, error) {
-	main, sub := s.service.peers.BestPeer()
-	s.service.synchronise(main, sub)
-	return main != nil || sub != nil, nil
+	var synced bool
+	for _, pair := range s.service.pairs {
+		main, sub := pair.peers.BestPeer()
+		pair.synchronise(main, sub)
+		synced = synced || main != nil || sub != nil
+	}
+	return synced, nil
 }
 
 func (s *PrivateCrossAdminAPI) Repair() (bool, error) {
+	var stores []cdb.CtxDB
+	for _, pair := range s.service.pairs {
+		for _, store := range pair.store.stores {
+			stores = append(stores, store)
+		}
+	}
 	var (
 		errs   []error
-		stores = s.service.store.stores
 		errsCh = make(chan error, len(stores))
 	)
 	repair := func(store cdb.CtxDB) {
@@ -52,7 +75,7 @@ func (s *PrivateCrossAdminAPI) Repair() (bool, error) {
 	for _, store := range stores {
 		go repair(store)
 	}
-	for i := 0; i < 4; i++ {
+	for range stores {
 		err := <-errsCh
 		if err != nil {
 			errs = append(errs, err)
@@ -64,50 +87,141 @@ func (s *PrivateCrossAdminAPI) Repair() (bool, error) {
 	return true, nil
 }
 
-func (s *PrivateCrossAdminAPI) Peers() (infos []*CrossPeerInfo, err error) {
-	for _, p := range s.service.peers.peers {
-		infos = append(infos, p.Info())
+// Peers returns anchor peers of every pair, keyed by remote chainID
+func (s *PrivateCrossAdminAPI) Peers() (infos map[uint64][]*CrossPeerInfo, err error) {
+	infos = make(map[uint64][]*CrossPeerInfo, len(s.service.pairs))
+	for _, pair := range s.service.pairs {
+		for _, p := range pair.peers.peers {
+			infos[pair.sub.chainID] = append(infos[pair.sub.chainID], p.Info())
+		}
 	}
 	return
 }
 
-func (s *PrivateCrossAdminAPI) Height() map[string]hexutil.Uint64 {
-	return map[string]hexutil.Uint64{
-		"main": hexutil.Uint64(s.service.main.handler.Height().Uint64()),
-		"sub":  hexutil.Uint64(s.service.sub.handler.Height().Uint64()),
+// Height returns store heights of every pair, keyed by remote chainID
+func (s *PrivateCrossAdminAPI) Height() map[uint64]map[string]hexutil.Uint64 {
+	heights := make(map[uint64]map[string]hexutil.Uint64, len(s.service.pairs))
+	for _, pair := range s.service.pairs {
+		heights[pair.sub.chainID] = map[string]hexutil.Uint64{
+			"main": hexutil.Uint64(pair.main.handler.Height().Uint64()),
+			"sub":  hexutil.Uint64(pair.sub.handler.Height().Uint64()),
+		}
+	}
+	return heights
+}
+
+// Stats returns store stats of every pair, keyed by remote chainID
+func (s *PrivateCrossAdminAPI) Stats() map[uint64]map[uint64]map[cc.CtxStatus]int {
+	stats := make(map[uint64]map[uint64]map[cc.CtxStatus]int, len(s.service.pairs))
+	for _, pair := range s.service.pairs {
+		stats[pair.sub.chainID] = pair.main.handler.StoreStats()
+	}
+	return stats
+}
+
+// ExecutorQueue returns journaled anchor transactions of every chain, keyed by chainID
+func (s *PrivateCrossAdminAPI) ExecutorQueue() map[uint64][]*cc.AnchorTransaction {
+	queues := make(map[uint64][]*cc.AnchorTransaction, len(s.service.executors))
+	for chainID, executor := range s.service.executors {
+		if queued, ok := executor.(trigger.QueuedExecutor); ok {
+			queues[chainID] = queued.Queue()
+		}
+	}
+	return queues
+}
+
+// AnchorProfit is the profit and loss of anchor in a chain
+type AnchorProfit struct {
+	*cc.AnchorProfit
+	Unclaimed map[uint64]*big.Int `json:"unclaimed"` // relay fees credited but not claimed yet, keyed by remote chainID
+	Profit    *big.Int            `json:"profit"`    // relay fees earned minus gas fees paid
+}
+
+// remoteChains returns chainIDs of the chains paired with the chain
+func (s *PrivateCrossAdminAPI) remoteChains(chainID uint64) []*big.Int {
+	var remotes []*big.Int
+	for _, pair := range s.service.pairs {
+		switch chainID {
+		case pair.main.chainID:
+			remotes = append(remotes, new(big.Int).SetUint64(pair.sub.chainID))
+		case pair.sub.chainID:
+			remotes = append(remotes, new(big.Int).SetUint64(pair.main.chainID))
+		}
 	}
+	return remotes
 }
 
-func (s *PrivateCrossAdminAPI) Stats() map[uint64]map[cc.CtxStatus]int {
-	return s.service.main.handler.StoreStats()
+// Profit returns profit and loss of anchor in every chain, keyed by chainID
+func (s *PrivateCrossAdminAPI) Profit() (map[uint64]*AnchorProfit, error) {
+	profits := make(map[uint64]*AnchorProfit, len(s.service.executors))
+	for chainID, executor := range s.service.executors {
+		accounted, ok := executor.(trigger.AccountedExecutor)
+		if !ok {
+			continue
+		}
+		profit := accounted.Profit()
+		unclaimed := make(map[uint64]*big.Int)
+		for _, remote := range s.remoteChains(chainID) {
+			fee, err := accounted.RelayFee(remote)
+			if err != nil {
+				return nil, err
+			}
+			unclaimed[remote.Uint64()] = fee
+		}
+		profits[chainID] = &AnchorProfit{AnchorProfit: profit, Unclaimed: unclaimed, Profit: profit.Profit()}
+	}
+	return profits, nil
+}
+
+// ClaimRelayFee claims relay fees of the chain from its cross contract, it returns the claiming fees keyed by remote chainID
+func (s *PrivateCrossAdminAPI) ClaimRelayFee(chainID *hexutil.Big) (map[uint64]*big.Int, error) {
+	executor, ok := s.service.executors[chainID.ToInt().Uint64()]
+	if !ok {
+		return nil, fmt.Errorf("no executor of chain %d", chainID.ToInt())
+	}
+	claimer, ok := executor.(trigger.AccountedExecutor)
+	if !ok {
+		return nil, fmt.Errorf("executor of chain %d does not claim relay fees", chainID.ToInt())
+	}
+	fees := make(map[uint64]*big.Int)
+	for _, remote := range s.remoteChains(chainID.ToInt().Uint64()) {
+		fee, err := claimer.ClaimRelayFee(remote)
+		if err != nil {
+			return nil, err
+		}
+		fees[remote.Uint64()] = fee
+	}
+	return fees, nil
 }
 
 func (s *PrivateCrossAdminAPI) SetStoreDelay(chainID *hexutil.Big, number hexutil.Uint64) bool {
-	handler := s.service.getCrossHandler(chainID.ToInt())
-	if handler == nil {
-		return false
+	handlers := s.service.getCrossHandlers(chainID.ToInt())
+	for _, handler := range handlers {
+		handler.SetStoreDelay(uint64(number))
 	}
-	handler.SetStoreDelay(uint64(number))
-	return true
+	return len(handlers) > 0
 }
 
 func (s *PrivateCrossAdminAPI) Remove(chainID *hexutil.Big, number hexutil.Uint64) bool {
-	handler := s.service.getCrossHandler(chainID.ToInt())
-	if handler == nil {
-		return false
-	}
-	if handler.RemoveCrossTransactionBefore(uint64(number)) == 0 {
-		return false
+	var removed int
+	for _, handler := range s.service.getCrossHandlers(chainID.ToInt()) {
+		removed += handler.RemoveCrossTransactionBefore(uint64(number))
 	}
-	return true
+	return removed > 0
 }
 
-func (s *PrivateCrossAdminAPI) importCtx(local, remote *Handler, ctxWithSignsSArgs hexutil.Bytes) error {
+func (s *PrivateCrossAdminAPI) importCtx(ctxWithSignsSArgs hexutil.Bytes) error {
 	ctx := new(cc.CrossTransactionWithSignatures)
 	if err := rlp.DecodeBytes(ctxWithSignsSArgs, ctx); err != nil {
 		return err
 	}
 
+	pair := s.service.getPair(ctx.ChainId(), ctx.DestinationId())
+	if pair == nil {
+		return fmt.Errorf("no pair for ctx from chain %v to chain %v", ctx.ChainId(), ctx.DestinationId())
+	}
+	local, remote := pair.getCrossHandler(ctx.ChainId()), pair.getCrossHandler(ctx.DestinationId())
+
 	if ctx.SignaturesLength() < local.retriever.RequireSignatures() {
 		return fmt.Errorf("invalid signture length ctx: %d,want: %d", ctx.SignaturesLength(), local.retriever.RequireSignatures())
 	}
@@ -122,7 +236,7 @@ func (s *PrivateCrossAdminAPI) importCtx(local, remote *Handler, ctxWithSignsSAr
 	if invalidSigIndex != nil {
 		return fmt.Errorf("invalid signature of ctx:%s for signature:%v\n", ctx.ID().String(), invalidSigIndex)
 	}
-	if err := s.service.store.Add(ctx); err != nil {
+	if err := pair.store.Add(ctx); err != nil {
 		return err
 	}
 	log.Info("rpc ImportCtx", "ctxID", ctx.ID().String())
@@ -130,19 +244,20 @@ func (s *PrivateCrossAdminAPI) importCtx(local, remote *Handler, ctxWithSignsSAr
 }
 
 func (s *PrivateCrossAdminAPI) ImportMainCtx(ctxWithSignsSArgs hexutil.Bytes) error {
-	return s.importCtx(s.service.main.handler, s.service.sub.handler, ctxWithSignsSArgs)
+	return s.importCtx(ctxWithSignsSArgs)
 }
 
 func (s *PrivateCrossAdminAPI) ImportSubCtx(ctxWithSignsSArgs hexutil.Bytes) error {
-	return s.importCtx(s.service.sub.handler, s.service.main.handler, ctxWithSignsSArgs)
+	return s.importCtx(ctxWithSignsSArgs)
 }
 
+// PublicCrossChainAPI serves cross transactions of a chain, which may be paired with several remote chains
 type PublicCrossChainAPI struct {
-	handler *Handler
+	handlers []*Handler
 }
 
-func NewPublicCrossChainAPI(handler *Handler) *PublicCrossChainAPI {
-	return &PublicCrossChainAPI{handler}
+func NewPublicCrossChainAPI(handlers ...*Handler) *PublicCrossChainAPI {
+	return &PublicCrossChainAPI{handlers}
 }
 
 type MonitorInfo struct {
@@ -151,12 +266,20 @@ type MonitorInfo struct {
 }
 
 func (s *PublicCrossChainAPI) Monitor() MonitorInfo {
-	tally, recently := s.handler.monitor.GetInfo()
-	return MonitorInfo{Tally: tally, Recently: recently}
+	info := MonitorInfo{Tally: make(map[common.Address]uint64), Recently: make(map[common.Address]uint32)}
+	for _, h := range s.handlers {
+		tally, recently := h.monitor.GetInfo()
+		for addr, n := range tally {
+			info.Tally[addr] += n
+		}
+		for addr, n := range recently {
+			info.Recently[addr] += n
+		}
+	}
+	return info
 }
 
 func (s *PublicCrossChainAPI) CtxContentByPage(localSize, localPage, remoteSize, remotePage int) map[string]RPCPageCrossTransactions {
-	locals, remotes, _, _ := s.handler.QueryByPage(localSize, localPage, remoteSize, remotePage)
 	content := map[string]RPCPageCrossTransactions{
 		"local": {
 			Data: make(map[uint64][]*RPCCrossTransaction),
@@ -167,118 +290,409 @@ func (s *PublicCrossChainAPI) CtxContentByPage(localSize, localPage, remoteSize,
 			//Total: remoteTotal,
 		},
 	}
-	for s, txs := range locals {
-		for _, tx := range txs {
-			content["local"].Data[s] = append(content["local"].Data[s], newRPCCrossTransaction(tx))
+	for _, h := range s.handlers {
+		locals, remotes, _, _ := h.QueryByPage(localSize, localPage, remoteSize, remotePage)
+		for s, txs := range locals {
+			for _, tx := range txs {
+				content["local"].Data[s] = append(content["local"].Data[s], newRPCCrossTransaction(tx))
+			}
 		}
-	}
-	for k, txs := range remotes {
-		for _, tx := range txs {
-			content["remote"].Data[k] = append(content["remote"].Data[k], newRPCCrossTransaction(tx))
+		for k, txs := range remotes {
+			for _, tx := range txs {
+				content["remote"].Data[k] = append(content["remote"].Data[k], newRPCCrossTransaction(tx))
+			}
 		}
 	}
 	return content
 }
 
 func (s *PublicCrossChainAPI) CtxIllegalByPage(pageSize, startPage int) *RPCPageCrossTransactions {
-	txs := s.handler.QueryLocalIllegalByPage(pageSize, startPage)
-	list := make([]*RPCCrossTransaction, len(txs))
-	for _, tx := range txs {
-		list = append(list, newRPCCrossTransaction(tx))
-	}
-	return &RPCPageCrossTransactions{
-		Data: map[uint64][]*RPCCrossTransaction{
-			s.handler.remoteID.Uint64(): list,
-		},
+	content := &RPCPageCrossTransactions{
+		Data: make(map[uint64][]*RPCCrossTransaction, len(s.handlers)),
 		//Total: total,
 	}
+	for _, h := range s.handlers {
+		txs := h.QueryLocalIllegalByPage(pageSize, startPage)
+		list := make([]*RPCCrossTransaction, 0, len(txs))
+		for _, tx := range txs {
+			list = append(list, newRPCCrossTransaction(tx))
+		}
+		content.Data[h.RemoteID()] = list
+	}
+	return content
 }
 
 func (s *PublicCrossChainAPI) CtxQuery(hash common.Hash) *RPCCrossTransaction {
-	return newRPCCrossTransaction(s.handler.FindByTxHash(hash))
+	for _, h := range s.handlers {
+		if ctx := h.FindByTxHash(hash); ctx != nil {
+			return newRPCCrossTransaction(ctx)
+		}
+	}
+	return nil
 }
 
 func (s *PublicCrossChainAPI) CtxQueryDestValue(value *hexutil.Big, pageSize, startPage int) *RPCPageCrossTransactions {
-	chainID, txs, _ := s.handler.QueryRemoteByDestinationValueAndPage(value.ToInt(), pageSize, startPage)
-	list := make([]*RPCCrossTransaction, len(txs))
-	for i, tx := range txs {
-		list[i] = newRPCCrossTransaction(tx)
-	}
-	return &RPCPageCrossTransactions{
-		Data: map[uint64][]*RPCCrossTransaction{
-			chainID: list,
-		},
+	content := &RPCPageCrossTransactions{
+		Data: make(map[uint64][]*RPCCrossTransaction, len(s.handlers)),
 		//Total: total,
 	}
+	for _, h := range s.handlers {
+		chainID, txs, _ := h.QueryRemoteByDestinationValueAndPage(value.ToInt(), pageSize, startPage)
+		list := make([]*RPCCrossTransaction, len(txs))
+		for i, tx := range txs {
+			list[i] = newRPCCrossTransaction(tx)
+		}
+		content.Data[chainID] = list
+	}
+	return content
 }
 
 func (s *PublicCrossChainAPI) CtxOwner(from common.Address) map[string]map[uint64][]*RPCOwnerCrossTransaction {
-	locals, _ := s.handler.QueryLocalBySenderAndPage(from, 0, 0)
 	content := map[string]map[uint64][]*RPCOwnerCrossTransaction{
 		"local": make(map[uint64][]*RPCOwnerCrossTransaction),
 	}
-	for s, txs := range locals {
-		for _, tx := range txs {
-			content["local"][s] = append(content["local"][s], newOwnerRPCCrossTransaction(tx))
+	for _, h := range s.handlers {
+		locals, _ := h.QueryLocalBySenderAndPage(from, 0, 0)
+		for s, txs := range locals {
+			for _, tx := range txs {
+				content["local"][s] = append(content["local"][s], newOwnerRPCCrossTransaction(tx))
+			}
 		}
 	}
 	return content
 }
 
 func (s *PublicCrossChainAPI) CtxOwnerByPage(from common.Address, pageSize, startPage int) RPCPageOwnerCrossTransactions {
-	locals, _ := s.handler.QueryLocalBySenderAndPage(from, pageSize, startPage)
 	content := RPCPageOwnerCrossTransactions{
-		Data: make(map[uint64][]*RPCOwnerCrossTransaction, len(locals)),
+		Data: make(map[uint64][]*RPCOwnerCrossTransaction, len(s.handlers)),
 		//Total: total,
 	}
-	for chainID, txs := range locals {
-		for _, tx := range txs {
-			content.Data[chainID] = append(content.Data[chainID], newOwnerRPCCrossTransaction(tx))
+	for _, h := range s.handlers {
+		locals, _ := h.QueryLocalBySenderAndPage(from, pageSize, startPage)
+		for chainID, txs := range locals {
+			for _, tx := range txs {
+				content.Data[chainID] = append(content.Data[chainID], newOwnerRPCCrossTransaction(tx))
+			}
+		}
+	}
+	return content
+}
+
+// CtxRefundableByPage returns ctxs of from which can be refunded after expired
+func (s *PublicCrossChainAPI) CtxRefundableByPage(from common.Address, pageSize, startPage int) RPCPageOwnerCrossTransactions {
+	content := RPCPageOwnerCrossTransactions{
+		Data: make(map[uint64][]*RPCOwnerCrossTransaction, len(s.handlers)),
+	}
+	for _, h := range s.handlers {
+		locals, _ := h.QueryRefundableBySenderAndPage(from, pageSize, startPage)
+		for chainID, txs := range locals {
+			for _, tx := range txs {
+				content.Data[chainID] = append(content.Data[chainID], newOwnerRPCCrossTransaction(tx))
+			}
 		}
 	}
 	return content
 }
 
 func (s *PublicCrossChainAPI) CtxTakerByPage(to common.Address, pageSize, startPage int) RPCPageOwnerCrossTransactions {
-	locals, _ := s.handler.QueryRemoteByTakerAndPage(to, pageSize, startPage)
 	content := RPCPageOwnerCrossTransactions{
-		Data: make(map[uint64][]*RPCOwnerCrossTransaction, len(locals)),
+		Data: make(map[uint64][]*RPCOwnerCrossTransaction, len(s.handlers)),
 		//Total: total,
 	}
-	for chainID, txs := range locals {
-		for _, tx := range txs {
-			content.Data[chainID] = append(content.Data[chainID], newOwnerRPCCrossTransaction(tx))
+	for _, h := range s.handlers {
+		remotes, _ := h.QueryRemoteByTakerAndPage(to, pageSize, startPage)
+		for chainID, txs := range remotes {
+			for _, tx := range txs {
+				content.Data[chainID] = append(content.Data[chainID], newOwnerRPCCrossTransaction(tx))
+			}
 		}
 	}
 	return content
 }
 
 func (s *PublicCrossChainAPI) CtxGet(id common.Hash) *RPCCrossTransaction {
-	ctx, _ := s.handler.txLog.GetFinish(id)
-	if ctx == nil {
-		ctx = s.handler.GetByCtxID(id)
+	for _, h := range s.handlers {
+		ctx, _ := h.txLog.GetFinish(id)
+		if ctx == nil {
+			ctx = h.GetByCtxID(id)
+		}
+		if ctx != nil {
+			return newRPCCrossTransaction(ctx)
+		}
 	}
-	return newRPCCrossTransaction(ctx)
+	return nil
 }
 
 func (s *PublicCrossChainAPI) CtxGetByNumber(begin, end hexutil.Uint64) map[cc.CtxStatus][]common.Hash {
-	ctxList := s.handler.GetByBlockNumber(uint64(begin), uint64(end))
 	result := make(map[cc.CtxStatus][]common.Hash)
-	for _, tx := range ctxList {
-		result[tx.Status] = append(result[tx.Status], tx.ID())
+	for _, h := range s.handlers {
+		for _, tx := range h.GetByBlockNumber(uint64(begin), uint64(end)) {
+			result[tx.Status] = append(result[tx.Status], tx.ID())
+		}
 	}
 	return result
 }
 
 func (s *PublicCrossChainAPI) PoolStats() map[string]int {
-	pending, queue := s.handler.PoolStats()
-	return map[string]int{"pending": pending, "queue": queue}
+	stats := map[string]int{"pending": 0, "queue": 0}
+	for _, h := range s.handlers {
+		pending, queue := h.PoolStats()
+		stats["pending"] += pending
+		stats["queue"] += queue
+	}
+	return stats
+}
+
+// Evidences returns equivocation evidences of anchors signing local ctxs, of the signer if it is not empty
+func (s *PublicCrossChainAPI) Evidences(signer common.Address, pageSize, startPage int) map[uint64][]*RPCEvidence {
+	evidences := make(map[uint64][]*RPCEvidence, len(s.handlers))
+	for _, h := range s.handlers {
+		list := make([]*RPCEvidence, 0)
+		for _, ev := range h.QueryEvidences(signer, pageSize, startPage) {
+			list = append(list, newRPCEvidence(ev))
+		}
+		evidences[h.RemoteID()] = list
+	}
+	return evidences
+}
+
+// RPCFinishProof is the merkle proof of a finished ctx in the trie of TransactionLog,
+// it is verified by db.VerifyFinishProof and the signers of root should be a quorum of anchors of the chain
+type RPCFinishProof struct {
+	ChainId    *hexutil.Big     `json:"chainId"`
+	CtxId      common.Hash      `json:"ctxId"`
+	Root       common.Hash      `json:"root"`
+	Number     hexutil.Uint64   `json:"number"`
+	Signers    []common.Address `json:"signers"` //empty if the root is not anchored by a quorum of anchors yet
+	Signatures []hexutil.Bytes  `json:"signatures"`
+	Proof      []hexutil.Bytes  `json:"proof"`
+}
+
+// GetFinishProof returns the merkle proof of finished ctx made in the chain
+func (s *PublicCrossChainAPI) GetFinishProof(chainID *hexutil.Big, ctxID common.Hash) (*RPCFinishProof, error) {
+	for _, h := range s.handlers {
+		if h.chainID.Cmp(chainID.ToInt()) != 0 {
+			continue
+		}
+		root, proof, err := h.FinishProof(ctxID)
+		if err != nil {
+			return nil, err
+		}
+		result := &RPCFinishProof{
+			ChainId:    chainID,
+			CtxId:      ctxID,
+			Root:       root.Root,
+			Number:     hexutil.Uint64(root.Number),
+			Signatures: root.Signatures,
+			Proof:      make([]hexutil.Bytes, len(proof)),
+		}
+		if signers, err := root.Signers(); err == nil {
+			result.Signers = signers
+		}
+		for i, node := range proof {
+			result.Proof[i] = node
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("chain %s is not served", chainID.ToInt())
+}
+
+// CtxFilterCriteria filters status events of ctx, an empty field matches any
+type CtxFilterCriteria struct {
+	Owners   []common.Address `json:"owner"`   // maker of ctx
+	Takers   []common.Address `json:"taker"`   // receiver of ctx
+	Statuses []cc.CtxStatus   `json:"status"`  // status after changed
+	ChainIDs []*hexutil.Big   `json:"chainId"` // chain that ctx is made in
+}
+
+func (crit CtxFilterCriteria) match(chainID *big.Int, tx *cc.CrossTransactionWithSignatures) bool {
+	matchAddress := func(addrs []common.Address, addr common.Address) bool {
+		for _, a := range addrs {
+			if a == addr {
+				return true
+			}
+		}
+		return len(addrs) == 0
+	}
+	if !matchAddress(crit.Owners, tx.Data.From) || !matchAddress(crit.Takers, tx.Data.To) {
+		return false
+	}
+	if len(crit.Statuses) > 0 {
+		var found bool
+		for _, status := range crit.Statuses {
+			if found = status == tx.Status; found {
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(crit.ChainIDs) > 0 {
+		for _, id := range crit.ChainIDs {
+			if id != nil && id.ToInt().Cmp(chainID) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// RPCCtxStatusEvent is a ctx with the status before changed
+type RPCCtxStatusEvent struct {
+	*RPCCrossTransaction
+	ChainId    *hexutil.Big `json:"chainId"`
+	PrevStatus cc.CtxStatus `json:"prevStatus"`
+}
+
+// CtxStatus sends a notification each time status of a ctx matched by crit is changed,
+// subscribe by cross_subscribe("ctxStatus", crit)
+func (s *PublicCrossChainAPI) CtxStatus(ctx context.Context, crit CtxFilterCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		var (
+			statusCh = make(chan cc.CtxStatusEvent, statusChanSize)
+			subs     []event.Subscription
+			quitCh   = make(chan struct{}, len(s.handlers))
+			stores   = make(map[*CrossStore]struct{})
+		)
+		// handlers of a chain may share the same store, subscribe it once
+		for _, h := range s.handlers {
+			if _, ok := stores[h.store]; ok {
+				continue
+			}
+			stores[h.store] = struct{}{}
+			sub := h.store.SubscribeCtxStatusEvent(statusCh)
+			subs = append(subs, sub)
+			go func() {
+				<-sub.Err() // closed if store is closed
+				quitCh <- struct{}{}
+			}()
+		}
+		defer func() {
+			for _, sub := range subs {
+				sub.Unsubscribe()
+			}
+		}()
+
+		for {
+			select {
+			case ev := <-statusCh:
+				chainID := ev.Tx.ChainId()
+				if crit.match(chainID, ev.Tx) {
+					notifier.Notify(rpcSub.ID, &RPCCtxStatusEvent{
+						RPCCrossTransaction: newRPCCrossTransaction(ev.Tx),
+						ChainId:             (*hexutil.Big)(chainID),
+						PrevStatus:          ev.PrevStatus,
+					})
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			case <-quitCh:
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// OrderBook returns depth snapshots of open orders with every remote chain, keyed by remote chainID,
+// at most depth price levels are returned for each side, or all levels if depth is 0
+func (s *PublicCrossChainAPI) OrderBook(depth int) map[uint64]*OrderBookDepth {
+	books := make(map[uint64]*OrderBookDepth, len(s.handlers))
+	for _, h := range s.handlers {
+		books[h.RemoteID()] = h.store.OrderBook().Depth(h.LocalID(), h.RemoteID(), depth)
+	}
+	return books
+}
+
+// RPCOrderBookEvent is a changed price level of the order book with a remote chain,
+// the amount and volume are zero if orders of the level are all gone
+type RPCOrderBookEvent struct {
+	*PriceLevel
+	RemoteChainId hexutil.Uint64 `json:"remoteChainId"`
+	Seq           hexutil.Uint64 `json:"seq"` // sequence of the change, compared with seq of depth snapshots
+}
+
+// OrderBookUpdates sends a notification each time a price level of the order book is changed,
+// subscribe by cross_subscribe("orderBookUpdates")
+func (s *PublicCrossChainAPI) OrderBookUpdates(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		var (
+			bookCh  = make(chan OrderBookEvent, statusChanSize)
+			subs    []event.Subscription
+			quitCh  = make(chan struct{}, len(s.handlers))
+			books   = make(map[*OrderBook]struct{})
+			remotes = make(map[uint64]uint64) // remote chainID => local chainID
+		)
+		for _, h := range s.handlers {
+			remotes[h.RemoteID()] = h.LocalID()
+			// handlers of a chain may share the same store, subscribe it once
+			book := h.store.OrderBook()
+			if _, ok := books[book]; ok {
+				continue
+			}
+			books[book] = struct{}{}
+			sub := book.SubscribeOrderBookEvent(bookCh)
+			subs = append(subs, sub)
+			go func() {
+				<-sub.Err() // closed if store is closed
+				quitCh <- struct{}{}
+			}()
+		}
+		defer func() {
+			for _, sub := range subs {
+				sub.Unsubscribe()
+			}
+		}()
+
+		for {
+			select {
+			case ev := <-bookCh:
+				for remote, local := range remotes {
+					if ev.From == local && ev.To == remote || ev.From == remote && ev.To == local {
+						notifier.Notify(rpcSub.ID, &RPCOrderBookEvent{
+							PriceLevel:    newPriceLevel(local, market{from: ev.From, to: ev.To}, &ev.level),
+							RemoteChainId: hexutil.Uint64(remote),
+							Seq:           hexutil.Uint64(ev.Seq),
+						})
+					}
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			case <-quitCh:
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
 }
 
 type RPCCrossTransaction struct {
 	Value            *hexutil.Big   `json:"value"`
 	CTxId            common.Hash    `json:"ctxId"`
 	Status           cc.CtxStatus   `json:"status"`
+	Kind             cc.CtxKind     `json:"kind"`
 	TxHash           common.Hash    `json:"txHash"`
 	From             common.Address `json:"from"`
 	To               common.Address `json:"to"`
@@ -290,6 +704,9 @@ type RPCCrossTransaction struct {
 	V                []*hexutil.Big `json:"v"`
 	R                []*hexutil.Big `json:"r"`
 	S                []*hexutil.Big `json:"s"`
+	Aggregate        hexutil.Bytes  `json:"aggregate,omitempty"` // BLS aggregate signature, taken by takerAggregate
+	Filled           *hexutil.Big   `json:"filled,omitempty"`    // destination value filled by takerPartial
+	Remaining        *hexutil.Big   `json:"remaining"`           // destination value open for takers
 }
 
 // newRPCCrossTransaction returns a transaction that will serialize to the RPC
@@ -302,6 +719,7 @@ func newRPCCrossTransaction(tx *cc.CrossTransactionWithSignatures) *RPCCrossTran
 		Value:            (*hexutil.Big)(tx.Data.Value),
 		CTxId:            tx.ID(),
 		Status:           tx.Status,
+		Kind:             tx.Kind(),
 		TxHash:           tx.Data.TxHash,
 		From:             tx.Data.From,
 		To:               tx.Data.To,
@@ -310,6 +728,9 @@ func newRPCCrossTransaction(tx *cc.CrossTransactionWithSignatures) *RPCCrossTran
 		DestinationId:    (*hexutil.Big)(tx.Data.DestinationId),
 		DestinationValue: (*hexutil.Big)(tx.Data.DestinationValue),
 		Input:            tx.Data.Input,
+		Aggregate:        tx.AggregateSignature(),
+		Filled:           (*hexutil.Big)(tx.Filled),
+		Remaining:        (*hexutil.Big)(tx.Remaining()),
 	}
 	for _, v := range tx.Data.V {
 		result.V = append(result.V, (*hexutil.Big)(v))
@@ -340,6 +761,7 @@ type RPCOwnerCrossTransaction struct {
 	V                []*hexutil.Big `json:"v"`
 	R                []*hexutil.Big `json:"r"`
 	S                []*hexutil.Big `json:"s"`
+	Aggregate        hexutil.Bytes  `json:"aggregate,omitempty"`
 }
 
 func newOwnerRPCCrossTransaction(tx *cc.OwnerCrossTransactionWithSignatures) *RPCOwnerCrossTransaction {
@@ -356,6 +778,7 @@ func newOwnerRPCCrossTransaction(tx *cc.OwnerCrossTransactionWithSignatures) *RP
 		DestinationValue: (*hexutil.Big)(tx.Cws.Data.DestinationValue),
 		Input:            tx.Cws.Data.Input,
 		Time:             hexutil.Uint64(tx.Time),
+		Aggregate:        tx.Cws.AggregateSignature(),
 	}
 	for _, v := range tx.Cws.Data.V {
 		result.V = append(result.V, (*hexutil.Big)(v))
@@ -370,6 +793,27 @@ func newOwnerRPCCrossTransaction(tx *cc.OwnerCrossTransactionWithSignatures) *RP
 	return result
 }
 
+// RPCEvidence is an anchor equivocation evidence, both ctxs are shown with their signatures
+type RPCEvidence struct {
+	Kind   cc.EvidenceKind      `json:"kind"`
+	Signer common.Address       `json:"signer"`
+	CTxId  common.Hash          `json:"ctxId"`
+	First  *RPCCrossTransaction `json:"first"`
+	Second *RPCCrossTransaction `json:"second"`
+	Time   hexutil.Uint64       `json:"time"`
+}
+
+func newRPCEvidence(ev *cc.Evidence) *RPCEvidence {
+	return &RPCEvidence{
+		Kind:   ev.Kind,
+		Signer: ev.Signer,
+		CTxId:  ev.ID(),
+		First:  newRPCCrossTransaction(cc.NewCrossTransactionWithSignatures(ev.First, 0)),
+		Second: newRPCCrossTransaction(cc.NewCrossTransactionWithSignatures(ev.Second, 0)),
+		Time:   hexutil.Uint64(ev.Time),
+	}
+}
+
 type RPCPageCrossTransactions struct {
 	Data map[uint64][]*RPCCrossTransaction `json:"data"`
 	//Total int                               `json:"total"`
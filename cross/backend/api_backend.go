@@ -20,6 +20,13 @@ func query(db cdb.CtxDB, pageSize, startPage int, orderBy []cdb.FieldName, rever
 //	return db.Count(condition...)
 //}
 
+// open matches ctxs which can be taken, partially filled ctxs are open for the remaining value
+// open matches orders which are open for takers, messages are delivered by anchors
+func open() q.Matcher {
+	return q.And(q.Eq(cdb.KindField, cc.CtxKindOrder),
+		q.Or(q.Eq(cdb.StatusField, cc.CtxStatusWaiting), q.Eq(cdb.StatusField, cc.CtxStatusPartialFilled)))
+}
+
 func one(db cdb.CtxDB, field cdb.FieldName, value interface{}) *cc.CrossTransactionWithSignatures {
 	return db.One(field, value)
 }
@@ -59,7 +66,7 @@ func (h *Handler) QueryRemoteByDestinationValueAndPage(value *big.Int, pageSize,
 	}
 	var (
 		store, _  = h.store.GetStore(h.chainID)
-		condition = []q.Matcher{q.Eq(cdb.StatusField, cc.CtxStatusWaiting), q.Gte(cdb.DestinationValue, value)}
+		condition = []q.Matcher{open(), q.Gte(cdb.RemainingValue, value)}
 		orderBy   = []cdb.FieldName{cdb.PriceIndex}
 		reverse   = false
 	)
@@ -76,7 +83,7 @@ func (h *Handler) QueryByPage(localSize, localPage, remoteSize, remotePage int)
 	var (
 		localStore, _  = h.store.GetStore(h.chainID)
 		remoteStore, _ = h.store.GetStore(h.remoteID)
-		condition      = []q.Matcher{q.Eq(cdb.StatusField, cc.CtxStatusWaiting)}
+		condition      = []q.Matcher{open()}
 		orderBy        = []cdb.FieldName{cdb.PriceIndex}
 		reverse        = false
 	)
@@ -103,6 +110,27 @@ func (h *Handler) QueryLocalIllegalByPage(pageSize, startPage int) []*cc.CrossTr
 	return query(store, pageSize, startPage, orderBy, reverse, condition...)
 }
 
+// QueryEvidences returns evidences of anchors signing local ctxs, of the signer if it is not empty
+func (h *Handler) QueryEvidences(signer common.Address, pageSize, startPage int) []*cc.Evidence {
+	return h.store.EvidenceDB(h.chainID).Query(signer, pageSize, startPage)
+}
+
+// FinishProof returns the merkle proof of the finished ctx, against the root signed by a quorum of anchors
+// if the ctx is anchored, otherwise against the last committed root which is unsigned
+func (h *Handler) FinishProof(ctxID common.Hash) (*cc.FinishRoot, [][]byte, error) {
+	if anchored := h.txLog.AnchoredRoot(); anchored != nil {
+		if proof, err := h.txLog.Prove(anchored.Root, ctxID); err == nil {
+			return anchored, proof, nil
+		}
+	}
+	root := cc.NewFinishRoot(h.chainID, h.txLog.Root(), h.Height().Uint64())
+	proof, err := h.txLog.Prove(root.Root, ctxID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return root, proof, nil
+}
+
 func (h *Handler) QueryLocalBySenderAndPage(from common.Address, pageSize, startPage int) (
 	locals map[uint64][]*cc.OwnerCrossTransactionWithSignatures, total int) {
 	if !h.retriever.CanAcceptTxs() {
@@ -114,6 +142,7 @@ func (h *Handler) QueryLocalBySenderAndPage(from common.Address, pageSize, start
 			q.Or(
 				q.Eq(cdb.StatusField, cc.CtxStatusWaiting),
 				q.Eq(cdb.StatusField, cc.CtxStatusIllegal),
+				q.Eq(cdb.StatusField, cc.CtxStatusPartialFilled),
 			),
 			q.Eq(cdb.FromField, from)}
 		orderBy = []cdb.FieldName{cdb.PriceIndex}
@@ -134,13 +163,45 @@ func (h *Handler) QueryLocalBySenderAndPage(from common.Address, pageSize, start
 	return locals, total
 }
 
+// QueryRefundableBySenderAndPage 查询from发起的可退款交易（已过期或已禁止接单）
+func (h *Handler) QueryRefundableBySenderAndPage(from common.Address, pageSize, startPage int) (
+	locals map[uint64][]*cc.OwnerCrossTransactionWithSignatures, total int) {
+	if !h.retriever.CanAcceptTxs() {
+		return nil, 0
+	}
+	refundable := []q.Matcher{q.Eq(cdb.StatusField, cc.CtxStatusCancelling)}
+	if expireNum, current := h.retriever.ExpireNumber(), h.retriever.CurrentBlockNumber(); expireNum >= 0 && current > uint64(expireNum) {
+		refundable = append(refundable, q.And(
+			q.Eq(cdb.StatusField, cc.CtxStatusWaiting),
+			q.Lte(cdb.BlockNumField, current-uint64(expireNum)),
+		))
+	}
+	var (
+		store, _  = h.store.GetStore(h.chainID)
+		condition = []q.Matcher{q.Or(refundable...), q.Eq(cdb.FromField, from)}
+		orderBy   = []cdb.FieldName{cdb.BlockNumField}
+		reverse   = false
+	)
+
+	txs := query(store, pageSize, startPage, orderBy, reverse, condition...)
+	locals = make(map[uint64][]*cc.OwnerCrossTransactionWithSignatures, 1)
+	for _, v := range txs {
+		locals[h.RemoteID()] = append(locals[h.RemoteID()], &cc.OwnerCrossTransactionWithSignatures{
+			Cws:  v,
+			Time: h.retriever.GetTransactionTimeOnChain(v),
+		})
+	}
+
+	return locals, total
+}
+
 func (h *Handler) QueryRemoteByTakerAndPage(to common.Address, pageSize, startPage int) (
 	remotes map[uint64][]*cc.OwnerCrossTransactionWithSignatures, total int) {
 	if !h.retriever.CanAcceptTxs() {
 		return nil, 0
 	}
 	var (
-		condition = []q.Matcher{q.Eq(cdb.StatusField, cc.CtxStatusWaiting), q.Eq(cdb.ToField, to)}
+		condition = []q.Matcher{open(), q.Eq(cdb.ToField, to)}
 		orderBy   = []cdb.FieldName{cdb.PriceIndex}
 		store, _  = h.store.GetStore(h.remoteID)
 		reverse   = false
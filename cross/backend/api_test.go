@@ -0,0 +1,32 @@
+package backend
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCtxFilterCriteria(t *testing.T) {
+	var crit CtxFilterCriteria
+	assert.NoError(t, json.Unmarshal([]byte(`{"owner":["0x0000000000000000000000000000000000000001"],"status":["executing","finished"],"chainId":["0xa"]}`), &crit))
+
+	ctx := generateCtx(1, cc.CtxStatusExecuting)[0]
+	assert.True(t, crit.match(big.NewInt(10), ctx))
+	assert.False(t, crit.match(big.NewInt(11), ctx), "chainID not matched")
+
+	ctx.Status = cc.CtxStatusWaiting
+	assert.False(t, crit.match(big.NewInt(10), ctx), "status not matched")
+
+	ctx.Status = cc.CtxStatusFinished
+	crit.Takers = []common.Address{common.HexToAddress("0x02")}
+	assert.False(t, crit.match(big.NewInt(10), ctx), "taker not matched")
+	ctx.Data.To = common.HexToAddress("0x02")
+	assert.True(t, crit.match(big.NewInt(10), ctx))
+
+	assert.True(t, CtxFilterCriteria{}.match(big.NewInt(1), ctx), "empty criteria matches any")
+}
@@ -2,7 +2,6 @@ package backend
 
 import (
 	"errors"
-	"fmt"
 	"math/big"
 	"sync"
 
@@ -10,51 +9,46 @@ import (
 	"github.com/simplechain-org/go-simplechain/log"
 	"github.com/simplechain-org/go-simplechain/node"
 	"github.com/simplechain-org/go-simplechain/p2p"
-	"github.com/simplechain-org/go-simplechain/p2p/enode"
-	"github.com/simplechain-org/go-simplechain/rlp"
 	"github.com/simplechain-org/go-simplechain/rpc"
 
 	"github.com/simplechain-org/go-simplechain/cross"
-	"github.com/simplechain-org/go-simplechain/cross/backend/synchronise"
 	cc "github.com/simplechain-org/go-simplechain/cross/core"
 	cdb "github.com/simplechain-org/go-simplechain/cross/database"
-	cm "github.com/simplechain-org/go-simplechain/cross/metric"
+	"github.com/simplechain-org/go-simplechain/cross/trigger"
 )
 
+// chainLifecycle is implemented by a ProtocolChain which should be started and stopped by the service
+type chainLifecycle interface {
+	Start() error
+	Stop()
+}
+
 // CrossService implements node.Service
 type CrossService struct {
 	store  *CrossStore
 	txLogs *cdb.TransactionLogs
 
 	config cross.Config
-	peers  *anchorSet
-
-	main crossCommons
-	sub  crossCommons
-
-	newPeerCh chan *anchorPeer
-	quitSync  chan struct{}
-	wg        sync.WaitGroup
-}
+	pairs  []*crossPair
 
-type crossCommons struct {
-	genesis common.Hash
-	chainID uint64
+	executors map[uint64]trigger.Executor // executors are shared by pairs of the same chain
+	chains    map[uint64]chainLifecycle   // chains which are not running in this node, e.g. followed over RPC
 
-	handler *Handler
-	channel chan interface{}
+	quitSync chan struct{}
+	wg       sync.WaitGroup
 }
 
-func NewCrossService(ctx *node.ServiceContext, main, sub *cross.ServiceContext, config cross.Config) (srv *CrossService, err error) {
+func NewCrossService(ctx *node.ServiceContext, pairs []cross.ChainPair, config cross.Config) (srv *CrossService, err error) {
+	if len(pairs) == 0 {
+		return nil, errors.New("no chain pair is configured")
+	}
 	srv = &CrossService{
 		config:    config,
-		peers:     newAnchorSet(),
-		newPeerCh: make(chan *anchorPeer),
+		executors: make(map[uint64]trigger.Executor),
+		chains:    make(map[uint64]chainLifecycle),
 		quitSync:  make(chan struct{}),
 	}
 
-	cm.Reporter.SetRootPath(ctx.ResolvePath(cross.LogDir))
-
 	logDB, err := cdb.OpenEtherDB(ctx, cross.TxLogDir)
 	if err != nil {
 		return nil, err
@@ -64,94 +58,119 @@ func NewCrossService(ctx *node.ServiceContext, main, sub *cross.ServiceContext,
 		return nil, err
 	}
 
-	srv.store, err = NewCrossStore(ctx, cross.DataDir)
+	srv.store, err = NewCrossStore(ctx, config.StoreEngine, StoreName(config.StoreEngine))
 	if err != nil {
 		return nil, err
 	}
 
-	mainCh, subCh := make(chan interface{}, defaultCrossChSize), make(chan interface{}, defaultCrossChSize)
-
-	mainHandler, err := NewCrossHandler(main, srv, mainCh, subCh)
-	if err != nil {
-		return nil, err
+	var (
+		apiChains   = make(map[uint64]cross.ProtocolChain)
+		apiHandlers = make(map[uint64][]*Handler)
+		apiOrder    []uint64
+	)
+	addAPIHandler := func(chain cross.ProtocolChain, h *Handler) {
+		chainID := chain.ChainID().Uint64()
+		if _, ok := apiChains[chainID]; !ok {
+			apiChains[chainID] = chain
+			apiOrder = append(apiOrder, chainID)
+		}
+		apiHandlers[chainID] = append(apiHandlers[chainID], h)
 	}
 
-	subHandler, err := NewCrossHandler(sub, srv, subCh, mainCh)
-	if err != nil {
-		return nil, err
-	}
+	remotes := make(map[uint64]struct{})
+	for _, p := range pairs {
+		remoteID := p.Sub.ProtocolChain.ChainID().Uint64()
+		if _, ok := remotes[remoteID]; ok {
+			return nil, errors.New("duplicate remote chain pair")
+		}
+		remotes[remoteID] = struct{}{}
 
-	mainHandler.RegisterChain(sub.ProtocolChain.ChainID())
-	subHandler.RegisterChain(main.ProtocolChain.ChainID())
+		pair, err := newCrossPair(srv, p.Main, p.Sub, p.Legacy)
+		if err != nil {
+			return nil, err
+		}
+		srv.pairs = append(srv.pairs, pair)
 
-	main.ProtocolChain.RegisterAPIs([]rpc.API{
-		{
-			Namespace: "cross",
-			Version:   "1.0",
-			Service:   NewPublicCrossChainAPI(mainHandler),
-			Public:    true,
-		},
-	})
-	sub.ProtocolChain.RegisterAPIs([]rpc.API{
-		{
-			Namespace: "cross",
-			Version:   "1.0",
-			Service:   NewPublicCrossChainAPI(subHandler),
-			Public:    true,
-		},
-	})
+		for _, ctx := range []*cross.ServiceContext{p.Main, p.Sub} {
+			if _, ok := srv.executors[ctx.ProtocolChain.ChainID().Uint64()]; !ok {
+				srv.executors[ctx.ProtocolChain.ChainID().Uint64()] = ctx.Executor
+			}
+			if chain, ok := ctx.ProtocolChain.(chainLifecycle); ok {
+				srv.chains[ctx.ProtocolChain.ChainID().Uint64()] = chain
+			}
+		}
 
-	srv.main = crossCommons{
-		genesis: main.ProtocolChain.GenesisHash(),
-		chainID: main.ProtocolChain.ChainID().Uint64(),
-		handler: mainHandler,
-		channel: mainCh,
+		addAPIHandler(p.Main.ProtocolChain, pair.main.handler)
+		addAPIHandler(p.Sub.ProtocolChain, pair.sub.handler)
 	}
 
-	srv.sub = crossCommons{
-		genesis: sub.ProtocolChain.GenesisHash(),
-		chainID: sub.ProtocolChain.ChainID().Uint64(),
-		handler: subHandler,
-		channel: subCh,
+	for _, chainID := range apiOrder {
+		apiChains[chainID].RegisterAPIs([]rpc.API{
+			{
+				Namespace: "cross",
+				Version:   "1.0",
+				Service:   NewPublicCrossChainAPI(apiHandlers[chainID]...),
+				Public:    true,
+			},
+		})
 	}
 
 	return srv, nil
 }
 
-func (srv *CrossService) getCrossHandler(chainID *big.Int) *Handler {
-	if chainID == nil {
-		return nil
+// getCrossHandlers returns handlers of the chain in every pair
+func (srv *CrossService) getCrossHandlers(chainID *big.Int) []*Handler {
+	var handlers []*Handler
+	for _, pair := range srv.pairs {
+		if h := pair.getCrossHandler(chainID); h != nil {
+			handlers = append(handlers, h)
+		}
 	}
-	if chainID.Uint64() == srv.main.chainID {
-		return srv.main.handler
+	return handlers
+}
+
+// getPair returns the pair which bridges the chain and the destination chain
+func (srv *CrossService) getPair(chainID, destinationID *big.Int) *crossPair {
+	for _, pair := range srv.pairs {
+		if pair.match(chainID, destinationID) {
+			return pair
+		}
 	}
-	if chainID.Uint64() == srv.sub.chainID {
-		return srv.sub.handler
+	return nil
+}
+
+// GetCtx returns the signed ctx made in the chain, nil if it is not stored by this anchor
+func (srv *CrossService) GetCtx(chainID *big.Int, ctxID common.Hash) *cc.CrossTransactionWithSignatures {
+	for _, pair := range srv.pairs {
+		if ctx := pair.store.Get(chainID, ctxID); ctx != nil {
+			return ctx
+		}
 	}
 	return nil
 }
 
+// CtxStatus returns the status of a ctx made in the chain, ctxs still collecting signatures are pending,
+// false if the ctx is unknown to this anchor
+func (srv *CrossService) CtxStatus(chainID *big.Int, ctxID common.Hash) (cc.CtxStatus, bool) {
+	if ctx := srv.GetCtx(chainID, ctxID); ctx != nil {
+		return ctx.Status, true
+	}
+	for _, h := range srv.getCrossHandlers(chainID) {
+		if h.pool.pending.Get(ctxID) != nil {
+			return cc.CtxStatusPending, true
+		}
+	}
+	return cc.CtxStatusPending, false
+}
+
 func (srv *CrossService) Protocols() []p2p.Protocol {
-	return []p2p.Protocol{{
-		Name:    "cross",
-		Version: protocolVersion,
-		Length:  protocolMaxMsgSize,
-		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
-			anchor := newAnchorPeer(p, rw)
-			srv.wg.Add(1)
-			defer srv.wg.Done()
-			return srv.handle(anchor)
-		},
-		NodeInfo: func() interface{} {
-			return srv.NodeInfo()
-		},
-		PeerInfo: func(id enode.ID) interface{} {
-			if p := srv.peers.Peer(fmt.Sprintf("%x", id[:8])); p != nil {
-				return p.Info()
-			}
-			return nil
-		},
-	}}
+	protocols := make([]p2p.Protocol, 0, len(srv.pairs)*len(protocolVersions))
+	for _, pair := range srv.pairs {
+		for _, version := range protocolVersions {
+			protocols = append(protocols, pair.protocol(version))
+		}
+	}
+	return protocols
 }
 
 func (srv *CrossService) APIs() []rpc.API {
@@ -166,287 +185,42 @@ func (srv *CrossService) APIs() []rpc.API {
 }
 
 func (srv *CrossService) Start(server *p2p.Server) error {
-	if srv.main.handler == nil {
-		return errors.New("main handler is not exist")
-	}
-	srv.main.handler.Start()
-
-	if srv.sub.handler == nil {
-		return errors.New("sub handler is not exist")
-	}
-	srv.sub.handler.Start()
-
-	// start sync handlers
-	go srv.sync()
-	return nil
-}
-
-func (srv *CrossService) Stop() error {
-	log.Info("Stopping CrossChain Service")
-	srv.main.handler.Stop()
-	srv.sub.handler.Stop()
-	close(srv.quitSync)
-	srv.peers.Close()
-	srv.wg.Wait()
-	srv.txLogs.Close()
-	log.Info("CrossChain Service Stopped")
-	return nil
-}
-
-func (srv *CrossService) handle(p *anchorPeer) error {
-	var (
-		mainNetworkID = srv.main.chainID
-		subNetworkID  = srv.sub.chainID
-		mainGenesis   = srv.main.genesis
-		subGenesis    = srv.sub.genesis
-		mainHeight    = srv.main.handler.Height()
-		subHeight     = srv.sub.handler.Height()
-		main          = srv.config.MainContract
-		sub           = srv.config.SubContract
-	)
-	if err := p.Handshake(mainNetworkID, subNetworkID, mainGenesis, subGenesis, mainHeight, subHeight, main, sub); err != nil {
-		log.Debug("anchor handshake failed", "err", err)
-		return err
-	}
-
-	// Register the anchor peer locally
-	if err := srv.peers.Register(p); err != nil {
-		p.Log().Error("CrossService peer registration failed", "err", err)
-		return err
-	}
-	defer srv.removePeer(p.id)
-
-	if err := srv.main.handler.synchronise.RegisterPeer(p.id, p); err != nil {
-		return err
-	}
-	if err := srv.sub.handler.synchronise.RegisterPeer(p.id, p); err != nil {
-		return err
-	}
-
-	select {
-	case srv.newPeerCh <- p:
-	case <-srv.quitSync:
-		return p2p.DiscQuitting
-	}
-
-	// Handle incoming messages until the connection is torn down
-	for {
-		if err := srv.handleMsg(p); err != nil {
+	for _, chain := range srv.chains {
+		if err := chain.Start(); err != nil {
 			return err
 		}
 	}
-}
-
-func (srv *CrossService) handleMsg(p *anchorPeer) error {
-	// Read the next message from the remote peer, and ensure it's fully consumed
-	msg, err := p.rw.ReadMsg()
-	if err != nil {
-		return err
-	}
-	if msg.Size > protocolMaxMsgSize {
-		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, protocolMaxMsgSize)
-	}
-	defer msg.Discard()
-
-	switch {
-	case msg.Code == StatusMsg:
-		// Status messages should never arrive after the handshake
-		return errResp(ErrExtraStatusMsg, "uncontrolled status message")
-
-	case msg.Code == GetCtxSyncMsg:
-		var req synchronise.SyncReq
-		if err := msg.Decode(&req); err != nil {
-			return errResp(ErrDecode, "msg %v: %v", msg, err)
-		}
-		p.Log().Info("receive ctx sync request", "chain", req.Chain, "height", req.Height)
-
-		h := srv.getCrossHandler(new(big.Int).SetUint64(req.Chain))
-		if h == nil {
-			break
-		}
-
-		ctxList := h.GetCrossTransactionByHeight(req.Height, defaultMaxSyncSize)
-		var data [][]byte
-		for _, ctx := range ctxList {
-			b, err := rlp.EncodeToBytes(ctx)
-			if err != nil {
-				continue
-			}
-			data = append(data, b)
-		}
-
-		return p.SendSyncResponse(req.Chain, data)
-
-	case msg.Code == CtxSyncMsg:
-		var resp synchronise.SyncResp
-		if err := msg.Decode(&resp); err != nil {
-			return errResp(ErrDecode, "msg %v: %v", msg, err)
-		}
-		p.Log().Debug("receive ctx sync response", "chain", resp.Chain, "len(data)", len(resp.Data))
-
-		h := srv.getCrossHandler(new(big.Int).SetUint64(resp.Chain))
-		if h == nil /*|| atomic.LoadUint32(&h.synchronising) == 0*/ { // ignore if handler isn't synchronising
-			break
-		}
-
-		var ctxList []*cc.CrossTransactionWithSignatures
-		for _, b := range resp.Data {
-			var ctx cc.CrossTransactionWithSignatures
-			if err := rlp.DecodeBytes(b, &ctx); err != nil {
-				return errResp(ErrDecode, "msg %v: %v", msg, err)
-			}
-			ctxList = append(ctxList, &ctx)
-		}
-
-		if err := h.synchronise.DeliverCrossTransactions(p.id, ctxList); err != nil {
-			log.Debug("Failed to deliver cross tx", "error", err)
-		}
-
-	case msg.Code == GetPendingSyncMsg:
-		var req synchronise.SyncPendingReq
-		if err := msg.Decode(&req); err != nil {
-			return errResp(ErrDecode, "msg %v: %v", msg, err)
-		}
-
-		h := srv.getCrossHandler(new(big.Int).SetUint64(req.Chain))
-		if h == nil {
-			break
-		}
-
-		ctxList := h.GetPending(req.Ids)
-		var data [][]byte
-		for _, ctx := range ctxList {
-			b, err := rlp.EncodeToBytes(ctx)
-			if err != nil {
-				continue
-			}
-			data = append(data, b)
-		}
-		return p.SendSyncPendingResponse(req.Chain, data)
-
-	case msg.Code == PendingSyncMsg:
-		var resp synchronise.SyncPendingResp
-		if err := msg.Decode(&resp); err != nil {
-			return errResp(ErrDecode, "msg %v: %v", msg, err)
-		}
-		p.Log().Debug("receive pending sync response", "chain", resp.Chain, "len(data)", len(resp.Data))
-
-		h := srv.getCrossHandler(new(big.Int).SetUint64(resp.Chain))
-		if h == nil {
-			break
-		}
-
-		var ctxList []*cc.CrossTransaction
-		for _, b := range resp.Data {
-			var ctx cc.CrossTransaction
-			if err := rlp.DecodeBytes(b, &ctx); err != nil {
-				return errResp(ErrDecode, "msg %v: %v", msg, err)
-			}
-			ctxList = append(ctxList, &ctx)
-		}
-
-		if err := h.synchronise.DeliverPending(p.id, ctxList); err != nil {
-			log.Debug("Failed to deliver pending", "error", err)
-		}
-
-	case msg.Code == CtxSignMsg:
-		var ctx *cc.CrossTransaction
-		if err := msg.Decode(&ctx); err != nil {
-			return errResp(ErrDecode, "msg %v: %v", msg, err)
-		}
-		p.MarkCrossTransaction(ctx.SignHash())
-
-		h := srv.getCrossHandler(ctx.ChainId())
-		if h == nil {
-			break
+	for _, pair := range srv.pairs {
+		if pair.main.handler == nil {
+			return errors.New("main handler is not exist")
 		}
-
-		err := h.AddRemoteCtx(ctx)
-		if err == cross.ErrExpiredCtx || err == cross.ErrInvalidSignCtx {
-			break
+		if pair.sub.handler == nil {
+			return errors.New("sub handler is not exist")
 		}
-		srv.BroadcastCrossTx([]*cc.CrossTransaction{ctx}, false)
-
-	default:
-		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+		pair.start()
 	}
-
-	return nil
-}
-
-func (srv *CrossService) removePeer(id string) {
-	// Short circuit if the peer was already removed
-	peer := srv.peers.Peer(id)
-	if peer == nil {
-		return
-	}
-	log.Debug("Removing cross anchor peer", "peer", id)
-
-	// Unregister the peer from the synchronise and anchor peer set
-	srv.main.handler.synchronise.UnregisterPeer(id)
-	srv.sub.handler.synchronise.UnregisterPeer(id)
-	if err := srv.peers.Unregister(id); err != nil {
-		log.Error("Peer removal failed", "peer", id, "err", err)
+	for _, executor := range srv.executors {
+		executor.Start()
 	}
-	// Hard disconnect at the networking layer
-	peer.Disconnect(p2p.DiscUselessPeer)
+	return nil
 }
 
-func (srv *CrossService) BroadcastCrossTx(ctxs []*cc.CrossTransaction, local bool) {
-	for _, ctx := range ctxs {
-		var txset = make(map[*anchorPeer]*cc.CrossTransaction)
-
-		// Broadcast ctx to a batch of peers not knowing about it
-		peers := srv.peers.PeersWithoutCtx(ctx.SignHash())
-		for _, peer := range peers {
-			txset[peer] = ctx
-		}
-		for peer, rt := range txset {
-			peer.AsyncSendCrossTransaction(rt, local)
-			log.Debug("Broadcast CrossTransaction", "hash", ctx.SignHash(), "peer", peer.id)
-		}
+func (srv *CrossService) Stop() error {
+	log.Info("Stopping CrossChain Service")
+	for _, pair := range srv.pairs {
+		pair.stop()
 	}
-}
-
-func (srv *CrossService) sync() {
-	for {
-		select {
-		case p := <-srv.newPeerCh:
-			if srv.peers.Len() > 0 {
-				srv.synchronise(srv.peers.BestPeer())
-			}
-			srv.syncPending(p)
-
-		case <-srv.quitSync:
-			return
-		}
+	close(srv.quitSync)
+	//handler停止后再停止executor与外部链，最后停store
+	for _, executor := range srv.executors {
+		executor.Stop()
 	}
-}
-
-func (srv *CrossService) synchronise(main, sub *anchorPeer) {
-	go srv.main.handler.synchronise.Synchronise(main.id, main.crossStatus.MainHeight)
-	go srv.sub.handler.synchronise.Synchronise(sub.id, sub.crossStatus.SubHeight)
-}
-
-func (srv *CrossService) syncPending(peer *anchorPeer) {
-	go srv.main.handler.synchronise.SynchronisePending(peer.id)
-	go srv.sub.handler.synchronise.SynchronisePending(peer.id)
-}
-
-type CrossNodeInfo struct {
-	MainChain   uint64       `json:"mainChain"`
-	MainGenesis common.Hash  `json:"mainGenesis"`
-	SubChain    uint64       `json:"subChain"`
-	SubGenesis  common.Hash  `json:"subGenesis"`
-	Config      cross.Config `json:"config"`
-}
-
-func (srv *CrossService) NodeInfo() *CrossNodeInfo {
-	return &CrossNodeInfo{
-		Config:      srv.config,
-		MainChain:   srv.main.chainID,
-		MainGenesis: srv.main.genesis,
-		SubChain:    srv.sub.chainID,
-		SubGenesis:  srv.sub.genesis,
+	for _, chain := range srv.chains {
+		chain.Stop()
 	}
+	srv.wg.Wait()
+	srv.store.Close()
+	srv.txLogs.Close()
+	log.Info("CrossChain Service Stopped")
+	return nil
 }
@@ -1,13 +1,16 @@
 package backend
 
 import (
+	"bytes"
 	"math/big"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/simplechain-org/go-simplechain/common"
 	"github.com/simplechain-org/go-simplechain/event"
 	"github.com/simplechain-org/go-simplechain/log"
+	"github.com/simplechain-org/go-simplechain/metrics"
 
 	"github.com/simplechain-org/go-simplechain/cross"
 	"github.com/simplechain-org/go-simplechain/cross/backend/synchronise"
@@ -17,15 +20,20 @@ import (
 	"github.com/simplechain-org/go-simplechain/cross/trigger"
 
 	"github.com/asdine/storm/v3/q"
+	lru "github.com/hashicorp/golang-lru"
 )
 
 const (
 	txChanSize        = 4096
+	statusChanSize    = 128
 	blockChanSize     = 1
 	signedPendingSize = 256
+	finishRootsSize   = 16 // roots collecting signatures of anchors
 
-	defaultStoreDelay  = 200
-	intervalStoreDelay = time.Minute * 10
+	defaultStoreDelay   = 200
+	intervalStoreDelay  = time.Minute * 10
+	intervalExpireCheck = time.Minute
+	intervalMetrics     = time.Second * 10
 )
 
 type Handler struct {
@@ -33,7 +41,7 @@ type Handler struct {
 	chainID  *big.Int
 	remoteID *big.Int
 
-	service            *CrossService
+	pair               *crossPair
 	synchronise        *synchronise.Sync
 	store              *CrossStore
 	pool               *CrossPool
@@ -43,8 +51,12 @@ type Handler struct {
 	executor   trigger.Executor
 	retriever  trigger.ChainRetriever
 
-	monitor *cm.CrossMonitor
-	txLog   *cdb.TransactionLog
+	monitor  *cm.CrossMonitor
+	txLog    *cdb.TransactionLog
+	signRoot bool // finish roots are anchored only if the anchor signer signs hashes
+
+	finishRoots *lru.Cache // root => *cc.FinishRoot with signatures of anchors collected so far
+	rootLock    sync.Mutex
 
 	quitSync chan struct{}
 	wg       sync.WaitGroup
@@ -58,17 +70,20 @@ type Handler struct {
 	signedCtxCh  chan cc.SignedCtxEvent // Channel to receive signed-completely makerTx from ctxStore
 	signedCtxSub event.Subscription
 
+	evidenceCh  chan cc.EvidenceEvent // Channel to receive equivocation evidences of anchors from pool
+	evidenceSub event.Subscription
+
 	log log.Logger
 }
 
-func NewCrossHandler(ctx *cross.ServiceContext, service *CrossService,
+func NewCrossHandler(ctx *cross.ServiceContext, pair *crossPair,
 	crossMsgReader <-chan interface{}, crossMsgWriter chan<- interface{}) (h *Handler, err error) {
 
 	h = &Handler{
 		config:             ctx.Config,
 		chainID:            ctx.ProtocolChain.ChainID(),
-		service:            service,
-		store:              service.store,
+		pair:               pair,
+		store:              pair.store,
 		storeDelayCleanNum: big.NewInt(defaultStoreDelay),
 		crossMsgReader:     crossMsgReader,
 		crossMsgWriter:     crossMsgWriter,
@@ -78,15 +93,26 @@ func NewCrossHandler(ctx *cross.ServiceContext, service *CrossService,
 
 	//initialize metric
 	h.monitor = cm.NewCrossMonitor()
-	h.txLog = service.txLogs.Get(h.chainID)
+	h.txLog = pair.service.txLogs.Get(h.chainID)
 
 	// 将由chain本身提供这些组件
 	h.subscriber = ctx.Subscriber
 	h.retriever = ctx.Retriever
 	h.executor = ctx.Executor
 
+	h.signRoot = true
+	if signer, ok := h.executor.(trigger.HashSigner); ok && !signer.CanSignHash() {
+		h.log.Warn("Finish roots are not anchored, the anchor signer does not sign hashes", "signer", h.config.Signer)
+		h.signRoot = false
+	}
+
+	h.finishRoots, _ = lru.New(finishRootsSize)
+
 	db := h.store.RegisterChain(h.chainID)
 	h.pool = NewCrossPool(h.chainID, h.config, h.store, h.txLog, h.retriever, h.executor.SignHash)
+	if signer, ok := h.executor.(trigger.AnchorSigner); ok {
+		h.pool.signCtx = signer.SignCtx // request external signer with the ctx content
+	}
 	h.synchronise = synchronise.New(h.chainID, h.pool, db, h.retriever, synchronise.ALL)
 
 	return h, nil
@@ -96,11 +122,12 @@ func (h *Handler) Start() {
 	h.signedCtxCh = make(chan cc.SignedCtxEvent, txChanSize)
 	h.signedCtxSub = h.pool.SubscribeSignedCtxEvent(h.signedCtxCh)
 
+	h.evidenceCh = make(chan cc.EvidenceEvent, statusChanSize)
+	h.evidenceSub = h.pool.SubscribeEvidenceEvent(h.evidenceCh)
+
 	h.crossBlockCh = make(chan cc.CrossBlockEvent, blockChanSize)
 	h.crossBlockSub = h.subscriber.SubscribeBlockEvent(h.crossBlockCh)
 
-	h.executor.Start()
-
 	h.wg.Add(2)
 	go h.loop()
 	go h.readCrossMessage()
@@ -111,23 +138,36 @@ func (h *Handler) Stop() {
 	h.synchronise.Terminate()
 	h.crossBlockSub.Unsubscribe()
 	h.signedCtxSub.Unsubscribe()
+	h.evidenceSub.Unsubscribe()
 	close(h.quitSync)
 	h.wg.Wait()
-	//先停止executor，再停pool最后停store
-	h.executor.Stop()
+	//executor与store由service停止
 	h.pool.Stop()
-	h.store.Close()
 }
 
 func (h *Handler) loop() {
 	defer h.wg.Done()
 	ticker := time.NewTicker(intervalStoreDelay)
 	defer ticker.Stop()
+	expireCheck := intervalExpireCheck
+	if h.config.ExpireCheck > 0 {
+		expireCheck = time.Duration(h.config.ExpireCheck) * time.Second
+	}
+	expire := time.NewTicker(expireCheck)
+	defer expire.Stop()
+	report := time.NewTicker(intervalMetrics)
+	defer report.Stop()
+	var claim <-chan time.Time // relay fees are claimed only if the period is set
+	if h.config.ClaimPeriod > 0 {
+		claimTicker := time.NewTicker(time.Duration(h.config.ClaimPeriod) * time.Second)
+		defer claimTicker.Stop()
+		claim = claimTicker.C
+	}
 
 	for {
 		select {
 		case ev := <-h.crossBlockCh:
-			if ev.IsEmpty() {
+			if ev = h.filter(ev); ev.IsEmpty() {
 				break
 			}
 			h.handle(&ev)
@@ -140,11 +180,29 @@ func (h *Handler) loop() {
 		case <-h.signedCtxSub.Err():
 			return
 
+		case ev := <-h.evidenceCh: // 锚定节点作恶证据，提交到ctx的目的链停用该锚定节点
+			h.writeCrossMessage(ev)
+		case <-h.evidenceSub.Err():
+			return
+
 		case <-ticker.C:
 			if height := h.Height(); h.storeDelayCleanNum.Cmp(common.Big0) > 0 && height != nil && height.Cmp(h.storeDelayCleanNum) > 0 {
 				h.log.Info("regular remove finished tx", "height", height,
 					"removed", h.RemoveCrossTransactionBefore(height.Uint64()-h.storeDelayCleanNum.Uint64()))
 			}
+			h.anchorFinishRoot()
+
+		case <-expire.C:
+			if cancels := h.expiredCtxs(); len(cancels) > 0 {
+				h.log.Info("cancel expired ctx", "count", len(cancels))
+				h.writeCrossMessage(cc.ExpiredCtxEvent{Txs: cancels})
+			}
+
+		case <-report.C:
+			h.updateMetrics()
+
+		case <-claim:
+			h.claimRelayFee()
 
 		case <-h.quitSync:
 			return
@@ -152,6 +210,71 @@ func (h *Handler) loop() {
 	}
 }
 
+// filter drops events which are not bridged with the remote chain,
+// the subscriber of a chain can be shared by more than one pair
+func (h *Handler) filter(ev cc.CrossBlockEvent) cc.CrossBlockEvent {
+	var (
+		makers    []*cc.CrossTransaction
+		takers    []*cc.ReceptTransaction
+		chainInfo []*cc.RemoteChainInfo
+	)
+	for _, ctx := range ev.ConfirmedMaker.Txs {
+		if ctx.DestinationId().Cmp(h.remoteID) == 0 {
+			makers = append(makers, ctx)
+		}
+	}
+	for _, rtx := range ev.ConfirmedTaker.Txs {
+		if rtx.DestinationId.Cmp(h.remoteID) == 0 {
+			takers = append(takers, rtx)
+		}
+	}
+	for _, info := range ev.NewAnchor.ChainInfo {
+		if info.RemoteChainId == h.remoteID.Uint64() {
+			chainInfo = append(chainInfo, info)
+		}
+	}
+	ev.ConfirmedMaker.Txs = makers
+	ev.ConfirmedTaker.Txs = takers
+	ev.NewAnchor.ChainInfo = chainInfo
+
+	// takers are saved in remote store, finishes are saved in local store
+	ev.NewTaker.Takers = h.filterModifiers(h.remoteID, ev.NewTaker.Takers)
+	ev.ReorgTaker.Takers = h.filterModifiers(h.remoteID, ev.ReorgTaker.Takers)
+	ev.NewFinish.Finishes = h.filterModifiers(h.chainID, ev.NewFinish.Finishes)
+	ev.ConfirmedFinish.Finishes = h.filterModifiers(h.chainID, ev.ConfirmedFinish.Finishes)
+	ev.ReorgFinish.Finishes = h.filterModifiers(h.chainID, ev.ReorgFinish.Finishes)
+
+	// cancels are saved in remote store, refunds are saved in local store
+	var cancels []*cc.CancelTransaction
+	for _, tx := range ev.ConfirmedCancel.Txs {
+		if tx.ChainId.Cmp(h.remoteID) == 0 {
+			cancels = append(cancels, tx)
+		}
+	}
+	ev.ConfirmedCancel.Txs = cancels
+	ev.NewCancel.Cancels = h.filterModifiers(h.remoteID, ev.NewCancel.Cancels)
+	ev.ReorgCancel.Cancels = h.filterModifiers(h.remoteID, ev.ReorgCancel.Cancels)
+	ev.ConfirmedRefund.Refunds = h.filterModifiers(h.chainID, ev.ConfirmedRefund.Refunds)
+	return ev
+}
+
+func (h *Handler) filterModifiers(chainID *big.Int, txmList []*cc.CrossTransactionModifier) []*cc.CrossTransactionModifier {
+	if len(txmList) == 0 {
+		return txmList
+	}
+	store, err := h.store.GetStore(chainID)
+	if err != nil {
+		return nil
+	}
+	var result []*cc.CrossTransactionModifier
+	for _, txm := range txmList {
+		if store.Has(txm.ID) {
+			result = append(result, txm)
+		}
+	}
+	return result
+}
+
 func (h *Handler) handle(current *cc.CrossBlockEvent) {
 	var (
 		local, remote []*cc.CrossTransactionModifier
@@ -178,7 +301,9 @@ func (h *Handler) handle(current *cc.CrossBlockEvent) {
 			"newAnchor", len(current.NewAnchor.ChainInfo), "confMaker", len(current.ConfirmedMaker.Txs),
 			"taker", len(current.NewTaker.Takers), "confTaker", len(current.ConfirmedTaker.Txs),
 			"finish", len(current.NewFinish.Finishes), "confFinish", len(current.ConfirmedFinish.Finishes),
-			"reTaker", len(current.ReorgTaker.Takers), "reFinish", len(current.ReorgFinish.Finishes))
+			"reTaker", len(current.ReorgTaker.Takers), "reFinish", len(current.ReorgFinish.Finishes),
+			"cancel", len(current.NewCancel.Cancels), "confCancel", len(current.ConfirmedCancel.Txs),
+			"reCancel", len(current.ReorgCancel.Cancels), "confRefund", len(current.ConfirmedRefund.Refunds))
 
 		// handle reorg, rollback unconfirmed status(executing->waiting, finishing->executed)
 		// reorg taker (remote)
@@ -191,6 +316,11 @@ func (h *Handler) handle(current *cc.CrossBlockEvent) {
 			local = append(local, finishes...)
 		}
 
+		// reorg cancel (remote)
+		if cancels := current.ReorgCancel.Cancels; len(cancels) > 0 {
+			remote = append(remote, cancels...)
+		}
+
 		// handle confirmed maker
 		if makers := current.ConfirmedMaker.Txs; len(makers) > 0 {
 			signed, errs := h.pool.AddLocals(makers...)
@@ -214,7 +344,7 @@ func (h *Handler) handle(current *cc.CrossBlockEvent) {
 			if err := h.store.Adds(h.chainID, cws, false); err != nil {
 				h.log.Warn("Store pending ctx failed", "error", err)
 			}
-			h.service.BroadcastCrossTx(signed, true)
+			h.pair.BroadcastCrossTx(signed, true)
 		}
 
 		// handle new taker
@@ -225,11 +355,14 @@ func (h *Handler) handle(current *cc.CrossBlockEvent) {
 		// handle confirmed taker
 		if takers := current.ConfirmedTaker.Txs; len(takers) > 0 {
 			for _, tx := range takers {
+				if tx.FillValue != nil { // partial fills are settled one by one, status is updated by taker logs
+					continue
+				}
 				remote = append(remote, &cc.CrossTransactionModifier{
 					ID: tx.CTxId,
 					// update from remote wouldn't modify blockNumber
 					Type:   cc.Remote,
-					Status: cc.CtxStatusExecuted,
+					Status: cc.CtxStatusExecuted.Of(tx.Kind),
 				})
 			}
 			h.writeCrossMessage(current.ConfirmedTaker)
@@ -244,6 +377,21 @@ func (h *Handler) handle(current *cc.CrossBlockEvent) {
 		if finishes := current.ConfirmedFinish.Finishes; len(finishes) > 0 {
 			local = append(local, finishes...)
 		}
+
+		// handle new cancel
+		if cancels := current.NewCancel.Cancels; len(cancels) > 0 {
+			remote = append(remote, cancels...)
+		}
+
+		// handle confirmed cancel, anchors refund maker in source chain
+		if cancels := current.ConfirmedCancel.Txs; len(cancels) > 0 {
+			h.writeCrossMessage(current.ConfirmedCancel)
+		}
+
+		// handle confirmed refund
+		if refunds := current.ConfirmedRefund.Refunds; len(refunds) > 0 {
+			local = append(local, refunds...)
+		}
 	}
 
 	if len(local) > 0 {
@@ -287,6 +435,28 @@ func (h *Handler) handleAnchorChange(number *big.Int) []*cc.CrossTransactionModi
 	return txm
 }
 
+// expiredCtxs 查询已过期仍未被接单或部分成交的交易，由目的链anchor禁止接单
+func (h *Handler) expiredCtxs() []*cc.CancelTransaction {
+	expireNum := h.retriever.ExpireNumber()
+	current := h.retriever.CurrentBlockNumber()
+	if expireNum < 0 || current <= uint64(expireNum) {
+		return nil
+	}
+	store, err := h.store.GetStore(h.chainID)
+	if err != nil {
+		h.log.Warn("query expired ctx failed", "error", err)
+		return nil
+	}
+	conditions := []q.Matcher{q.Or(q.Eq(cdb.StatusField, cc.CtxStatusWaiting), q.Eq(cdb.StatusField, cc.CtxStatusPartialFilled)),
+		q.Eq(cdb.KindField, cc.CtxKindOrder),
+		q.Lte(cdb.BlockNumField, current-uint64(expireNum))}
+	var cancels []*cc.CancelTransaction
+	for _, ctx := range store.Query(0, 0, []cdb.FieldName{cdb.BlockNumField}, false, conditions...) {
+		cancels = append(cancels, cc.NewCancelTransaction(ctx.ID(), h.remoteID, h.chainID, false))
+	}
+	return cancels
+}
+
 func (h *Handler) writeCrossMessage(v interface{}) {
 	select {
 	case h.crossMsgWriter <- v:
@@ -326,10 +496,24 @@ func (h *Handler) readCrossMessage() {
 					if ev.CallBack != nil {
 						ev.CallBack(cws, invalidSigIndex...) //call callback with signer checking results
 					}
+
+					// 跨链消息由选出的anchor直接投递给目标合约
+					if invalidSigIndex == nil && cws.Kind() == cc.CtxKindMessage && h.isDeliverer(cws.ID()) {
+						h.executor.SubmitDelivery([]*cc.DeliverTransaction{cc.NewDeliverTransaction(cws)})
+					}
 				}
 
 			case cc.ConfirmedTakerEvent: // taker确认消息，需要anchor发起解锁交易
 				h.executor.SubmitTransaction(ev.Txs)
+
+			case cc.ExpiredCtxEvent: // 对面链过期交易，需要anchor在此链禁止接单
+				h.executor.SubmitCancel(ev.Txs)
+
+			case cc.ConfirmedCancelEvent: // 禁止接单确认消息，需要anchor在此链退款给maker
+				h.executor.SubmitCancel(ev.Txs)
+
+			case cc.EvidenceEvent: // 对面链锚定节点的作恶证据，在此链的合约中验证并停用
+				h.executor.SubmitEvidence(ev.Evidences)
 			}
 
 		case <-h.quitSync:
@@ -338,6 +522,18 @@ func (h *Handler) readCrossMessage() {
 	}
 }
 
+// isDeliverer reports whether the anchor is elected to deliver the message ctx, anchors take turns by ctxID
+// so that the others don't pay gas for reverted deliveries
+func (h *Handler) isDeliverer(id common.Hash) bool {
+	anchors := append([]common.Address{}, h.config.Anchors...)
+	if len(anchors) == 0 {
+		return true
+	}
+	sort.Slice(anchors, func(i, j int) bool { return bytes.Compare(anchors[i][:], anchors[j][:]) < 0 })
+	turn := new(big.Int).Mod(id.Big(), big.NewInt(int64(len(anchors))))
+	return anchors[turn.Uint64()] == h.config.Signer
+}
+
 // 往pool里添加从P2P网络接收的ctx与节点签名信息
 func (h *Handler) AddRemoteCtx(ctx *cc.CrossTransaction) error {
 	if !h.retriever.CanAcceptTxs() { // wait until block synchronize completely
@@ -400,7 +596,8 @@ func (h *Handler) RemoveCrossTransactionBefore(number uint64) int {
 		var deletes []common.Hash
 		for _, ctx := range ctxList {
 			current = ctx.BlockNum + 1
-			if ctx.Status == cc.CtxStatusFinished { // only finished ctx can be deleted
+			switch ctx.Status {
+			case cc.CtxStatusFinished, cc.CtxStatusReceipted, cc.CtxStatusCancelled: // only finished or cancelled ctx can be deleted
 				if err := h.txLog.AddFinish(ctx); err == nil {
 					deletes = append(deletes, ctx.ID())
 				}
@@ -423,6 +620,109 @@ func (h *Handler) RemoveCrossTransactionBefore(number uint64) int {
 	return removed
 }
 
+// updateMetrics 更新store中各状态交易数量与pool的指标
+func (h *Handler) updateMetrics() {
+	if !metrics.Enabled || !h.retriever.CanAcceptTxs() {
+		return
+	}
+	chainID := h.chainID.Uint64()
+	for status, count := range h.store.Stats()[chainID] {
+		cm.StatusGauge(chainID, status).Update(int64(count))
+	}
+	pendingGauge, queuedGauge := cm.PoolGauges(chainID)
+	pending, queued := h.pool.Stats()
+	pendingGauge.Update(int64(pending))
+	queuedGauge.Update(int64(queued))
+}
+
+// claimRelayFee 锚定节点领取本链合约中与remote链交易的中继费
+func (h *Handler) claimRelayFee() {
+	claimer, ok := h.executor.(trigger.AccountedExecutor)
+	if !ok {
+		return
+	}
+	fee, err := claimer.ClaimRelayFee(h.remoteID)
+	if err != nil {
+		h.log.Warn("claim relay fee failed", "remoteChainID", h.remoteID, "error", err)
+		return
+	}
+	if fee.Sign() > 0 {
+		h.log.Info("claim relay fee", "remoteChainID", h.remoteID, "fee", fee)
+	}
+}
+
+// anchorFinishRoot 锚定节点对txLog新提交的root签名并广播给其他锚定节点，
+// 收集到足够多锚定节点对同一root的签名后，对其证明的finished交易由锚定节点共同担保
+func (h *Handler) anchorFinishRoot() {
+	if !h.signRoot {
+		return
+	}
+	root := h.txLog.Root()
+	if anchored := h.txLog.AnchoredRoot(); anchored != nil && anchored.Root == root {
+		return
+	}
+	finishRoot := cc.NewFinishRoot(h.chainID, root, h.Height().Uint64())
+	if err := finishRoot.Sign(h.executor.SignHash); err != nil {
+		h.log.Warn("sign finish root failed", "root", root.String(), "error", err)
+		return
+	}
+	h.pair.BroadcastFinishRoot(finishRoot)
+	h.addFinishRoot(finishRoot)
+}
+
+// AddRemoteFinishRoot collects the signatures of finish root signed by other anchors
+func (h *Handler) AddRemoteFinishRoot(root *cc.FinishRoot) {
+	if root.ChainId == nil || root.ChainId.Cmp(h.chainID) != 0 {
+		return
+	}
+	h.addFinishRoot(root)
+}
+
+// addFinishRoot merges the signatures of anchors for the root, the committed root of local txLog is
+// anchored once it is signed by local anchor and the quorum of anchors
+func (h *Handler) addFinishRoot(root *cc.FinishRoot) {
+	anchors := make(map[common.Address]bool, len(h.config.Anchors))
+	for _, anchor := range h.config.Anchors {
+		anchors[anchor] = true
+	}
+	signed := root.Filter(func(signer common.Address) bool { return anchors[signer] })
+	if len(signed.Signatures) == 0 {
+		return
+	}
+
+	h.rootLock.Lock()
+	defer h.rootLock.Unlock()
+
+	if cached, ok := h.finishRoots.Get(root.Root); ok {
+		collected := cached.(*cc.FinishRoot)
+		collected.Merge(signed)
+		signed = collected
+	}
+	h.finishRoots.Add(root.Root, signed)
+
+	if signed.Root != h.txLog.Root() {
+		return
+	}
+	if anchored := h.txLog.AnchoredRoot(); anchored != nil && anchored.Root == signed.Root {
+		return
+	}
+	signers, _ := signed.Signers()
+	var self bool
+	for _, signer := range signers {
+		self = self || signer == h.config.Signer
+	}
+	if !self || len(signers) < h.retriever.RequireSignatures() {
+		return
+	}
+	anchored := cc.NewFinishRoot(signed.ChainId, signed.Root, h.Height().Uint64())
+	anchored.Signatures = signed.Signatures
+	if err := h.txLog.SetAnchoredRoot(anchored); err != nil {
+		h.log.Warn("save anchored root failed", "root", anchored.Root.String(), "error", err)
+		return
+	}
+	h.log.Info("anchor finish root", "root", anchored.Root.String(), "number", anchored.Number, "signers", len(signers))
+}
+
 // 通过id获取本地pool.pending或store中的交易，并添加自己的签名
 func (h *Handler) GetPending(ids []common.Hash) []*cc.CrossTransaction {
 	results := make([]*cc.CrossTransaction, 0, len(ids))
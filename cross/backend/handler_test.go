@@ -1,16 +1,21 @@
 package backend
 
 import (
+	"crypto/ecdsa"
 	"math/big"
 	"math/rand"
 	"testing"
 
 	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/crypto"
 	"github.com/simplechain-org/go-simplechain/ethdb/memorydb"
+	"github.com/simplechain-org/go-simplechain/log"
 
+	"github.com/simplechain-org/go-simplechain/cross"
 	cc "github.com/simplechain-org/go-simplechain/cross/core"
 	db "github.com/simplechain-org/go-simplechain/cross/database"
 
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -28,6 +33,7 @@ func newHandlerTester(chainID *big.Int) (*Handler, error) {
 		chainID: chainID,
 		store:   store,
 		txLog:   memLog.Get(chainID),
+		log:     log.New(),
 	}, nil
 }
 
@@ -78,3 +84,98 @@ func TestHandler_RemoveCrossTransactionBefore(t *testing.T) {
 		assert.True(t, ctx.BlockNum > 60 || ctx.Status != cc.CtxStatusFinished)
 	}
 }
+
+func TestHandler_filter(t *testing.T) {
+	chainID, remoteID := big.NewInt(1), big.NewInt(2)
+	handler, err := newHandlerTester(chainID)
+	assert.NoError(t, err)
+	defer handler.store.Close()
+	handler.remoteID = remoteID
+	handler.store.RegisterChain(remoteID)
+	handler.store.stores[remoteID.Uint64()].Clean()
+
+	locals, remotes := generateCtx(4, cc.CtxStatusWaiting), generateCtx(4, cc.CtxStatusWaiting)
+	assert.NoError(t, handler.store.Adds(chainID, locals[:2], false))
+	assert.NoError(t, handler.store.Adds(remoteID, remotes[:2], false))
+
+	var ev cc.CrossBlockEvent
+	for _, ctx := range locals {
+		ev.ConfirmedMaker.Txs = append(ev.ConfirmedMaker.Txs, cc.NewCrossTransaction(nil, nil, ctx.DestinationId(), ctx.ID(), ctx.Data.TxHash, common.Hash{}, common.Address{}, common.Address{}, nil))
+		ev.NewFinish.Finishes = append(ev.NewFinish.Finishes, &cc.CrossTransactionModifier{ID: ctx.ID()})
+	}
+	for _, ctx := range remotes {
+		ev.ConfirmedTaker.Txs = append(ev.ConfirmedTaker.Txs, &cc.ReceptTransaction{CTxId: ctx.ID(), DestinationId: ctx.DestinationId()})
+		ev.NewTaker.Takers = append(ev.NewTaker.Takers, &cc.CrossTransactionModifier{ID: ctx.ID()})
+	}
+	ev.NewAnchor.ChainInfo = []*cc.RemoteChainInfo{{RemoteChainId: 2}, {RemoteChainId: 3}}
+
+	ev = handler.filter(ev)
+	// generated ctx[1] is sent to chain 2
+	assert.Equal(t, 1, len(ev.ConfirmedMaker.Txs))
+	assert.Equal(t, 1, len(ev.ConfirmedTaker.Txs))
+	assert.Equal(t, 1, len(ev.NewAnchor.ChainInfo))
+	assert.Equal(t, 2, len(ev.NewFinish.Finishes))
+	assert.Equal(t, 2, len(ev.NewTaker.Takers))
+}
+
+func TestHandler_addFinishRoot(t *testing.T) {
+	chainID := big.NewInt(1)
+	handler, err := newHandlerTester(chainID)
+	assert.NoError(t, err)
+	defer handler.store.Close()
+
+	localKey, _ := crypto.GenerateKey()
+	remoteKey, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	handler.config = &cross.Config{
+		Signer:  crypto.PubkeyToAddress(localKey.PublicKey),
+		Anchors: []common.Address{crypto.PubkeyToAddress(localKey.PublicKey), crypto.PubkeyToAddress(remoteKey.PublicKey)},
+	}
+	handler.retriever = testChainRetriever{}
+	handler.finishRoots, _ = lru.New(finishRootsSize)
+
+	assert.NoError(t, handler.txLog.AddFinish(generateCtx(1, cc.CtxStatusFinished)[0]))
+	root, err := handler.txLog.Commit()
+	assert.NoError(t, err)
+	signed := func(root common.Hash, key *ecdsa.PrivateKey) *cc.FinishRoot {
+		r := cc.NewFinishRoot(chainID, root, 1)
+		assert.NoError(t, r.Sign(func(hash []byte) ([]byte, error) { return crypto.Sign(hash, key) }))
+		return r
+	}
+
+	// roots of other anchors are collected, but not anchored before local anchor signs it
+	handler.addFinishRoot(signed(root, remoteKey))
+	handler.addFinishRoot(signed(common.HexToHash("0x01"), remoteKey))
+	assert.Nil(t, handler.txLog.AnchoredRoot())
+
+	// signatures of others are not counted
+	handler.addFinishRoot(signed(root, otherKey))
+	assert.Nil(t, handler.txLog.AnchoredRoot())
+
+	handler.addFinishRoot(signed(root, localKey))
+	anchored := handler.txLog.AnchoredRoot()
+	assert.NotNil(t, anchored)
+	assert.Equal(t, root, anchored.Root)
+	signers, err := anchored.Signers()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, handler.config.Anchors, signers)
+}
+
+func TestHandler_isDeliverer(t *testing.T) {
+	anchors := []common.Address{common.HexToAddress("0x03"), common.HexToAddress("0x01"), common.HexToAddress("0x02")}
+	handlers := make([]*Handler, len(anchors))
+	for i, anchor := range anchors {
+		// anchors are elected regardless of the order configured
+		handlers[i] = &Handler{config: &cross.Config{Signer: anchor, Anchors: []common.Address{anchors[(i+1)%3], anchors[i], anchors[(i+2)%3]}}}
+	}
+	for _, ctx := range generateCtx(10, cc.CtxStatusWaiting) {
+		var elected int
+		for _, h := range handlers {
+			if h.isDeliverer(ctx.ID()) {
+				elected++
+			}
+		}
+		assert.Equal(t, 1, elected, "ctx %s", ctx.ID().String())
+	}
+	assert.True(t, (&Handler{config: &cross.Config{}}).isDeliverer(common.Hash{}), "delivered without anchors configured")
+}
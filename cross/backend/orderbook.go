@@ -0,0 +1,433 @@
+package backend
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/common/hexutil"
+	"github.com/simplechain-org/go-simplechain/event"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+	cdb "github.com/simplechain-org/go-simplechain/cross/database"
+
+	"github.com/asdine/storm/v3/q"
+)
+
+const priceDecimals = 18 // decimals of prices in rpc
+
+// market is the direction of orders, from the chain paying value to the chain paying destination value
+type market struct {
+	from, to uint64
+}
+
+// bookOrder is an open order, value and dest are the remaining values in source and destination chains
+type bookOrder struct {
+	market market
+	price  *big.Rat // destination value per source value
+	value  *big.Int
+	dest   *big.Int
+}
+
+// bookLevel aggregates open orders of a market at the same price
+type bookLevel struct {
+	price  *big.Rat
+	value  *big.Int
+	dest   *big.Int
+	orders int
+}
+
+func (l *bookLevel) copy() bookLevel {
+	return bookLevel{price: l.price, value: new(big.Int).Set(l.value), dest: new(big.Int).Set(l.dest), orders: l.orders}
+}
+
+// bookSide is the price levels of a market sorted by price, the lowest price is the best one for takers
+type bookSide struct {
+	levels []*bookLevel
+}
+
+// level returns the level of price, it is created if create is true
+func (s *bookSide) level(price *big.Rat, create bool) *bookLevel {
+	i := sort.Search(len(s.levels), func(i int) bool { return s.levels[i].price.Cmp(price) >= 0 })
+	if i < len(s.levels) && s.levels[i].price.Cmp(price) == 0 {
+		return s.levels[i]
+	}
+	if !create {
+		return nil
+	}
+	level := &bookLevel{price: price, value: new(big.Int), dest: new(big.Int)}
+	s.levels = append(s.levels, nil)
+	copy(s.levels[i+1:], s.levels[i:])
+	s.levels[i] = level
+	return level
+}
+
+func (s *bookSide) drop(level *bookLevel) {
+	for i, l := range s.levels {
+		if l == level {
+			s.levels = append(s.levels[:i], s.levels[i+1:]...)
+			return
+		}
+	}
+}
+
+// OrderBookEvent is posted when a price level is changed, the level is empty if its orders are all gone
+type OrderBookEvent struct {
+	From, To uint64 // chains paying value and destination value of the orders
+	Seq      uint64 // sequence of changes in the book
+	level    bookLevel
+}
+
+// OrderBook keeps open orders of the store in memory, and aggregates them by price for takers.
+// Orders are ctxs waiting for takers or partially filled, messages are not orders.
+type OrderBook struct {
+	orders map[common.Hash]*bookOrder
+	sides  map[market]*bookSide
+	seq    uint64
+	mu     sync.RWMutex
+
+	feed  event.Feed
+	scope event.SubscriptionScope
+}
+
+func NewOrderBook() *OrderBook {
+	return &OrderBook{
+		orders: make(map[common.Hash]*bookOrder),
+		sides:  make(map[market]*bookSide),
+	}
+}
+
+// openFilter matches open orders in a store
+func openFilter() q.Matcher {
+	return q.And(
+		q.Eq(cdb.KindField, uint8(cc.CtxKindOrder)),
+		q.Or(q.Eq(cdb.StatusField, uint8(cc.CtxStatusWaiting)), q.Eq(cdb.StatusField, uint8(cc.CtxStatusPartialFilled))),
+	)
+}
+
+// newBookOrder returns nil if the ctx is not open for takers
+func newBookOrder(chainID uint64, ctx *cc.CrossTransactionWithSignatures) *bookOrder {
+	if ctx.Kind() != cc.CtxKindOrder || ctx.Status != cc.CtxStatusWaiting && ctx.Status != cc.CtxStatusPartialFilled {
+		return nil
+	}
+	value, dest, remaining := ctx.Data.Value, ctx.Data.DestinationValue, ctx.Remaining()
+	if value == nil || dest == nil || value.Sign() <= 0 || dest.Sign() <= 0 || remaining.Sign() <= 0 {
+		return nil
+	}
+	return &bookOrder{
+		market: market{from: chainID, to: ctx.DestinationId().Uint64()},
+		price:  new(big.Rat).SetFrac(dest, value),
+		value:  new(big.Int).Div(new(big.Int).Mul(value, remaining), dest),
+		dest:   new(big.Int).Set(remaining),
+	}
+}
+
+// Load replaces orders of the chain by the open ctxs in store
+func (b *OrderBook) Load(store cdb.CtxDB) {
+	ctxs := store.Query(0, 0, nil, false, openFilter())
+	b.Reset(store.ChainID())
+	b.Update(store.ChainID(), ctxs...)
+}
+
+// Reset drops orders made in the chain
+func (b *OrderBook) Reset(chainID *big.Int) {
+	var ids []common.Hash
+	b.mu.RLock()
+	for id, order := range b.orders {
+		if order.market.from == chainID.Uint64() {
+			ids = append(ids, id)
+		}
+	}
+	b.mu.RUnlock()
+	b.Remove(ids...)
+}
+
+// Update adds, replaces or removes orders by the latest state of ctxs made in the chain
+func (b *OrderBook) Update(chainID *big.Int, ctxs ...*cc.CrossTransactionWithSignatures) {
+	b.mu.Lock()
+	var events []OrderBookEvent
+	for _, ctx := range ctxs {
+		events = append(events, b.remove(ctx.ID())...)
+		if order := newBookOrder(chainID.Uint64(), ctx); order != nil {
+			events = append(events, b.add(ctx.ID(), order))
+		}
+	}
+	b.mu.Unlock()
+	b.post(events)
+}
+
+// Remove drops orders of the ids
+func (b *OrderBook) Remove(ids ...common.Hash) {
+	b.mu.Lock()
+	var events []OrderBookEvent
+	for _, id := range ids {
+		events = append(events, b.remove(id)...)
+	}
+	b.mu.Unlock()
+	b.post(events)
+}
+
+func (b *OrderBook) add(id common.Hash, order *bookOrder) OrderBookEvent {
+	side := b.sides[order.market]
+	if side == nil {
+		side = new(bookSide)
+		b.sides[order.market] = side
+	}
+	level := side.level(order.price, true)
+	level.value.Add(level.value, order.value)
+	level.dest.Add(level.dest, order.dest)
+	level.orders++
+	b.orders[id] = order
+	return b.event(order.market, level)
+}
+
+func (b *OrderBook) remove(id common.Hash) []OrderBookEvent {
+	order, ok := b.orders[id]
+	if !ok {
+		return nil
+	}
+	delete(b.orders, id)
+	side := b.sides[order.market]
+	level := side.level(order.price, false)
+	level.value.Sub(level.value, order.value)
+	level.dest.Sub(level.dest, order.dest)
+	level.orders--
+	if level.orders == 0 {
+		side.drop(level)
+	}
+	return []OrderBookEvent{b.event(order.market, level)}
+}
+
+func (b *OrderBook) event(m market, level *bookLevel) OrderBookEvent {
+	b.seq++
+	return OrderBookEvent{From: m.from, To: m.to, Seq: b.seq, level: level.copy()}
+}
+
+// post sends events outside the lock, the feed blocks until every subscriber receives the events
+func (b *OrderBook) post(events []OrderBookEvent) {
+	for _, ev := range events {
+		b.feed.Send(ev)
+	}
+}
+
+// SubscribeOrderBookEvent registers a subscription of price level changes
+func (b *OrderBook) SubscribeOrderBookEvent(ch chan<- OrderBookEvent) event.Subscription {
+	return b.scope.Track(b.feed.Subscribe(ch))
+}
+
+func (b *OrderBook) Close() {
+	b.scope.Close()
+}
+
+// Depth returns the best levels of orders between local and remote chains in view of the local chain,
+// asks are orders made in local chain and bids are made in remote chain, all levels are returned if limit is 0
+func (b *OrderBook) Depth(local, remote uint64, limit int) *OrderBookDepth {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	depth := &OrderBookDepth{
+		RemoteChainId: hexutil.Uint64(remote),
+		Seq:           hexutil.Uint64(b.seq),
+		Asks:          make([]*PriceLevel, 0),
+		Bids:          make([]*PriceLevel, 0),
+	}
+	if side := b.sides[market{from: local, to: remote}]; side != nil {
+		for _, level := range side.levels {
+			if limit > 0 && len(depth.Asks) >= limit {
+				break
+			}
+			depth.Asks = append(depth.Asks, newPriceLevel(local, market{from: local, to: remote}, level))
+		}
+	}
+	if side := b.sides[market{from: remote, to: local}]; side != nil {
+		for _, level := range side.levels {
+			if limit > 0 && len(depth.Bids) >= limit {
+				break
+			}
+			depth.Bids = append(depth.Bids, newPriceLevel(local, market{from: remote, to: local}, level))
+		}
+	}
+	return depth
+}
+
+// OrderBookDepth is a snapshot of the order book with a remote chain, prices are remote values per local value,
+// asks are sorted by price ascending and bids are sorted by price descending
+type OrderBookDepth struct {
+	RemoteChainId hexutil.Uint64 `json:"remoteChainId"`
+	Seq           hexutil.Uint64 `json:"seq"` // sequence of the latest change included
+	Asks          []*PriceLevel  `json:"asks"`
+	Bids          []*PriceLevel  `json:"bids"`
+}
+
+// PriceLevel is the aggregate of open orders at a price in view of the local chain
+type PriceLevel struct {
+	Side   string       `json:"side"`   // ask if the orders sell local value, bid if they buy local value
+	Price  string       `json:"price"`  // remote value per local value
+	Amount *hexutil.Big `json:"amount"` // local value of the orders
+	Volume *hexutil.Big `json:"volume"` // remote value of the orders
+	Orders int          `json:"orders"`
+}
+
+func newPriceLevel(local uint64, m market, level *bookLevel) *PriceLevel {
+	if m.from == local {
+		return &PriceLevel{
+			Side:   "ask",
+			Price:  level.price.FloatString(priceDecimals),
+			Amount: (*hexutil.Big)(new(big.Int).Set(level.value)),
+			Volume: (*hexutil.Big)(new(big.Int).Set(level.dest)),
+			Orders: level.orders,
+		}
+	}
+	return &PriceLevel{
+		Side:   "bid",
+		Price:  new(big.Rat).Inv(level.price).FloatString(priceDecimals),
+		Amount: (*hexutil.Big)(new(big.Int).Set(level.dest)),
+		Volume: (*hexutil.Big)(new(big.Int).Set(level.value)),
+		Orders: level.orders,
+	}
+}
+
+// bookedCtxDB keeps the order book consistent with every write of the store, and publishes the status changes
+type bookedCtxDB struct {
+	cdb.CtxDB
+	book    *OrderBook
+	publish func(events []cc.CtxStatusEvent)
+}
+
+// ctxState is the stored state of a ctx compared for status changes
+type ctxState struct {
+	status cc.CtxStatus
+	filled *big.Int
+}
+
+func stateOf(ctx *cc.CrossTransactionWithSignatures) ctxState {
+	return ctxState{status: ctx.Status, filled: ctx.Filled}
+}
+
+// changed reports whether the status or the filled value differs
+func (st ctxState) changed(ctx *cc.CrossTransactionWithSignatures) bool {
+	if st.status != ctx.Status {
+		return true
+	}
+	if st.filled == nil || ctx.Filled == nil {
+		return st.filled != ctx.Filled
+	}
+	return st.filled.Cmp(ctx.Filled) != 0
+}
+
+// states reads the stored states of ctxs, a ctx not stored yet is taken as pending
+func (db *bookedCtxDB) states(ids []common.Hash) []ctxState {
+	states := make([]ctxState, len(ids))
+	for i, id := range ids {
+		states[i].status = cc.CtxStatusPending
+		if ctx, err := db.CtxDB.Read(id); err == nil {
+			states[i] = stateOf(ctx)
+		}
+	}
+	return states
+}
+
+// reload updates the book by the stored ctxs, skipped ones of a non-replaceable writing are also correct
+func (db *bookedCtxDB) reload(ids []common.Hash, prev []ctxState) {
+	ctxs := make([]*cc.CrossTransactionWithSignatures, 0, len(ids))
+	var events []cc.CtxStatusEvent
+	for i, id := range ids {
+		if ctx, err := db.CtxDB.Read(id); err == nil {
+			ctxs = append(ctxs, ctx)
+			if prev[i].changed(ctx) {
+				events = append(events, cc.CtxStatusEvent{Tx: ctx, PrevStatus: prev[i].status})
+			}
+		}
+	}
+	db.book.Update(db.ChainID(), ctxs...)
+	db.notify(events)
+}
+
+func (db *bookedCtxDB) notify(events []cc.CtxStatusEvent) {
+	if db.publish != nil && len(events) > 0 {
+		db.publish(events)
+	}
+}
+
+func (db *bookedCtxDB) Write(ctx *cc.CrossTransactionWithSignatures) error {
+	ids := []common.Hash{ctx.ID()}
+	prev := db.states(ids)
+	if err := db.CtxDB.Write(ctx); err != nil {
+		return err
+	}
+	db.reload(ids, prev)
+	return nil
+}
+
+func (db *bookedCtxDB) Writes(ctxList []*cc.CrossTransactionWithSignatures, replaceable bool) error {
+	ids := make([]common.Hash, len(ctxList))
+	for i, ctx := range ctxList {
+		ids[i] = ctx.ID()
+	}
+	prev := db.states(ids)
+	if err := db.CtxDB.Writes(ctxList, replaceable); err != nil {
+		return err
+	}
+	db.reload(ids, prev)
+	return nil
+}
+
+func (db *bookedCtxDB) Update(id common.Hash, updater func(ctx *cdb.CrossTransactionIndexed)) error {
+	return db.Updates([]common.Hash{id}, []func(ctx *cdb.CrossTransactionIndexed){updater})
+}
+
+func (db *bookedCtxDB) Updates(idList []common.Hash, updaters []func(ctx *cdb.CrossTransactionIndexed)) error {
+	var (
+		updated []*cc.CrossTransactionWithSignatures
+		events  []cc.CtxStatusEvent
+	)
+	wrapped := make([]func(ctx *cdb.CrossTransactionIndexed), len(updaters))
+	for i, updater := range updaters {
+		updater := updater
+		wrapped[i] = func(ctx *cdb.CrossTransactionIndexed) {
+			prev := stateOf(ctx.ToCrossTransaction())
+			updater(ctx)
+			tx := ctx.ToCrossTransaction()
+			updated = append(updated, tx)
+			if prev.changed(tx) {
+				events = append(events, cc.CtxStatusEvent{Tx: tx, PrevStatus: prev.status})
+			}
+		}
+	}
+	if err := db.CtxDB.Updates(idList, wrapped); err != nil {
+		return err
+	}
+	db.book.Update(db.ChainID(), updated...)
+	db.notify(events) // notify status changes after committed
+	return nil
+}
+
+func (db *bookedCtxDB) Deletes(idList []common.Hash) error {
+	if err := db.CtxDB.Deletes(idList); err != nil {
+		return err
+	}
+	db.book.Remove(idList...)
+	return nil
+}
+
+func (db *bookedCtxDB) Load() error {
+	err := db.CtxDB.Load()
+	db.book.Load(db.CtxDB)
+	return err
+}
+
+func (db *bookedCtxDB) Repair() error {
+	if err := db.CtxDB.Repair(); err != nil {
+		return err
+	}
+	db.book.Load(db.CtxDB)
+	return nil
+}
+
+func (db *bookedCtxDB) Clean() error {
+	if err := db.CtxDB.Clean(); err != nil {
+		return err
+	}
+	db.book.Reset(db.ChainID())
+	return nil
+}
@@ -0,0 +1,154 @@
+package backend
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/common"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newOrderCtx(id int64, remote int64, value, dest int64, status cc.CtxStatus) *cc.CrossTransactionWithSignatures {
+	return &cc.CrossTransactionWithSignatures{
+		Data: cc.CtxDatas{
+			CTxId:            common.BigToHash(big.NewInt(id)),
+			TxHash:           common.BigToHash(big.NewInt(id)),
+			Value:            big.NewInt(value),
+			From:             common.BigToAddress(big.NewInt(id)),
+			DestinationId:    big.NewInt(remote),
+			DestinationValue: big.NewInt(dest),
+		},
+		BlockNum: uint64(id),
+		Status:   status,
+	}
+}
+
+func TestOrderBook_Depth(t *testing.T) {
+	local, remote := big.NewInt(1), big.NewInt(2)
+	book := NewOrderBook()
+	defer book.Close()
+
+	book.Update(local,
+		newOrderCtx(1, 2, 10, 20, cc.CtxStatusWaiting),
+		newOrderCtx(2, 2, 5, 10, cc.CtxStatusWaiting),
+		newOrderCtx(3, 2, 10, 30, cc.CtxStatusWaiting),
+		newOrderCtx(4, 2, 10, 10, cc.CtxStatusExecuted), // not open
+	)
+	book.Update(remote,
+		newOrderCtx(11, 1, 40, 10, cc.CtxStatusWaiting),
+		newOrderCtx(12, 1, 30, 10, cc.CtxStatusWaiting),
+	)
+
+	depth := book.Depth(1, 2, 0)
+	assert.Equal(t, uint64(5), uint64(depth.Seq))
+	assert.Equal(t, 2, len(depth.Asks))
+	assert.Equal(t, "ask", depth.Asks[0].Side)
+	assert.Equal(t, "2.000000000000000000", depth.Asks[0].Price)
+	assert.Equal(t, int64(15), depth.Asks[0].Amount.ToInt().Int64())
+	assert.Equal(t, int64(30), depth.Asks[0].Volume.ToInt().Int64())
+	assert.Equal(t, 2, depth.Asks[0].Orders)
+	assert.Equal(t, "3.000000000000000000", depth.Asks[1].Price)
+
+	// bids are sorted by price descending in view of the local chain
+	assert.Equal(t, 2, len(depth.Bids))
+	assert.Equal(t, "bid", depth.Bids[0].Side)
+	assert.Equal(t, "4.000000000000000000", depth.Bids[0].Price)
+	assert.Equal(t, int64(10), depth.Bids[0].Amount.ToInt().Int64())
+	assert.Equal(t, int64(40), depth.Bids[0].Volume.ToInt().Int64())
+	assert.Equal(t, "3.000000000000000000", depth.Bids[1].Price)
+
+	// the remote chain views the same orders inversely
+	remoteDepth := book.Depth(2, 1, 1)
+	assert.Equal(t, 1, len(remoteDepth.Asks))
+	assert.Equal(t, 1, len(remoteDepth.Bids))
+	assert.Equal(t, "0.250000000000000000", remoteDepth.Asks[0].Price)
+	assert.Equal(t, "0.500000000000000000", remoteDepth.Bids[0].Price)
+
+	// partial filled order stays in book by the remaining value
+	filled := newOrderCtx(1, 2, 10, 20, cc.CtxStatusPartialFilled)
+	filled.Filled = big.NewInt(8)
+	book.Update(local, filled)
+	depth = book.Depth(1, 2, 0)
+	assert.Equal(t, int64(11), depth.Asks[0].Amount.ToInt().Int64())
+	assert.Equal(t, int64(22), depth.Asks[0].Volume.ToInt().Int64())
+	assert.Equal(t, 2, depth.Asks[0].Orders)
+
+	book.Remove(common.BigToHash(big.NewInt(1)), common.BigToHash(big.NewInt(2)))
+	depth = book.Depth(1, 2, 0)
+	assert.Equal(t, 1, len(depth.Asks))
+	assert.Equal(t, "3.000000000000000000", depth.Asks[0].Price)
+
+	book.Reset(remote)
+	assert.Equal(t, 0, len(book.Depth(1, 2, 0).Bids))
+}
+
+func TestOrderBook_Event(t *testing.T) {
+	local := big.NewInt(1)
+	book := NewOrderBook()
+	defer book.Close()
+
+	ch := make(chan OrderBookEvent, 4)
+	sub := book.SubscribeOrderBookEvent(ch)
+	defer sub.Unsubscribe()
+
+	book.Update(local, newOrderCtx(1, 2, 10, 20, cc.CtxStatusWaiting))
+	ev := <-ch
+	assert.Equal(t, uint64(1), ev.From)
+	assert.Equal(t, uint64(2), ev.To)
+	assert.Equal(t, uint64(1), ev.Seq)
+	assert.Equal(t, 1, ev.level.orders)
+
+	book.Remove(common.BigToHash(big.NewInt(1)))
+	ev = <-ch
+	assert.Equal(t, uint64(2), ev.Seq)
+	assert.Equal(t, 0, ev.level.orders)
+	assert.Equal(t, int64(0), ev.level.value.Int64())
+}
+
+func TestCrossStore_OrderBook(t *testing.T) {
+	chainID := big.NewInt(10)
+	s, err := newStoreTester(chainID)
+	assert.NoError(t, err)
+	defer s.Close()
+
+	ctxList := []*cc.CrossTransactionWithSignatures{
+		newOrderCtx(1, 11, 10, 20, cc.CtxStatusWaiting),
+		newOrderCtx(2, 11, 10, 20, cc.CtxStatusWaiting),
+		newOrderCtx(3, 11, 10, 30, cc.CtxStatusWaiting),
+	}
+	assert.NoError(t, s.Adds(chainID, ctxList, false))
+
+	depth := s.OrderBook().Depth(10, 11, 0)
+	assert.Equal(t, 2, len(depth.Asks))
+	assert.Equal(t, 2, depth.Asks[0].Orders)
+
+	// orders taken by updates are removed from book
+	assert.NoError(t, s.Updates(chainID, []*cc.CrossTransactionModifier{{
+		ID:            ctxList[0].ID(),
+		Type:          cc.Normal,
+		AtBlockNumber: ctxList[0].BlockNum + 1,
+		Status:        cc.CtxStatusExecuting,
+	}}))
+	depth = s.OrderBook().Depth(10, 11, 0)
+	assert.Equal(t, 1, depth.Asks[0].Orders)
+
+	// reorg puts the order back to book
+	assert.NoError(t, s.Updates(chainID, []*cc.CrossTransactionModifier{{
+		ID:     ctxList[0].ID(),
+		Type:   cc.Reorg,
+		Status: cc.CtxStatusWaiting,
+	}}))
+	depth = s.OrderBook().Depth(10, 11, 0)
+	assert.Equal(t, 2, depth.Asks[0].Orders)
+
+	// book is rebuilt from the store
+	s.OrderBook().Reset(chainID)
+	assert.NoError(t, s.stores[chainID.Uint64()].Load())
+	assert.Equal(t, 2, len(s.OrderBook().Depth(10, 11, 0).Asks))
+
+	assert.NoError(t, s.stores[chainID.Uint64()].Clean())
+	assert.Equal(t, 0, len(s.OrderBook().Depth(10, 11, 0).Asks))
+}
@@ -0,0 +1,515 @@
+package backend
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/log"
+	"github.com/simplechain-org/go-simplechain/p2p"
+	"github.com/simplechain-org/go-simplechain/p2p/enode"
+	"github.com/simplechain-org/go-simplechain/rlp"
+
+	"github.com/simplechain-org/go-simplechain/cross"
+	"github.com/simplechain-org/go-simplechain/cross/backend/synchronise"
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+)
+
+// crossPair bridges the main chain with one remote chain. Every pair has its own
+// store namespace, anchor peers, pools and synchronise, and talks to other anchors
+// through its own p2p protocol.
+type crossPair struct {
+	name    string // protocol name, also used as the store namespace
+	service *CrossService
+	store   *CrossStore
+	config  cross.Config
+	peers   *anchorSet
+
+	main crossCommons
+	sub  crossCommons
+
+	newPeerCh chan *anchorPeer
+}
+
+type crossCommons struct {
+	genesis common.Hash
+	chainID uint64
+
+	handler *Handler
+	channel chan interface{}
+}
+
+// pairName returns the protocol name of the pair with the remote chain,
+// the legacy main/sub pair keeps the legacy protocol name.
+func pairName(remote uint64, legacy bool) string {
+	if legacy {
+		return protocolName
+	}
+	return fmt.Sprintf("%s%d", protocolName, remote)
+}
+
+// StoreNamespace returns the store namespace of the pair with the remote chain, the legacy main/sub pair uses the root store.
+func StoreNamespace(remote uint64, legacy bool) string {
+	if legacy {
+		return ""
+	}
+	return pairName(remote, legacy)
+}
+
+func newCrossPair(srv *CrossService, main, sub *cross.ServiceContext, legacy bool) (*crossPair, error) {
+	pair := &crossPair{
+		name:      pairName(sub.ProtocolChain.ChainID().Uint64(), legacy),
+		service:   srv,
+		config:    *sub.Config,
+		peers:     newAnchorSet(),
+		newPeerCh: make(chan *anchorPeer),
+	}
+	pair.store = srv.store.Namespace(StoreNamespace(sub.ProtocolChain.ChainID().Uint64(), legacy))
+
+	mainCh, subCh := make(chan interface{}, defaultCrossChSize), make(chan interface{}, defaultCrossChSize)
+
+	mainHandler, err := NewCrossHandler(main, pair, mainCh, subCh)
+	if err != nil {
+		return nil, err
+	}
+
+	subHandler, err := NewCrossHandler(sub, pair, subCh, mainCh)
+	if err != nil {
+		return nil, err
+	}
+
+	mainHandler.RegisterChain(sub.ProtocolChain.ChainID())
+	subHandler.RegisterChain(main.ProtocolChain.ChainID())
+
+	pair.main = crossCommons{
+		genesis: main.ProtocolChain.GenesisHash(),
+		chainID: main.ProtocolChain.ChainID().Uint64(),
+		handler: mainHandler,
+		channel: mainCh,
+	}
+
+	pair.sub = crossCommons{
+		genesis: sub.ProtocolChain.GenesisHash(),
+		chainID: sub.ProtocolChain.ChainID().Uint64(),
+		handler: subHandler,
+		channel: subCh,
+	}
+
+	return pair, nil
+}
+
+func (pair *crossPair) protocol(version uint) p2p.Protocol {
+	return p2p.Protocol{
+		Name:    pair.name,
+		Version: version,
+		Length:  protocolMaxMsgSize,
+		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+			anchor := newAnchorPeer(int(version), p, rw)
+			pair.service.wg.Add(1)
+			defer pair.service.wg.Done()
+			return pair.handle(anchor)
+		},
+		NodeInfo: func() interface{} {
+			return pair.NodeInfo()
+		},
+		PeerInfo: func(id enode.ID) interface{} {
+			if p := pair.peers.Peer(fmt.Sprintf("%x", id[:8])); p != nil {
+				return p.Info()
+			}
+			return nil
+		},
+	}
+}
+
+func (pair *crossPair) start() {
+	pair.main.handler.Start()
+	pair.sub.handler.Start()
+
+	// start sync handlers
+	go pair.sync()
+}
+
+func (pair *crossPair) stop() {
+	pair.main.handler.Stop()
+	pair.sub.handler.Stop()
+	pair.peers.Close()
+}
+
+func (pair *crossPair) getCrossHandler(chainID *big.Int) *Handler {
+	if chainID == nil {
+		return nil
+	}
+	if chainID.Uint64() == pair.main.chainID {
+		return pair.main.handler
+	}
+	if chainID.Uint64() == pair.sub.chainID {
+		return pair.sub.handler
+	}
+	return nil
+}
+
+// match reports whether the ctx is bridged by this pair
+func (pair *crossPair) match(chainID, destinationID *big.Int) bool {
+	if chainID == nil || destinationID == nil {
+		return false
+	}
+	from, to := chainID.Uint64(), destinationID.Uint64()
+	return (from == pair.main.chainID && to == pair.sub.chainID) || (from == pair.sub.chainID && to == pair.main.chainID)
+}
+
+func (pair *crossPair) handle(p *anchorPeer) error {
+	var (
+		mainNetworkID = pair.main.chainID
+		subNetworkID  = pair.sub.chainID
+		mainGenesis   = pair.main.genesis
+		subGenesis    = pair.sub.genesis
+		mainHeight    = pair.main.handler.Height()
+		subHeight     = pair.sub.handler.Height()
+		main          = pair.config.MainContract
+		sub           = pair.config.SubContract
+	)
+	if err := p.Handshake(mainNetworkID, subNetworkID, mainGenesis, subGenesis, mainHeight, subHeight, main, sub); err != nil {
+		log.Debug("anchor handshake failed", "err", err)
+		return err
+	}
+
+	// Register the anchor peer locally
+	if err := pair.peers.Register(p); err != nil {
+		p.Log().Error("CrossService peer registration failed", "err", err)
+		return err
+	}
+	defer pair.removePeer(p.id)
+
+	if err := pair.main.handler.synchronise.RegisterPeer(p.id, p); err != nil {
+		return err
+	}
+	if err := pair.sub.handler.synchronise.RegisterPeer(p.id, p); err != nil {
+		return err
+	}
+
+	select {
+	case pair.newPeerCh <- p:
+	case <-pair.service.quitSync:
+		return p2p.DiscQuitting
+	}
+
+	// Handle incoming messages until the connection is torn down
+	for {
+		if err := pair.handleMsg(p); err != nil {
+			return err
+		}
+	}
+}
+
+func (pair *crossPair) handleMsg(p *anchorPeer) error {
+	// Read the next message from the remote peer, and ensure it's fully consumed
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > protocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, protocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	switch {
+	case msg.Code == StatusMsg:
+		// Status messages should never arrive after the handshake
+		return errResp(ErrExtraStatusMsg, "uncontrolled status message")
+
+	case msg.Code == GetCtxSyncMsg:
+		var req synchronise.SyncReq
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		p.Log().Info("receive ctx sync request", "chain", req.Chain, "height", req.Height)
+
+		h := pair.getCrossHandler(new(big.Int).SetUint64(req.Chain))
+		if h == nil {
+			break
+		}
+
+		ctxList := h.GetCrossTransactionByHeight(req.Height, defaultMaxSyncSize)
+		var data [][]byte
+		for _, ctx := range ctxList {
+			b, err := rlp.EncodeToBytes(ctx)
+			if err != nil {
+				continue
+			}
+			data = append(data, b)
+		}
+
+		return p.SendSyncResponse(req.Chain, data)
+
+	case msg.Code == CtxSyncMsg:
+		var resp synchronise.SyncResp
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		p.Log().Debug("receive ctx sync response", "chain", resp.Chain, "len(data)", len(resp.Data))
+
+		h := pair.getCrossHandler(new(big.Int).SetUint64(resp.Chain))
+		if h == nil /*|| atomic.LoadUint32(&h.synchronising) == 0*/ { // ignore if handler isn't synchronising
+			break
+		}
+
+		var ctxList []*cc.CrossTransactionWithSignatures
+		for _, b := range resp.Data {
+			var ctx cc.CrossTransactionWithSignatures
+			if err := rlp.DecodeBytes(b, &ctx); err != nil {
+				return errResp(ErrDecode, "msg %v: %v", msg, err)
+			}
+			ctxList = append(ctxList, &ctx)
+		}
+
+		if err := h.synchronise.DeliverCrossTransactions(p.id, ctxList); err != nil {
+			log.Debug("Failed to deliver cross tx", "error", err)
+		}
+
+	case msg.Code == GetPendingSyncMsg:
+		var req synchronise.SyncPendingReq
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+
+		h := pair.getCrossHandler(new(big.Int).SetUint64(req.Chain))
+		if h == nil {
+			break
+		}
+
+		ctxList := h.GetPending(req.Ids)
+		var data [][]byte
+		for _, ctx := range ctxList {
+			b, err := rlp.EncodeToBytes(ctx)
+			if err != nil {
+				continue
+			}
+			data = append(data, b)
+		}
+		return p.SendSyncPendingResponse(req.Chain, data)
+
+	case msg.Code == PendingSyncMsg:
+		var resp synchronise.SyncPendingResp
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		p.Log().Debug("receive pending sync response", "chain", resp.Chain, "len(data)", len(resp.Data))
+
+		h := pair.getCrossHandler(new(big.Int).SetUint64(resp.Chain))
+		if h == nil {
+			break
+		}
+
+		var ctxList []*cc.CrossTransaction
+		for _, b := range resp.Data {
+			var ctx cc.CrossTransaction
+			if err := rlp.DecodeBytes(b, &ctx); err != nil {
+				return errResp(ErrDecode, "msg %v: %v", msg, err)
+			}
+			ctxList = append(ctxList, &ctx)
+		}
+
+		if err := h.synchronise.DeliverPending(p.id, ctxList); err != nil {
+			log.Debug("Failed to deliver pending", "error", err)
+		}
+
+	case p.version >= snapshotVersion && msg.Code == GetSnapshotMsg:
+		var req synchronise.SnapshotReq
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		h := pair.getCrossHandler(new(big.Int).SetUint64(req.Chain))
+		if h == nil {
+			break
+		}
+		return p.SendSnapshot(h.synchronise.Snapshot())
+
+	case p.version >= snapshotVersion && msg.Code == SnapshotMsg:
+		var resp synchronise.SnapshotResp
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		h := pair.getCrossHandler(new(big.Int).SetUint64(resp.Chain))
+		if h == nil {
+			break
+		}
+		if err := h.synchronise.DeliverSnapshot(p.id, &resp); err != nil {
+			log.Debug("Failed to deliver snapshot", "error", err)
+		}
+
+	case p.version >= snapshotVersion && msg.Code == GetBucketMsg:
+		var req synchronise.BucketReq
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		h := pair.getCrossHandler(new(big.Int).SetUint64(req.Chain))
+		if h == nil {
+			break
+		}
+		ctxList, total := h.synchronise.Bucket(req.Index, req.Offset)
+		var data [][]byte
+		for _, ctx := range ctxList {
+			b, err := rlp.EncodeToBytes(ctx)
+			if err != nil {
+				continue
+			}
+			data = append(data, b)
+		}
+		return p.SendBucket(req.Chain, req.Index, req.Offset, total, data)
+
+	case p.version >= snapshotVersion && msg.Code == BucketMsg:
+		var resp synchronise.BucketResp
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		p.Log().Debug("receive bucket sync response", "chain", resp.Chain, "index", resp.Index, "len(data)", len(resp.Data))
+
+		h := pair.getCrossHandler(new(big.Int).SetUint64(resp.Chain))
+		if h == nil {
+			break
+		}
+		var ctxList []*cc.CrossTransactionWithSignatures
+		for _, b := range resp.Data {
+			var ctx cc.CrossTransactionWithSignatures
+			if err := rlp.DecodeBytes(b, &ctx); err != nil {
+				return errResp(ErrDecode, "msg %v: %v", msg, err)
+			}
+			ctxList = append(ctxList, &ctx)
+		}
+		if err := h.synchronise.DeliverBucket(p.id, resp.Index, resp.Offset, resp.Total, ctxList); err != nil {
+			log.Debug("Failed to deliver bucket", "error", err)
+		}
+
+	case p.version >= finishRootVersion && msg.Code == FinishRootMsg:
+		var root cc.FinishRoot
+		if err := msg.Decode(&root); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		h := pair.getCrossHandler(root.ChainId)
+		if h == nil {
+			break
+		}
+		h.AddRemoteFinishRoot(&root)
+
+	case msg.Code == CtxSignMsg:
+		var ctx *cc.CrossTransaction
+		if err := msg.Decode(&ctx); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		p.MarkCrossTransaction(ctx.SignHash())
+
+		if !pair.match(ctx.ChainId(), ctx.DestinationId()) {
+			break
+		}
+		h := pair.getCrossHandler(ctx.ChainId())
+		if h == nil {
+			break
+		}
+
+		err := h.AddRemoteCtx(ctx)
+		if err == cross.ErrExpiredCtx || err == cross.ErrInvalidSignCtx {
+			break
+		}
+		pair.BroadcastCrossTx([]*cc.CrossTransaction{ctx}, false)
+
+	default:
+		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+	}
+
+	return nil
+}
+
+func (pair *crossPair) removePeer(id string) {
+	// Short circuit if the peer was already removed
+	peer := pair.peers.Peer(id)
+	if peer == nil {
+		return
+	}
+	log.Debug("Removing cross anchor peer", "peer", id, "pair", pair.name)
+
+	// Unregister the peer from the synchronise and anchor peer set
+	pair.main.handler.synchronise.UnregisterPeer(id)
+	pair.sub.handler.synchronise.UnregisterPeer(id)
+	if err := pair.peers.Unregister(id); err != nil {
+		log.Error("Peer removal failed", "peer", id, "err", err)
+	}
+	// Hard disconnect at the networking layer
+	peer.Disconnect(p2p.DiscUselessPeer)
+}
+
+func (pair *crossPair) BroadcastCrossTx(ctxs []*cc.CrossTransaction, local bool) {
+	for _, ctx := range ctxs {
+		var txset = make(map[*anchorPeer]*cc.CrossTransaction)
+
+		// Broadcast ctx to a batch of peers not knowing about it
+		peers := pair.peers.PeersWithoutCtx(ctx.SignHash())
+		for _, peer := range peers {
+			txset[peer] = ctx
+		}
+		for peer, rt := range txset {
+			peer.AsyncSendCrossTransaction(rt, local)
+			log.Debug("Broadcast CrossTransaction", "hash", ctx.SignHash(), "peer", peer.id)
+		}
+	}
+}
+
+// BroadcastFinishRoot sends the finish root signed by local anchor to the peers collecting signatures of roots
+func (pair *crossPair) BroadcastFinishRoot(root *cc.FinishRoot) {
+	for _, peer := range pair.peers.Peers() {
+		if peer.version < finishRootVersion {
+			continue
+		}
+		go func(peer *anchorPeer) {
+			if err := peer.SendFinishRoot(root); err != nil {
+				peer.Log().Trace("SendFinishRoot", "err", err)
+			}
+		}(peer)
+	}
+}
+
+func (pair *crossPair) sync() {
+	for {
+		select {
+		case p := <-pair.newPeerCh:
+			if pair.peers.Len() > 0 {
+				pair.synchronise(pair.peers.BestPeer())
+			}
+			pair.syncPending(p)
+
+		case <-pair.service.quitSync:
+			return
+		}
+	}
+}
+
+func (pair *crossPair) synchronise(main, sub *anchorPeer) {
+	if main != nil {
+		go pair.main.handler.synchronise.Synchronise(main.id, main.crossStatus.MainHeight)
+	}
+	if sub != nil {
+		go pair.sub.handler.synchronise.Synchronise(sub.id, sub.crossStatus.SubHeight)
+	}
+}
+
+func (pair *crossPair) syncPending(peer *anchorPeer) {
+	go pair.main.handler.synchronise.SynchronisePending(peer.id)
+	go pair.sub.handler.synchronise.SynchronisePending(peer.id)
+}
+
+type CrossNodeInfo struct {
+	MainChain   uint64       `json:"mainChain"`
+	MainGenesis common.Hash  `json:"mainGenesis"`
+	SubChain    uint64       `json:"subChain"`
+	SubGenesis  common.Hash  `json:"subGenesis"`
+	Config      cross.Config `json:"config"`
+}
+
+func (pair *crossPair) NodeInfo() *CrossNodeInfo {
+	return &CrossNodeInfo{
+		Config:      pair.config,
+		MainChain:   pair.main.chainID,
+		MainGenesis: pair.main.genesis,
+		SubChain:    pair.sub.chainID,
+		SubGenesis:  pair.sub.genesis,
+	}
+}
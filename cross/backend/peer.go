@@ -38,10 +38,10 @@ type anchorPeer struct {
 	pendingFetchRequest chan *synchronise.SyncPendingReq
 }
 
-func newAnchorPeer(p *p2p.Peer, rw p2p.MsgReadWriter) *anchorPeer {
+func newAnchorPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *anchorPeer {
 	return &anchorPeer{
 		Peer:                p,
-		version:             protocolVersion,
+		version:             version,
 		id:                  fmt.Sprintf("%x", p.ID().Bytes()[:8]),
 		rw:                  rw,
 		term:                make(chan struct{}),
@@ -145,6 +145,37 @@ func (p *anchorPeer) SendSyncPendingResponse(chain uint64, data [][]byte) error
 	return p2p.Send(p.rw, PendingSyncMsg, &synchronise.SyncPendingResp{Chain: chain, Data: data})
 }
 
+// SupportSnapshot reports whether the peer syncs stores by snapshot
+func (p *anchorPeer) SupportSnapshot() bool {
+	return p.version >= snapshotVersion
+}
+
+func (p *anchorPeer) RequestSnapshot(chain uint64) error {
+	p.Log().Debug("Sending store snapshot request", "chain", chain)
+	return p2p.Send(p.rw, GetSnapshotMsg, &synchronise.SnapshotReq{Chain: chain})
+}
+
+func (p *anchorPeer) SendSnapshot(snapshot *synchronise.SnapshotResp) error {
+	p.Log().Debug("Sending store snapshot", "chain", snapshot.Chain, "buckets", len(snapshot.Buckets))
+	return p2p.Send(p.rw, SnapshotMsg, snapshot)
+}
+
+func (p *anchorPeer) RequestBucket(chain uint64, index uint64, offset uint32) error {
+	p.Log().Debug("Sending bucket sync request", "chain", chain, "index", index, "offset", offset)
+	return p2p.Send(p.rw, GetBucketMsg, &synchronise.BucketReq{Chain: chain, Index: index, Offset: offset})
+}
+
+func (p *anchorPeer) SendBucket(chain uint64, index uint64, offset, total uint32, data [][]byte) error {
+	p.Log().Debug("Sending bucket sync response", "chain", chain, "index", index, "count", len(data))
+	return p2p.Send(p.rw, BucketMsg, &synchronise.BucketResp{Chain: chain, Index: index, Offset: offset, Total: total, Data: data})
+}
+
+// SendFinishRoot sends the finish root signed by local anchor
+func (p *anchorPeer) SendFinishRoot(root *cc.FinishRoot) error {
+	p.Log().Debug("Sending finish root", "chain", root.ChainId, "root", root.Root)
+	return p2p.Send(p.rw, FinishRootMsg, root)
+}
+
 func (p *anchorPeer) MarkCrossTransaction(hash common.Hash) {
 	// If we reached the memory allowance, drop a previously known transaction hash
 	for p.knownCTxs.Cardinality() >= maxKnownCtx {
@@ -320,3 +351,15 @@ func (ps *anchorSet) PeersWithoutCtx(hash common.Hash) []*anchorPeer {
 	}
 	return list
 }
+
+// Peers returns all registered peers
+func (ps *anchorSet) Peers() []*anchorPeer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*anchorPeer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		list = append(list, p)
+	}
+	return list
+}
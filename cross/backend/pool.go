@@ -6,8 +6,10 @@ import (
 	"time"
 
 	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/crypto/bls"
 	"github.com/simplechain-org/go-simplechain/event"
 	"github.com/simplechain-org/go-simplechain/log"
+	"github.com/simplechain-org/go-simplechain/metrics"
 
 	"github.com/simplechain-org/go-simplechain/cross"
 	cc "github.com/simplechain-org/go-simplechain/cross/core"
@@ -23,12 +25,20 @@ import (
 const (
 	expireInterval    = time.Minute * 6
 	expireQueueNumber = 63
+	signedCacheSize   = 4096
 )
 
 type store interface {
 	GetStore(chainID *big.Int) (db.CtxDB, error)
 	Add(ctx *cc.CrossTransactionWithSignatures) error
 	Get(chainID *big.Int, ctxID common.Hash) *cc.CrossTransactionWithSignatures
+	AddEvidence(ev *cc.Evidence) (bool, error)
+}
+
+// signedKey is the key of ctx signed by a remote anchor
+type signedKey struct {
+	id     common.Hash
+	signer common.Address
 }
 
 type finishedLog interface {
@@ -45,12 +55,18 @@ type CrossPool struct {
 	pending      *db.CtxSortedByBlockNum //带有local签名
 	queued       *db.CtxSortedByBlockNum //网络其他节点签名
 	pendingCache *lru.Cache              // cache signed pending ctx
+	signedCache  *lru.Cache              // ctx signed by remote anchors, for equivocation checking
+	signStarts   *lru.Cache              // time of local ctx added to pending, for signature latency
+	signLatency  metrics.Histogram
 
-	commitFeed  event.Feed
-	commitScope event.SubscriptionScope
+	commitFeed   event.Feed
+	evidenceFeed event.Feed
+	commitScope  event.SubscriptionScope
 
 	signer   cc.CtxSigner
 	signHash cc.SignHash
+	signCtx  func(*cc.CrossTransaction, *big.Int) (*cc.CrossTransaction, error) // sign local ctxs by anchor signer if not nil
+	blsKey   *bls.KeyShare                                                      // sign BLS share and aggregate signatures if not nil
 	txLog    finishedLog
 
 	mu     sync.RWMutex
@@ -64,6 +80,8 @@ func NewCrossPool(chainID *big.Int, config *cross.Config, store store, txLog fin
 	retriever trigger.ChainRetriever, signHash cc.SignHash) *CrossPool {
 
 	pendingCache, _ := lru.New(signedPendingSize)
+	signedCache, _ := lru.New(signedCacheSize)
+	signStarts, _ := lru.New(signedCacheSize)
 	logger := log.New("X-module", "pool")
 
 	pool := &CrossPool{
@@ -75,12 +93,28 @@ func NewCrossPool(chainID *big.Int, config *cross.Config, store store, txLog fin
 		pending:      db.NewCtxSortedMap(),
 		queued:       db.NewCtxSortedMap(),
 		pendingCache: pendingCache,
+		signedCache:  signedCache,
+		signStarts:   signStarts,
+		signLatency:  cm.SignLatency(chainID.Uint64()),
 		signer:       cc.MakeCtxSigner(chainID),
 		signHash:     signHash,
 		stopCh:       make(chan struct{}),
 		logger:       logger,
 	}
 
+	if config.BLSKey != "" {
+		key, err := bls.LoadKeyShare(config.BLSKey)
+		switch {
+		case err != nil:
+			logger.Error("Load BLS key share failed, aggregate signature is disabled", "error", err)
+		case len(key.Anchors) == 0:
+			// shares of others can't be bound to their signers
+			logger.Error("BLS key share is not bound to anchors, aggregate signature is disabled", "file", config.BLSKey)
+		default:
+			pool.blsKey = key
+		}
+	}
+
 	if err := pool.load(); err != nil {
 		logger.Error("Load pending transaction failed", "error", err)
 	}
@@ -204,6 +238,14 @@ func (pool *CrossPool) AddRemote(ctx *cc.CrossTransaction) (signer common.Addres
 	if signer == pool.config.Signer {
 		return signer, nil
 	}
+	if ev := pool.checkEquivocation(ctx, signer); ev != nil {
+		pool.addEvidence(ev)
+	}
+	if err := pool.verifyBLSShare(ctx, signer); err != nil {
+		// ECDSA signature is still collected
+		pool.logger.Warn("Reject BLS signature share", "ctxID", ctx.ID().String(), "signer", signer.String(), "error", err)
+		ctx = ctx.WithoutBLSSignature()
+	}
 	if pool.txLog.IsFinish(ctx.ID()) {
 		// already exist in finished log, ignore ctx
 		return signer, cross.ErrFinishedCtx
@@ -225,10 +267,18 @@ func (pool *CrossPool) AddRemote(ctx *cc.CrossTransaction) (signer common.Addres
 }
 
 func (pool *CrossPool) signTx(ctx *cc.CrossTransaction) (*cc.CrossTransaction, error) {
-	ctx, err := cc.SignCtx(ctx, pool.signer, pool.signHash)
+	var err error
+	if pool.signCtx != nil {
+		ctx, err = pool.signCtx(ctx, pool.chainID)
+	} else {
+		ctx, err = cc.SignCtx(ctx, pool.signer, pool.signHash)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if pool.blsKey != nil {
+		ctx = ctx.WithBLSSignature(pool.blsKey.Index, pool.blsKey.Sign(pool.signer.Hash(ctx).Bytes()).Bytes())
+	}
 	pool.pendingCache.Add(ctx.ID(), ctx) // add to cache
 	return ctx, nil
 }
@@ -238,6 +288,7 @@ func (pool *CrossPool) addTx(ctx *cc.CrossTransaction, local bool) error {
 
 	checkAndCommit := func(id common.Hash) error {
 		if cws := pool.pending.Get(id); cws != nil && cws.SignaturesLength() >= pool.retriever.RequireSignatures() {
+			pool.aggregate(cws)
 			pool.Commit(cws)
 		}
 		return nil
@@ -264,6 +315,7 @@ func (pool *CrossPool) addTx(ctx *cc.CrossTransaction, local bool) error {
 		}
 		pool.pending.Put(pendingRws)
 		pool.queued.RemoveByID(id)
+		pool.signStarts.Add(id, time.Now())
 		return checkAndCommit(id)
 	}
 
@@ -291,9 +343,84 @@ func (pool *CrossPool) verifyReorg(ctx *cc.CrossTransaction) error {
 	return nil
 }
 
+// checkEquivocation returns the evidence if signer has signed a different ctx with the same ctxID,
+// or signed a ctx whose blockHash is rejected by verifyReorg
+func (pool *CrossPool) checkEquivocation(ctx *cc.CrossTransaction, signer common.Address) *cc.Evidence {
+	now := uint64(time.Now().Unix())
+	key := signedKey{ctx.ID(), signer}
+	if prev, ok := pool.signedCache.Get(key); ok {
+		if prev := prev.(*cc.CrossTransaction); pool.signer.Hash(prev) != pool.signer.Hash(ctx) {
+			return cc.NewEvidence(cc.EvidenceDoubleSign, signer, prev, ctx, now)
+		}
+	} else {
+		pool.signedCache.Add(key, ctx)
+	}
+	local := pool.pending.Get(ctx.ID())
+	if local == nil {
+		local = pool.store.Get(pool.chainID, ctx.ID())
+	}
+	if local != nil && local.BlockHash() != ctx.BlockHash() {
+		return cc.NewEvidence(cc.EvidenceReorg, signer, ctx, local.CrossTransaction(), now)
+	}
+	return nil
+}
+
+// addEvidence saves the evidence and sends it to be submitted in the destination chain
+func (pool *CrossPool) addEvidence(ev *cc.Evidence) {
+	added, err := pool.store.AddEvidence(ev)
+	if err != nil {
+		pool.logger.Warn("Store anchor evidence failed", "ctxID", ev.ID(), "err", err)
+		return
+	}
+	if !added {
+		return
+	}
+	cm.Report(pool.chainID.Uint64(), "anchor equivocation", "kind", ev.Kind.String(),
+		"signer", ev.Signer.String(), "ctxID", ev.ID().String())
+	pool.wg.Add(1)
+	go func() {
+		defer pool.wg.Done()
+		pool.evidenceFeed.Send(cc.EvidenceEvent{Evidences: []*cc.Evidence{ev}})
+	}()
+}
+
+// verifyBLSShare checks the BLS share of a remote ctx is signed by the key share bound to its ECDSA signer
+func (pool *CrossPool) verifyBLSShare(ctx *cc.CrossTransaction, signer common.Address) error {
+	if pool.blsKey == nil || len(ctx.Data.BLS) == 0 {
+		return nil
+	}
+	if len(ctx.Data.BLS) > 1 {
+		return cross.ErrInvalidBLSShare
+	}
+	share := ctx.Data.BLS[0]
+	if anchor, ok := pool.blsKey.AnchorOf(share.Index); !ok || anchor != signer {
+		return cross.ErrInvalidBLSShare
+	}
+	sig, err := bls.NewSignature(share.Sig)
+	if err != nil {
+		return err
+	}
+	return pool.blsKey.VerifyShare(share.Index, pool.signer.Hash(ctx).Bytes(), sig)
+}
+
+// aggregate recovers the BLS aggregate signature of cws,
+// cws is still committed with ECDSA signatures if aggregation failed
+func (pool *CrossPool) aggregate(cws *cc.CrossTransactionWithSignatures) {
+	if pool.blsKey == nil {
+		return
+	}
+	if err := cws.AggregateBLS(pool.blsKey.Threshold, pool.blsKey.Master); err != nil {
+		pool.logger.Debug("Aggregate BLS signature failed", "ctxID", cws.ID().String(), "error", err)
+	}
+}
+
 // Commit signed ctx with callback
 func (pool *CrossPool) Commit(cws *cc.CrossTransactionWithSignatures) {
 	pool.pending.RemoveByID(cws.ID()) // remove it from pending
+	if start, ok := pool.signStarts.Get(cws.ID()); ok {
+		pool.signLatency.Update(time.Since(start.(time.Time)).Milliseconds())
+		pool.signStarts.Remove(cws.ID())
+	}
 	pool.wg.Add(1)
 	go func() { //TODO: 同步还是异步？
 		defer pool.wg.Done()
@@ -364,3 +491,9 @@ func (pool *CrossPool) SubscribeSignedCtxEvent(ch chan<- cc.SignedCtxEvent) even
 	defer pool.mu.Unlock()
 	return pool.commitScope.Track(pool.commitFeed.Subscribe(ch))
 }
+
+func (pool *CrossPool) SubscribeEvidenceEvent(ch chan<- cc.EvidenceEvent) event.Subscription {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.commitScope.Track(pool.evidenceFeed.Subscribe(ch))
+}
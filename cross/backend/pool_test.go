@@ -2,6 +2,7 @@ package backend
 
 import (
 	"crypto/ecdsa"
+	"crypto/rand"
 	"errors"
 	"math/big"
 	"sync"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/simplechain-org/go-simplechain/common"
 	"github.com/simplechain-org/go-simplechain/crypto"
+	"github.com/simplechain-org/go-simplechain/crypto/bls"
 	"github.com/simplechain-org/go-simplechain/params"
 
 	"github.com/simplechain-org/go-simplechain/cross"
@@ -193,12 +195,16 @@ func (p *poolTester) add(t *testing.T) {
 }
 
 type testMemoryStore struct {
-	db   map[common.Hash]*cc.CrossTransactionWithSignatures
-	lock sync.RWMutex
+	db        map[common.Hash]*cc.CrossTransactionWithSignatures
+	evidences map[common.Hash]*cc.Evidence
+	lock      sync.RWMutex
 }
 
 func newTestMemoryStore() *testMemoryStore {
-	return &testMemoryStore{db: make(map[common.Hash]*cc.CrossTransactionWithSignatures)}
+	return &testMemoryStore{
+		db:        make(map[common.Hash]*cc.CrossTransactionWithSignatures),
+		evidences: make(map[common.Hash]*cc.Evidence),
+	}
 }
 
 func (s *testMemoryStore) GetStore(chainID *big.Int) (cdb.CtxDB, error) {
@@ -222,6 +228,16 @@ func (s *testMemoryStore) Update(ctx *cc.CrossTransactionWithSignatures) error {
 	return nil
 }
 
+func (s *testMemoryStore) AddEvidence(ev *cc.Evidence) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.evidences[ev.Hash()] != nil {
+		return false, nil
+	}
+	s.evidences[ev.Hash()] = ev
+	return true, nil
+}
+
 type testFinishLog struct{}
 
 func (l testFinishLog) IsFinish(hash common.Hash) bool { return false }
@@ -248,3 +264,68 @@ func (r testChainRetriever) VerifySigner(ctx *cc.CrossTransaction, signChain, st
 func (r testChainRetriever) UpdateAnchors(info *cc.RemoteChainInfo) error { return nil }
 func (r testChainRetriever) RequireSignatures() int                       { return 2 }
 func (r testChainRetriever) ExpireNumber() int                            { return -1 }
+
+func TestCrossPool_Equivocation(t *testing.T) {
+	store := newTestMemoryStore()
+	p := newPoolTester(store)
+	evidenceCh := make(chan cc.EvidenceEvent, 1)
+	p.SubscribeEvidenceEvent(evidenceCh)
+
+	signer := cc.NewEIP155CtxSigner(p.chainID)
+	anchor := crypto.PubkeyToAddress(p.remoteKey.PublicKey)
+	sign := func(value int64, blockHash common.Hash) *cc.CrossTransaction {
+		ctx := cc.NewCrossTransaction(big.NewInt(value), big.NewInt(2e18), big.NewInt(19),
+			common.HexToHash("0x01"), common.HexToHash("0x02"), blockHash, anchor, common.Address{}, nil)
+		signed, err := cc.SignCtx(ctx, signer, func(hash []byte) ([]byte, error) { return crypto.Sign(hash, p.remoteKey) })
+		assert.NoError(t, err)
+		return signed
+	}
+
+	first := sign(1e18, common.HexToHash("0x03"))
+	assert.Nil(t, p.checkEquivocation(first, anchor))
+	assert.Nil(t, p.checkEquivocation(first, anchor), "same ctx signed again")
+
+	// double signing
+	ev := p.checkEquivocation(sign(2e18, common.HexToHash("0x03")), anchor)
+	assert.NotNil(t, ev)
+	assert.Equal(t, cc.EvidenceDoubleSign, ev.Kind)
+	assert.NoError(t, ev.Verify())
+	p.addEvidence(ev)
+	select {
+	case got := <-evidenceCh:
+		assert.Equal(t, ev.Hash(), got.Evidences[0].Hash())
+	case <-time.After(time.Second):
+		t.Error("evidence timeout")
+	}
+	p.addEvidence(ev)
+	assert.Len(t, store.evidences, 1, "evidence is saved once")
+
+	// signing a reorged ctx
+	assert.NoError(t, store.Add(cc.NewCrossTransactionWithSignatures(sign(1e18, common.HexToHash("0x03")), 1)))
+	ev = p.checkEquivocation(sign(1e18, common.HexToHash("0x04")), common.HexToAddress("0x05"))
+	assert.NotNil(t, ev)
+	assert.Equal(t, cc.EvidenceReorg, ev.Kind)
+	assert.Equal(t, common.HexToHash("0x03"), ev.Second.BlockHash())
+}
+
+func TestCrossPool_VerifyBLSShare(t *testing.T) {
+	p := newPoolTester(newTestMemoryStore())
+	shares, err := bls.GenerateShares(rand.Reader, 2, 3)
+	assert.NoError(t, err)
+	local, remote := crypto.PubkeyToAddress(p.localKey.PublicKey), crypto.PubkeyToAddress(p.remoteKey.PublicKey)
+	shares[0].Anchors = []common.Address{local, remote, common.HexToAddress("0x03")}
+	p.blsKey = shares[0]
+
+	ctx := cc.NewCrossTransaction(big.NewInt(1e18), big.NewInt(2e18), big.NewInt(19),
+		common.HexToHash("0x01"), common.HexToHash("0x02"), common.HexToHash("0x03"), local, remote, nil)
+	signed, err := cc.SignCtx(ctx, cc.NewEIP155CtxSigner(p.chainID), func(hash []byte) ([]byte, error) { return crypto.Sign(hash, p.remoteKey) })
+	assert.NoError(t, err)
+	hash := p.signer.Hash(signed).Bytes()
+
+	assert.NoError(t, p.verifyBLSShare(signed, remote), "no share")
+	assert.NoError(t, p.verifyBLSShare(signed.WithBLSSignature(2, shares[1].Sign(hash).Bytes()), remote))
+	assert.Equal(t, cross.ErrInvalidBLSShare, p.verifyBLSShare(signed.WithBLSSignature(3, shares[2].Sign(hash).Bytes()), remote),
+		"share of another anchor")
+	assert.Equal(t, bls.ErrInvalidShare, p.verifyBLSShare(signed.WithBLSSignature(2, shares[2].Sign(hash).Bytes()), remote),
+		"forged share")
+}
@@ -9,7 +9,10 @@ import (
 )
 
 const (
-	protocolVersion    = 1
+	protocolName       = "cross"
+	protocolVersion    = 3
+	snapshotVersion    = 2 // cross/2 syncs stores by snapshot and buckets
+	finishRootVersion  = 3 // cross/3 collects anchor signatures of finish roots
 	protocolMaxMsgSize = 10 * 1024 * 1024
 	handshakeTimeout   = 5 * time.Second
 	//rttMaxEstimate     = 20 * time.Second // Maximum round-trip time to target for download requests
@@ -21,6 +24,9 @@ const (
 	maxQueuedRemoteCtx = 128
 )
 
+// protocolVersions are the supported versions of the cross protocol, the first is the primary one
+var protocolVersions = []uint{protocolVersion, snapshotVersion, 1}
+
 const (
 	StatusMsg         = 0x00
 	CtxSignMsg        = 0x31
@@ -28,6 +34,15 @@ const (
 	CtxSyncMsg        = 0x33
 	GetPendingSyncMsg = 0x34
 	PendingSyncMsg    = 0x35
+
+	// cross/2
+	GetSnapshotMsg = 0x36
+	SnapshotMsg    = 0x37
+	GetBucketMsg   = 0x38
+	BucketMsg      = 0x39
+
+	// cross/3
+	FinishRootMsg = 0x3a
 )
 
 var (
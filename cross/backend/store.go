@@ -6,12 +6,13 @@ import (
 	"sync"
 
 	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/event"
 	"github.com/simplechain-org/go-simplechain/log"
 
+	"github.com/simplechain-org/go-simplechain/cross"
 	cc "github.com/simplechain-org/go-simplechain/cross/core"
 	cdb "github.com/simplechain-org/go-simplechain/cross/database"
 
-	"github.com/asdine/storm/v3"
 	"github.com/asdine/storm/v3/q"
 )
 
@@ -20,28 +21,77 @@ const defaultCacheSize = 4096
 var ErrInvalidChainStore = errors.New("invalid chain store, chainID can not be nil")
 
 type CrossStore struct {
-	stores map[uint64]cdb.CtxDB
-	db     *storm.DB // database to store cws
-	mu     sync.Mutex
-	logger log.Logger
+	stores    map[uint64]cdb.CtxDB
+	evidences map[uint64]cdb.EvidenceDB
+	engine    cdb.Engine // storage engine of cws, namespace of the root engine if ns is not empty
+	ns        string
+	book      *OrderBook // open orders of the stores
+	mu        sync.Mutex
+	logger    log.Logger
+
+	statusFeed  event.Feed
+	statusScope event.SubscriptionScope
+	statusQueue []cc.CtxStatusEvent // status changes waiting to be sent by statusLoop
+	statusMu    sync.Mutex
+	statusWake  chan struct{}
+	quit        chan struct{}
 }
 
-func NewCrossStore(ctx cdb.ServiceContext, makerDb string) (*CrossStore, error) {
+// StoreName returns the name of the database opened by the store engine
+func StoreName(engine string) string {
+	if engine == cdb.LevelDBEngine {
+		return cross.IndexDir
+	}
+	return cross.DataDir
+}
+
+func NewCrossStore(ctx cdb.ServiceContext, engine string, makerDb string) (*CrossStore, error) {
 	store := &CrossStore{
-		logger: log.New("X-module", "store"),
+		book:       NewOrderBook(),
+		logger:     log.New("X-module", "store"),
+		statusWake: make(chan struct{}, 1),
+		quit:       make(chan struct{}),
 	}
 
-	db, err := cdb.OpenStormDB(ctx, makerDb)
+	db, err := cdb.OpenEngine(ctx, engine, makerDb)
 	if err != nil {
 		return nil, err
 	}
-	store.db = db
+	store.engine = db
 	store.stores = make(map[uint64]cdb.CtxDB)
+	store.evidences = make(map[uint64]cdb.EvidenceDB)
+	go store.statusLoop()
 	return store, nil
 }
 
+// Namespace returns a store sharing the same db, ctx of every chain are saved in the namespace node,
+// so the same chain can be registered by more than one namespace.
+func (s *CrossStore) Namespace(ns string) *CrossStore {
+	if ns == "" {
+		return s
+	}
+	store := &CrossStore{
+		stores:     make(map[uint64]cdb.CtxDB),
+		evidences:  make(map[uint64]cdb.EvidenceDB),
+		engine:     s.engine.Namespace(ns),
+		ns:         ns,
+		book:       NewOrderBook(),
+		logger:     log.New("X-module", "store", "namespace", ns),
+		statusWake: make(chan struct{}, 1),
+		quit:       make(chan struct{}),
+	}
+	go store.statusLoop()
+	return store
+}
+
 func (s *CrossStore) Close() {
-	if err := s.db.Close(); err != nil {
+	close(s.quit)
+	s.statusScope.Close()
+	s.book.Close()
+	if s.ns != "" { // only the root store closes db
+		return
+	}
+	if err := s.engine.Close(); err != nil {
 		s.logger.Warn("close store failed", "error", err)
 	}
 }
@@ -50,13 +100,32 @@ func (s *CrossStore) RegisterChain(chainID *big.Int) cdb.CtxDB {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.stores[chainID.Uint64()] == nil {
-		s.stores[chainID.Uint64()] = cdb.NewIndexDB(chainID, s.db, defaultCacheSize)
+		store := &bookedCtxDB{CtxDB: s.engine.CtxDB(chainID, defaultCacheSize), book: s.book, publish: s.publishStatus}
+		if err := store.Load(); err != nil {
+			s.logger.Warn("load store failed", "chainID", chainID, "error", err)
+		}
+		s.stores[chainID.Uint64()] = store
 		s.logger.New("remote", chainID)
 		s.logger.Info("Register chain successfully")
 	}
 	return s.stores[chainID.Uint64()]
 }
 
+// EvidenceDB returns evidences of anchors signing ctxs made in the chain
+func (s *CrossStore) EvidenceDB(chainID *big.Int) cdb.EvidenceDB {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.evidences[chainID.Uint64()] == nil {
+		s.evidences[chainID.Uint64()] = s.engine.EvidenceDB(chainID)
+	}
+	return s.evidences[chainID.Uint64()]
+}
+
+// AddEvidence saves the evidence, returns false if it is already saved
+func (s *CrossStore) AddEvidence(ev *cc.Evidence) (bool, error) {
+	return s.EvidenceDB(ev.ChainId()).Add(ev)
+}
+
 func (s *CrossStore) Add(ctx *cc.CrossTransactionWithSignatures) error {
 	store, err := s.GetStore(ctx.ChainId())
 	if err != nil {
@@ -104,26 +173,79 @@ func (s *CrossStore) Updates(chainID *big.Int, txmList []*cc.CrossTransactionMod
 		updaters []func(ctx *cdb.CrossTransactionIndexed)
 	)
 	for _, txm := range txmList {
-		upType, upStatus, upNumber := txm.Type, uint8(txm.Status), txm.AtBlockNumber //必须复制变量，迭代器引用会产生的问题
+		upType, upStatus, upNumber, upFilled := txm.Type, txm.Status, txm.AtBlockNumber, txm.Filled //必须复制变量，迭代器引用会产生的问题
 		ids = append(ids, txm.ID)
 		updaters = append(updaters, func(ctx *cdb.CrossTransactionIndexed) {
-			switch {
+			// statuses are compared by order of transitions, partialFilled is appended after cancelled
+			switch current := cc.CtxStatus(ctx.Status).Order(); {
 			// force update if tx status is changed by block reorg
-			case upType == cc.Reorg && upStatus < ctx.Status:
-				ctx.Status = upStatus
+			case upType == cc.Reorg && upStatus.Order() < current:
+				ctx.Status = uint8(upStatus)
 			// update from remote
-			case upType == cc.Remote && upStatus > ctx.Status:
-				ctx.Status = upStatus
+			case upType == cc.Remote && upStatus.Order() > current:
+				ctx.Status = uint8(upStatus)
 			// update from local
-			case upType == cc.Normal && upStatus > ctx.Status: // 正常情况下，status更大则状态变更的高度更高，但是回滚时就不一定，所以不限制高度大小
-				ctx.Status = upStatus
+			case upType == cc.Normal && upStatus.Order() > current: // 正常情况下，status更大则状态变更的高度更高，但是回滚时就不一定，所以不限制高度大小
+				ctx.Status = uint8(upStatus)
 				ctx.BlockNum = upNumber
 			}
+			// filled value of partial takers increases from remote, and decreases by block reorg
+			if upFilled != nil {
+				filled := ctx.Filled
+				if filled == nil {
+					filled = common.Big0
+				}
+				if upType == cc.Remote && upFilled.Cmp(filled) > 0 || upType == cc.Reorg && upFilled.Cmp(filled) < 0 {
+					ctx.SetFilled(upFilled)
+				}
+			}
 		})
 	}
 	return store.Updates(ids, updaters)
 }
 
+// OrderBook returns open orders of the stores
+func (s *CrossStore) OrderBook() *OrderBook {
+	return s.book
+}
+
+// SubscribeCtxStatusEvent registers a subscription of status changes made by every write of the stores,
+// a ctx written for the first time is changed from pending
+func (s *CrossStore) SubscribeCtxStatusEvent(ch chan<- cc.CtxStatusEvent) event.Subscription {
+	return s.statusScope.Track(s.statusFeed.Subscribe(ch))
+}
+
+// publishStatus queues the status changes, they are sent by statusLoop so that writes of the stores
+// are never blocked by slow subscribers
+func (s *CrossStore) publishStatus(events []cc.CtxStatusEvent) {
+	s.statusMu.Lock()
+	s.statusQueue = append(s.statusQueue, events...)
+	s.statusMu.Unlock()
+
+	select {
+	case s.statusWake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *CrossStore) statusLoop() {
+	for {
+		select {
+		case <-s.statusWake:
+			s.statusMu.Lock()
+			events := s.statusQueue
+			s.statusQueue = nil
+			s.statusMu.Unlock()
+
+			for _, ev := range events {
+				s.statusFeed.Send(ev)
+			}
+		case <-s.quit:
+			return
+		}
+	}
+}
+
 func (s *CrossStore) Height(chainID *big.Int) uint64 {
 	store, err := s.GetStore(chainID)
 	if err != nil {
@@ -139,19 +261,33 @@ func (s *CrossStore) Stats() map[uint64]map[cc.CtxStatus]int {
 	executed := q.Eq(cdb.StatusField, cc.CtxStatusExecuted)
 	finishing := q.Eq(cdb.StatusField, cc.CtxStatusFinishing)
 	finished := q.Eq(cdb.StatusField, cc.CtxStatusFinished)
+	cancelling := q.Eq(cdb.StatusField, cc.CtxStatusCancelling)
+	cancelled := q.Eq(cdb.StatusField, cc.CtxStatusCancelled)
 	pending := q.Eq(cdb.StatusField, cc.CtxStatusPending)
+	partialFilled := q.Eq(cdb.StatusField, cc.CtxStatusPartialFilled)
+	delivering := q.Eq(cdb.StatusField, cc.CtxStatusDelivering)
+	delivered := q.Eq(cdb.StatusField, cc.CtxStatusDelivered)
+	receipting := q.Eq(cdb.StatusField, cc.CtxStatusReceipting)
+	receipted := q.Eq(cdb.StatusField, cc.CtxStatusReceipted)
 
 	results := make(map[uint64]map[cc.CtxStatus]int, len(s.stores))
 
 	stats := func(db cdb.CtxDB) map[cc.CtxStatus]int {
 		return map[cc.CtxStatus]int{
-			cc.CtxStatusWaiting:   db.Count(waiting),
-			cc.CtxStatusIllegal:   db.Count(illegal),
-			cc.CtxStatusExecuting: db.Count(executing),
-			cc.CtxStatusExecuted:  db.Count(executed),
-			cc.CtxStatusFinishing: db.Count(finishing),
-			cc.CtxStatusFinished:  db.Count(finished),
-			cc.CtxStatusPending:   db.Count(pending),
+			cc.CtxStatusWaiting:       db.Count(waiting),
+			cc.CtxStatusIllegal:       db.Count(illegal),
+			cc.CtxStatusExecuting:     db.Count(executing),
+			cc.CtxStatusExecuted:      db.Count(executed),
+			cc.CtxStatusFinishing:     db.Count(finishing),
+			cc.CtxStatusFinished:      db.Count(finished),
+			cc.CtxStatusCancelling:    db.Count(cancelling),
+			cc.CtxStatusCancelled:     db.Count(cancelled),
+			cc.CtxStatusPending:       db.Count(pending),
+			cc.CtxStatusPartialFilled: db.Count(partialFilled),
+			cc.CtxStatusDelivering:    db.Count(delivering),
+			cc.CtxStatusDelivered:     db.Count(delivered),
+			cc.CtxStatusReceipting:    db.Count(receipting),
+			cc.CtxStatusReceipted:     db.Count(receipted),
 		}
 	}
 	for chain, store := range s.stores {
@@ -3,7 +3,9 @@ package backend
 import (
 	"math/big"
 	"testing"
+	"time"
 
+	"github.com/simplechain-org/go-simplechain/crypto"
 	"github.com/simplechain-org/go-simplechain/params"
 
 	cc "github.com/simplechain-org/go-simplechain/cross/core"
@@ -48,6 +50,30 @@ func TestCrossStore_GetStore(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestCrossStore_Namespace(t *testing.T) {
+	chainID := big.NewInt(10)
+	s, err := newStoreTester(chainID)
+	assert.NoError(t, err)
+	defer s.Close()
+
+	ns := s.Namespace("cross100")
+	ns.RegisterChain(chainID)
+	ns.stores[chainID.Uint64()].Clean()
+	assert.Equal(t, s, s.Namespace(""))
+
+	ctxList := generateCtx(10, cc.CtxStatusWaiting)
+	assert.NoError(t, ns.Adds(chainID, ctxList, false))
+
+	// the same chain in different namespaces are isolated
+	assert.Equal(t, 10, ns.stores[chainID.Uint64()].Count())
+	assert.Equal(t, 0, s.stores[chainID.Uint64()].Count())
+	assert.Nil(t, s.Get(chainID, ctxList[0].ID()))
+	assert.NotNil(t, ns.Get(chainID, ctxList[0].ID()))
+
+	ns.Close() // namespace store wouldn't close db
+	assert.Equal(t, 10, ns.stores[chainID.Uint64()].Count())
+}
+
 func TestCrossStore_UpdatesReorg(t *testing.T) {
 	chainID := big.NewInt(10)
 	s, err := newStoreTester(chainID)
@@ -171,8 +197,90 @@ func TestCrossStore_UpdatesNormal(t *testing.T) {
 	}
 }
 
+func TestCrossStore_SubscribeCtxStatusEvent(t *testing.T) {
+	chainID := big.NewInt(10)
+	s, err := newStoreTester(chainID)
+	assert.NoError(t, err)
+	defer s.Close()
+
+	ch := make(chan cc.CtxStatusEvent, 3)
+	sub := s.SubscribeCtxStatusEvent(ch)
+	defer sub.Unsubscribe()
+
+	ctxList := generateCtx(3, cc.CtxStatusWaiting)
+	assert.NoError(t, s.Adds(chainID, ctxList, false))
+	for _, ctx := range ctxList {
+		ev := recvCtxStatusEvent(t, ch)
+		assert.Equal(t, ctx.ID(), ev.Tx.ID())
+		assert.Equal(t, cc.CtxStatusPending, ev.PrevStatus, "new ctx is changed from pending")
+	}
+
+	txmList := []*cc.CrossTransactionModifier{
+		{ID: ctxList[0].ID(), Type: cc.Normal, Status: cc.CtxStatusExecuting},
+		{ID: ctxList[1].ID(), Type: cc.Reorg, Status: cc.CtxStatusExecuting}, // ignored, reorg to higher status
+		{ID: ctxList[2].ID(), Type: cc.Remote, Status: cc.CtxStatusFinished},
+	}
+	assert.NoError(t, s.Updates(chainID, txmList))
+
+	ev := recvCtxStatusEvent(t, ch)
+	assert.Equal(t, ctxList[0].ID(), ev.Tx.ID())
+	assert.Equal(t, cc.CtxStatusWaiting, ev.PrevStatus)
+	assert.Equal(t, cc.CtxStatusExecuting, ev.Tx.Status)
+	ev = recvCtxStatusEvent(t, ch)
+	assert.Equal(t, ctxList[2].ID(), ev.Tx.ID())
+	assert.Equal(t, cc.CtxStatusWaiting, ev.PrevStatus)
+	assert.Equal(t, cc.CtxStatusFinished, ev.Tx.Status)
+
+	// rewriting the same status is not notified
+	assert.NoError(t, s.Adds(chainID, ctxList[:1], false))
+	assert.NoError(t, s.Updates(chainID, txmList[1:2]))
+	select {
+	case ev := <-ch:
+		t.Errorf("unchanged ctx %s is notified", ev.Tx.ID().String())
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestCrossStore_SubscribePendingToWaiting(t *testing.T) {
+	chainID := params.TestChainConfig.ChainID
+	s, err := newStoreTester(chainID)
+	assert.NoError(t, err)
+	defer s.Close()
+
+	ch := make(chan cc.CtxStatusEvent, 1)
+	sub := s.SubscribeCtxStatusEvent(ch)
+	defer sub.Unsubscribe()
+
+	pool := newPoolTester(s)
+	signTx, err := cc.SignCtx(generateCtx(1, cc.CtxStatusPending)[0].CrossTransaction(), cc.NewEIP155CtxSigner(chainID),
+		func(hash []byte) ([]byte, error) { return crypto.Sign(hash, pool.localKey) })
+	assert.NoError(t, err)
+
+	// local ctx is stored as pending until signed by enough anchors
+	ctx := cc.NewCrossTransactionWithSignatures(signTx, 1)
+	assert.NoError(t, s.Adds(chainID, []*cc.CrossTransactionWithSignatures{ctx}, false))
+
+	pool.Store(cc.NewCrossTransactionWithSignatures(signTx, 1))
+
+	ev := recvCtxStatusEvent(t, ch)
+	assert.Equal(t, ctx.ID(), ev.Tx.ID())
+	assert.Equal(t, cc.CtxStatusPending, ev.PrevStatus)
+	assert.Equal(t, cc.CtxStatusWaiting, ev.Tx.Status)
+	assert.Len(t, ch, 0, "stored pending ctx is not notified")
+}
+
+func recvCtxStatusEvent(t *testing.T, ch <-chan cc.CtxStatusEvent) cc.CtxStatusEvent {
+	select {
+	case ev := <-ch:
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("ctx status event timeout")
+	}
+	return cc.CtxStatusEvent{}
+}
+
 func newStoreTester(chainID *big.Int) (*CrossStore, error) {
-	store, err := NewCrossStore(nil, "testing-cross-store")
+	store, err := NewCrossStore(nil, cdb.StormEngine, "testing-cross-store")
 	if err != nil {
 		return nil, err
 	}
@@ -3,6 +3,7 @@ package synchronise
 import (
 	"errors"
 	"sync"
+	"sync/atomic"
 
 	"github.com/simplechain-org/go-simplechain/common"
 	"github.com/simplechain-org/go-simplechain/log"
@@ -13,10 +14,16 @@ var (
 	errNotRegistered     = errors.New("peer is not registered")
 )
 
+const (
+	maxPeerScore = 10
+	badPeerScore = -3 // peers scored below are not synced with
+)
+
 type peerConnection struct {
-	id   string
-	peer Peer
-	log  log.Logger
+	id    string
+	peer  Peer
+	score int32 // raised by valid responses, lowered by invalid or timed out ones
+	log   log.Logger
 }
 
 type Peer interface {
@@ -25,6 +32,14 @@ type Peer interface {
 	HasCrossTransaction(hash common.Hash) bool
 }
 
+// SnapshotPeer serves store snapshots and buckets, peers of legacy protocol are synced by height
+type SnapshotPeer interface {
+	Peer
+	SupportSnapshot() bool
+	RequestSnapshot(chain uint64) error
+	RequestBucket(chain uint64, index uint64, offset uint32) error
+}
+
 func newPeerConnection(id string, peer Peer, log log.Logger) *peerConnection {
 	return &peerConnection{
 		id:   id,
@@ -38,6 +53,29 @@ type peerSet struct {
 	lock  sync.RWMutex
 }
 
+func (p *peerConnection) snapshotPeer() SnapshotPeer {
+	if sp, ok := p.peer.(SnapshotPeer); ok && sp.SupportSnapshot() {
+		return sp
+	}
+	return nil
+}
+
+func (p *peerConnection) reward() {
+	if atomic.LoadInt32(&p.score) < maxPeerScore {
+		atomic.AddInt32(&p.score, 1)
+	}
+}
+
+func (p *peerConnection) penalize() {
+	if score := atomic.AddInt32(&p.score, -1); score < badPeerScore {
+		p.log.Warn("bad sync peer", "score", score)
+	}
+}
+
+func (p *peerConnection) isBad() bool {
+	return atomic.LoadInt32(&p.score) < badPeerScore
+}
+
 // newPeerSet creates a new peer set top track the active download sources.
 func newPeerSet() *peerSet {
 	return &peerSet{
@@ -95,6 +133,20 @@ func (ps *peerSet) Len() int {
 	return len(ps.peers)
 }
 
+// SnapshotPeers retrieves the peers serving snapshots which are not bad.
+func (ps *peerSet) SnapshotPeers() []*peerConnection {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*peerConnection, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		if p.snapshotPeer() != nil && !p.isBad() {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
 // AllPeers retrieves a flat list of all the peers within the set.
 func (ps *peerSet) AllPeers() []*peerConnection {
 	ps.lock.RLock()
@@ -0,0 +1,444 @@
+package synchronise
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/crypto"
+	"github.com/simplechain-org/go-simplechain/rlp"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+)
+
+const (
+	snapshotBucketSize = 1024 // block numbers in a bucket
+	maxBucketSyncSize  = 500  // ctxs in a bucket response
+	snapshotRangeLimit = 1000
+	maxSnapshotPeers   = 5 // peers to request snapshots from
+)
+
+// RangeStore ranges ctxs by block number, it is the CtxDB of the chain
+type RangeStore interface {
+	Height() uint64
+	RangeByNumber(begin, end uint64, limit int) []*cc.CrossTransactionWithSignatures
+}
+
+func bucketOf(number uint64) uint64 {
+	return number / snapshotBucketSize
+}
+
+// isSynced reports whether the ctx is synced between anchors, pending ctxs are signed from blocks by each anchor
+func isSynced(ctx *cc.CrossTransactionWithSignatures) bool {
+	return ctx.Status != cc.CtxStatusPending
+}
+
+// leafHash is the summary of ctx in bucket, ctxs differ in status are synced again
+func leafHash(ctx *cc.CrossTransactionWithSignatures) common.Hash {
+	enc, _ := rlp.EncodeToBytes([]interface{}{ctx.ID(), uint8(ctx.Status), ctx.BlockNum})
+	return crypto.Keccak256Hash(enc)
+}
+
+// merkleRoot hashes the leaves pairwise, the odd one is promoted to the upper level
+func merkleRoot(leaves []common.Hash) common.Hash {
+	if len(leaves) == 0 {
+		return common.Hash{}
+	}
+	level := append([]common.Hash{}, leaves...)
+	for len(level) > 1 {
+		next := make([]common.Hash, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, crypto.Keccak256Hash(level[i].Bytes(), level[i+1].Bytes()))
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// sortByID sorts ctxs of a bucket so the digest and the pages of bucket are stable
+func sortByID(ctxs []*cc.CrossTransactionWithSignatures) {
+	sort.Slice(ctxs, func(i, j int) bool {
+		id1, id2 := ctxs[i].ID(), ctxs[j].ID()
+		return bytes.Compare(id1[:], id2[:]) < 0
+	})
+}
+
+// digestOf returns the digest of ctxs in the bucket
+func digestOf(index uint64, ctxs []*cc.CrossTransactionWithSignatures) BucketDigest {
+	sortByID(ctxs)
+	leaves := make([]common.Hash, len(ctxs))
+	for i, ctx := range ctxs {
+		leaves[i] = leafHash(ctx)
+	}
+	return BucketDigest{Index: index, Count: uint32(len(ctxs)), Root: merkleRoot(leaves)}
+}
+
+// eachSynced calls fn with synced ctxs whose block number is in [begin, end] by block number
+func eachSynced(store RangeStore, begin, end uint64, fn func(ctx *cc.CrossTransactionWithSignatures)) {
+	for begin <= end {
+		list := store.RangeByNumber(begin, end, snapshotRangeLimit)
+		if len(list) == 0 {
+			break
+		}
+		for _, ctx := range list {
+			if ctx.BlockNum >= begin && ctx.BlockNum <= end && isSynced(ctx) {
+				fn(ctx)
+			}
+		}
+		last := list[len(list)-1].BlockNum
+		if last < begin || last == ^uint64(0) {
+			break
+		}
+		begin = last + 1
+	}
+}
+
+// BuildSnapshot summarizes the store by buckets of block number, empty buckets are omitted
+func BuildSnapshot(chainID uint64, store RangeStore) *SnapshotResp {
+	var (
+		height  = store.Height()
+		buckets []BucketDigest
+		current []*cc.CrossTransactionWithSignatures
+		index   uint64
+	)
+	flush := func() {
+		if len(current) > 0 {
+			buckets = append(buckets, digestOf(index, current))
+			current = nil
+		}
+	}
+	eachSynced(store, 0, height, func(ctx *cc.CrossTransactionWithSignatures) {
+		if b := bucketOf(ctx.BlockNum); b != index {
+			flush()
+			index = b
+		}
+		current = append(current, ctx)
+	})
+	flush()
+
+	leaves := make([]common.Hash, len(buckets))
+	for i, b := range buckets {
+		enc, _ := rlp.EncodeToBytes(b)
+		leaves[i] = crypto.Keccak256Hash(enc)
+	}
+	return &SnapshotResp{Chain: chainID, Height: height, Root: merkleRoot(leaves), Buckets: buckets}
+}
+
+// BucketPage returns synced ctxs of the bucket sorted by ID from offset, and the total of bucket
+func BucketPage(store RangeStore, index uint64, offset uint32, limit int) ([]*cc.CrossTransactionWithSignatures, uint32) {
+	var ctxs []*cc.CrossTransactionWithSignatures
+	eachSynced(store, index*snapshotBucketSize, (index+1)*snapshotBucketSize-1, func(ctx *cc.CrossTransactionWithSignatures) {
+		ctxs = append(ctxs, ctx)
+	})
+	sortByID(ctxs)
+	total := uint32(len(ctxs))
+	if offset >= total {
+		return nil, total
+	}
+	end := int(offset) + limit
+	if end > len(ctxs) {
+		end = len(ctxs)
+	}
+	return ctxs[offset:end], total
+}
+
+type snapshotPack struct {
+	peer string
+	resp *SnapshotResp
+}
+
+type bucketPack struct {
+	peer   string
+	index  uint64
+	offset uint32
+	total  uint32
+	ctxs   []*cc.CrossTransactionWithSignatures
+}
+
+// bucketTask is a bucket differs from the local one, it is fetched from peers advertising the digest
+type bucketTask struct {
+	digest BucketDigest
+	peers  map[string]bool
+}
+
+type bucketFetch struct {
+	task *bucketTask
+	ctxs []*cc.CrossTransactionWithSignatures
+	sent time.Time
+}
+
+// Snapshot returns the snapshot of local store
+func (s *Sync) Snapshot() *SnapshotResp {
+	return BuildSnapshot(s.chainID.Uint64(), s.store)
+}
+
+// Bucket returns a page of the bucket in local store
+func (s *Sync) Bucket(index uint64, offset uint32) ([]*cc.CrossTransactionWithSignatures, uint32) {
+	return BucketPage(s.store, index, offset, maxBucketSyncSize)
+}
+
+func (s *Sync) DeliverSnapshot(pid string, resp *SnapshotResp) error {
+	if s.peers.Peer(pid) == nil {
+		return errUnknownPeer
+	}
+	if atomic.LoadUint32(&s.synchronising) == 0 {
+		return errSyncNotStart
+	}
+	select {
+	case s.snapshotCh <- snapshotPack{peer: pid, resp: resp}:
+	default:
+		return errBusy
+	}
+	return nil
+}
+
+func (s *Sync) DeliverBucket(pid string, index uint64, offset, total uint32, ctxList []*cc.CrossTransactionWithSignatures) error {
+	if s.peers.Peer(pid) == nil {
+		return errUnknownPeer
+	}
+	if atomic.LoadUint32(&s.synchronising) == 0 {
+		return errSyncNotStart
+	}
+	select {
+	case s.bucketCh <- bucketPack{peer: pid, index: index, offset: offset, total: total, ctxs: ctxList}:
+	default:
+		return errBusy
+	}
+	return nil
+}
+
+// syncSnapshot compares snapshots of peers with the local one, and fetches the different buckets
+// from peers in parallel
+func (s *Sync) syncSnapshot(peers []*peerConnection) error {
+	if !atomic.CompareAndSwapUint32(&s.synchronising, 0, 1) {
+		s.log.Debug("sync busy")
+		return errBusy
+	}
+	defer atomic.StoreUint32(&s.synchronising, 0)
+
+	// ignore prev sync
+	for empty := false; !empty; {
+		select {
+		case <-s.snapshotCh:
+		case <-s.bucketCh:
+		default:
+			empty = true
+		}
+	}
+
+	rand.Shuffle(len(peers), func(i, j int) { peers[i], peers[j] = peers[j], peers[i] })
+	if len(peers) > maxSnapshotPeers {
+		peers = peers[:maxSnapshotPeers]
+	}
+	requested := make(map[string]*peerConnection, len(peers))
+	for _, p := range peers {
+		requested[p.id] = p
+		go p.snapshotPeer().RequestSnapshot(s.chainID.Uint64())
+	}
+
+	snapshots := make(map[string]*SnapshotResp, len(peers))
+	timeout := time.NewTimer(rttMaxEstimate)
+	defer timeout.Stop()
+	for len(snapshots) < len(requested) {
+		select {
+		case pack := <-s.snapshotCh:
+			p := requested[pack.peer]
+			if p == nil {
+				break
+			}
+			if !validSnapshot(pack.resp) {
+				p.log.Debug("invalid snapshot", "root", pack.resp.Root)
+				p.penalize()
+				delete(requested, pack.peer)
+				break
+			}
+			snapshots[pack.peer] = pack.resp
+
+		case <-timeout.C:
+			for id, p := range requested {
+				if snapshots[id] == nil {
+					p.penalize()
+					delete(requested, id)
+				}
+			}
+
+		case <-s.quitSync:
+			return errCanceled
+		}
+	}
+	if len(snapshots) == 0 {
+		return errTimeout
+	}
+
+	var peerHeight uint64
+	for _, snapshot := range snapshots {
+		if snapshot.Height > peerHeight {
+			peerHeight = snapshot.Height
+		}
+	}
+	defer s.updateLag(peerHeight)
+
+	tasks := diffSnapshot(s.Snapshot(), snapshots)
+	if len(tasks) == 0 {
+		s.log.Debug("store is same with peers, sync snapshot completed")
+		return nil
+	}
+	s.log.Info("start sync snapshot buckets", "peers", len(snapshots), "buckets", len(tasks))
+	synced, err := s.fetchBuckets(tasks, requested)
+	s.log.Info("Import cross transactions by snapshot", "chainID", s.chainID.Uint64(), "synced", synced, "error", err)
+	return err
+}
+
+// validSnapshot checks the root of snapshot is made by its buckets
+func validSnapshot(snapshot *SnapshotResp) bool {
+	leaves := make([]common.Hash, len(snapshot.Buckets))
+	for i, b := range snapshot.Buckets {
+		enc, _ := rlp.EncodeToBytes(b)
+		leaves[i] = crypto.Keccak256Hash(enc)
+	}
+	return merkleRoot(leaves) == snapshot.Root
+}
+
+// diffSnapshot returns the buckets of peers differ from the local ones, for each bucket the digest
+// advertised by most peers is fetched
+func diffSnapshot(local *SnapshotResp, snapshots map[string]*SnapshotResp) []*bucketTask {
+	localRoots := make(map[uint64]common.Hash, len(local.Buckets))
+	for _, b := range local.Buckets {
+		localRoots[b.Index] = b.Root
+	}
+	votes := make(map[uint64]map[BucketDigest]map[string]bool)
+	for pid, snapshot := range snapshots {
+		if snapshot.Root == local.Root {
+			continue
+		}
+		for _, b := range snapshot.Buckets {
+			if localRoots[b.Index] == b.Root {
+				continue
+			}
+			if votes[b.Index] == nil {
+				votes[b.Index] = make(map[BucketDigest]map[string]bool)
+			}
+			if votes[b.Index][b] == nil {
+				votes[b.Index][b] = make(map[string]bool)
+			}
+			votes[b.Index][b][pid] = true
+		}
+	}
+	tasks := make([]*bucketTask, 0, len(votes))
+	for _, digests := range votes {
+		var best *bucketTask
+		for digest, peers := range digests {
+			if best == nil || len(peers) > len(best.peers) ||
+				(len(peers) == len(best.peers) && digest.Count > best.digest.Count) {
+				best = &bucketTask{digest: digest, peers: peers}
+			}
+		}
+		tasks = append(tasks, best)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].digest.Index < tasks[j].digest.Index })
+	return tasks
+}
+
+// changedOf returns the ctxs of the fetched bucket differing from the local ones, the same ctxs are not written again
+func (s *Sync) changedOf(index uint64, ctxs []*cc.CrossTransactionWithSignatures) []*cc.CrossTransactionWithSignatures {
+	local := make(map[common.Hash]common.Hash)
+	eachSynced(s.store, index*snapshotBucketSize, (index+1)*snapshotBucketSize-1, func(ctx *cc.CrossTransactionWithSignatures) {
+		local[ctx.ID()] = leafHash(ctx)
+	})
+	changed := make([]*cc.CrossTransactionWithSignatures, 0, len(ctxs))
+	for _, ctx := range ctxs {
+		if leaf, ok := local[ctx.ID()]; !ok || leaf != leafHash(ctx) {
+			changed = append(changed, ctx)
+		}
+	}
+	return changed
+}
+
+// fetchBuckets fetches buckets from peers in parallel, one bucket from a peer at a time.
+// A bucket not matching its digest or timed out is fetched from another peer, and the peer is penalized.
+func (s *Sync) fetchBuckets(tasks []*bucketTask, peers map[string]*peerConnection) (synced int, err error) {
+	var (
+		chainID  = s.chainID.Uint64()
+		queue    = tasks
+		inflight = make(map[string]*bucketFetch)
+		ticker   = time.NewTicker(time.Second)
+	)
+	defer ticker.Stop()
+
+	assign := func() {
+		for pid, p := range peers {
+			if inflight[pid] != nil || p.isBad() {
+				continue
+			}
+			for i, task := range queue {
+				if !task.peers[pid] {
+					continue
+				}
+				queue = append(queue[:i], queue[i+1:]...)
+				inflight[pid] = &bucketFetch{task: task, sent: time.Now()}
+				go p.snapshotPeer().RequestBucket(chainID, task.digest.Index, 0)
+				break
+			}
+		}
+	}
+	retry := func(pid string, fetch *bucketFetch) {
+		delete(inflight, pid)
+		delete(fetch.task.peers, pid)
+		peers[pid].penalize()
+		if len(fetch.task.peers) > 0 {
+			queue = append(queue, fetch.task)
+		} else {
+			s.log.Warn("no peer to sync bucket", "index", fetch.task.digest.Index)
+		}
+	}
+
+	for assign(); len(queue) > 0 || len(inflight) > 0; assign() {
+		if len(inflight) == 0 {
+			return synced, errBadPeer
+		}
+		select {
+		case pack := <-s.bucketCh:
+			fetch := inflight[pack.peer]
+			if fetch == nil || fetch.task.digest.Index != pack.index || pack.offset != uint32(len(fetch.ctxs)) {
+				continue // stale response
+			}
+			fetch.ctxs = append(fetch.ctxs, pack.ctxs...)
+			if pack.total != fetch.task.digest.Count || (len(pack.ctxs) == 0 && uint32(len(fetch.ctxs)) < pack.total) {
+				retry(pack.peer, fetch)
+				continue
+			}
+			if uint32(len(fetch.ctxs)) < pack.total {
+				fetch.sent = time.Now()
+				go peers[pack.peer].snapshotPeer().RequestBucket(chainID, pack.index, uint32(len(fetch.ctxs)))
+				continue
+			}
+			if digestOf(pack.index, fetch.ctxs) != fetch.task.digest {
+				retry(pack.peer, fetch)
+				continue
+			}
+			delete(inflight, pack.peer)
+			peers[pack.peer].reward()
+			changed := s.changedOf(pack.index, fetch.ctxs)
+			sort.Sort(SortedTxByBlockNum(changed))
+			synced += s.syncCrossTransaction(changed)
+
+		case <-ticker.C:
+			for pid, fetch := range inflight {
+				if time.Since(fetch.sent) > rttMaxEstimate {
+					retry(pid, fetch)
+				}
+			}
+
+		case <-s.quitSync:
+			return synced, errCanceled
+		}
+	}
+	return synced, nil
+}
@@ -0,0 +1,112 @@
+package synchronise
+
+import (
+	"testing"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type snapshotTesterPeer struct {
+	*syncTesterPeer
+	corrupt bool // sends buckets mismatching its snapshot
+}
+
+func (sc *syncTester) newSnapshotPeer(id string, store *storeTester, corrupt bool) error {
+	peer := &snapshotTesterPeer{
+		syncTesterPeer: &syncTesterPeer{id: id, sc: sc, store: store},
+		corrupt:        corrupt,
+	}
+	sc.peers[peer.id] = peer.syncTesterPeer
+	return sc.synchronize.RegisterPeer(id, peer)
+}
+
+func (p *snapshotTesterPeer) SupportSnapshot() bool {
+	return true
+}
+
+func (p *snapshotTesterPeer) RequestSnapshot(chain uint64) error {
+	return p.sc.synchronize.DeliverSnapshot(p.id, BuildSnapshot(chain, p.store))
+}
+
+func (p *snapshotTesterPeer) RequestBucket(chain uint64, index uint64, offset uint32) error {
+	ctxList, total := BucketPage(p.store, index, offset, maxBucketSyncSize)
+	if p.corrupt {
+		corrupted := make([]*cc.CrossTransactionWithSignatures, len(ctxList))
+		for i, ctx := range ctxList {
+			corrupted[i] = &cc.CrossTransactionWithSignatures{
+				Data:     ctx.Data,
+				Status:   cc.CtxStatusIllegal,
+				BlockNum: ctx.BlockNum,
+			}
+		}
+		ctxList = corrupted
+	}
+	return p.sc.synchronize.DeliverBucket(p.id, index, offset, total, ctxList)
+}
+
+func TestBuildSnapshot(t *testing.T) {
+	store := newStoreTester()
+	store.generate(1, 10)
+	store.generate(snapshotBucketSize+1, 10)
+	store.generate(snapshotBucketSize*5, 10)
+
+	snapshot := BuildSnapshot(1, store)
+	assert.Len(t, snapshot.Buckets, 3)
+	assert.Equal(t, []uint64{0, 1, 5}, []uint64{snapshot.Buckets[0].Index, snapshot.Buckets[1].Index, snapshot.Buckets[2].Index})
+	assert.True(t, validSnapshot(snapshot))
+
+	ctxList, total := BucketPage(store, 1, 5, 3)
+	assert.Equal(t, uint32(10), total)
+	assert.Len(t, ctxList, 3)
+
+	// status changed
+	other := newStoreTester()
+	other.generate(1, 10)
+	other.generate(snapshotBucketSize+1, 10)
+	other.generate(snapshotBucketSize*5, 10)
+	assert.Equal(t, snapshot.Root, BuildSnapshot(1, other).Root)
+	other.get(encodeBlockNumber(1, 0)).Status = cc.CtxStatusFinished
+	diff := BuildSnapshot(1, other)
+	assert.NotEqual(t, snapshot.Root, diff.Root)
+	assert.NotEqual(t, snapshot.Buckets[0], diff.Buckets[0])
+	assert.Equal(t, snapshot.Buckets[1], diff.Buckets[1])
+}
+
+func TestSync_SynchroniseSnapshot(t *testing.T) {
+	sc := newTester()
+	defer sc.synchronize.Terminate()
+	store := newStoreTester()
+	assert.NoError(t, sc.newSnapshotPeer("pa", store, false))
+	assert.NoError(t, sc.newSnapshotPeer("pb", store, false))
+
+	store.generate(1, 100)
+	store.generate(3, 100)
+	store.generate(snapshotBucketSize*2, 1200) // paged bucket
+	store.generate(snapshotBucketSize*10, 100)
+	sc.store.generate(1, 100) // same bucket is skipped
+
+	assert.NoError(t, sc.sync("pa", nil))
+	assert.Equal(t, store.Height(), sc.store.Height())
+	assert.Equal(t, len(store.transactionm), len(sc.store.transactionm))
+	assert.Equal(t, BuildSnapshot(0, store).Root, BuildSnapshot(0, sc.store).Root)
+}
+
+func TestSync_SynchroniseSnapshotBadPeer(t *testing.T) {
+	sc := newTester()
+	defer sc.synchronize.Terminate()
+	store := newStoreTester()
+	assert.NoError(t, sc.newSnapshotPeer("pa", store, true))
+	assert.NoError(t, sc.newSnapshotPeer("pb", store, false))
+
+	for i := uint64(0); i < 8; i++ {
+		store.generate(i*snapshotBucketSize, 10)
+	}
+
+	assert.NoError(t, sc.sync("pb", nil))
+	assert.Equal(t, len(store.transactionm), len(sc.store.transactionm))
+	assert.Equal(t, BuildSnapshot(0, store).Root, BuildSnapshot(0, sc.store).Root)
+	assert.True(t, sc.synchronize.peers.Peer("pa").score < 0)
+	assert.True(t, sc.synchronize.peers.Peer("pb").score > 0)
+}
@@ -11,8 +11,10 @@ import (
 
 	"github.com/simplechain-org/go-simplechain/common"
 	cc "github.com/simplechain-org/go-simplechain/cross/core"
+	cm "github.com/simplechain-org/go-simplechain/cross/metric"
 	"github.com/simplechain-org/go-simplechain/cross/trigger"
 	"github.com/simplechain-org/go-simplechain/log"
+	"github.com/simplechain-org/go-simplechain/metrics"
 
 	"golang.org/x/sync/syncmap"
 )
@@ -22,6 +24,7 @@ const (
 	defaultMaxSyncSize     = 100
 	syncChannelSize        = 1
 	syncPendingChannelSize = 1
+	snapshotChannelSize    = maxSnapshotPeers
 )
 
 var (
@@ -36,6 +39,8 @@ var (
 type Sync struct {
 	synchronising  uint32
 	synchronizeCh  chan []*cc.CrossTransactionWithSignatures
+	snapshotCh     chan snapshotPack
+	bucketCh       chan bucketPack
 	pendingSyncing syncmap.Map // map[string]chan []*cc.CrossTransaction
 
 	peers *peerSet
@@ -45,6 +50,7 @@ type Sync struct {
 	pool    CrossPool
 	store   CrossStore
 	chain   CrossChain
+	lag     metrics.Gauge // store height behind the syncing peer
 
 	wg       sync.WaitGroup
 	quitSync chan struct{}
@@ -57,7 +63,7 @@ type CrossPool interface {
 }
 
 type CrossStore interface {
-	Height() uint64
+	RangeStore
 	Writes([]*cc.CrossTransactionWithSignatures, bool) error
 }
 
@@ -75,7 +81,10 @@ func New(chainID *big.Int, pool CrossPool, store CrossStore, chain CrossChain, m
 		pool:          pool,
 		store:         store,
 		chain:         chain,
+		lag:           cm.SyncLag(chainID.Uint64()),
 		synchronizeCh: make(chan []*cc.CrossTransactionWithSignatures, syncChannelSize),
+		snapshotCh:    make(chan snapshotPack, snapshotChannelSize),
+		bucketCh:      make(chan bucketPack, snapshotChannelSize),
 		quitSync:      make(chan struct{}),
 		log:           log.New("X-module", "sync", "chainID", chainID),
 	}
@@ -149,8 +158,14 @@ func (s *Sync) Synchronise(id string, height *big.Int) error {
 	if s.mode == OFF || s.mode == PENDING {
 		return nil
 	}
-	s.log.Info("start sync cross transactions", "peer", id, "height", height)
-	err := s.syncWithPeer(id, height)
+	var err error
+	if peers := s.peers.SnapshotPeers(); len(peers) > 0 {
+		s.log.Info("start sync cross transactions by snapshot", "peers", len(peers))
+		err = s.syncSnapshot(peers)
+	} else {
+		s.log.Info("start sync cross transactions", "peer", id, "height", height)
+		err = s.syncWithPeer(id, height)
+	}
 	switch err {
 	case nil:
 	case errBusy, errCanceled:
@@ -242,9 +257,11 @@ func (s *Sync) syncWithPeer(id string, peerHeight *big.Int) error {
 		}
 	}
 
+	s.updateLag(peerHeight.Uint64())
 	if height := s.store.Height(); height <= peerHeight.Uint64() {
 		go p.peer.RequestCtxSyncByHeight(s.chainID.Uint64(), height)
 	}
+	defer s.updateLag(peerHeight.Uint64())
 
 	timeout := time.NewTimer(rttMaxEstimate)
 	defer timeout.Stop()
@@ -273,6 +290,7 @@ func (s *Sync) syncWithPeer(id string, peerHeight *big.Int) error {
 			sort.Sort(sortedTxs)
 			self = s.syncCrossTransaction(sortedTxs)
 			lastHeight = sortedTxs.LastNumber()
+			s.updateLag(peerHeight.Uint64())
 			go p.peer.RequestCtxSyncByHeight(s.chainID.Uint64(), lastHeight+1)
 
 			log.Info("Import cross transactions", "chainID", s.chainID.Uint64(), "total", len(txs),
@@ -287,6 +305,15 @@ func (s *Sync) syncWithPeer(id string, peerHeight *big.Int) error {
 	}
 }
 
+// updateLag sets the blocks of store behind the peer
+func (s *Sync) updateLag(peerHeight uint64) {
+	var lag int64
+	if height := s.store.Height(); height < peerHeight {
+		lag = int64(peerHeight - height)
+	}
+	s.lag.Update(lag)
+}
+
 func (s *Sync) syncPendingWithPeer(id string, request []common.Hash) error {
 	p := s.peers.Peer(id)
 	if p == nil {
@@ -85,6 +85,10 @@ func (sc *syncTester) Height() uint64 {
 	return sc.store.Height()
 }
 
+func (sc *syncTester) RangeByNumber(begin, end uint64, limit int) []*cc.CrossTransactionWithSignatures {
+	return sc.store.rangeByNumber(begin, end, limit)
+}
+
 func (sc *syncTester) Writes(ctxList []*cc.CrossTransactionWithSignatures, replaceable bool) error {
 	return sc.store.Writes(ctxList, replaceable)
 }
@@ -176,8 +180,17 @@ func (st *storeTester) Writes(ctxList []*cc.CrossTransactionWithSignatures, repl
 	st.lock.Lock()
 	defer st.lock.Unlock()
 	for _, ctx := range ctxList {
-		if st.transactionm[ctx.ID()] != nil && !replaceable {
-			continue
+		if old := st.transactionm[ctx.ID()]; old != nil {
+			if !replaceable {
+				continue
+			}
+			// replace the old one in number index
+			for it := st.numberTree.LowerBound(old.BlockNum); it != st.numberTree.UpperBound(old.BlockNum); it.Next() {
+				if it.Value().(*cc.CrossTransactionWithSignatures).ID() == ctx.ID() {
+					st.numberTree.RemoveOne(it)
+					break
+				}
+			}
 		}
 		st.numberTree.Put(ctx.BlockNum, ctx)
 		st.transactionm[ctx.ID()] = ctx
@@ -199,6 +212,10 @@ func (st *storeTester) rangeByNumber(begin, end uint64, limit int) []*cc.CrossTr
 	return res
 }
 
+func (st *storeTester) RangeByNumber(begin, end uint64, limit int) []*cc.CrossTransactionWithSignatures {
+	return st.rangeByNumber(begin, end, limit)
+}
+
 func (st *storeTester) get(id common.Hash) *cc.CrossTransactionWithSignatures {
 	st.lock.RLock()
 	defer st.lock.RUnlock()
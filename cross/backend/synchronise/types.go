@@ -46,3 +46,35 @@ func (s SortedTxByBlockNum) LastNumber() uint64 {
 	}
 	return 0
 }
+
+// BucketDigest is the merkle root of synced ctxs whose block number is in the bucket
+type BucketDigest struct {
+	Index uint64
+	Count uint32
+	Root  common.Hash
+}
+
+type SnapshotReq struct {
+	Chain uint64
+}
+
+type SnapshotResp struct {
+	Chain   uint64
+	Height  uint64
+	Root    common.Hash // merkle root of buckets
+	Buckets []BucketDigest
+}
+
+type BucketReq struct {
+	Chain  uint64
+	Index  uint64
+	Offset uint32
+}
+
+type BucketResp struct {
+	Chain  uint64
+	Index  uint64
+	Offset uint32
+	Total  uint32
+	Data   [][]byte
+}
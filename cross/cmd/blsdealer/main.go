@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"path/filepath"
+	"strings"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/crypto/bls"
+)
+
+var (
+	thresholdVar = flag.Int("threshold", 2, "恢复聚合签名所需的签名数量(与signConfirm一致)")
+
+	anchorsVar = flag.String("anchors", "", "锚定节点地址列表(逗号分隔)，第i个地址持有第i个密钥分片")
+
+	outVar = flag.String("out", ".", "密钥分片输出目录")
+)
+
+func main() {
+	flag.Parse()
+	dealer()
+}
+
+// dealer 生成BLS门限密钥分片，每个锚定节点使用 --anchor.blskey 加载自己的分片，
+// 分片文件包含所有分片的公钥及其绑定的锚定节点地址，用于逐个验证其他锚定节点的签名分片，
+// 输出的聚合公钥通过合约 setBLSPublicKey 登记
+func dealer() {
+	var anchors []common.Address
+	for _, addr := range strings.Split(*anchorsVar, ",") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		if !common.IsHexAddress(addr) {
+			log.Fatalln("invalid anchor address:", addr)
+		}
+		anchors = append(anchors, common.HexToAddress(addr))
+	}
+	shares, err := bls.GenerateShares(rand.Reader, *thresholdVar, len(anchors))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	for _, share := range shares {
+		share.Anchors = anchors
+		file := filepath.Join(*outVar, fmt.Sprintf("blskey%d.json", share.Index))
+		if err := bls.SaveKeyShare(file, share); err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Println("key share", share.Index, "of", share.Anchors[share.Index-1].String(), "saved to", file,
+			"public key", common.Bytes2Hex(share.Publics[share.Index-1].Bytes()))
+	}
+	master := shares[0].Master.Bytes()
+	fmt.Printf("master public key: [%s,%s,%s,%s]\n",
+		new(big.Int).SetBytes(master[:32]), new(big.Int).SetBytes(master[32:64]),
+		new(big.Int).SetBytes(master[64:96]), new(big.Int).SetBytes(master[96:]))
+}
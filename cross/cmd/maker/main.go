@@ -24,6 +24,8 @@ var (
 
 	destValue = flag.Uint64("destValue", 1e+18, "兑换数量")
 
+	feeVar = flag.Uint64("fee", 0, "支付给锚定节点的中继费")
+
 	chainId = flag.Uint64("chainId", 512, "目的链id")
 
 	fromVar = flag.String("from", "0x7964576407c299ec0e65991ba74019d622316a0d", "发起人地址")
@@ -78,9 +80,10 @@ func maker() {
 	remoteChainId := new(big.Int).SetUint64(*chainId)
 
 	des := new(big.Int).SetUint64(*destValue)
+	fee := new(big.Int).SetUint64(*feeVar)
 
 	//out, err := abi.Pack("makerStart",remoteChainId ,des,[]byte("In the end, it’s not the years in your life that count. It’s the life in your years."))
-	out, err := abi.Pack("makerStart", remoteChainId, des, focusAddr, []byte{})
+	out, err := abi.Pack("makerStart", remoteChainId, des, focusAddr, []byte{}, fee)
 	if err != nil {
 		fmt.Println(err)
 		return
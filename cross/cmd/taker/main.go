@@ -52,6 +52,7 @@ type RPCCrossTransaction struct {
 	V                []*hexutil.Big `json:"V"`
 	R                []*hexutil.Big `json:"R"`
 	S                []*hexutil.Big `json:"S"`
+	Aggregate        hexutil.Bytes  `json:"aggregate"`
 }
 
 type RPCPageCrossTransactions struct {
@@ -73,6 +74,19 @@ type Order struct {
 	S                [][32]byte
 }
 
+// AggregateOrder is taken by the BLS aggregate signature
+type AggregateOrder struct {
+	Value            *big.Int
+	TxId             common.Hash
+	TxHash           common.Hash
+	From             common.Address
+	To               common.Address
+	BlockHash        common.Hash
+	DestinationValue *big.Int
+	Data             []byte
+	Signature        [2]*big.Int
+}
+
 var signatures map[string]RPCPageCrossTransactions
 
 func main() {
@@ -151,7 +165,22 @@ func taker() {
 				S:                s,
 			}
 
-			out, err := abi.Pack("taker", &ord, chainId)
+			var out []byte
+			if len(v.Aggregate) == 64 { //锚定节点开启了BLS聚合签名
+				out, err = abi.Pack("takerAggregate", &AggregateOrder{
+					Value:            ord.Value,
+					TxId:             ord.TxId,
+					TxHash:           ord.TxHash,
+					From:             ord.From,
+					To:               ord.To,
+					BlockHash:        ord.BlockHash,
+					DestinationValue: ord.DestinationValue,
+					Data:             ord.Data,
+					Signature:        [2]*big.Int{new(big.Int).SetBytes(v.Aggregate[:32]), new(big.Int).SetBytes(v.Aggregate[32:])},
+				}, chainId)
+			} else {
+				out, err = abi.Pack("taker", &ord, chainId)
+			}
 			if err != nil {
 				fmt.Println("abi.Pack err=", err)
 				continue
@@ -1,11 +1,15 @@
 package cross
 
-import "github.com/simplechain-org/go-simplechain/common"
+import (
+	"fmt"
+
+	"github.com/simplechain-org/go-simplechain/common"
+)
 
 const (
-	LogDir   = "crosslog"
 	TxLogDir = "crosstxlog"
 	DataDir  = "crossdata"
+	IndexDir = "crossindex" // ctx store of the leveldb engine
 )
 
 type Config struct {
@@ -13,6 +17,23 @@ type Config struct {
 	SubContract  common.Address   `json:"subContract"`
 	Signer       common.Address   `json:"signer"`
 	Anchors      []common.Address `json:"anchors"`
+	Chains       []ChainConfig    `json:"chains"`       // remote chains bridged with the main chain
+	ExpireNumber uint64           `json:"expireNumber"` // blocks before an untaken ctx is refundable, 0 means never
+	BLSKey       string           `json:"blsKey"`       // file of anchor's BLS key share, aggregate signatures are disabled if empty
+	StoreEngine  string           `json:"storeEngine"`  // storage engine of ctx stores (storm or leveldb), storm if empty
+	ClaimPeriod  uint64           `json:"claimPeriod"`  // seconds between claiming relay fees from cross contracts, 0 means never
+	ExpireCheck  uint64           `json:"expireCheck"`  // seconds between checking expired ctxs, every minute if 0
+}
+
+// ChainConfig describes a remote chain which is paired with the main chain
+type ChainConfig struct {
+	ChainID  uint64           `json:"chainId"`
+	Contract common.Address   `json:"contract"`
+	Anchors  []common.Address `json:"anchors"`
+	RPC      string           `json:"rpc"` // websocket endpoint to follow the chain which is not running in this node
+
+	Consensus    string `json:"consensus"`    // consensus engine of the rpc chain (ethash, scrypt, clique, dpos, raft, istanbul), ethash if empty
+	ConfirmDepth uint64 `json:"confirmDepth"` // blocks to confirm a block of PoW chains, the default depth is used if 0
 }
 
 func (config *Config) Sanitize() Config {
@@ -20,13 +41,104 @@ func (config *Config) Sanitize() Config {
 		MainContract: config.MainContract,
 		SubContract:  config.SubContract,
 		Signer:       config.Signer,
+		Anchors:      sanitizeAnchors(config.Anchors),
+		ExpireNumber: config.ExpireNumber,
+		BLSKey:       config.BLSKey,
+		StoreEngine:  config.StoreEngine,
+		ClaimPeriod:  config.ClaimPeriod,
+		ExpireCheck:  config.ExpireCheck,
+	}
+	set := make(map[uint64]struct{})
+	for _, chain := range config.Chains {
+		if _, ok := set[chain.ChainID]; !ok {
+			cfg.Chains = append(cfg.Chains, ChainConfig{
+				ChainID:  chain.ChainID,
+				Contract: chain.Contract,
+				Anchors:  sanitizeAnchors(chain.Anchors),
+				RPC:      chain.RPC,
+
+				Consensus:    chain.Consensus,
+				ConfirmDepth: chain.ConfirmDepth,
+			})
+			set[chain.ChainID] = struct{}{}
+		}
+	}
+	return cfg
+}
+
+// Validate rejects remote chains which can not be followed by this node, only the sub chain
+// running in this node is followed without an rpc endpoint
+func (config *Config) Validate() error {
+	var local []uint64
+	for _, chain := range config.Chains {
+		if chain.RPC == "" {
+			local = append(local, chain.ChainID)
+		}
+	}
+	if len(local) > 1 {
+		return fmt.Errorf("cross chains %v have no rpc endpoint, only the sub chain of this node can be paired without rpc", local)
+	}
+	return nil
+}
+
+// ValidateChains rejects remote chains which are the main chain, or have no rpc endpoint but are not
+// the sub chain running in this node
+func (config *Config) ValidateChains(mainChainID, subChainID uint64) error {
+	for _, chain := range config.RemoteChains(subChainID) {
+		switch {
+		case chain.ChainID == mainChainID:
+			return fmt.Errorf("cross chain %d is the main chain of this node, it can't be paired with itself", chain.ChainID)
+		case chain.RPC == "" && chain.ChainID != subChainID:
+			return fmt.Errorf("cross chain %d has no rpc endpoint, only the sub chain %d of this node can be paired without rpc", chain.ChainID, subChainID)
+		}
+	}
+	return nil
+}
+
+// RemoteChains returns the configured remote chains, a single chain made from SubContract
+// is returned if no chain is configured (the legacy main/sub pair)
+func (config *Config) RemoteChains(subChainID uint64) []ChainConfig {
+	if len(config.Chains) > 0 {
+		return config.Chains
+	}
+	return []ChainConfig{{ChainID: subChainID, Contract: config.SubContract, Anchors: config.Anchors}}
+}
+
+// LegacyPair reports whether the remote chain is the main/sub pair migrated from the legacy config,
+// which is the sub chain of this node with the legacy SubContract. The pair keeps the legacy
+// protocol name and store namespace, other pairs are keyed by the chain ID of remote chain.
+func (config *Config) LegacyPair(remote ChainConfig) bool {
+	return remote.RPC == "" && remote.Contract == config.SubContract
+}
+
+// PairConfig makes a copy of config for the main chain paired with the remote chain,
+// the anchors of config are used if the remote chain has none
+func (config *Config) PairConfig(remote ChainConfig) Config {
+	anchors := remote.Anchors
+	if len(anchors) == 0 {
+		anchors = config.Anchors
+	}
+	return Config{
+		MainContract: config.MainContract,
+		SubContract:  remote.Contract,
+		Signer:       config.Signer,
+		Anchors:      append([]common.Address{}, anchors...),
+		ExpireNumber: config.ExpireNumber,
+		BLSKey:       config.BLSKey,
+		StoreEngine:  config.StoreEngine,
+		ClaimPeriod:  config.ClaimPeriod,
+		ExpireCheck:  config.ExpireCheck,
 	}
+}
+
+func sanitizeAnchors(anchors []common.Address) []common.Address {
+	var result []common.Address
 	set := make(map[common.Address]struct{})
-	for _, anchor := range config.Anchors {
+	for _, anchor := range anchors {
 		if _, ok := set[anchor]; !ok {
-			cfg.Anchors = append(cfg.Anchors, anchor)
+			result = append(result, anchor)
 			set[anchor] = struct{}{}
 		}
 	}
-	return cfg
+	return result
 }
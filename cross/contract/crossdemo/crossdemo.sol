@@ -7,6 +7,15 @@ contract crossDemo{
     //其他链的信息
     mapping (uint => Chain) public crossChains;
 
+    //锚定节点BLS门限签名的聚合公钥(G2) remoteChainId => [x.imag, x.real, y.imag, y.real]
+    mapping (uint => uint256[4]) blsPublicKeys;
+
+    //bn256曲线的基域模数
+    uint256 constant FIELD_MODULUS = 0x30644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd47;
+
+    //跨链消息在目标合约上调用的方法 onCrossMessage(uint256 remoteChainId, bytes32 txId, address from, bytes data)
+    bytes4 constant MESSAGE_SELECTOR = 0xc6d06c69;
+
     //仅做信息登记，关联chainId
     struct Chain{
         uint remoteChainId;
@@ -23,6 +32,14 @@ contract crossDemo{
         uint8 delId;
         uint reward;
         uint totalReward;
+        mapping(bytes32=>mapping(address=>bool)) cancelSigns; //过期交易取消签名 txId => anchor => signed
+        mapping(bytes32=>uint8) cancelCount;
+        mapping(bytes32=>uint256) takerFilled; //部分成交 已成交的destValue txId => filled
+        mapping(bytes32=>mapping(address=>bool)) fillSigns; //部分成交结算签名 fillId => anchor => signed
+        mapping(bytes32=>uint8) fillCount;
+        mapping(bytes32=>MessageInfo) messages; //跨链消息 txId => MessageInfo, 回执后删除
+        mapping(bytes32=>uint8) messageReceipts; //跨链消息回执 txId => 1 投递成功, 2 目标合约执行失败
+        mapping(address=>uint256) relayFees; //锚定节点已赚取未领取的中继费 anchor => fee
     }
 
     struct Anchor {
@@ -40,23 +57,55 @@ contract crossDemo{
         address payable from;
         address payable to;
         bytes32 takerHash;
+        uint256 destValue;
+        uint256 filled; //部分成交已结算的destValue
+        uint256 fee; //maker支付给锚定节点的中继费
+        uint256 feePaid; //已计入锚定节点的中继费
+    }
+
+    struct MessageInfo {
+        uint256 value; //支付给锚定节点的手续费
+        uint8 signatureCount;
+        mapping (address => uint8) signatures;
+        address from;
     }
 
     //创建交易 maker
     event MakerTx(bytes32 indexed txId, address indexed from, address to, uint remoteChainId, uint value, uint destValue,bytes data);
 
     event MakerFinish(bytes32 indexed txId, address indexed to);
+    //批量结算 maker, 每笔结算的结果, success为false时该笔被跳过
+    event MakerFinishResult(bytes32 indexed txId, address indexed anchor, uint remoteChainId, bool success);
     //达成交易 taker
     event TakerTx(bytes32 indexed txId, address indexed to, uint remoteChainId, address from,uint value, uint destValue);
+    //部分吃单 taker, fillValue为本次成交的destValue, filled为累计成交的destValue
+    event TakerPartial(bytes32 indexed txId, address indexed to, uint remoteChainId, address from, uint fillValue, uint filled, uint destValue);
+    //部分成交结算 maker, value为本次支付给taker的value
+    event MakerPartialFinish(bytes32 indexed txId, address indexed to, uint value, uint fillValue);
+    //跨链消息投递 目的链, success为目标合约的执行结果
+    event MessageDelivered(bytes32 indexed txId, address indexed target, uint remoteChainId, address from, bool success);
+    //跨链消息回执 源链
+    event MessageReceipt(bytes32 indexed txId, address indexed from, bool success);
 
     event AddAnchors(uint remoteChainId);
 
     event RemoveAnchors(uint remoteChainId);
 
     event AccumulateRewards(uint remoteChainId, address indexed anchor, uint reward);
+    //锚定节点结算签名后计入的中继费
+    event RelayFee(bytes32 indexed txId, address indexed anchor, uint remoteChainId, uint fee);
+    //锚定节点领取中继费
+    event ClaimRelayFee(uint remoteChainId, address indexed anchor, uint fee);
 
     event SetAnchorStatus(uint remoteChainId);
 
+    //过期交易退款 maker
+    event MakerCancel(bytes32 indexed txId, address indexed from);
+    //过期交易禁止吃单 taker
+    event TakerCancel(bytes32 indexed txId, address indexed anchor, uint remoteChainId);
+    //锚定节点对同一txId签名了不同的交易，已被停用
+    event AnchorEquivocation(bytes32 indexed txId, address indexed anchor, uint remoteChainId);
+
     modifier onlyAnchor(uint remoteChainId) {
         require(crossChains[remoteChainId].remoteChainId > 0,"remoteChainId not exist");
         require(crossChains[remoteChainId].anchors[msg.sender].remoteChainId == remoteChainId,"not anchors");
@@ -89,6 +138,31 @@ contract crossDemo{
         emit AccumulateRewards(remoteChainId, anchor, reward);
     }
 
+    function getRelayFee(uint remoteChainId, address anchor) public view returns(uint) { return crossChains[remoteChainId].relayFees[anchor]; }
+
+    //锚定节点领取已赚取的中继费，已移除的锚定节点也可以领取
+    function claimRelayFee(uint remoteChainId) public {
+        uint fee = crossChains[remoteChainId].relayFees[msg.sender];
+        require(fee > 0,"no relay fee");
+        crossChains[remoteChainId].relayFees[msg.sender] = 0;
+        msg.sender.transfer(fee);
+        emit ClaimRelayFee(remoteChainId, msg.sender, fee);
+    }
+
+    //结算签名的锚定节点平分中继费，share为本次结算的中继费
+    function payRelayFee(bytes32 txId, uint remoteChainId, MakerInfo storage info, uint share, address anchor) private {
+        uint fee = share / crossChains[remoteChainId].signConfirmCount;
+        if (info.feePaid + fee > info.fee) {
+            fee = info.fee - info.feePaid;
+        }
+        if (fee == 0) {
+            return;
+        }
+        info.feePaid += fee;
+        crossChains[remoteChainId].relayFees[anchor] += fee;
+        emit RelayFee(txId, anchor, remoteChainId, fee);
+    }
+
     //登记链信息 管理员操作
     function chainRegister(uint remoteChainId,uint maxValue, uint8 signConfirmCount, address[] memory _anchors) public onlyOwner returns(bool) {
         require (crossChains[remoteChainId].remoteChainId == 0,"remoteChainId already exist");
@@ -198,6 +272,10 @@ contract crossDemo{
     }
 
     function setAnchorStatus(uint remoteChainId, address _anchor,bool status) public onlyOwner {
+        updateAnchorStatus(remoteChainId, _anchor, status);
+    }
+
+    function updateAnchorStatus(uint remoteChainId, address _anchor,bool status) private {
         if (!status) {
             uint8 j=0;
             for (uint8 i=0; i<crossChains[remoteChainId].anchorAddress.length; i++) {
@@ -228,8 +306,18 @@ contract crossDemo{
         crossChains[remoteChainId].maxValue = maxValue;
     }
 
+    //登记锚定节点BLS聚合公钥 管理员操作
+    function setBLSPublicKey(uint remoteChainId, uint256[4] memory publicKey) public onlyOwner {
+        require (crossChains[remoteChainId].remoteChainId > 0,"remoteChainId not exist");
+        blsPublicKeys[remoteChainId] = publicKey;
+    }
+
+    function getBLSPublicKey(uint remoteChainId) public view returns(uint256[4] memory){
+        return blsPublicKeys[remoteChainId];
+    }
+
     function getMakerTx(bytes32 txId, uint remoteChainId) public view returns(uint){
-        return crossChains[remoteChainId].makerTxs[txId].value;
+        return crossChains[remoteChainId].makerTxs[txId].value + crossChains[remoteChainId].messages[txId].value;
     }
 
     function getTakerTx(bytes32 txId, uint remoteChainId) public view returns(uint){
@@ -262,18 +350,23 @@ contract crossDemo{
         return (crossChains[remoteChainId].delAnchors[_anchor].signCount);
     }
 
-    //增加跨链交易
-    function makerStart(uint remoteChainId, uint destValue, address payable focus, bytes memory data) public payable {
-        require(msg.value > crossChains[remoteChainId].reward && msg.value < crossChains[remoteChainId].maxValue,"value out of range");
+    //增加跨链交易，fee为支付给锚定节点的中继费，结算时由签名的锚定节点平分
+    function makerStart(uint remoteChainId, uint destValue, address payable focus, bytes memory data, uint fee) public payable {
+        require(msg.value > crossChains[remoteChainId].reward + fee && msg.value < crossChains[remoteChainId].maxValue,"value out of range");
         require(crossChains[remoteChainId].remoteChainId > 0,"chainId not exist"); //是否支持的跨链
+        require(!isMessage(data),"message data");
         bytes32 txId = keccak256(abi.encodePacked(msg.sender, list(), remoteChainId));
-        assert(crossChains[remoteChainId].makerTxs[txId].value == 0);
+        assert(crossChains[remoteChainId].makerTxs[txId].value == 0 && crossChains[remoteChainId].messages[txId].value == 0);
         crossChains[remoteChainId].makerTxs[txId] = MakerInfo({
-            value:(msg.value - crossChains[remoteChainId].reward),
+            value:(msg.value - crossChains[remoteChainId].reward - fee),
             signatureCount:0,
             to:focus,
             from:msg.sender,
-            takerHash:bytes32(0x0)
+            takerHash:bytes32(0x0),
+            destValue:destValue,
+            filled:0,
+            fee:fee,
+            feePaid:0
             });
         uint total = crossChains[remoteChainId].totalReward + crossChains[remoteChainId].reward;
         assert(total >= crossChains[remoteChainId].totalReward);
@@ -281,6 +374,55 @@ contract crossDemo{
         emit MakerTx(txId, msg.sender, focus, remoteChainId, msg.value, destValue, data);
     }
 
+    //发送跨链消息，锚定节点签名后在目的链调用target的onCrossMessage，msg.value全部作为锚定节点的手续费
+    function sendMessage(uint remoteChainId, address target, bytes memory data) public payable {
+        require(msg.value > crossChains[remoteChainId].reward && msg.value < crossChains[remoteChainId].maxValue,"value out of range");
+        require(crossChains[remoteChainId].remoteChainId > 0,"chainId not exist");
+        require(target != address(0x0),"target is empty");
+        bytes32 txId = keccak256(abi.encodePacked(msg.sender, list(), remoteChainId));
+        assert(crossChains[remoteChainId].makerTxs[txId].value == 0 && crossChains[remoteChainId].messages[txId].value == 0);
+        crossChains[remoteChainId].messages[txId].value = msg.value;
+        crossChains[remoteChainId].messages[txId].from = msg.sender;
+        uint total = crossChains[remoteChainId].totalReward + msg.value;
+        assert(total >= crossChains[remoteChainId].totalReward);
+        crossChains[remoteChainId].totalReward = total;
+        emit MakerTx(txId, msg.sender, target, remoteChainId, msg.value, 0, abi.encodeWithSelector(MESSAGE_SELECTOR, chainId(), txId, msg.sender, data));
+    }
+
+    //锚定节点执行，目的链投递后在源链记录回执，签名达到signConfirmCount后生效
+    function messageReceipt(bytes32 txId, bool success, uint remoteChainId) public onlyAnchor(remoteChainId) {
+        require(crossChains[remoteChainId].anchors[msg.sender].status);
+        MessageInfo storage info = crossChains[remoteChainId].messages[txId];
+        require(info.value > 0,"message not exist");
+        require(info.signatures[msg.sender] != 1,"already signed");
+        info.signatures[msg.sender] = 1;
+        info.signatureCount ++;
+        crossChains[remoteChainId].anchors[msg.sender].finishCount ++;
+
+        if (info.signatureCount >= crossChains[remoteChainId].signConfirmCount){
+            address from = info.from;
+            crossChains[remoteChainId].messageReceipts[txId] = success ? 1 : 2;
+            delete crossChains[remoteChainId].messages[txId];
+            emit MessageReceipt(txId, from, success);
+        }
+    }
+
+    function getMessageReceipt(bytes32 txId, uint remoteChainId) public view returns(uint8){
+        return crossChains[remoteChainId].messageReceipts[txId];
+    }
+
+    function isMessage(bytes memory data) private pure returns (bool) {
+        if (data.length < 4) {
+            return false;
+        }
+        for (uint i = 0; i < 4; i++) {
+            if (data[i] != MESSAGE_SELECTOR[i]) {
+                return false;
+            }
+        }
+        return true;
+    }
+
     struct Recept {
         bytes32 txId;
         bytes32 txHash;
@@ -294,19 +436,121 @@ contract crossDemo{
         require(crossChains[remoteChainId].makerTxs[rtx.txId].value > 0);
         require(crossChains[remoteChainId].makerTxs[rtx.txId].to == address(0x0) || crossChains[remoteChainId].makerTxs[rtx.txId].to == rtx.to || crossChains[remoteChainId].makerTxs[rtx.txId].from == rtx.to,"to is error");
         require(crossChains[remoteChainId].makerTxs[rtx.txId].takerHash == bytes32(0x0) || crossChains[remoteChainId].makerTxs[rtx.txId].takerHash == rtx.txHash,"txHash is error");
+        require(crossChains[remoteChainId].makerTxs[rtx.txId].filled == 0,"partially filled");
+        signFinish(rtx, remoteChainId);
+    }
+
+    //锚定节点批量执行makerFinish，不满足条件的单笔被跳过而不回滚整批交易，每笔结果由MakerFinishResult报告
+    function makerFinishBatch(Recept[] memory rtxs,uint remoteChainId) public onlyAnchor(remoteChainId) {
+        require(crossChains[remoteChainId].anchors[msg.sender].status);
+        for (uint i = 0; i < rtxs.length; i++) {
+            bool success = canFinish(rtxs[i], remoteChainId);
+            if (success) {
+                signFinish(rtxs[i], remoteChainId);
+            }
+            emit MakerFinishResult(rtxs[i].txId, msg.sender, remoteChainId, success);
+        }
+    }
+
+    //与makerFinish的校验条件相同
+    function canFinish(Recept memory rtx,uint remoteChainId) internal view returns(bool) {
+        MakerInfo storage info = crossChains[remoteChainId].makerTxs[rtx.txId];
+        return info.signatures[msg.sender] != 1 && info.value > 0 &&
+            (info.to == address(0x0) || info.to == rtx.to || info.from == rtx.to) &&
+            (info.takerHash == bytes32(0x0) || info.takerHash == rtx.txHash) &&
+            info.filled == 0;
+    }
+
+    //记录锚定节点的结算签名，达到signConfirmCount后向taker支付
+    function signFinish(Recept memory rtx,uint remoteChainId) internal {
         crossChains[remoteChainId].makerTxs[rtx.txId].signatures[msg.sender] = 1;
         crossChains[remoteChainId].makerTxs[rtx.txId].signatureCount ++;
         crossChains[remoteChainId].makerTxs[rtx.txId].to = rtx.to;
         crossChains[remoteChainId].makerTxs[rtx.txId].takerHash = rtx.txHash;
         crossChains[remoteChainId].anchors[msg.sender].finishCount ++;
+        payRelayFee(rtx.txId, remoteChainId, crossChains[remoteChainId].makerTxs[rtx.txId], crossChains[remoteChainId].makerTxs[rtx.txId].fee, msg.sender);
 
         if (crossChains[remoteChainId].makerTxs[rtx.txId].signatureCount >= crossChains[remoteChainId].signConfirmCount){
             rtx.to.transfer(crossChains[remoteChainId].makerTxs[rtx.txId].value);
+            //除不尽的中继费计入totalReward
+            crossChains[remoteChainId].totalReward += crossChains[remoteChainId].makerTxs[rtx.txId].fee - crossChains[remoteChainId].makerTxs[rtx.txId].feePaid;
             delete crossChains[remoteChainId].makerTxs[rtx.txId];
             emit MakerFinish(rtx.txId,rtx.to);
         }
     }
 
+    //锚定节点执行，按目的链部分吃单的成交量结算，每笔部分吃单(txHash)单独计签名
+    function makerPartialFinish(Recept memory rtx,uint remoteChainId,uint fillValue) public onlyAnchor(remoteChainId) {
+        require(crossChains[remoteChainId].anchors[msg.sender].status);
+        MakerInfo storage info = crossChains[remoteChainId].makerTxs[rtx.txId];
+        require(info.value > 0);
+        require(info.takerHash == bytes32(0x0),"already taken");
+        require(info.to == address(0x0) || info.to == rtx.to || info.from == rtx.to,"to is error");
+        require(fillValue > 0 && info.filled + fillValue <= info.destValue,"fill out of range");
+        bytes32 fillId = keccak256(abi.encodePacked(rtx.txId, rtx.txHash, rtx.to, fillValue));
+        require(!crossChains[remoteChainId].fillSigns[fillId][msg.sender],"already signed");
+        crossChains[remoteChainId].fillSigns[fillId][msg.sender] = true;
+        crossChains[remoteChainId].fillCount[fillId] ++;
+        crossChains[remoteChainId].anchors[msg.sender].finishCount ++;
+
+        if (crossChains[remoteChainId].fillCount[fillId] == crossChains[remoteChainId].signConfirmCount){
+            //每笔部分成交按fillValue占destValue的比例支付中继费，达到signConfirmCount后由签名的锚定节点平分
+            uint share = info.fee * fillValue / info.destValue;
+            for (uint8 i=0; i<crossChains[remoteChainId].anchorAddress.length; i++) {
+                address anchor = crossChains[remoteChainId].anchorAddress[i];
+                if (crossChains[remoteChainId].fillSigns[fillId][anchor]) {
+                    payRelayFee(rtx.txId, remoteChainId, info, share, anchor);
+                }
+            }
+            //按剩余value与剩余destValue的比例结算，最后一笔结算全部剩余value
+            uint value = info.value * fillValue / (info.destValue - info.filled);
+            info.value -= value;
+            info.filled += fillValue;
+            rtx.to.transfer(value);
+            emit MakerPartialFinish(rtx.txId, rtx.to, value, fillValue);
+            if (info.value == 0) {
+                crossChains[remoteChainId].totalReward += info.fee - info.feePaid;
+                delete crossChains[remoteChainId].makerTxs[rtx.txId];
+                emit MakerFinish(rtx.txId,rtx.to);
+            }
+        }
+    }
+
+    //锚定节点执行，目的链上禁止已过期的挂单被吃单，签名达到signConfirmCount后生效，部分成交的挂单禁止吃剩余部分
+    function takerCancel(bytes32 txId, uint remoteChainId) public onlyAnchor(remoteChainId) {
+        require(crossChains[remoteChainId].anchors[msg.sender].status);
+        require(crossChains[remoteChainId].takerTxs[txId] == 0,"txId exist");
+        require(!crossChains[remoteChainId].cancelSigns[txId][msg.sender],"already signed");
+        crossChains[remoteChainId].cancelSigns[txId][msg.sender] = true;
+        crossChains[remoteChainId].cancelCount[txId] ++;
+
+        if (crossChains[remoteChainId].cancelCount[txId] >= crossChains[remoteChainId].signConfirmCount){
+            crossChains[remoteChainId].takerTxs[txId] = uint(-1);
+            delete crossChains[remoteChainId].cancelCount[txId];
+            emit TakerCancel(txId, msg.sender, remoteChainId);
+        }
+    }
+
+    //锚定节点执行，目的链takerCancel确认后，源链上将锁定的value退还给maker
+    //部分成交的挂单退还未结算的value，锚定节点先于makerCancel提交之前部分成交的makerPartialFinish
+    function makerCancel(bytes32 txId, uint remoteChainId) public onlyAnchor(remoteChainId) {
+        require(crossChains[remoteChainId].anchors[msg.sender].status);
+        require(crossChains[remoteChainId].makerTxs[txId].value > 0);
+        require(crossChains[remoteChainId].makerTxs[txId].takerHash == bytes32(0x0),"already taken");
+        require(!crossChains[remoteChainId].cancelSigns[txId][msg.sender],"already signed");
+        crossChains[remoteChainId].cancelSigns[txId][msg.sender] = true;
+        crossChains[remoteChainId].cancelCount[txId] ++;
+
+        if (crossChains[remoteChainId].cancelCount[txId] >= crossChains[remoteChainId].signConfirmCount){
+            address payable from = crossChains[remoteChainId].makerTxs[txId].from;
+            //退还剩余锁定的value与未计入锚定节点的中继费
+            from.transfer(crossChains[remoteChainId].makerTxs[txId].value + crossChains[remoteChainId].makerTxs[txId].fee - crossChains[remoteChainId].makerTxs[txId].feePaid);
+            delete crossChains[remoteChainId].makerTxs[txId];
+            delete crossChains[remoteChainId].cancelCount[txId];
+            emit MakerCancel(txId, from);
+        }
+    }
+
     function verifySignAndCount(bytes32 hash, uint remoteChainId, uint[] memory v, bytes32[] memory r, bytes32[] memory s) private returns (uint8) {
         uint64 ret = 0;
         uint64 base = 1;
@@ -367,19 +611,164 @@ contract crossDemo{
         require(ctx.v.length == ctx.s.length,"length error");
         require(ctx.to == address(0x0) || ctx.to == msg.sender || ctx.from == msg.sender,"to is err");
         require(crossChains[remoteChainId].takerTxs[ctx.txId] == 0,"txId exist");
+        require(crossChains[remoteChainId].takerFilled[ctx.txId] == 0,"partially filled");
+        require(!isMessage(ctx.data),"message data");
         if(msg.sender == ctx.from){
-            require(verifyOwnerSignAndCount(keccak256(abi.encodePacked(ctx.value, ctx.txId, ctx.txHash, ctx.from, ctx.blockHash, chainId(), ctx.destinationValue,ctx.data)), remoteChainId,ctx.v,ctx.r,ctx.s) >= crossChains[remoteChainId].signConfirmCount,"sign error");
+            require(verifyOwnerSignAndCount(keccak256(abi.encodePacked(ctx.value, ctx.txId, ctx.txHash, ctx.from, ctx.to, ctx.blockHash, chainId(), ctx.destinationValue,ctx.data)), remoteChainId,ctx.v,ctx.r,ctx.s) >= crossChains[remoteChainId].signConfirmCount,"sign error");
             crossChains[remoteChainId].takerTxs[ctx.txId] = ctx.value;
             ctx.from.transfer(msg.value);
         } else {
             require(msg.value >= ctx.destinationValue,"price wrong");
-            require(verifySignAndCount(keccak256(abi.encodePacked(ctx.value, ctx.txId, ctx.txHash, ctx.from, ctx.blockHash, chainId(), ctx.destinationValue,ctx.data)), remoteChainId,ctx.v,ctx.r,ctx.s) >= crossChains[remoteChainId].signConfirmCount,"sign error");
+            require(verifySignAndCount(keccak256(abi.encodePacked(ctx.value, ctx.txId, ctx.txHash, ctx.from, ctx.to, ctx.blockHash, chainId(), ctx.destinationValue,ctx.data)), remoteChainId,ctx.v,ctx.r,ctx.s) >= crossChains[remoteChainId].signConfirmCount,"sign error");
             crossChains[remoteChainId].takerTxs[ctx.txId] = ctx.value;
             ctx.from.transfer(msg.value);
         }
         emit TakerTx(ctx.txId,msg.sender,remoteChainId,ctx.from,ctx.value,ctx.destinationValue);
     }
 
+    //部分吃单，fillValue为本次成交的destValue，全部成交后与taker相同禁止再吃单
+    function takerPartial(Order memory ctx,uint remoteChainId,uint fillValue) payable public{
+        require(ctx.v.length == ctx.r.length,"length error");
+        require(ctx.v.length == ctx.s.length,"length error");
+        require(ctx.to == address(0x0) || ctx.to == msg.sender || ctx.from == msg.sender,"to is err");
+        require(crossChains[remoteChainId].takerTxs[ctx.txId] == 0,"txId exist");
+        require(!isMessage(ctx.data),"message data");
+        uint filled = crossChains[remoteChainId].takerFilled[ctx.txId] + fillValue;
+        require(fillValue > 0 && filled <= ctx.destinationValue,"fill out of range");
+        bytes32 hash = keccak256(abi.encodePacked(ctx.value, ctx.txId, ctx.txHash, ctx.from, ctx.to, ctx.blockHash, chainId(), ctx.destinationValue,ctx.data));
+        if(msg.sender == ctx.from){
+            require(verifyOwnerSignAndCount(hash, remoteChainId,ctx.v,ctx.r,ctx.s) >= crossChains[remoteChainId].signConfirmCount,"sign error");
+        } else {
+            require(msg.value >= fillValue,"price wrong");
+            require(verifySignAndCount(hash, remoteChainId,ctx.v,ctx.r,ctx.s) >= crossChains[remoteChainId].signConfirmCount,"sign error");
+        }
+        crossChains[remoteChainId].takerFilled[ctx.txId] = filled;
+        if (filled == ctx.destinationValue) {
+            crossChains[remoteChainId].takerTxs[ctx.txId] = ctx.value;
+        }
+        ctx.from.transfer(msg.value);
+        emit TakerPartial(ctx.txId,msg.sender,remoteChainId,ctx.from,fillValue,filled,ctx.destinationValue);
+    }
+
+    function getTakerFilled(bytes32 txId, uint remoteChainId) public view returns(uint){
+        return crossChains[remoteChainId].takerFilled[txId];
+    }
+
+    //锚定节点执行，验证锚定节点签名后调用目标合约，每条消息只投递一次
+    function deliverMessage(Order memory ctx,uint remoteChainId) public onlyAnchor(remoteChainId) {
+        require(crossChains[remoteChainId].anchors[msg.sender].status);
+        require(ctx.v.length == ctx.r.length,"length error");
+        require(ctx.v.length == ctx.s.length,"length error");
+        require(isMessage(ctx.data),"not message");
+        require(crossChains[remoteChainId].takerTxs[ctx.txId] == 0,"txId exist");
+        require(verifySignAndCount(keccak256(abi.encodePacked(ctx.value, ctx.txId, ctx.txHash, ctx.from, ctx.to, ctx.blockHash, chainId(), ctx.destinationValue,ctx.data)), remoteChainId,ctx.v,ctx.r,ctx.s) >= crossChains[remoteChainId].signConfirmCount,"sign error");
+        crossChains[remoteChainId].takerTxs[ctx.txId] = ctx.value;
+        (bool success,) = ctx.to.call(ctx.data);
+        emit MessageDelivered(ctx.txId,ctx.to,remoteChainId,ctx.from,success);
+    }
+
+    //任何人可提交锚定节点对同一txId签名的两个不同交易作为证据，验证后停用该锚定节点
+    function reportEquivocation(Order memory first, Order memory second, uint remoteChainId) public {
+        require(first.txId == second.txId,"txId not match");
+        require(first.v.length == 1 && first.r.length == 1 && first.s.length == 1,"length error");
+        require(second.v.length == 1 && second.r.length == 1 && second.s.length == 1,"length error");
+        bytes32 firstHash = keccak256(abi.encodePacked(first.value, first.txId, first.txHash, first.from, first.to, first.blockHash, chainId(), first.destinationValue,first.data));
+        bytes32 secondHash = keccak256(abi.encodePacked(second.value, second.txId, second.txHash, second.from, second.to, second.blockHash, chainId(), second.destinationValue,second.data));
+        require(firstHash != secondHash,"same transaction");
+        address anchor = recoverAnchor(firstHash, remoteChainId, first.v[0], first.r[0], first.s[0]);
+        require(anchor == recoverAnchor(secondHash, remoteChainId, second.v[0], second.r[0], second.s[0]),"signer not match");
+        require(crossChains[remoteChainId].anchors[anchor].remoteChainId == remoteChainId && crossChains[remoteChainId].anchors[anchor].status,"not anchor");
+        updateAnchorStatus(remoteChainId, anchor, false);
+        emit AnchorEquivocation(first.txId, anchor, remoteChainId);
+    }
+
+    function recoverAnchor(bytes32 hash, uint remoteChainId, uint v, bytes32 r, bytes32 s) private pure returns (address) {
+        return ecrecover(hash, uint8(v - remoteChainId*2 - 8), r, s);
+    }
+
+    struct AggregateOrder {
+        uint value;
+        bytes32 txId;
+        bytes32 txHash;
+        address payable from;
+        address to;
+        bytes32 blockHash;
+        uint destinationValue;
+        bytes data;
+        uint256[2] signature; //锚定节点BLS聚合签名(G1)
+    }
+
+    //使用BLS聚合签名吃单，只做一次配对验证
+    function takerAggregate(AggregateOrder memory ctx,uint remoteChainId) payable public{
+        require(ctx.to == address(0x0) || ctx.to == msg.sender || ctx.from == msg.sender,"to is err");
+        require(crossChains[remoteChainId].takerTxs[ctx.txId] == 0,"txId exist");
+        require(crossChains[remoteChainId].takerFilled[ctx.txId] == 0,"partially filled");
+        require(!isMessage(ctx.data),"message data");
+        if(msg.sender != ctx.from){
+            require(msg.value >= ctx.destinationValue,"price wrong");
+        }
+        require(verifyAggregate(keccak256(abi.encodePacked(ctx.value, ctx.txId, ctx.txHash, ctx.from, ctx.to, ctx.blockHash, chainId(), ctx.destinationValue,ctx.data)), remoteChainId, ctx.signature),"sign error");
+        crossChains[remoteChainId].takerTxs[ctx.txId] = ctx.value;
+        ctx.from.transfer(msg.value);
+        emit TakerTx(ctx.txId,msg.sender,remoteChainId,ctx.from,ctx.value,ctx.destinationValue);
+    }
+
+    //验证 e(signature, g2) == e(H(hash), publicKey)，使用bn256Pairing预编译合约
+    function verifyAggregate(bytes32 hash, uint remoteChainId, uint256[2] memory signature) private view returns (bool) {
+        uint256[4] memory publicKey = blsPublicKeys[remoteChainId];
+        require(publicKey[0] != 0 || publicKey[1] != 0 || publicKey[2] != 0 || publicKey[3] != 0,"bls public key not registered");
+        uint256[2] memory h = hashToPoint(hash);
+        uint256[12] memory input = [
+            signature[0], signature[1],
+            // g2
+            11559732032986387107991004021392285783925812861821192530917403151452391805634,
+            10857046999023057135944570762232829481370756359578518086990519993285655852781,
+            4082367875863433681332203403145435568316851327593401208105741076214120093531,
+            8495653923123431417604973247489272438418190587263600148770280649306958101930,
+            // -H(hash)
+            h[0], (FIELD_MODULUS - h[1]) % FIELD_MODULUS,
+            publicKey[0], publicKey[1], publicKey[2], publicKey[3]
+        ];
+        uint256[1] memory out;
+        bool success;
+        assembly {
+            success := staticcall(gas(), 0x08, input, 384, out, 0x20)
+        }
+        require(success,"pairing failed");
+        return out[0] == 1;
+    }
+
+    //try-and-increment: x = keccak256(hash, counter) mod p，直到 x^3+3 为平方数
+    function hashToPoint(bytes32 hash) private view returns (uint256[2] memory p) {
+        for (uint256 counter = 0; ; counter++) {
+            uint256 x = uint256(keccak256(abi.encodePacked(hash, counter))) % FIELD_MODULUS;
+            uint256 beta = addmod(mulmod(mulmod(x, x, FIELD_MODULUS), x, FIELD_MODULUS), 3, FIELD_MODULUS);
+            uint256 y = expMod(beta, (FIELD_MODULUS + 1) / 4, FIELD_MODULUS);
+            if (mulmod(y, y, FIELD_MODULUS) == beta) {
+                p[0] = x;
+                p[1] = y;
+                return p;
+            }
+        }
+    }
+
+    //使用bigModExp预编译合约
+    function expMod(uint256 base, uint256 exponent, uint256 modulus) private view returns (uint256 result) {
+        bool success;
+        assembly {
+            let input := mload(0x40)
+            mstore(input, 0x20)
+            mstore(add(input, 0x20), 0x20)
+            mstore(add(input, 0x40), 0x20)
+            mstore(add(input, 0x60), base)
+            mstore(add(input, 0x80), exponent)
+            mstore(add(input, 0xa0), modulus)
+            success := staticcall(gas(), 0x05, input, 0xc0, input, 0x20)
+            result := mload(input)
+        }
+        require(success,"expMod failed");
+    }
+
     function chainId() public pure returns (uint id) {
         assembly {
             id := chainid()
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/accounts/abi"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/common/hexutil"
+	"github.com/simplechain-org/go-simplechain/params"
+)
+
+func TestABI(t *testing.T) {
+	data, err := ioutil.ReadFile("crossDemo.abi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hexutil.Encode(data) != params.CrossDemoAbi {
+		t.Errorf("params.CrossDemoAbi is not the hex of crossDemo.abi, run go generate")
+	}
+}
+
+// TestBin checks the dispatcher of crossDemo.bin has a selector for every method of crossDemo.abi
+func TestBin(t *testing.T) {
+	data, err := ioutil.ReadFile("crossDemo.abi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crossABI, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin, err := ioutil.ReadFile("crossDemo.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := common.FromHex(strings.TrimSpace(string(bin)))
+	for name, method := range crossABI.Methods {
+		// PUSH4 selector
+		if !bytes.Contains(code, append([]byte{0x63}, method.ID()...)) {
+			t.Errorf("crossDemo.bin has no method %s, recompile it from crossdemo.sol", name)
+		}
+	}
+}
@@ -1,3 +1,8 @@
+// crossDemo.abi and crossDemo.bin are compiled from crossdemo.sol by solc 0.6 with go generate,
+// params.CrossDemoAbi is updated with the hex of crossDemo.abi printed by this program.
+// crossdemo_test.go fails if the bin or params.CrossDemoAbi is older than crossDemo.abi.
+//
+//go:generate solc --optimize --abi --bin --overwrite -o . crossdemo.sol
 package main
 
 import (
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/common/hexutil"
+)
+
+type AnchorTxStatus uint8
+
+const (
+	// AnchorTxPending is the status of an anchor transaction if sent but not packed yet.
+	AnchorTxPending AnchorTxStatus = iota
+	// AnchorTxSucceeded is the status of an anchor transaction if packed with a successful receipt.
+	AnchorTxSucceeded
+	// AnchorTxFailed is the status of an anchor transaction if packed with a failed receipt.
+	AnchorTxFailed
+	// AnchorTxDropped is the status of an anchor transaction if its nonce is used by another transaction.
+	AnchorTxDropped
+)
+
+var anchorTxStatusToString = map[AnchorTxStatus]string{
+	AnchorTxPending:   "pending",
+	AnchorTxSucceeded: "succeeded",
+	AnchorTxFailed:    "failed",
+	AnchorTxDropped:   "dropped",
+}
+
+func (s AnchorTxStatus) String() string {
+	str, ok := anchorTxStatusToString[s]
+	if !ok {
+		return "unknown"
+	}
+	return str
+}
+
+func (s AnchorTxStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// AnchorTransaction is a transaction of cross contract submitted by the anchor,
+// it is journaled by executor until the nonce is consumed on chain.
+type AnchorTransaction struct {
+	CTxId       common.Hash    `json:"ctxId" gencodec:"required"`    //cross_transaction ID, empty for relay fee claims
+	Nonce       uint64         `json:"nonce" gencodec:"required"`    //nonce of anchor
+	GasPrice    *big.Int       `json:"gasPrice" gencodec:"required"` //gas price of the latest sent tx
+	GasLimit    uint64         `json:"gasLimit" gencodec:"required"`
+	Data        hexutil.Bytes  `json:"data" gencodec:"required"`   //input of cross contract
+	TxHash      common.Hash    `json:"txHash" gencodec:"required"` //hash of the latest sent tx
+	Replaced    []common.Hash  `json:"replaced"`                   //hashes of txs replaced by gas-price bumps
+	Status      AnchorTxStatus `json:"status"`
+	BlockNumber uint64         `json:"blockNumber"`                //number of the block packed the tx
+	Time        uint64         `json:"time"`                       //unix time of the latest sending
+	GasUsed     uint64         `json:"gasUsed"`                    //gas used by the packed tx
+	GasCost     *big.Int       `json:"gasCost"`                    //gas fee paid for the packed tx
+	RelayFee    *big.Int       `json:"relayFee"`                   //relay fee credited to anchor by the packed tx
+	Claimed     *big.Int       `json:"claimed"`                    //relay fee claimed from cross contract by the packed tx
+	Batch       []common.Hash  `json:"batch,omitempty" rlp:"tail"` //ctxs finished by a makerFinishBatch tx, CTxId comes first
+}
+
+func (tx *AnchorTransaction) Finished() bool {
+	return tx.Status != AnchorTxPending
+}
+
+// Hashes returns all hashes sent with the nonce, the latest one comes first
+func (tx *AnchorTransaction) Hashes() []common.Hash {
+	hashes := make([]common.Hash, 0, len(tx.Replaced)+1)
+	hashes = append(hashes, tx.TxHash)
+	for i := len(tx.Replaced) - 1; i >= 0; i-- {
+		hashes = append(hashes, tx.Replaced[i])
+	}
+	return hashes
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/simplechain-org/go-simplechain/common/hexutil"
+	"github.com/simplechain-org/go-simplechain/crypto/bls"
+)
+
+// AggregateIndex is the index of the aggregate signature recovered from BLS shares
+const AggregateIndex = 0
+
+var ErrInvalidAggregate = errors.New("invalid aggregate signature")
+
+// BLSSignature is a BLS signature of the ctx sign hash,
+// Index is the key share index of anchor, or AggregateIndex for the recovered aggregate signature.
+type BLSSignature struct {
+	Index uint64        `json:"index"`
+	Sig   hexutil.Bytes `json:"sig"`
+}
+
+// addBLSShares merges signature shares of other anchors, which are verified by the pool on arrival,
+// should be called with lock held
+func (cws *CrossTransactionWithSignatures) addBLSShares(shares []BLSSignature) {
+	if cws.aggregateSignature() != nil {
+		return
+	}
+	for _, share := range shares {
+		if share.Index == AggregateIndex {
+			continue
+		}
+		var exist bool
+		for _, s := range cws.Data.BLS {
+			if s.Index == share.Index {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			cws.Data.BLS = append(cws.Data.BLS, share)
+		}
+	}
+}
+
+// AggregateSignature returns the recovered aggregate BLS signature, nil if not aggregated
+func (cws *CrossTransactionWithSignatures) AggregateSignature() []byte {
+	cws.lock.RLock()
+	defer cws.lock.RUnlock()
+	return cws.aggregateSignature()
+}
+
+func (cws *CrossTransactionWithSignatures) aggregateSignature() []byte {
+	if len(cws.Data.BLS) == 1 && cws.Data.BLS[0].Index == AggregateIndex {
+		return cws.Data.BLS[0].Sig
+	}
+	return nil
+}
+
+// AggregateBLS recovers the aggregate signature from threshold shares and verifies it by the master key,
+// other subsets of shares are tried if the verification fails. The shares are replaced by the aggregate
+// signature if succeeded
+func (cws *CrossTransactionWithSignatures) AggregateBLS(threshold int, master *bls.PublicKey) error {
+	cws.lock.Lock()
+	defer cws.lock.Unlock()
+	if cws.aggregateSignature() != nil {
+		return nil
+	}
+	shares := make(map[uint64]*bls.Signature, len(cws.Data.BLS))
+	for _, share := range cws.Data.BLS {
+		sig, err := bls.NewSignature(share.Sig)
+		if err != nil {
+			continue
+		}
+		shares[share.Index] = sig
+	}
+	hash := MakeCtxSigner(nil).Hash(cws.CrossTransaction())
+	aggregate, err := bls.RecoverVerified(shares, threshold, master, hash.Bytes())
+	if err == bls.ErrNoValidSubset {
+		return ErrInvalidAggregate
+	}
+	if err != nil {
+		return err
+	}
+	cws.Data.BLS = []BLSSignature{{Index: AggregateIndex, Sig: aggregate.Bytes()}}
+	return nil
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/crypto"
+	"github.com/simplechain-org/go-simplechain/crypto/bls"
+	"github.com/simplechain-org/go-simplechain/rlp"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrossTransactionWithSignatures_AggregateBLS(t *testing.T) {
+	shares, err := bls.GenerateShares(rand.Reader, 2, 3)
+	assert.NoError(t, err)
+
+	signer := NewEIP155CtxSigner(big.NewInt(18))
+	ctx := NewCrossTransaction(big.NewInt(1e18), big.NewInt(2e18), big.NewInt(19),
+		common.HexToHash("0b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbca"),
+		common.HexToHash("0b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbca"),
+		common.HexToHash("0b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbca"),
+		common.HexToAddress("095e7baea6a6c7c4c2dfeb977efac326af552d87"), common.Address{}, nil)
+	hash := signer.Hash(ctx)
+
+	var signed []*CrossTransaction
+	for _, share := range shares[:2] {
+		key, _ := crypto.GenerateKey()
+		tx, err := SignCtx(ctx, signer, func(hash []byte) ([]byte, error) { return crypto.Sign(hash, key) })
+		assert.NoError(t, err)
+		signed = append(signed, tx.WithBLSSignature(share.Index, share.Sign(hash.Bytes()).Bytes()))
+	}
+
+	// BLS share survives the rlp encoding
+	enc, err := rlp.EncodeToBytes(signed[0])
+	assert.NoError(t, err)
+	decoded := new(CrossTransaction)
+	assert.NoError(t, rlp.DecodeBytes(enc, decoded))
+	assert.Equal(t, signed[0].Data.BLS, decoded.Data.BLS)
+
+	cws := NewCrossTransactionWithSignatures(signed[0], 1)
+	assert.Equal(t, bls.ErrNotEnoughShares, cws.AggregateBLS(2, shares[0].Master))
+	assert.Nil(t, cws.AggregateSignature())
+
+	assert.NoError(t, cws.AddSignature(signed[1]))
+	assert.Len(t, cws.Data.BLS, 2)
+	assert.NoError(t, cws.AggregateBLS(2, shares[0].Master))
+
+	aggregate, err := bls.NewSignature(cws.AggregateSignature())
+	assert.NoError(t, err)
+	assert.True(t, bls.Verify(shares[0].Master, hash.Bytes(), aggregate))
+	assert.Len(t, cws.Data.BLS, 1)
+	assert.Equal(t, 2, cws.SignaturesLength())
+
+	// a wrong master key is rejected
+	other, err := bls.GenerateShares(rand.Reader, 2, 3)
+	assert.NoError(t, err)
+	cws = NewCrossTransactionWithSignatures(signed[0], 1)
+	assert.NoError(t, cws.AddSignature(signed[1]))
+	assert.Equal(t, ErrInvalidAggregate, cws.AggregateBLS(2, other[0].Master))
+	assert.Nil(t, cws.AggregateSignature())
+
+	// a forged share is skipped by trying other subsets
+	forged := signed[0].WithBLSSignature(shares[0].Index, shares[2].Sign(hash.Bytes()).Bytes())
+	key, _ := crypto.GenerateKey()
+	third, err := SignCtx(ctx, signer, func(hash []byte) ([]byte, error) { return crypto.Sign(hash, key) })
+	assert.NoError(t, err)
+	cws = NewCrossTransactionWithSignatures(forged, 1)
+	assert.NoError(t, cws.AddSignature(signed[1]))
+	assert.NoError(t, cws.AddSignature(third.WithBLSSignature(shares[2].Index, shares[2].Sign(hash.Bytes()).Bytes())))
+	assert.NoError(t, cws.AggregateBLS(2, shares[0].Master))
+	aggregate, err = bls.NewSignature(cws.AggregateSignature())
+	assert.NoError(t, err)
+	assert.True(t, bls.Verify(shares[0].Master, hash.Bytes(), aggregate))
+	assert.Nil(t, signed[0].WithoutBLSSignature().Data.BLS)
+}
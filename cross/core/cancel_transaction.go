@@ -0,0 +1,33 @@
+package core
+
+import (
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain/accounts/abi"
+	"github.com/simplechain-org/go-simplechain/common"
+)
+
+// CancelTransaction cancels an expired ctx, it is submitted to the destination chain to stop takers first,
+// and then to the source chain to refund the maker.
+type CancelTransaction struct {
+	CTxId         common.Hash `json:"ctxId" gencodec:"required"`         //cross_transaction ID
+	ChainId       *big.Int    `json:"chainId" gencodec:"required"`       //chain which the cancel is submitted to
+	RemoteChainId *big.Int    `json:"remoteChainId" gencodec:"required"` //the other chain of the ctx
+	Refund        bool        `json:"refund"`                            //refund maker in source chain if true
+}
+
+func NewCancelTransaction(id common.Hash, chainId, remoteChainId *big.Int, refund bool) *CancelTransaction {
+	return &CancelTransaction{
+		CTxId:         id,
+		ChainId:       chainId,
+		RemoteChainId: remoteChainId,
+		Refund:        refund,
+	}
+}
+
+func (ctx *CancelTransaction) ConstructData(crossContract abi.ABI) ([]byte, error) {
+	if ctx.Refund {
+		return crossContract.Pack("makerCancel", ctx.CTxId, ctx.RemoteChainId)
+	}
+	return crossContract.Pack("takerCancel", ctx.CTxId, ctx.RemoteChainId)
+}
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/accounts/abi"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/common/hexutil"
+	"github.com/simplechain-org/go-simplechain/params"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelTransaction_ConstructData(t *testing.T) {
+	data, err := hexutil.Decode(params.CrossDemoAbi)
+	assert.NoError(t, err)
+	crossABI, err := abi.JSON(bytes.NewReader(data))
+	assert.NoError(t, err)
+
+	id := common.HexToHash("0b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbca")
+
+	// takerCancel in destination chain
+	input, err := NewCancelTransaction(id, big.NewInt(2), big.NewInt(1), false).ConstructData(crossABI)
+	assert.NoError(t, err)
+	assert.Equal(t, common.Hex2Bytes("fe973b99"), input[:4])
+	assert.Equal(t, id.Bytes(), input[4:36])
+	assert.Equal(t, int64(1), new(big.Int).SetBytes(input[36:68]).Int64())
+
+	// makerCancel in source chain
+	input, err = NewCancelTransaction(id, big.NewInt(1), big.NewInt(2), true).ConstructData(crossABI)
+	assert.NoError(t, err)
+	assert.Equal(t, common.Hex2Bytes("88a3184b"), input[:4])
+	assert.Equal(t, id.Bytes(), input[4:36])
+	assert.Equal(t, int64(2), new(big.Int).SetBytes(input[36:68]).Int64())
+}
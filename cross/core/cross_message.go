@@ -0,0 +1,163 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain/accounts/abi"
+	"github.com/simplechain-org/go-simplechain/common"
+)
+
+// CtxKind is the kind of a cross transaction, it is derived from the signed input so
+// the ctx format of store and sync is unchanged
+type CtxKind uint8
+
+const (
+	// CtxKindOrder is a value-for-value order made by makerStart
+	CtxKindOrder CtxKind = iota
+	// CtxKindMessage is a message made by sendMessage and delivered to a target contract
+	CtxKindMessage
+)
+
+func (k CtxKind) String() string {
+	switch k {
+	case CtxKindOrder:
+		return "order"
+	case CtxKindMessage:
+		return "message"
+	default:
+		return "unknown"
+	}
+}
+
+func (k CtxKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *CtxKind) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "order":
+		*k = CtxKindOrder
+	case "message":
+		*k = CtxKindMessage
+	default:
+		return fmt.Errorf("unknown ctx kind %q", text)
+	}
+	return nil
+}
+
+// MessageSelector is the selector of onCrossMessage(uint256,bytes32,address,bytes),
+// the input of a message ctx is the call of it to the target contract
+var MessageSelector = common.Hex2Bytes("c6d06c69")
+
+var ErrNotMessage = errors.New("not a cross message")
+
+// KindOf returns the kind of a ctx by its input
+func KindOf(input []byte) CtxKind {
+	if len(input) >= len(MessageSelector) && bytes.Equal(input[:len(MessageSelector)], MessageSelector) {
+		return CtxKindMessage
+	}
+	return CtxKindOrder
+}
+
+func (tx *CrossTransaction) Kind() CtxKind {
+	return KindOf(tx.Data.Input)
+}
+
+func (cws *CrossTransactionWithSignatures) Kind() CtxKind {
+	return KindOf(cws.Data.Input)
+}
+
+// CrossMessage is the message carried by the input of a message ctx
+type CrossMessage struct {
+	SourceChainId *big.Int
+	CTxId         common.Hash
+	From          common.Address
+	Data          []byte
+}
+
+// DecodeMessage decodes the onCrossMessage call of a message ctx
+func DecodeMessage(input []byte) (*CrossMessage, error) {
+	if KindOf(input) != CtxKindMessage {
+		return nil, ErrNotMessage
+	}
+	args := input[len(MessageSelector):]
+	if len(args) < common.HashLength*5 {
+		return nil, ErrNotMessage
+	}
+	offset := new(big.Int).SetBytes(args[common.HashLength*3 : common.HashLength*4])
+	if !offset.IsUint64() || offset.Uint64()+common.HashLength > uint64(len(args)) {
+		return nil, ErrNotMessage
+	}
+	start := offset.Uint64() + common.HashLength
+	size := new(big.Int).SetBytes(args[offset.Uint64():start])
+	if !size.IsUint64() || start+size.Uint64() > uint64(len(args)) {
+		return nil, ErrNotMessage
+	}
+	return &CrossMessage{
+		SourceChainId: new(big.Int).SetBytes(args[:common.HashLength]),
+		CTxId:         common.BytesToHash(args[common.HashLength : common.HashLength*2]),
+		From:          common.BytesToAddress(args[common.HashLength*2 : common.HashLength*3]),
+		Data:          common.CopyBytes(args[start : start+size.Uint64()]),
+	}, nil
+}
+
+// order is the Order struct of the cross contract
+type order struct {
+	Value            *big.Int
+	TxId             common.Hash
+	TxHash           common.Hash
+	From             common.Address
+	To               common.Address
+	BlockHash        common.Hash
+	DestinationValue *big.Int
+	Data             []byte
+	V                []*big.Int
+	R                [][32]byte
+	S                [][32]byte
+}
+
+// DeliverTransaction delivers a signed message ctx to the target contract in the destination chain
+type DeliverTransaction struct {
+	Ctx     *CrossTransactionWithSignatures `json:"ctx" gencodec:"required"`
+	ChainId *big.Int                        `json:"chainId" gencodec:"required"` //source chain of the message
+}
+
+func NewDeliverTransaction(cws *CrossTransactionWithSignatures) *DeliverTransaction {
+	return &DeliverTransaction{Ctx: cws, ChainId: cws.ChainId()}
+}
+
+func (tx *DeliverTransaction) ID() common.Hash {
+	return tx.Ctx.ID()
+}
+
+func (tx *DeliverTransaction) ConstructData(crossContract abi.ABI) ([]byte, error) {
+	return crossContract.Pack("deliverMessage", newOrder(tx.Ctx), tx.ChainId)
+}
+
+// newOrder converts the signed ctx to the Order of cross contract
+func newOrder(cws *CrossTransactionWithSignatures) *order {
+	d := cws.Data
+	ord := &order{
+		Value:            d.Value,
+		TxId:             d.CTxId,
+		TxHash:           d.TxHash,
+		From:             d.From,
+		To:               d.To,
+		BlockHash:        d.BlockHash,
+		DestinationValue: d.DestinationValue,
+		Data:             d.Input,
+		V:                d.V,
+		R:                make([][32]byte, len(d.R)),
+		S:                make([][32]byte, len(d.S)),
+	}
+	for i := range d.R {
+		copy(ord.R[i][:], common.LeftPadBytes(d.R[i].Bytes(), 32))
+	}
+	for i := range d.S {
+		copy(ord.S[i][:], common.LeftPadBytes(d.S[i].Bytes(), 32))
+	}
+	return ord
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/accounts/abi"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/common/hexutil"
+	"github.com/simplechain-org/go-simplechain/params"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newMessageInput(t *testing.T, chainID int64, id common.Hash, from common.Address, data []byte) []byte {
+	var args abi.Arguments
+	for _, typ := range []string{"uint256", "bytes32", "address", "bytes"} {
+		ty, err := abi.NewType(typ, "", nil)
+		assert.NoError(t, err)
+		args = append(args, abi.Argument{Type: ty})
+	}
+	packed, err := args.Pack(big.NewInt(chainID), id, from, data)
+	assert.NoError(t, err)
+	return append(common.CopyBytes(MessageSelector), packed...)
+}
+
+func TestDecodeMessage(t *testing.T) {
+	id := common.HexToHash("0x01")
+	from := common.HexToAddress("0x02")
+	input := newMessageInput(t, 1, id, from, []byte("hello"))
+
+	assert.Equal(t, CtxKindMessage, KindOf(input))
+	assert.Equal(t, CtxKindOrder, KindOf(nil))
+	assert.Equal(t, CtxKindOrder, KindOf([]byte("hello")))
+
+	msg, err := DecodeMessage(input)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), msg.SourceChainId)
+	assert.Equal(t, id, msg.CTxId)
+	assert.Equal(t, from, msg.From)
+	assert.Equal(t, []byte("hello"), msg.Data)
+
+	_, err = DecodeMessage(input[:len(input)-common.HashLength])
+	assert.Equal(t, ErrNotMessage, err)
+	_, err = DecodeMessage([]byte("hello"))
+	assert.Equal(t, ErrNotMessage, err)
+}
+
+func TestDeliverTransaction_ConstructData(t *testing.T) {
+	data, err := hexutil.Decode(params.CrossDemoAbi)
+	assert.NoError(t, err)
+	crossABI, err := abi.JSON(bytes.NewReader(data))
+	assert.NoError(t, err)
+
+	id := common.HexToHash("0b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbca")
+	ctx := NewCrossTransaction(big.NewInt(1e18), big.NewInt(0), big.NewInt(2), id, common.Hash{}, common.Hash{},
+		common.HexToAddress("0x02"), common.HexToAddress("0x03"), newMessageInput(t, 1, id, common.HexToAddress("0x02"), nil))
+	ctx.Data.V, ctx.Data.R, ctx.Data.S = big.NewInt(37), big.NewInt(1), big.NewInt(2)
+	cws := NewCrossTransactionWithSignatures(ctx, 1)
+	assert.Equal(t, CtxKindMessage, cws.Kind())
+
+	// deliverMessage in destination chain
+	deliver := NewDeliverTransaction(cws)
+	assert.Equal(t, big.NewInt(1), deliver.ChainId)
+	input, err := deliver.ConstructData(crossABI)
+	assert.NoError(t, err)
+	assert.Equal(t, common.Hex2Bytes("91024df5"), input[:4])
+	assert.Equal(t, int64(1), new(big.Int).SetBytes(input[36:68]).Int64())
+
+	// messageReceipt in source chain
+	rtx := NewReceptTransaction(id, common.Hash{}, common.HexToAddress("0x02"), common.HexToAddress("0x03"), big.NewInt(1), big.NewInt(2))
+	rtx.Kind, rtx.Success = CtxKindMessage, true
+	input, err = rtx.ConstructData(crossABI)
+	assert.NoError(t, err)
+	assert.Equal(t, common.Hex2Bytes("9d05de60"), input[:4])
+	assert.Equal(t, id.Bytes(), input[4:36])
+	assert.Equal(t, int64(1), new(big.Int).SetBytes(input[36:68]).Int64())
+	assert.Equal(t, int64(2), new(big.Int).SetBytes(input[68:100]).Int64())
+}
@@ -21,6 +21,21 @@ const (
 	CtxStatusFinishing
 	// CtxStatusFinished is the status code of a cross transaction if make finish confirmed.
 	CtxStatusFinished
+	// CtxStatusCancelling is the status code of a cross transaction if taking is cancelled after expired.
+	CtxStatusCancelling
+	// CtxStatusCancelled is the status code of a cross transaction if maker is refunded.
+	CtxStatusCancelled
+	// CtxStatusPartialFilled is the status code of a cross transaction if taker filled a part of it.
+	// appended to keep status codes in db, use Order to compare statuses
+	CtxStatusPartialFilled
+	// CtxStatusDelivering is the status code of a message ctx if delivered to target contract.
+	CtxStatusDelivering
+	// CtxStatusDelivered is the status code of a message ctx if delivery confirmed.
+	CtxStatusDelivered
+	// CtxStatusReceipting is the status code of a message ctx if delivery receipt recorded in source chain.
+	CtxStatusReceipting
+	// CtxStatusReceipted is the status code of a message ctx if delivery receipt confirmed.
+	CtxStatusReceipted
 )
 
 /**
@@ -39,17 +54,84 @@ const (
   |      |                       |saving|
   |      | <-mod-- confirmFinish |      |
   |      | (finished)            |      |
+  |      |                       |      |
+  |      |  expired(waiting) --> |      |
+  |      |   takerCancel --mod-> |      |
+  |      |          (cancelling) |      |
+  |      | <-mod-- makerCancel   |      |
+  |      | (cancelled)           |      |
+  |      |                       |      |
+  |      |  takerPartial --mod-> |      |
+  |      |       (partialFilled) |      |
+  |      | <-mod-- partialFinish |      |
+  |      |                       |      |
+  |      |  (message ctx)        |      |
+  |      |  deliver --mod->      |      |
+  |      |          (delivering) |      |
+  |      |  confirmDeliver --mod->      |
+  |      |           (delivered) |      |
+  |      | <-mod-- receipt       |      |
+  |      | (receipting)          |      |
+  |      | <-mod-- confirmReceipt|      |
+  |      | (receipted)           |      |
   |------|                       |------|
 */
 
+// messageStatus is the status of message ctx in the same step of the order status flow
+var messageStatus = map[CtxStatus]CtxStatus{
+	CtxStatusExecuting: CtxStatusDelivering,
+	CtxStatusExecuted:  CtxStatusDelivered,
+	CtxStatusFinishing: CtxStatusReceipting,
+	CtxStatusFinished:  CtxStatusReceipted,
+}
+
+// Of returns the status of a ctx of kind in the step of order status s
+func (s CtxStatus) Of(kind CtxKind) CtxStatus {
+	if status, ok := messageStatus[s]; ok && kind == CtxKindMessage {
+		return status
+	}
+	return s
+}
+
+// ctxStatusOrder is the order of status transitions, a partially filled ctx is still open for takers
+var ctxStatusOrder = map[CtxStatus]uint8{
+	CtxStatusPending:       0,
+	CtxStatusWaiting:       1,
+	CtxStatusIllegal:       2,
+	CtxStatusPartialFilled: 3,
+	CtxStatusExecuting:     4,
+	CtxStatusExecuted:      5,
+	CtxStatusFinishing:     6,
+	CtxStatusFinished:      7,
+	CtxStatusCancelling:    8,
+	CtxStatusCancelled:     9,
+	// message ctxs are in the same steps of orders
+	CtxStatusDelivering: 4,
+	CtxStatusDelivered:  5,
+	CtxStatusReceipting: 6,
+	CtxStatusReceipted:  7,
+}
+
+// Order returns the position of status in transitions
+func (s CtxStatus) Order() uint8 {
+	return ctxStatusOrder[s]
+}
+
 var ctxStatusToString = map[CtxStatus]string{
-	CtxStatusPending:   "pending",
-	CtxStatusWaiting:   "waiting",
-	CtxStatusIllegal:   "illegal",
-	CtxStatusExecuting: "executing",
-	CtxStatusExecuted:  "executed",
-	CtxStatusFinishing: "finishing",
-	CtxStatusFinished:  "finished",
+	CtxStatusPending:       "pending",
+	CtxStatusWaiting:       "waiting",
+	CtxStatusIllegal:       "illegal",
+	CtxStatusExecuting:     "executing",
+	CtxStatusExecuted:      "executed",
+	CtxStatusFinishing:     "finishing",
+	CtxStatusFinished:      "finished",
+	CtxStatusCancelling:    "cancelling",
+	CtxStatusCancelled:     "cancelled",
+	CtxStatusPartialFilled: "partialFilled",
+	CtxStatusDelivering:    "delivering",
+	CtxStatusDelivered:     "delivered",
+	CtxStatusReceipting:    "receipting",
+	CtxStatusReceipted:     "receipted",
 }
 
 func (s CtxStatus) String() string {
@@ -57,6 +57,8 @@ type ctxdata struct {
 	V *big.Int `json:"v" gencodec:"required"` //chainId
 	R *big.Int `json:"r" gencodec:"required"`
 	S *big.Int `json:"s" gencodec:"required"`
+
+	BLS []BLSSignature `json:"bls" rlp:"tail"` //BLS signature share of anchor, empty if BLS mode disabled
 }
 
 func NewCrossTransaction(amount, charge, networkId *big.Int, id, txHash, bHash common.Hash, from, to common.Address, input []byte) *CrossTransaction {
@@ -87,6 +89,20 @@ func (tx *CrossTransaction) WithSignature(signer CtxSigner, sig []byte) (*CrossT
 	return cpy, nil
 }
 
+// WithBLSSignature returns a copy of tx with the BLS signature share of anchor
+func (tx *CrossTransaction) WithBLSSignature(index uint64, sig []byte) *CrossTransaction {
+	cpy := &CrossTransaction{Data: tx.Data}
+	cpy.Data.BLS = []BLSSignature{{Index: index, Sig: sig}}
+	return cpy
+}
+
+// WithoutBLSSignature returns a copy of tx without BLS signature shares
+func (tx *CrossTransaction) WithoutBLSSignature() *CrossTransaction {
+	cpy := &CrossTransaction{Data: tx.Data}
+	cpy.Data.BLS = nil
+	return cpy
+}
+
 func (tx *CrossTransaction) ID() common.Hash {
 	return tx.Data.CTxId
 }
@@ -189,6 +205,7 @@ type CrossTransactionWithSignatures struct {
 	Data     CtxDatas
 	Status   CtxStatus `json:"status" gencodec:"required"` // default = pending
 	BlockNum uint64    `json:"blockNum" gencodec:"required"`
+	Filled   *big.Int  `json:"filled,omitempty" rlp:"-"` // destination value filled by partial takers, nil if not filled
 
 	// caches
 	hash atomic.Value
@@ -212,6 +229,8 @@ type CtxDatas struct {
 	V []*big.Int `json:"v" gencodec:"required"` //chainId
 	R []*big.Int `json:"r" gencodec:"required"`
 	S []*big.Int `json:"s" gencodec:"required"`
+
+	BLS []BLSSignature `json:"bls" rlp:"tail"` //BLS signature shares, or the aggregate signature after recovered
 }
 
 func NewCrossTransactionWithSignatures(ctx *CrossTransaction, num uint64) *CrossTransactionWithSignatures {
@@ -232,6 +251,7 @@ func NewCrossTransactionWithSignatures(ctx *CrossTransaction, num uint64) *Cross
 		d.R = append(d.R, ctx.Data.R)
 		d.S = append(d.S, ctx.Data.S)
 	}
+	d.BLS = append(d.BLS, ctx.Data.BLS...)
 
 	return &CrossTransactionWithSignatures{Data: d, BlockNum: num}
 }
@@ -273,6 +293,14 @@ func (cws *CrossTransactionWithSignatures) Hash() (h common.Hash) {
 	return h
 }
 
+// Remaining returns the destination value which is still open for takers
+func (cws *CrossTransactionWithSignatures) Remaining() *big.Int {
+	if cws.Filled == nil {
+		return cws.Data.DestinationValue
+	}
+	return new(big.Int).Sub(cws.Data.DestinationValue, cws.Filled)
+}
+
 func (cws *CrossTransactionWithSignatures) BlockHash() common.Hash {
 	return cws.Data.BlockHash
 }
@@ -295,6 +323,7 @@ func (cws *CrossTransactionWithSignatures) AddSignature(ctx *CrossTransaction) e
 	cws.Data.V = append(cws.Data.V, ctx.Data.V)
 	cws.Data.R = append(cws.Data.R, ctx.Data.R)
 	cws.Data.S = append(cws.Data.S, ctx.Data.S)
+	cws.addBLSShares(ctx.Data.BLS)
 	return nil
 }
 func (cws *CrossTransactionWithSignatures) RemoveSignature(index int) {
@@ -121,16 +121,22 @@ func (s EIP155CtxSigner) SignatureValues(tx *CrossTransaction, sig []byte) (R, S
 
 func (s EIP155CtxSigner) Hash(tx *CrossTransaction) (h common.Hash) {
 	hash := sha3.NewKeccak256()
+	hash.Write(CtxSigningData(tx))
+	hash.Sum(h[:0])
+	return h
+}
+
+// CtxSigningData returns the data signed by anchors, the keccak256 hash of which is the signature hash of ctx
+func CtxSigningData(tx *CrossTransaction) []byte {
 	var b []byte
 	b = append(b, common.LeftPadBytes(tx.Data.Value.Bytes(), 32)...)
 	b = append(b, tx.Data.CTxId.Bytes()...)
 	b = append(b, tx.Data.TxHash.Bytes()...)
 	b = append(b, tx.Data.From.Bytes()...)
+	b = append(b, tx.Data.To.Bytes()...)
 	b = append(b, tx.Data.BlockHash.Bytes()...)
 	b = append(b, common.LeftPadBytes(tx.Data.DestinationId.Bytes(), 32)...)
 	b = append(b, common.LeftPadBytes(tx.Data.DestinationValue.Bytes(), 32)...)
 	b = append(b, tx.Data.Input...)
-	hash.Write(b)
-	hash.Sum(h[:0])
-	return h
+	return b
 }
@@ -36,16 +36,16 @@ var (
 		nil,
 	).WithSignature(
 		NewEIP155CtxSigner(big.NewInt(1)),
-		common.Hex2Bytes("fff9e65e751407a69c5125a0e0dafd2e0048ce9b60d39bb0b58c251b4a72d3822005750b091faae17b20a0a966b0c40ca44134fb214d0df0fed1c10646141f7000"),
+		common.Hex2Bytes("1d1bb0cd9337df144525cbc260b53e6e6b1bfd02e0ad62acf4a85cd168d65eae5daa1d11ade75f0a65edf36a9cbf4baa61d092012898b98131fc8e9f6dfd558701"),
 	)
 )
 
 func TestCrossTransactionSigHash(t *testing.T) {
 	signer := NewEIP155CtxSigner(big.NewInt(1))
-	if signer.Hash(emptyCtx) != common.HexToHash("4f4a4cb2a67de52c0036f3ab5046388144471c13d42d62e2390e0c6eaa7e3d50") {
+	if signer.Hash(emptyCtx) != common.HexToHash("a7637b5fd683f6b48020f3f832687b48a63d280dcfbb6cd305debbafb9f05d90") {
 		t.Errorf("empty transaction hash mismatch, got %x", emptyCtx.Hash())
 	}
-	if signer.Hash(rightvrsCtx) != common.HexToHash("ba84e225625b9b69304e867a2c9af871b37086b7238190dd44dbd57e5429304e") {
+	if signer.Hash(rightvrsCtx) != common.HexToHash("f83affb2c4a1c895f1df5fa1bbc39e85bd7548e5132cee3d17214b6a0d450923") {
 		t.Errorf("RightVRS transaction hash mismatch, got %x", rightvrsCtx.Hash())
 	}
 }
@@ -55,7 +55,7 @@ func TestCrossTransactionEncode(t *testing.T) {
 	if err != nil {
 		t.Fatalf("encode error: %v", err)
 	}
-	should := common.FromHex("f8e8f8e6880de0b6b3a7640000a00b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbcaa00b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbca94095e7baea6a6c7c4c2dfeb977efac326af552d87940000000000000000000000000000000000000000a00b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbca820400881bc16d674ec800008025a0fff9e65e751407a69c5125a0e0dafd2e0048ce9b60d39bb0b58c251b4a72d382a02005750b091faae17b20a0a966b0c40ca44134fb214d0df0fed1c10646141f70")
+	should := common.FromHex("f8e8f8e6880de0b6b3a7640000a00b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbcaa00b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbca94095e7baea6a6c7c4c2dfeb977efac326af552d87940000000000000000000000000000000000000000a00b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbca820400881bc16d674ec800008026a01d1bb0cd9337df144525cbc260b53e6e6b1bfd02e0ad62acf4a85cd168d65eaea05daa1d11ade75f0a65edf36a9cbf4baa61d092012898b98131fc8e9f6dfd5587")
 	if !bytes.Equal(ctxb, should) {
 		t.Errorf("encoded RLP mismatch, got %x", ctxb)
 	}
@@ -76,7 +76,7 @@ func defaultTestKey() (*ecdsa.PrivateKey, common.Address) {
 
 func TestCtxRecipient(t *testing.T) {
 	_, addr := defaultTestKey()
-	tx, err := decodeCtx(common.Hex2Bytes("f8e8f8e6880de0b6b3a7640000a00b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbcaa00b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbca94095e7baea6a6c7c4c2dfeb977efac326af552d87940000000000000000000000000000000000000000a00b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbca820400881bc16d674ec800008025a0fff9e65e751407a69c5125a0e0dafd2e0048ce9b60d39bb0b58c251b4a72d382a02005750b091faae17b20a0a966b0c40ca44134fb214d0df0fed1c10646141f70"))
+	tx, err := decodeCtx(common.Hex2Bytes("f8e8f8e6880de0b6b3a7640000a00b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbcaa00b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbca94095e7baea6a6c7c4c2dfeb977efac326af552d87940000000000000000000000000000000000000000a00b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbca820400881bc16d674ec800008026a01d1bb0cd9337df144525cbc260b53e6e6b1bfd02e0ad62acf4a85cd168d65eaea05daa1d11ade75f0a65edf36a9cbf4baa61d092012898b98131fc8e9f6dfd5587"))
 	if err != nil {
 		t.Error(err)
 		t.FailNow()
@@ -23,6 +23,11 @@ type SignedCtxEvent struct { // pool event
 	CallBack func(cws *CrossTransactionWithSignatures, invalidSigIndex ...int)
 }
 
+type CtxStatusEvent struct { // store event, status of ctx is changed
+	Tx         *CrossTransactionWithSignatures
+	PrevStatus CtxStatus
+}
+
 type NewFinishEvent struct {
 	Finishes []*CrossTransactionModifier
 }
@@ -35,6 +40,26 @@ type NewAnchorEvent struct {
 	ChainInfo []*RemoteChainInfo
 }
 
+type NewCancelEvent struct {
+	Cancels []*CrossTransactionModifier
+}
+
+type ConfirmedCancelEvent struct { // taking is cancelled in destination chain, refund maker in source chain
+	Txs []*CancelTransaction
+}
+
+type ConfirmedRefundEvent struct {
+	Refunds []*CrossTransactionModifier
+}
+
+type EvidenceEvent struct { // pool event, an anchor signed conflicting ctxs
+	Evidences []*Evidence
+}
+
+type ExpiredCtxEvent struct { // handler event, cancel taking of expired ctx in destination chain
+	Txs []*CancelTransaction
+}
+
 type ModType uint8
 
 const (
@@ -48,6 +73,7 @@ type CrossTransactionModifier struct {
 	ID            common.Hash
 	Status        CtxStatus
 	AtBlockNumber uint64
+	Filled        *big.Int // destination value filled by partial takers, nil if not a partial fill
 }
 
 type CrossBlockEvent struct {
@@ -60,11 +86,17 @@ type CrossBlockEvent struct {
 	NewAnchor       NewAnchorEvent
 	ReorgTaker      NewTakerEvent
 	ReorgFinish     NewFinishEvent
+	NewCancel       NewCancelEvent
+	ConfirmedCancel ConfirmedCancelEvent
+	ConfirmedRefund ConfirmedRefundEvent
+	ReorgCancel     NewCancelEvent
 }
 
 func (e CrossBlockEvent) IsEmpty() bool {
 	return len(e.ConfirmedMaker.Txs)|len(e.ConfirmedTaker.Txs)|
 		len(e.ConfirmedFinish.Finishes)|len(e.NewTaker.Takers)|
 		len(e.NewFinish.Finishes)|len(e.NewAnchor.ChainInfo)|
-		len(e.ReorgTaker.Takers)|len(e.ReorgFinish.Finishes) == 0
+		len(e.ReorgTaker.Takers)|len(e.ReorgFinish.Finishes)|
+		len(e.NewCancel.Cancels)|len(e.ConfirmedCancel.Txs)|
+		len(e.ConfirmedRefund.Refunds)|len(e.ReorgCancel.Cancels) == 0
 }
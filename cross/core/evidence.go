@@ -0,0 +1,103 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain/accounts/abi"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/crypto"
+)
+
+type EvidenceKind uint8
+
+const (
+	// EvidenceDoubleSign is an anchor signed two different ctxs with the same ctxID
+	EvidenceDoubleSign EvidenceKind = iota
+	// EvidenceReorg is an anchor signed a ctx whose block is not canonical
+	EvidenceReorg
+)
+
+func (k EvidenceKind) String() string {
+	switch k {
+	case EvidenceDoubleSign:
+		return "doubleSign"
+	case EvidenceReorg:
+		return "reorg"
+	default:
+		return "unknown"
+	}
+}
+
+func (k EvidenceKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+var (
+	ErrInvalidEvidence = errors.New("invalid evidence")
+	ErrNoDoubleSign    = errors.New("evidence is not double signing")
+)
+
+// Evidence is the proof of an anchor signing conflicting ctxs.
+// For double signing both ctxs are signed by the anchor, for reorg the second one is the ctx in canonical chain.
+type Evidence struct {
+	Kind   EvidenceKind      `json:"kind"`
+	Signer common.Address    `json:"signer"`
+	First  *CrossTransaction `json:"first"`
+	Second *CrossTransaction `json:"second"`
+	Time   uint64            `json:"time"` //unix time of found
+}
+
+func NewEvidence(kind EvidenceKind, signer common.Address, first, second *CrossTransaction, time uint64) *Evidence {
+	return &Evidence{Kind: kind, Signer: signer, First: first, Second: second, Time: time}
+}
+
+func (e *Evidence) ID() common.Hash {
+	return e.First.ID()
+}
+
+// ChainId returns the chain where the ctx is made
+func (e *Evidence) ChainId() *big.Int {
+	return e.First.ChainId()
+}
+
+func (e *Evidence) DestinationId() *big.Int {
+	return e.First.DestinationId()
+}
+
+// Hash identifies the evidence by signer and both signed payloads
+func (e *Evidence) Hash() common.Hash {
+	signer := NewEIP155CtxSigner(e.ChainId())
+	return crypto.Keccak256Hash([]byte{byte(e.Kind)}, e.Signer.Bytes(), signer.Hash(e.First).Bytes(), signer.Hash(e.Second).Bytes())
+}
+
+// Verify checks the signer signed the first ctx, and the second one for double signing
+func (e *Evidence) Verify() error {
+	if e.First == nil || e.Second == nil || e.First.ID() != e.Second.ID() {
+		return ErrInvalidEvidence
+	}
+	signer := NewEIP155CtxSigner(e.ChainId())
+	if signer.Hash(e.First) == signer.Hash(e.Second) {
+		return ErrInvalidEvidence
+	}
+	if from, err := CtxSender(signer, e.First); err != nil || from != e.Signer {
+		return ErrInvalidEvidence
+	}
+	if e.Kind == EvidenceDoubleSign {
+		if from, err := CtxSender(signer, e.Second); err != nil || from != e.Signer {
+			return ErrInvalidEvidence
+		}
+	}
+	return nil
+}
+
+// ConstructData packs reportEquivocation to deactivate the anchor in the destination chain,
+// only double signing can be verified by the cross contract
+func (e *Evidence) ConstructData(crossContract abi.ABI) ([]byte, error) {
+	if e.Kind != EvidenceDoubleSign {
+		return nil, ErrNoDoubleSign
+	}
+	first := NewCrossTransactionWithSignatures(e.First, 0)
+	second := NewCrossTransactionWithSignatures(e.Second, 0)
+	return crossContract.Pack("reportEquivocation", newOrder(first), newOrder(second), e.ChainId())
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/accounts/abi"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/common/hexutil"
+	"github.com/simplechain-org/go-simplechain/crypto"
+	"github.com/simplechain-org/go-simplechain/params"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvidence(t *testing.T) {
+	data, err := hexutil.Decode(params.CrossDemoAbi)
+	assert.NoError(t, err)
+	crossABI, err := abi.JSON(bytes.NewReader(data))
+	assert.NoError(t, err)
+
+	key, _ := crypto.GenerateKey()
+	anchor := crypto.PubkeyToAddress(key.PublicKey)
+	signer := NewEIP155CtxSigner(big.NewInt(1))
+	sign := func(value int64) *CrossTransaction {
+		ctx := NewCrossTransaction(big.NewInt(value), big.NewInt(2), big.NewInt(2), common.HexToHash("0x01"),
+			common.HexToHash("0x02"), common.HexToHash("0x03"), anchor, common.Address{}, nil)
+		signed, err := SignCtx(ctx, signer, func(hash []byte) ([]byte, error) { return crypto.Sign(hash, key) })
+		assert.NoError(t, err)
+		return signed
+	}
+	first, second := sign(1), sign(2)
+
+	ev := NewEvidence(EvidenceDoubleSign, anchor, first, second, 0)
+	assert.NoError(t, ev.Verify())
+	assert.Equal(t, big.NewInt(1), ev.ChainId())
+	assert.Equal(t, big.NewInt(2), ev.DestinationId())
+	assert.NotEqual(t, ev.Hash(), NewEvidence(EvidenceDoubleSign, anchor, second, first, 0).Hash())
+
+	input, err := ev.ConstructData(crossABI)
+	assert.NoError(t, err)
+	assert.Equal(t, common.Hex2Bytes("5b030771"), input[:4])
+
+	assert.Equal(t, ErrInvalidEvidence, NewEvidence(EvidenceDoubleSign, anchor, first, first, 0).Verify())
+	assert.Equal(t, ErrInvalidEvidence, NewEvidence(EvidenceDoubleSign, common.Address{}, first, second, 0).Verify())
+
+	// the canonical ctx of reorg evidence is not signed by the anchor
+	canonical := sign(1)
+	canonical.Data.BlockHash = common.HexToHash("0x04")
+	canonical.Data.V, canonical.Data.R, canonical.Data.S = big.NewInt(37), big.NewInt(1), big.NewInt(2)
+	ev = NewEvidence(EvidenceReorg, anchor, first, canonical, 0)
+	assert.NoError(t, ev.Verify())
+	_, err = ev.ConstructData(crossABI)
+	assert.Equal(t, ErrNoDoubleSign, err)
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/common/hexutil"
+	"github.com/simplechain-org/go-simplechain/crypto"
+)
+
+var ErrUnsignedRoot = errors.New("finish root is not signed")
+
+// FinishRoot is a committed root of the finished ctx trie signed by anchors, finished ctxs proved
+// against it are guaranteed by a quorum of anchors instead of the database of one anchor
+type FinishRoot struct {
+	ChainId    *big.Int        `json:"chainId"`
+	Root       common.Hash     `json:"root"`
+	Number     uint64          `json:"number"` //store height of the chain when the root is signed, it differs between anchors and is not signed
+	Signatures []hexutil.Bytes `json:"signatures"`
+}
+
+func NewFinishRoot(chainID *big.Int, root common.Hash, number uint64) *FinishRoot {
+	return &FinishRoot{ChainId: chainID, Root: root, Number: number}
+}
+
+// Hash returns keccak256(chainId, root) signed by anchors
+func (r *FinishRoot) Hash() common.Hash {
+	return crypto.Keccak256Hash(common.LeftPadBytes(r.ChainId.Bytes(), 32), r.Root.Bytes())
+}
+
+// Sign appends the signature of anchor to the root
+func (r *FinishRoot) Sign(signHash SignHash) error {
+	h := r.Hash()
+	sig, err := signHash(h[:])
+	if err != nil {
+		return err
+	}
+	r.Signatures = append(r.Signatures, sig)
+	return nil
+}
+
+// Signers recovers the distinct anchors who signed the root, invalid signatures are skipped
+func (r *FinishRoot) Signers() ([]common.Address, error) {
+	var (
+		signers []common.Address
+		seen    = make(map[common.Address]bool)
+	)
+	for _, sig := range r.Signatures {
+		if signer, ok := r.signerOf(sig); ok && !seen[signer] {
+			seen[signer] = true
+			signers = append(signers, signer)
+		}
+	}
+	if len(signers) == 0 {
+		return nil, ErrUnsignedRoot
+	}
+	return signers, nil
+}
+
+// Merge adds the signatures of the same root by other signers
+func (r *FinishRoot) Merge(other *FinishRoot) {
+	if other.Hash() != r.Hash() {
+		return
+	}
+	merged := &FinishRoot{ChainId: r.ChainId, Root: r.Root, Number: r.Number}
+	merged.Signatures = append(append(merged.Signatures, r.Signatures...), other.Signatures...)
+	r.Signatures = merged.Filter(func(common.Address) bool { return true }).Signatures
+}
+
+// Filter returns a copy of root with the distinct valid signatures of the signers accepted by keep
+func (r *FinishRoot) Filter(keep func(signer common.Address) bool) *FinishRoot {
+	filtered := &FinishRoot{ChainId: r.ChainId, Root: r.Root, Number: r.Number}
+	seen := make(map[common.Address]bool)
+	for _, sig := range r.Signatures {
+		if signer, ok := r.signerOf(sig); ok && !seen[signer] && keep(signer) {
+			seen[signer] = true
+			filtered.Signatures = append(filtered.Signatures, sig)
+		}
+	}
+	return filtered
+}
+
+func (r *FinishRoot) signerOf(sig []byte) (common.Address, bool) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, false
+	}
+	h := r.Hash()
+	pub, err := crypto.SigToPub(h[:], sig)
+	if err != nil {
+		return common.Address{}, false
+	}
+	return crypto.PubkeyToAddress(*pub), true
+}
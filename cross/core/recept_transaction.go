@@ -14,6 +14,9 @@ type ReceptTransaction struct {
 	To            common.Address `json:"to" gencodec:"required"`            //Token buyer
 	DestinationId *big.Int       `json:"destinationId" gencodec:"required"` //Message destination networkId
 	ChainId       *big.Int       `json:"chainId" gencodec:"required"`
+	FillValue     *big.Int       `json:"fillValue,omitempty"` //destination value filled by a partial taker, nil if taken entirely
+	Kind          CtxKind        `json:"kind"`                //receipt of a message ctx is recorded by messageReceipt
+	Success       bool           `json:"success,omitempty"`   //result of the message call to target contract
 }
 
 func NewReceptTransaction(id, txHash common.Hash, from, to common.Address, remoteChainId, chainId *big.Int) *ReceptTransaction {
@@ -42,5 +45,59 @@ func (rws *ReceptTransaction) ConstructData(crossContract abi.ABI) ([]byte, erro
 		From:   rws.From,
 		To:     rws.To,
 	}
+	if rws.Kind == CtxKindMessage {
+		return crossContract.Pack("messageReceipt", rws.CTxId, rws.Success, rws.ChainId)
+	}
+	if rws.FillValue != nil {
+		return crossContract.Pack("makerPartialFinish", rep, rws.ChainId, rws.FillValue)
+	}
 	return crossContract.Pack("makerFinish", rep, rws.ChainId)
 }
+
+// Batchable reports whether the receipt can be finished by makerFinishBatch with others
+func (rws *ReceptTransaction) Batchable() bool {
+	return rws.Kind != CtxKindMessage && rws.FillValue == nil
+}
+
+// ReceptBatch finishes receipts of the same chain by one makerFinishBatch transaction
+type ReceptBatch struct {
+	ChainId *big.Int
+	Recepts []*ReceptTransaction
+}
+
+func NewReceptBatch(chainId *big.Int, rtxs ...*ReceptTransaction) *ReceptBatch {
+	return &ReceptBatch{ChainId: chainId, Recepts: rtxs}
+}
+
+// ID returns the ctx ID of the first receipt, which identifies the batch transaction in executor queue
+func (b *ReceptBatch) ID() common.Hash {
+	if len(b.Recepts) == 0 {
+		return common.Hash{}
+	}
+	return b.Recepts[0].CTxId
+}
+
+func (b *ReceptBatch) IDs() []common.Hash {
+	ids := make([]common.Hash, len(b.Recepts))
+	for i, rtx := range b.Recepts {
+		ids[i] = rtx.CTxId
+	}
+	return ids
+}
+
+// ConstructData packs makerFinish for a single receipt, otherwise makerFinishBatch
+func (b *ReceptBatch) ConstructData(crossContract abi.ABI) ([]byte, error) {
+	if len(b.Recepts) == 1 {
+		return b.Recepts[0].ConstructData(crossContract)
+	}
+	reps := make([]Recept, len(b.Recepts))
+	for i, rtx := range b.Recepts {
+		reps[i] = Recept{
+			TxId:   rtx.CTxId,
+			TxHash: rtx.TxHash,
+			From:   rtx.From,
+			To:     rtx.To,
+		}
+	}
+	return crossContract.Pack("makerFinishBatch", reps, b.ChainId)
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain/accounts/abi"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/crypto"
+)
+
+// ClaimTransaction withdraws the relay fees which are paid by makers and credited to the anchor
+// by the cross contract of the chain
+type ClaimTransaction struct {
+	RemoteChainId *big.Int       `json:"remoteChainId" gencodec:"required"` //the other chain of the ctxs
+	Anchor        common.Address `json:"anchor" gencodec:"required"`        //the anchor claiming fees
+	Fee           *big.Int       `json:"fee" gencodec:"required"`           //the fee credited to anchor when claiming
+}
+
+func NewClaimTransaction(remoteChainId *big.Int, anchor common.Address, fee *big.Int) *ClaimTransaction {
+	return &ClaimTransaction{RemoteChainId: remoteChainId, Anchor: anchor, Fee: fee}
+}
+
+// ID identifies the claim in the queue of anchor transactions, claims of different credited fees have different IDs
+func (tx *ClaimTransaction) ID() common.Hash {
+	return crypto.Keccak256Hash(tx.Anchor.Bytes(), common.LeftPadBytes(tx.RemoteChainId.Bytes(), 32),
+		common.LeftPadBytes(tx.Fee.Bytes(), 32))
+}
+
+func (tx *ClaimTransaction) ConstructData(crossContract abi.ABI) ([]byte, error) {
+	return crossContract.Pack("claimRelayFee", tx.RemoteChainId)
+}
+
+// AnchorProfit is the profit and loss of an anchor in a chain, accounted from its finished transactions
+type AnchorProfit struct {
+	Txs      uint64   `json:"txs"`      //finished anchor transactions
+	GasUsed  uint64   `json:"gasUsed"`  //gas used by the finished transactions
+	GasCost  *big.Int `json:"gasCost"`  //gas fee paid by anchor
+	RelayFee *big.Int `json:"relayFee"` //relay fee credited to anchor by cross contract
+	Claimed  *big.Int `json:"claimed"`  //relay fee withdrawn from cross contract
+}
+
+func NewAnchorProfit() *AnchorProfit {
+	return &AnchorProfit{
+		GasCost:  new(big.Int),
+		RelayFee: new(big.Int),
+		Claimed:  new(big.Int),
+	}
+}
+
+// Add accounts a finished anchor transaction
+func (p *AnchorProfit) Add(tx *AnchorTransaction) {
+	p.Txs++
+	p.GasUsed += tx.GasUsed
+	if tx.GasCost != nil {
+		p.GasCost.Add(p.GasCost, tx.GasCost)
+	}
+	if tx.RelayFee != nil {
+		p.RelayFee.Add(p.RelayFee, tx.RelayFee)
+	}
+	if tx.Claimed != nil {
+		p.Claimed.Add(p.Claimed, tx.Claimed)
+	}
+}
+
+// Profit returns relay fee earned minus gas fee paid
+func (p *AnchorProfit) Profit() *big.Int {
+	return new(big.Int).Sub(p.RelayFee, p.GasCost)
+}
+
+func (p *AnchorProfit) Copy() *AnchorProfit {
+	cpy := *p
+	cpy.GasCost = new(big.Int).Set(p.GasCost)
+	cpy.RelayFee = new(big.Int).Set(p.RelayFee)
+	cpy.Claimed = new(big.Int).Set(p.Claimed)
+	return &cpy
+}
@@ -0,0 +1,20 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaimTransaction_ID(t *testing.T) {
+	anchor := common.HexToAddress("0xa1")
+	claim := NewClaimTransaction(big.NewInt(512), anchor, big.NewInt(100))
+	assert.NotEqual(t, common.Hash{}, claim.ID())
+	assert.Equal(t, claim.ID(), NewClaimTransaction(big.NewInt(512), anchor, big.NewInt(100)).ID())
+	assert.NotEqual(t, claim.ID(), NewClaimTransaction(big.NewInt(512), anchor, big.NewInt(200)).ID())
+	assert.NotEqual(t, claim.ID(), NewClaimTransaction(big.NewInt(1024), anchor, big.NewInt(100)).ID())
+	assert.NotEqual(t, claim.ID(), NewClaimTransaction(big.NewInt(512), common.HexToAddress("0xa2"), big.NewInt(100)).ID())
+}
@@ -115,6 +115,7 @@ type CrossTransactionIndexed struct {
 	BlockNum uint64         `storm:"index"`
 	// normal field
 	Status uint8 `storm:"index"`
+	Kind   uint8 `storm:"index"` // order or message, derived from input
 
 	Value            *big.Int
 	BlockHash        common.Hash
@@ -125,6 +126,12 @@ type CrossTransactionIndexed struct {
 	V []*big.Int
 	R []*big.Int
 	S []*big.Int
+
+	BLS []cc.BLSSignature
+
+	// partial fills
+	Filled    *big.Int
+	Remaining *big.Int `storm:"index"` // destination value open for takers
 }
 
 //type CrossTransactionIndexed struct {
@@ -153,6 +160,7 @@ func NewCrossTransactionIndexed(ctx *cc.CrossTransactionWithSignatures) *CrossTr
 	return &CrossTransactionIndexed{
 		CtxId:            ctx.ID(),
 		Status:           uint8(ctx.Status),
+		Kind:             uint8(ctx.Kind()),
 		BlockNum:         ctx.BlockNum,
 		From:             ctx.Data.From,
 		To:               ctx.Data.To,
@@ -166,14 +174,28 @@ func NewCrossTransactionIndexed(ctx *cc.CrossTransactionWithSignatures) *CrossTr
 		V:                ctx.Data.V,
 		R:                ctx.Data.R,
 		S:                ctx.Data.S,
+		BLS:              ctx.Data.BLS,
+		Filled:           ctx.Filled,
+		Remaining:        ctx.Remaining(),
 	}
 
 }
 
+// SetFilled sets the destination value filled by partial takers, and the remaining value
+func (c *CrossTransactionIndexed) SetFilled(filled *big.Int) {
+	c.Filled = filled
+	if filled == nil {
+		c.Remaining = c.DestinationValue
+		return
+	}
+	c.Remaining = new(big.Int).Sub(c.DestinationValue, filled)
+}
+
 func (c CrossTransactionIndexed) ToCrossTransaction() *cc.CrossTransactionWithSignatures {
 	return &cc.CrossTransactionWithSignatures{
 		Status:   cc.CtxStatus(c.Status),
 		BlockNum: c.BlockNum,
+		Filled:   c.Filled,
 		Data: cc.CtxDatas{
 			Value:            c.Value,
 			CTxId:            c.CtxId,
@@ -187,6 +209,7 @@ func (c CrossTransactionIndexed) ToCrossTransaction() *cc.CrossTransactionWithSi
 			V:                c.V,
 			R:                c.R,
 			S:                c.S,
+			BLS:              c.BLS,
 		},
 	}
 }
@@ -227,3 +250,7 @@ func (m *IndexDbCache) Put(index FieldName, key interface{}, ctx *CrossTransacti
 func (m *IndexDbCache) Remove(index FieldName, key interface{}) {
 	(*lru.ARCCache)(m).Remove(indexCacheKey(index, key))
 }
+
+func (m *IndexDbCache) Purge() {
+	(*lru.ARCCache)(m).Purge()
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain/ethdb"
+
+	"github.com/asdine/storm/v3"
+)
+
+const (
+	StormEngine   = "storm"   // ctxs are saved in a BoltDB file indexed by storm
+	LevelDBEngine = "leveldb" // ctxs are saved in an ethdb with hand-maintained indexes
+)
+
+// Engine is the storage engine of cross stores, stores of a namespace share the database of the engine
+type Engine interface {
+	io.Closer
+	Namespace(ns string) Engine
+	CtxDB(chainID *big.Int, cacheSize uint64) CtxDB
+	EvidenceDB(chainID *big.Int) EvidenceDB
+}
+
+// OpenEngine opens the named database by the engine, storm is used if the engine is empty
+func OpenEngine(ctx ServiceContext, engine string, name string) (Engine, error) {
+	switch engine {
+	case "", StormEngine:
+		db, err := OpenStormDB(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return NewStormEngine(db), nil
+	case LevelDBEngine:
+		db, err := OpenEtherDB(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return NewEtherEngine(db), nil
+	default:
+		return nil, fmt.Errorf("unknown store engine %q", engine)
+	}
+}
+
+type stormEngine struct {
+	db   *storm.DB
+	node storm.Node // namespace node of the db, root node if namespace is empty
+	root bool
+}
+
+func NewStormEngine(db *storm.DB) *stormEngine {
+	return &stormEngine{db: db, node: db, root: true}
+}
+
+// Namespace returns an engine saving stores in the namespace node of the db
+func (e *stormEngine) Namespace(ns string) Engine {
+	if ns == "" {
+		return e
+	}
+	return &stormEngine{db: e.db, node: e.db.From(ns)}
+}
+
+func (e *stormEngine) CtxDB(chainID *big.Int, cacheSize uint64) CtxDB {
+	return NewIndexDB(chainID, e.node, cacheSize)
+}
+
+func (e *stormEngine) EvidenceDB(chainID *big.Int) EvidenceDB {
+	return NewEvidenceDB(chainID, e.node)
+}
+
+// Close closes the db, namespaces are closed with the root engine
+func (e *stormEngine) Close() error {
+	if !e.root {
+		return nil
+	}
+	return e.db.Close()
+}
+
+type etherEngine struct {
+	db     ethdb.Database
+	prefix string // key prefix of the namespace
+	root   bool
+}
+
+func NewEtherEngine(db ethdb.Database) *etherEngine {
+	return &etherEngine{db: db, root: true}
+}
+
+// Namespace returns an engine saving stores with the namespace prefixed keys
+func (e *etherEngine) Namespace(ns string) Engine {
+	if ns == "" {
+		return e
+	}
+	return &etherEngine{db: e.db, prefix: ns + "/"}
+}
+
+func (e *etherEngine) CtxDB(chainID *big.Int, cacheSize uint64) CtxDB {
+	return NewEtherIndexDB(chainID, e.db, e.prefix, cacheSize)
+}
+
+func (e *etherEngine) EvidenceDB(chainID *big.Int) EvidenceDB {
+	return NewEtherEvidenceDB(chainID, e.db, e.prefix)
+}
+
+// Close closes the db, namespaces are closed with the root engine
+func (e *etherEngine) Close() error {
+	if !e.root {
+		return nil
+	}
+	return e.db.Close()
+}
@@ -0,0 +1,677 @@
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/ethdb"
+	"github.com/simplechain-org/go-simplechain/log"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+
+	"github.com/asdine/storm/v3/q"
+)
+
+var (
+	ctxPrefix   = []byte("c")   // ctxPrefix + ctxID -> json encoded CrossTransactionIndexed
+	pkPrefix    = []byte("p")   // pkPrefix + pk -> ctxID, ctxs are iterated in order of insertion
+	indexPrefix = []byte("i")   // indexPrefix + field + "/" + value + ctxID -> nil
+	pkSeqKey    = []byte("seq") // pk of the last inserted ctx
+)
+
+// etherIndex encodes values of an indexed field, the encoded values are in the same order as the values
+type etherIndex struct {
+	value func(ctx *CrossTransactionIndexed) []byte
+	key   func(key interface{}) ([]byte, bool)
+}
+
+// etherIndexes are secondary indexes maintained by etherIndexDB, other fields are matched by scanning
+var etherIndexes = map[FieldName]etherIndex{
+	TxHashIndex: {
+		value: func(ctx *CrossTransactionIndexed) []byte { return ctx.TxHash.Bytes() },
+		key:   hashKey,
+	},
+	FromField: {
+		value: func(ctx *CrossTransactionIndexed) []byte { return ctx.From.Bytes() },
+		key:   addressKey,
+	},
+	ToField: {
+		value: func(ctx *CrossTransactionIndexed) []byte { return ctx.To.Bytes() },
+		key:   addressKey,
+	},
+	StatusField: {
+		value: func(ctx *CrossTransactionIndexed) []byte { return []byte{ctx.Status} },
+		key: func(key interface{}) ([]byte, bool) {
+			n, ok := toUint64(key)
+			return []byte{byte(n)}, ok && n <= 0xff
+		},
+	},
+	DestinationValue: {
+		value: func(ctx *CrossTransactionIndexed) []byte { return encodeBig(ctx.DestinationValue) },
+		key: func(key interface{}) ([]byte, bool) {
+			v, ok := key.(*big.Int)
+			return encodeBig(v), ok && v != nil && v.Sign() >= 0
+		},
+	},
+	BlockNumField: {
+		value: func(ctx *CrossTransactionIndexed) []byte { return encodeNumber(ctx.BlockNum) },
+		key: func(key interface{}) ([]byte, bool) {
+			n, ok := toUint64(key)
+			return encodeNumber(n), ok
+		},
+	},
+}
+
+func hashKey(key interface{}) ([]byte, bool) {
+	v, ok := key.(common.Hash)
+	return v.Bytes(), ok
+}
+
+func addressKey(key interface{}) ([]byte, bool) {
+	v, ok := key.(common.Address)
+	return v.Bytes(), ok
+}
+
+func toUint64(key interface{}) (uint64, bool) {
+	v := reflect.ValueOf(key)
+	switch {
+	case v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uint64:
+		return v.Uint(), true
+	case v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64 && v.Int() >= 0:
+		return uint64(v.Int()), true
+	}
+	return 0, false
+}
+
+func encodeNumber(n uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, n)
+	return enc
+}
+
+// encodeBig pads the uint256 value to 32 bytes, nil is encoded as zero
+func encodeBig(v *big.Int) []byte {
+	if v == nil || v.Sign() < 0 {
+		return make([]byte, common.HashLength)
+	}
+	return common.LeftPadBytes(v.Bytes(), common.HashLength)
+}
+
+// etherIndexDB saves ctxs of the chain in ethdb, indexes are updated with ctxs in the same batch
+type etherIndexDB struct {
+	chainID *big.Int
+	db      ethdb.Database
+	prefix  []byte
+	cache   *IndexDbCache
+	height  uint64
+	dirty   bool       // height is recounted if the highest ctx may be removed
+	mu      sync.Mutex // lock of writes and height
+	logger  log.Logger
+}
+
+func NewEtherIndexDB(chainID *big.Int, db ethdb.Database, prefix string, cacheSize uint64) *etherIndexDB {
+	dbName := "chain" + chainID.String()
+	log.Info("New EtherIndexDB", "dbName", prefix+dbName, "cacheSize", cacheSize)
+	return &etherIndexDB{
+		chainID: chainID,
+		db:      db,
+		prefix:  []byte(prefix + dbName + "/"),
+		cache:   newIndexDbCache(int(cacheSize)),
+		dirty:   true,
+		logger:  log.New("name", dbName),
+	}
+}
+
+func (d *etherIndexDB) key(prefix []byte, parts ...[]byte) []byte {
+	key := append(common.CopyBytes(d.prefix), prefix...)
+	for _, part := range parts {
+		key = append(key, part...)
+	}
+	return key
+}
+
+func (d *etherIndexDB) indexKey(field FieldName, value []byte) []byte {
+	return d.key(indexPrefix, []byte(field), []byte{'/'}, value)
+}
+
+func (d *etherIndexDB) indexEntry(field FieldName, value []byte, id common.Hash) []byte {
+	return d.key(indexPrefix, []byte(field), []byte{'/'}, value, id.Bytes())
+}
+
+func (d *etherIndexDB) ChainID() *big.Int {
+	return d.chainID
+}
+
+func (d *etherIndexDB) Count(filter ...q.Matcher) int {
+	var count int
+	matcher := q.And(filter...)
+	d.each("", func(ctx *CrossTransactionIndexed) bool {
+		if ok, err := matcher.Match(ctx); err == nil && ok {
+			count++
+		}
+		return false
+	})
+	return count
+}
+
+// Load fills the remaining value of ctxs which are stored before partial fills
+func (d *etherIndexDB) Load() error {
+	var ids []common.Hash
+	d.each("", func(ctx *CrossTransactionIndexed) bool {
+		if ctx.Remaining == nil {
+			ids = append(ids, ctx.CtxId)
+		}
+		return false
+	})
+	if len(ids) == 0 {
+		return nil
+	}
+	d.logger.Info("fill remaining value of ctx", "count", len(ids))
+	updaters := make([]func(ctx *CrossTransactionIndexed), len(ids))
+	for i := range ids {
+		updaters[i] = func(ctx *CrossTransactionIndexed) { ctx.SetFilled(ctx.Filled) }
+	}
+	return d.Updates(ids, updaters)
+}
+
+func (d *etherIndexDB) Height() uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.dirty {
+		d.height, d.dirty = 0, false
+		prefix := d.indexKey(BlockNumField, nil)
+		it := d.db.NewIteratorWithPrefix(prefix)
+		for it.Next() {
+			d.height = binary.BigEndian.Uint64(it.Key()[len(prefix):])
+		}
+		it.Release()
+	}
+	return d.height
+}
+
+// Repair rebuilds indexes of all ctxs, ctxs which can't be decoded are dropped
+func (d *etherIndexDB) Repair() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	batch := d.db.NewBatch()
+	if err := d.deletePrefix(batch, d.key(indexPrefix)); err != nil {
+		return ErrCtxDbFailure{"drop indexes failed", err}
+	}
+	if err := d.deletePrefix(batch, d.key(pkPrefix)); err != nil {
+		return ErrCtxDbFailure{"drop indexes failed", err}
+	}
+	var pk uint64
+	prefix := d.key(ctxPrefix)
+	it := d.db.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+	for it.Next() {
+		var ctx CrossTransactionIndexed
+		if err := json.Unmarshal(it.Value(), &ctx); err != nil {
+			d.logger.Warn("drop broken ctx", "id", common.BytesToHash(it.Key()[len(prefix):]).String(), "error", err)
+			batch.Delete(common.CopyBytes(it.Key()))
+			continue
+		}
+		if ctx.PK > pk {
+			pk = ctx.PK
+		}
+		if err := d.put(batch, nil, &ctx); err != nil {
+			return ErrCtxDbFailure{"repair ctx failed", err}
+		}
+	}
+	batch.Put(d.key(pkSeqKey), encodeNumber(pk))
+	if err := batch.Write(); err != nil {
+		return ErrCtxDbFailure{"commit indexes failed", err}
+	}
+	if d.cache != nil {
+		d.cache.Purge()
+	}
+	d.dirty = true
+	return nil
+}
+
+// Clean drops all ctxs and indexes of the chain
+func (d *etherIndexDB) Clean() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	batch := d.db.NewBatch()
+	if err := d.deletePrefix(batch, d.prefix); err != nil {
+		return ErrCtxDbFailure{"drop ctxs failed", err}
+	}
+	if err := batch.Write(); err != nil {
+		return ErrCtxDbFailure{"drop ctxs failed", err}
+	}
+	if d.cache != nil {
+		d.cache.Purge()
+	}
+	d.height, d.dirty = 0, false
+	return nil
+}
+
+func (d *etherIndexDB) deletePrefix(batch ethdb.Batch, prefix []byte) error {
+	it := d.db.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+	for it.Next() {
+		if err := batch.Delete(common.CopyBytes(it.Key())); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// Close does nothing, the db is closed by the engine
+func (d *etherIndexDB) Close() error {
+	return nil
+}
+
+func (d *etherIndexDB) Write(ctx *cc.CrossTransactionWithSignatures) error {
+	return d.Writes([]*cc.CrossTransactionWithSignatures{ctx}, true)
+}
+
+func (d *etherIndexDB) Writes(ctxList []*cc.CrossTransactionWithSignatures, replaceable bool) error {
+	d.logger.Debug("write cross transaction", "count", len(ctxList), "replaceable", replaceable)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var (
+		batch   = d.db.NewBatch()
+		written = make(map[common.Hash]*CrossTransactionIndexed) // ctxs written in the batch
+		pk      = d.lastPK()
+	)
+	for _, ctx := range ctxList {
+		new := NewCrossTransactionIndexed(ctx)
+		old := written[ctx.ID()]
+		if old == nil {
+			old, _ = d.read(ctx.ID())
+		}
+		switch {
+		case old == nil:
+			d.logger.Debug("add new cross transaction",
+				"id", ctx.ID().String(), "status", ctx.Status.String(), "number", ctx.BlockNum)
+			pk++
+			new.PK = pk
+
+		case canReplace(old, new, replaceable):
+			d.logger.Debug("replace cross transaction", "id", ctx.ID().String(),
+				"old_status", cc.CtxStatus(old.Status).String(), "new_status", ctx.Status.String(),
+				"old_height", old.BlockNum, "new_height", ctx.BlockNum)
+			new.PK = old.PK
+			if new.Filled == nil && old.Filled != nil { // filled value isn't synced with ctx
+				new.SetFilled(old.Filled)
+			}
+
+		default:
+			d.logger.Debug("can't add or replace cross transaction", "id", ctx.ID().String(),
+				"old_status", cc.CtxStatus(old.Status).String(), "new_status", ctx.Status.String(),
+				"old_height", old.BlockNum, "new_height", ctx.BlockNum, "replaceable", replaceable)
+			continue
+		}
+		if err := d.put(batch, old, new); err != nil {
+			return ErrCtxDbFailure{"write ctx failed", err}
+		}
+		written[new.CtxId] = new
+	}
+	if err := batch.Put(d.key(pkSeqKey), encodeNumber(pk)); err != nil {
+		return ErrCtxDbFailure{"write ctx failed", err}
+	}
+	if err := batch.Write(); err != nil {
+		return ErrCtxDbFailure{"commit ctxs failed", err}
+	}
+	d.committed(written)
+	return nil
+}
+
+func (d *etherIndexDB) lastPK() uint64 {
+	enc, err := d.db.Get(d.key(pkSeqKey))
+	if err != nil || len(enc) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(enc)
+}
+
+// put writes the ctx and its indexes to the batch, stale indexes of the old ctx are deleted
+func (d *etherIndexDB) put(batch ethdb.KeyValueWriter, old, new *CrossTransactionIndexed) error {
+	enc, err := json.Marshal(new)
+	if err != nil {
+		return err
+	}
+	if old != nil {
+		for field, index := range etherIndexes {
+			if value := index.value(old); !bytes.Equal(value, index.value(new)) {
+				if err := batch.Delete(d.indexEntry(field, value, old.CtxId)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := batch.Put(d.key(ctxPrefix, new.CtxId.Bytes()), enc); err != nil {
+		return err
+	}
+	if err := batch.Put(d.key(pkPrefix, encodeNumber(new.PK)), new.CtxId.Bytes()); err != nil {
+		return err
+	}
+	for field, index := range etherIndexes {
+		if err := batch.Put(d.indexEntry(field, index.value(new), new.CtxId), []byte{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// committed removes the written ctxs from cache and raises the height, it is called with lock held
+func (d *etherIndexDB) committed(written map[common.Hash]*CrossTransactionIndexed) {
+	for id, ctx := range written {
+		if d.cache != nil {
+			d.cache.Remove(CtxIdIndex, id)
+		}
+		if ctx.BlockNum > d.height {
+			d.height = ctx.BlockNum
+		}
+	}
+}
+
+func (d *etherIndexDB) Read(ctxId common.Hash) (*cc.CrossTransactionWithSignatures, error) {
+	ctx, err := d.get(ctxId)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.ToCrossTransaction(), nil
+}
+
+// read loads the ctx from db, ctxs returned by it can be modified
+func (d *etherIndexDB) read(ctxId common.Hash) (*CrossTransactionIndexed, error) {
+	enc, err := d.db.Get(d.key(ctxPrefix, ctxId.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	var ctx CrossTransactionIndexed
+	if err := json.Unmarshal(enc, &ctx); err != nil {
+		return nil, err
+	}
+	return &ctx, nil
+}
+
+func (d *etherIndexDB) get(ctxId common.Hash) (*CrossTransactionIndexed, error) {
+	if d.cache != nil {
+		if ctx := d.cache.Get(CtxIdIndex, ctxId); ctx != nil {
+			return ctx, nil
+		}
+	}
+	ctx, err := d.read(ctxId)
+	if err != nil {
+		return nil, ErrCtxDbFailure{fmt.Sprintf("get ctx:%s failed", ctxId.String()), err}
+	}
+	if d.cache != nil {
+		d.cache.Put(CtxIdIndex, ctxId, ctx)
+	}
+	return ctx, nil
+}
+
+// One returns the first ctx of the field value, ctxs are scanned if the field is not indexed
+func (d *etherIndexDB) One(field FieldName, key interface{}) *cc.CrossTransactionWithSignatures {
+	if field == CtxIdIndex {
+		id, ok := key.(common.Hash)
+		if !ok {
+			return nil
+		}
+		ctx, err := d.get(id)
+		if err != nil {
+			return nil
+		}
+		return ctx.ToCrossTransaction()
+	}
+	index, ok := etherIndexes[field]
+	if !ok {
+		if list := d.Query(1, 1, nil, false, q.Eq(field, key)); len(list) > 0 {
+			return list[0]
+		}
+		return nil
+	}
+	value, ok := index.key(key)
+	if !ok {
+		return nil
+	}
+	it := d.db.NewIteratorWithPrefix(d.indexKey(field, value))
+	defer it.Release()
+	for it.Next() {
+		if ctx, err := d.get(common.BytesToHash(it.Key()[len(it.Key())-common.HashLength:])); err == nil {
+			return ctx.ToCrossTransaction()
+		}
+	}
+	return nil
+}
+
+func (d *etherIndexDB) Update(id common.Hash, updater func(ctx *CrossTransactionIndexed)) error {
+	return d.Updates([]common.Hash{id}, []func(ctx *CrossTransactionIndexed){updater})
+}
+
+func (d *etherIndexDB) Updates(idList []common.Hash, updaters []func(ctx *CrossTransactionIndexed)) error {
+	if len(idList) != len(updaters) {
+		return ErrCtxDbFailure{err: errors.New("invalid updates params")}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var (
+		batch   = d.db.NewBatch()
+		written = make(map[common.Hash]*CrossTransactionIndexed)
+	)
+	for i, id := range idList {
+		old := written[id]
+		if old == nil {
+			var err error
+			if old, err = d.read(id); err != nil {
+				return ErrCtxDbFailure{fmt.Sprintf("get ctx:%s failed", id.String()), err}
+			}
+		}
+		ctx := *old
+		updaters[i](&ctx)
+		if ctx.BlockNum < old.BlockNum { // the highest ctx may be moved down
+			d.dirty = true
+		}
+		if err := d.put(batch, old, &ctx); err != nil {
+			return ErrCtxDbFailure{"update ctx failed", err}
+		}
+		written[id] = &ctx
+	}
+	if err := batch.Write(); err != nil {
+		return ErrCtxDbFailure{"commit ctxs failed", err}
+	}
+	d.committed(written)
+	return nil
+}
+
+func (d *etherIndexDB) Deletes(idList []common.Hash) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	batch := d.db.NewBatch()
+	for _, id := range idList {
+		ctx, err := d.read(id)
+		if err != nil {
+			continue
+		}
+		batch.Delete(d.key(ctxPrefix, id.Bytes()))
+		batch.Delete(d.key(pkPrefix, encodeNumber(ctx.PK)))
+		for field, index := range etherIndexes {
+			batch.Delete(d.indexEntry(field, index.value(ctx), id))
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return ErrCtxDbFailure{"delete ctxs failed", err}
+	}
+	for _, id := range idList {
+		if d.cache != nil {
+			d.cache.Remove(CtxIdIndex, id)
+		}
+	}
+	d.dirty = true
+	return nil
+}
+
+func (d *etherIndexDB) Has(id common.Hash) bool {
+	_, err := d.get(id)
+	return err == nil
+}
+
+// each iterates ctxs in order of the indexed field, or in order of insertion if the field is empty.
+// Iteration is stopped if fn returns true.
+func (d *etherIndexDB) each(field FieldName, fn func(ctx *CrossTransactionIndexed) bool) {
+	prefix := d.key(pkPrefix)
+	if field != "" {
+		prefix = d.indexKey(field, nil)
+	}
+	it := d.db.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+	for it.Next() {
+		var id common.Hash
+		if field == "" {
+			id = common.BytesToHash(it.Value())
+		} else {
+			id = common.BytesToHash(it.Key()[len(it.Key())-common.HashLength:])
+		}
+		ctx, err := d.get(id)
+		if err != nil {
+			continue
+		}
+		if fn(ctx) {
+			return
+		}
+	}
+}
+
+func (d *etherIndexDB) Query(pageSize int, startPage int, orderBy []FieldName, reverse bool, filter ...q.Matcher) []*cc.CrossTransactionWithSignatures {
+	if pageSize > 0 && startPage <= 0 {
+		return nil
+	}
+	var (
+		ctxs    []*CrossTransactionIndexed
+		matcher = q.And(filter...)
+		field   FieldName
+		sorted  = len(orderBy) == 0 // ctxs are iterated in order
+	)
+	if len(orderBy) == 1 {
+		if _, ok := etherIndexes[orderBy[0]]; ok {
+			field, sorted = orderBy[0], true
+		}
+	}
+	d.each(field, func(ctx *CrossTransactionIndexed) bool {
+		if ok, err := matcher.Match(ctx); err == nil && ok {
+			ctxs = append(ctxs, ctx)
+		}
+		return sorted && !reverse && pageSize > 0 && len(ctxs) >= pageSize*startPage
+	})
+	if !sorted {
+		sort.SliceStable(ctxs, func(i, j int) bool {
+			for _, field := range orderBy {
+				if c := compareField(ctxs[i], ctxs[j], field); c != 0 {
+					return c < 0
+				}
+			}
+			return false
+		})
+	}
+	if reverse {
+		for i, j := 0, len(ctxs)-1; i < j; i, j = i+1, j-1 {
+			ctxs[i], ctxs[j] = ctxs[j], ctxs[i]
+		}
+	}
+	if pageSize > 0 {
+		start, end := pageSize*(startPage-1), pageSize*startPage
+		if start > len(ctxs) {
+			start = len(ctxs)
+		}
+		if end > len(ctxs) {
+			end = len(ctxs)
+		}
+		ctxs = ctxs[start:end]
+	}
+	results := make([]*cc.CrossTransactionWithSignatures, len(ctxs))
+	for i, ctx := range ctxs {
+		results[i] = ctx.ToCrossTransaction()
+	}
+	return results
+}
+
+// compareField compares the field of ctxs for sorting, nil values are the lowest
+func compareField(a, b *CrossTransactionIndexed, field FieldName) int {
+	switch field {
+	case PK:
+		return compareUint64(a.PK, b.PK)
+	case KindField:
+		return compareUint64(uint64(a.Kind), uint64(b.Kind))
+	case PriceIndex:
+		switch {
+		case a.Price == nil || b.Price == nil:
+			return compareNil(a.Price == nil, b.Price == nil)
+		default:
+			return a.Price.Cmp(b.Price)
+		}
+	case RemainingValue:
+		switch {
+		case a.Remaining == nil || b.Remaining == nil:
+			return compareNil(a.Remaining == nil, b.Remaining == nil)
+		default:
+			return a.Remaining.Cmp(b.Remaining)
+		}
+	}
+	if index, ok := etherIndexes[field]; ok {
+		return bytes.Compare(index.value(a), index.value(b))
+	}
+	return 0
+}
+
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareNil(aNil, bNil bool) int {
+	switch {
+	case aNil && !bNil:
+		return -1
+	case !aNil && bNil:
+		return 1
+	}
+	return 0
+}
+
+func (d *etherIndexDB) RangeByNumber(begin, end uint64, limit int) []*cc.CrossTransactionWithSignatures {
+	var (
+		results []*cc.CrossTransactionWithSignatures
+		last    uint64
+		prefix  = d.indexKey(BlockNumField, nil)
+	)
+	it := d.db.NewIteratorWithStart(d.indexKey(BlockNumField, encodeNumber(begin)))
+	defer it.Release()
+	for it.Next() && bytes.HasPrefix(it.Key(), prefix) {
+		key := it.Key()[len(prefix):]
+		number := binary.BigEndian.Uint64(key[:8])
+		if number > end {
+			break
+		}
+		//把最后一笔ctx所在高度的所有ctx取出来
+		if limit > 0 && len(results) >= limit && number != last {
+			break
+		}
+		ctx, err := d.get(common.BytesToHash(key[8:]))
+		if err != nil {
+			continue
+		}
+		results = append(results, ctx.ToCrossTransaction())
+		last = number
+	}
+	return results
+}
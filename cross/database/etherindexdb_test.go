@@ -0,0 +1,278 @@
+package db
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/core/rawdb"
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+	"github.com/simplechain-org/go-simplechain/crypto"
+
+	"github.com/asdine/storm/v3"
+	"github.com/asdine/storm/v3/q"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEtherIndexDB_ReadWrite(t *testing.T) {
+	ctxList := generateCtx(2)
+	for _, cacheSize := range []uint64{0, 10} {
+		db := NewEtherIndexDB(big.NewInt(1), rawdb.NewMemoryDatabase(), "", cacheSize)
+
+		assert.NoError(t, db.Write(ctxList[0]))
+		assert.Equal(t, 1, db.Count(q.Eq(StatusField, cc.CtxStatusPending)))
+		assert.NoError(t, db.Write(ctxList[1]))
+		ctx, err := db.Read(ctxList[1].ID())
+		assert.NoError(t, err)
+		assert.Equal(t, ctxList[1], ctx)
+
+		assert.Equal(t, ctxList[0], db.One(CtxIdIndex, ctxList[0].ID()))
+		assert.Equal(t, ctxList[0], db.One(TxHashIndex, ctxList[0].Data.TxHash))
+		assert.Equal(t, ctxList[1], db.One(FromField, ctxList[1].Data.From))
+		assert.Nil(t, db.One(FromField, common.Address{}))
+		assert.True(t, db.Has(ctxList[0].ID()))
+		assert.EqualValues(t, 1, db.Height())
+	}
+}
+
+func TestEtherIndexDB_Namespace(t *testing.T) {
+	engine := NewEtherEngine(rawdb.NewMemoryDatabase())
+	ctxList := generateCtx(2)
+
+	assert.NoError(t, engine.CtxDB(big.NewInt(1), 0).Write(ctxList[0]))
+	assert.NoError(t, engine.Namespace("cross2").CtxDB(big.NewInt(1), 0).Write(ctxList[1]))
+	assert.NoError(t, engine.CtxDB(big.NewInt(12), 0).Write(ctxList[1]))
+
+	assert.Equal(t, 1, engine.CtxDB(big.NewInt(1), 0).Count())
+	assert.Equal(t, 1, engine.Namespace("cross2").CtxDB(big.NewInt(1), 0).Count())
+	assert.False(t, engine.CtxDB(big.NewInt(1), 0).Has(ctxList[1].ID()))
+
+	assert.NoError(t, engine.CtxDB(big.NewInt(1), 0).Clean())
+	assert.Equal(t, 0, engine.CtxDB(big.NewInt(1), 0).Count())
+	assert.Equal(t, 1, engine.CtxDB(big.NewInt(12), 0).Count())
+}
+
+func TestEtherIndexDB_Writes(t *testing.T) {
+	ctxList := generateCtx(10)
+	db := NewEtherIndexDB(big.NewInt(1), rawdb.NewMemoryDatabase(), "", 20)
+
+	assert.NoError(t, db.Writes(ctxList, false))
+	assert.Equal(t, 10, db.Count())
+
+	// replace to waiting
+	for _, ctx := range ctxList[0:6] {
+		ctx.Status = cc.CtxStatusWaiting
+	}
+	assert.NoError(t, db.Writes(ctxList, true))
+	assert.Equal(t, 6, db.Count(q.Eq(StatusField, cc.CtxStatusWaiting)))
+
+	// replace to finishing with number
+	for _, ctx := range ctxList[0:3] {
+		ctx.Status = cc.CtxStatusFinishing
+	}
+	// replace to finishing without number
+	for _, ctx := range ctxList[3:6] {
+		ctx.Status = cc.CtxStatusFinishing
+		ctx.BlockNum--
+	}
+	assert.NoError(t, db.Writes(ctxList, true))
+	assert.Equal(t, 3, db.Count(q.Eq(StatusField, cc.CtxStatusFinishing)))
+
+	// status index is replaced
+	finishing := db.Query(0, 0, []FieldName{StatusField}, true)
+	assert.Equal(t, 10, len(finishing))
+	for i, ctx := range finishing {
+		if i < 3 {
+			assert.Equal(t, cc.CtxStatusFinishing, ctx.Status)
+		} else {
+			assert.NotEqual(t, cc.CtxStatusFinishing, ctx.Status)
+		}
+	}
+	for _, ctx := range ctxList[3:6] {
+		assert.Equal(t, cc.CtxStatusWaiting, db.One(CtxIdIndex, ctx.ID()).Status)
+	}
+}
+
+func TestEtherIndexDB_Updates(t *testing.T) {
+	ctxList := generateCtx(10)
+	db := NewEtherIndexDB(big.NewInt(1), rawdb.NewMemoryDatabase(), "", 20)
+	assert.NoError(t, db.Writes(ctxList, false))
+	assert.EqualValues(t, 9, db.Height())
+
+	var (
+		ids      []common.Hash
+		updaters []func(ctx *CrossTransactionIndexed)
+	)
+	for _, ctx := range ctxList[0:6] {
+		ids = append(ids, ctx.ID())
+		updaters = append(updaters, func(ctx *CrossTransactionIndexed) {
+			ctx.Status = uint8(cc.CtxStatusWaiting)
+		})
+	}
+	assert.NoError(t, db.Updates(ids, updaters))
+	assert.Equal(t, 6, db.Count(q.Eq(StatusField, cc.CtxStatusWaiting)))
+	assert.NotNil(t, db.One(StatusField, cc.CtxStatusWaiting))
+	for _, ctx := range ctxList[0:6] {
+		assert.Equal(t, cc.CtxStatusWaiting, db.One(CtxIdIndex, ctx.ID()).Status)
+	}
+
+	// the highest ctx is moved down
+	assert.NoError(t, db.Update(ctxList[9].ID(), func(ctx *CrossTransactionIndexed) {
+		ctx.BlockNum = 1
+	}))
+	assert.EqualValues(t, 8, db.Height())
+	assert.Len(t, db.RangeByNumber(1, 1, 0), 2)
+
+	assert.Error(t, db.Update(common.Hash{}, func(ctx *CrossTransactionIndexed) {}))
+}
+
+func TestEtherIndexDB_Query(t *testing.T) {
+	ctxList := generateCtx(100)
+	db := NewEtherIndexDB(big.NewInt(1), rawdb.NewMemoryDatabase(), "", 20)
+	for _, ctx := range ctxList {
+		assert.NoError(t, db.Write(ctx))
+	}
+	assert.EqualValues(t, 99, db.Height())
+
+	// order by price
+	{
+		list := db.Query(50, 1, []FieldName{PriceIndex}, false)
+		assert.Equal(t, 50, len(list))
+		for i := 1; i < 50; i++ {
+			price1, _ := list[i-1].Price().Float64()
+			price2, _ := list[i].Price().Float64()
+			assert.LessOrEqual(t, price1, price2)
+		}
+		assert.Equal(t, 5, len(db.Query(5, 4, []FieldName{PriceIndex}, false)))
+		assert.Equal(t, 0, len(db.Query(50, 5, []FieldName{PriceIndex}, false)))
+	}
+
+	// order by indexed destination value
+	{
+		list := db.Query(10, 2, []FieldName{DestinationValue}, true)
+		assert.Equal(t, 10, len(list))
+		for i := 1; i < 10; i++ {
+			assert.True(t, list[i-1].Data.DestinationValue.Cmp(list[i].Data.DestinationValue) >= 0)
+		}
+	}
+
+	// order of insertion
+	{
+		list := db.Query(10, 3, nil, false)
+		assert.Equal(t, ctxList[20].ID(), list[0].ID())
+	}
+
+	// update status
+	{
+		assert.NoError(t, db.Update(ctxList[0].ID(), func(ctx *CrossTransactionIndexed) {
+			ctx.Status = uint8(cc.CtxStatusFinished)
+		}))
+		list := db.Query(100, 1, []FieldName{PriceIndex}, false, q.Eq(StatusField, cc.CtxStatusFinished))
+		assert.Equal(t, 1, len(list))
+		assert.Equal(t, ctxList[0].ID(), list[0].ID())
+	}
+
+	{
+		list := db.Query(0, 0, []FieldName{BlockNumField}, false, q.Eq(StatusField, cc.CtxStatusPending), q.Gte(DestinationValue, ctxList[10].Data.DestinationValue))
+		assert.NotEmpty(t, list)
+		for _, ctx := range list {
+			assert.True(t, ctx.Data.DestinationValue.Cmp(ctxList[10].Data.DestinationValue) >= 0)
+		}
+	}
+}
+
+func TestEtherIndexDB_RangeByNumber(t *testing.T) {
+	ctxList := generateCtx(10)
+	for _, ctx := range ctxList[5:] {
+		ctx.BlockNum = 5
+	}
+	db := NewEtherIndexDB(big.NewInt(1), rawdb.NewMemoryDatabase(), "", 0)
+	assert.NoError(t, db.Writes(ctxList, false))
+
+	assert.Len(t, db.RangeByNumber(0, 3, 0), 4)
+	assert.Len(t, db.RangeByNumber(2, 10, 2), 2)
+	// all ctxs in the last block are returned
+	assert.Len(t, db.RangeByNumber(4, 10, 2), 6)
+	assert.Len(t, db.RangeByNumber(6, 10, 0), 0)
+
+	assert.NoError(t, db.Deletes([]common.Hash{ctxList[5].ID(), ctxList[6].ID()}))
+	assert.Len(t, db.RangeByNumber(5, 5, 0), 3)
+	assert.EqualValues(t, 5, db.Height())
+	assert.NoError(t, db.Deletes([]common.Hash{ctxList[7].ID(), ctxList[8].ID(), ctxList[9].ID()}))
+	assert.EqualValues(t, 4, db.Height())
+	assert.Nil(t, db.One(TxHashIndex, ctxList[9].Data.TxHash))
+
+	assert.NoError(t, db.Repair())
+	assert.Equal(t, 5, db.Count())
+	assert.Len(t, db.RangeByNumber(0, 10, 0), 5)
+}
+
+func TestEtherEvidenceDB(t *testing.T) {
+	db := NewEtherEvidenceDB(big.NewInt(1), rawdb.NewMemoryDatabase(), "")
+	key, _ := crypto.GenerateKey()
+	anchor := crypto.PubkeyToAddress(key.PublicKey)
+	signer := cc.NewEIP155CtxSigner(big.NewInt(1))
+	sign := func(value int64) *cc.CrossTransaction {
+		ctx := cc.NewCrossTransaction(big.NewInt(value), big.NewInt(2), big.NewInt(2), common.HexToHash("0x01"),
+			common.HexToHash("0x02"), common.HexToHash("0x03"), anchor, common.Address{}, nil)
+		signed, err := cc.SignCtx(ctx, signer, func(hash []byte) ([]byte, error) { return crypto.Sign(hash, key) })
+		assert.NoError(t, err)
+		return signed
+	}
+	for i := int64(0); i < 3; i++ {
+		ev := cc.NewEvidence(cc.EvidenceDoubleSign, anchor, sign(1), sign(i+2), uint64(3-i))
+		added, err := db.Add(ev)
+		assert.NoError(t, err)
+		assert.True(t, added)
+		added, err = db.Add(ev)
+		assert.NoError(t, err)
+		assert.False(t, added)
+	}
+	list := db.Query(common.Address{}, 0, 0)
+	assert.Len(t, list, 3)
+	assert.EqualValues(t, 1, list[0].Time)
+	assert.NoError(t, list[0].Verify())
+	assert.Len(t, db.Query(anchor, 2, 2), 1)
+	assert.Len(t, db.Query(common.HexToAddress("0x02"), 0, 0), 0)
+}
+
+func TestMigrateStorm(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cross-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	root, err := storm.Open(filepath.Join(dir, "crossdata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer root.Close()
+
+	ctxList := generateCtx(10)
+	ctxList[0].Filled = big.NewInt(1)
+	chainDB := NewIndexDB(big.NewInt(1), root, 0)
+	pairDB := NewIndexDB(big.NewInt(2), root.From("cross2"), 0)
+	assert.NoError(t, chainDB.Writes(ctxList[:6], false))
+	assert.NoError(t, pairDB.Writes(ctxList[6:], false))
+	chainDB.Close()
+	pairDB.Close()
+
+	engine := NewEtherEngine(rawdb.NewMemoryDatabase())
+	ctxs, _, err := MigrateStorm(root, engine)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, ctxs)
+
+	migrated := engine.CtxDB(big.NewInt(1), 0)
+	assert.Equal(t, 6, migrated.Count())
+	assert.Equal(t, ctxList[0], migrated.One(TxHashIndex, ctxList[0].Data.TxHash))
+	assert.EqualValues(t, 5, migrated.Height())
+	assert.Equal(t, 4, engine.Namespace("cross2").CtxDB(big.NewInt(2), 0).Count())
+
+	// migrate again
+	_, _, err = MigrateStorm(root, engine)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, engine.CtxDB(big.NewInt(1), 0).Count())
+}
@@ -0,0 +1,227 @@
+package db
+
+import (
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/ethdb"
+	"github.com/simplechain-org/go-simplechain/log"
+	"github.com/simplechain-org/go-simplechain/rlp"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+
+	"github.com/asdine/storm/v3"
+	"github.com/asdine/storm/v3/q"
+)
+
+const (
+	EvidenceSignerField FieldName = "Signer"
+	EvidenceTimeField   FieldName = "Time"
+)
+
+// EvidenceIndexed is an anchor equivocation evidence, ctxs are saved in rlp to keep the signatures
+type EvidenceIndexed struct {
+	PK     uint64         `storm:"id,increment"`
+	Hash   common.Hash    `storm:"unique"`
+	CtxId  common.Hash    `storm:"index"`
+	Signer common.Address `storm:"index"`
+	Kind   uint8
+	First  []byte
+	Second []byte
+	Time   uint64 `storm:"index"`
+}
+
+func NewEvidenceIndexed(ev *cc.Evidence) (*EvidenceIndexed, error) {
+	first, err := rlp.EncodeToBytes(ev.First)
+	if err != nil {
+		return nil, err
+	}
+	second, err := rlp.EncodeToBytes(ev.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &EvidenceIndexed{
+		Hash:   ev.Hash(),
+		CtxId:  ev.ID(),
+		Signer: ev.Signer,
+		Kind:   uint8(ev.Kind),
+		First:  first,
+		Second: second,
+		Time:   ev.Time,
+	}, nil
+}
+
+func (e EvidenceIndexed) ToEvidence() (*cc.Evidence, error) {
+	first, second := new(cc.CrossTransaction), new(cc.CrossTransaction)
+	if err := rlp.DecodeBytes(e.First, first); err != nil {
+		return nil, err
+	}
+	if err := rlp.DecodeBytes(e.Second, second); err != nil {
+		return nil, err
+	}
+	return cc.NewEvidence(cc.EvidenceKind(e.Kind), e.Signer, first, second, e.Time), nil
+}
+
+// EvidenceDB persists anchor equivocation evidences of ctxs made in the chain
+type EvidenceDB interface {
+	// Add saves the evidence, returns false if it is already saved
+	Add(ev *cc.Evidence) (bool, error)
+	// Query returns evidences by time, of the signer if it is not empty
+	Query(signer common.Address, pageSize int, startPage int) []*cc.Evidence
+}
+
+type evidenceDB struct {
+	chainID *big.Int
+	db      storm.Node
+	logger  log.Logger
+}
+
+func NewEvidenceDB(chainID *big.Int, rootDB storm.Node) *evidenceDB {
+	dbName := "evidence" + chainID.String()
+	return &evidenceDB{
+		chainID: chainID,
+		db:      rootDB.From(dbName),
+		logger:  log.New("name", dbName),
+	}
+}
+
+func (d *evidenceDB) Add(ev *cc.Evidence) (bool, error) {
+	indexed, err := NewEvidenceIndexed(ev)
+	if err != nil {
+		return false, ErrCtxDbFailure{"encode evidence failed", err}
+	}
+	switch err := d.db.Save(indexed); err {
+	case nil:
+		d.logger.Warn("save anchor evidence", "kind", ev.Kind, "signer", ev.Signer.String(), "ctxID", ev.ID().String())
+		return true, nil
+	case storm.ErrAlreadyExists:
+		return false, nil
+	default:
+		return false, ErrCtxDbFailure{"save evidence failed", err}
+	}
+}
+
+func (d *evidenceDB) Query(signer common.Address, pageSize int, startPage int) []*cc.Evidence {
+	if pageSize > 0 && startPage <= 0 {
+		return nil
+	}
+	var (
+		records []*EvidenceIndexed
+		filter  []q.Matcher
+	)
+	if signer != (common.Address{}) {
+		filter = append(filter, q.Eq(EvidenceSignerField, signer))
+	}
+	query := d.db.Select(filter...).OrderBy(EvidenceTimeField)
+	if pageSize > 0 {
+		query.Limit(pageSize).Skip(pageSize * (startPage - 1))
+	}
+	if err := query.Find(&records); err != nil && err != storm.ErrNotFound {
+		d.logger.Warn("query evidence failed", "error", err)
+		return nil
+	}
+	evidences := make([]*cc.Evidence, 0, len(records))
+	for _, record := range records {
+		ev, err := record.ToEvidence()
+		if err != nil {
+			d.logger.Warn("decode evidence failed", "hash", record.Hash.String(), "error", err)
+			continue
+		}
+		evidences = append(evidences, ev)
+	}
+	return evidences
+}
+
+var (
+	evidencePrefix     = []byte("e") // evidencePrefix + hash -> rlp encoded EvidenceIndexed
+	evidenceTimePrefix = []byte("t") // evidenceTimePrefix + time + hash -> nil
+)
+
+type etherEvidenceDB struct {
+	chainID *big.Int
+	db      ethdb.Database
+	prefix  []byte
+	logger  log.Logger
+}
+
+func NewEtherEvidenceDB(chainID *big.Int, db ethdb.Database, prefix string) *etherEvidenceDB {
+	dbName := "evidence" + chainID.String()
+	return &etherEvidenceDB{
+		chainID: chainID,
+		db:      db,
+		prefix:  []byte(prefix + dbName + "/"),
+		logger:  log.New("name", dbName),
+	}
+}
+
+func (d *etherEvidenceDB) key(prefix []byte, parts ...[]byte) []byte {
+	key := append(common.CopyBytes(d.prefix), prefix...)
+	for _, part := range parts {
+		key = append(key, part...)
+	}
+	return key
+}
+
+func (d *etherEvidenceDB) Add(ev *cc.Evidence) (bool, error) {
+	indexed, err := NewEvidenceIndexed(ev)
+	if err != nil {
+		return false, ErrCtxDbFailure{"encode evidence failed", err}
+	}
+	if has, _ := d.db.Has(d.key(evidencePrefix, indexed.Hash.Bytes())); has {
+		return false, nil
+	}
+	enc, err := rlp.EncodeToBytes(indexed)
+	if err != nil {
+		return false, ErrCtxDbFailure{"encode evidence failed", err}
+	}
+	batch := d.db.NewBatch()
+	batch.Put(d.key(evidencePrefix, indexed.Hash.Bytes()), enc)
+	batch.Put(d.key(evidenceTimePrefix, encodeNumber(indexed.Time), indexed.Hash.Bytes()), []byte{})
+	if err := batch.Write(); err != nil {
+		return false, ErrCtxDbFailure{"save evidence failed", err}
+	}
+	d.logger.Warn("save anchor evidence", "kind", ev.Kind, "signer", ev.Signer.String(), "ctxID", ev.ID().String())
+	return true, nil
+}
+
+func (d *etherEvidenceDB) Query(signer common.Address, pageSize int, startPage int) []*cc.Evidence {
+	if pageSize > 0 && startPage <= 0 {
+		return nil
+	}
+	var (
+		evidences []*cc.Evidence
+		skip      = pageSize * (startPage - 1)
+		prefix    = d.key(evidenceTimePrefix)
+	)
+	it := d.db.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+	for it.Next() {
+		if pageSize > 0 && len(evidences) >= pageSize {
+			break
+		}
+		hash := it.Key()[len(prefix)+8:]
+		enc, err := d.db.Get(d.key(evidencePrefix, hash))
+		if err != nil {
+			continue
+		}
+		var record EvidenceIndexed
+		if err := rlp.DecodeBytes(enc, &record); err != nil {
+			d.logger.Warn("decode evidence failed", "hash", common.BytesToHash(hash).String(), "error", err)
+			continue
+		}
+		if signer != (common.Address{}) && record.Signer != signer {
+			continue
+		}
+		if pageSize > 0 && skip > 0 {
+			skip--
+			continue
+		}
+		ev, err := record.ToEvidence()
+		if err != nil {
+			d.logger.Warn("decode evidence failed", "hash", record.Hash.String(), "error", err)
+			continue
+		}
+		evidences = append(evidences, ev)
+	}
+	return evidences
+}
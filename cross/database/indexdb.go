@@ -17,7 +17,6 @@ import (
 
 type indexDB struct {
 	chainID *big.Int
-	root    *storm.DB // root db of stormDB
 	db      storm.Node
 	cache   *IndexDbCache
 	//txLog   *TransactionLog
@@ -32,13 +31,15 @@ const (
 	TxHashIndex      FieldName = "TxHash"
 	PriceIndex       FieldName = "Price"
 	StatusField      FieldName = "Status"
+	KindField        FieldName = "Kind"
 	FromField        FieldName = "From"
 	ToField          FieldName = "To"
 	DestinationValue FieldName = "DestinationValue"
+	RemainingValue   FieldName = "Remaining"
 	BlockNumField    FieldName = "BlockNum"
 )
 
-func NewIndexDB(chainID *big.Int, rootDB *storm.DB, cacheSize uint64) *indexDB {
+func NewIndexDB(chainID *big.Int, rootDB storm.Node, cacheSize uint64) *indexDB {
 	dbName := "chain" + chainID.String()
 	log.Info("New IndexDB", "dbName", dbName, "cacheSize", cacheSize)
 	return &indexDB{
@@ -58,8 +59,27 @@ func (d *indexDB) Count(filter ...q.Matcher) int {
 	return count
 }
 
+// Load fills the remaining value of ctxs which are stored before partial fills
 func (d *indexDB) Load() error {
-	return nil
+	var ids []common.Hash
+	err := d.db.Select().Each(new(CrossTransactionIndexed), func(record interface{}) error {
+		if ctx := record.(*CrossTransactionIndexed); ctx.Remaining == nil {
+			ids = append(ids, ctx.CtxId)
+		}
+		return nil
+	})
+	if err != nil && err != storm.ErrNotFound {
+		return ErrCtxDbFailure{"load ctx failed", err}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	d.logger.Info("fill remaining value of ctx", "count", len(ids))
+	updaters := make([]func(ctx *CrossTransactionIndexed), len(ids))
+	for i := range ids {
+		updaters[i] = func(ctx *CrossTransactionIndexed) { ctx.SetFilled(ctx.Filled) }
+	}
+	return d.Updates(ids, updaters)
 }
 
 func (d *indexDB) Height() uint64 {
@@ -100,19 +120,6 @@ func (d *indexDB) Writes(ctxList []*cc.CrossTransactionWithSignatures, replaceab
 	}
 	defer tx.Rollback()
 
-	canReplace := func(old, new *CrossTransactionIndexed) bool {
-		if !replaceable {
-			return false
-		}
-		if new.Status == uint8(cc.CtxStatusPending) {
-			return false
-		}
-		if new.BlockNum < old.BlockNum {
-			return false
-		}
-		return true
-	}
-
 	for _, ctx := range ctxList {
 		//if d.txLog.IsFinish(ctx.ID()) {
 		//	continue
@@ -128,12 +135,15 @@ func (d *indexDB) Writes(ctxList []*cc.CrossTransactionWithSignatures, replaceab
 				return err
 			}
 
-		} else if canReplace(&old, new) {
+		} else if canReplace(&old, new, replaceable) {
 			d.logger.Debug("replace cross transaction", "id", ctx.ID().String(),
 				"old_status", cc.CtxStatus(old.Status).String(), "new_status", ctx.Status.String(),
 				"old_height", old.BlockNum, "new_height", ctx.BlockNum)
 
 			new.PK = old.PK
+			if new.Filled == nil && old.Filled != nil { // filled value isn't synced with ctx
+				new.SetFilled(old.Filled)
+			}
 			if err = tx.Update(new); err != nil {
 				return err
 			}
@@ -155,6 +165,21 @@ func (d *indexDB) Writes(ctxList []*cc.CrossTransactionWithSignatures, replaceab
 	return tx.Commit()
 }
 
+// canReplace returns whether the stored ctx can be replaced by the new one,
+// pending ctxs and ctxs made in lower blocks are not replaced
+func canReplace(old, new *CrossTransactionIndexed, replaceable bool) bool {
+	if !replaceable {
+		return false
+	}
+	if new.Status == uint8(cc.CtxStatusPending) {
+		return false
+	}
+	if new.BlockNum < old.BlockNum {
+		return false
+	}
+	return true
+}
+
 func (d *indexDB) Read(ctxId common.Hash) (*cc.CrossTransactionWithSignatures, error) {
 	ctx, err := d.get(ctxId)
 	if err != nil {
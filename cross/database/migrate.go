@@ -0,0 +1,130 @@
+package db
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/simplechain-org/go-simplechain/log"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+
+	"github.com/asdine/storm/v3"
+)
+
+const (
+	chainBucket      = "chain"
+	evidenceBucket   = "evidence"
+	migrateBatchSize = 1000
+)
+
+// MigrateStorm copies ctxs and evidences of every namespace in the storm db to the engine.
+// Ctxs which already exist in the engine are not replaced, so an interrupted migration can be run again.
+func MigrateStorm(src *storm.DB, dst Engine) (ctxs int, evidences int, err error) {
+	migrate := func(node storm.Node, engine Engine) error {
+		for _, chain := range node.PrefixScan(chainBucket) {
+			chainID, ok := bucketChainID(chain, chainBucket)
+			if !ok {
+				continue
+			}
+			n, err := migrateCtxs(chain, engine.CtxDB(chainID, 0))
+			ctxs += n
+			if err != nil {
+				return err
+			}
+		}
+		for _, bucket := range node.PrefixScan(evidenceBucket) {
+			chainID, ok := bucketChainID(bucket, evidenceBucket)
+			if !ok {
+				continue
+			}
+			n, err := migrateEvidences(bucket, engine.EvidenceDB(chainID))
+			evidences += n
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := migrate(src, dst); err != nil {
+		return ctxs, evidences, err
+	}
+	for _, node := range src.PrefixScan("") {
+		ns := node.Bucket()[0]
+		if isStoreBucket(ns) {
+			continue
+		}
+		log.Info("Migrate cross store namespace", "namespace", ns)
+		if err := migrate(node, dst.Namespace(ns)); err != nil {
+			return ctxs, evidences, err
+		}
+	}
+	return ctxs, evidences, nil
+}
+
+// bucketChainID parses the chainID of buckets named by NewIndexDB and NewEvidenceDB
+func bucketChainID(node storm.Node, prefix string) (*big.Int, bool) {
+	buckets := node.Bucket()
+	if len(buckets) == 0 || !strings.HasPrefix(buckets[len(buckets)-1], prefix) {
+		return nil, false
+	}
+	return new(big.Int).SetString(strings.TrimPrefix(buckets[len(buckets)-1], prefix), 10)
+}
+
+func isStoreBucket(name string) bool {
+	for _, prefix := range []string{chainBucket, evidenceBucket} {
+		if _, ok := new(big.Int).SetString(strings.TrimPrefix(name, prefix), 10); ok && strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func migrateCtxs(src storm.Node, dst CtxDB) (int, error) {
+	var (
+		count int
+		batch []*cc.CrossTransactionWithSignatures
+	)
+	flush := func() error {
+		if err := dst.Writes(batch, false); err != nil {
+			return err
+		}
+		count += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	err := src.Select().Each(new(CrossTransactionIndexed), func(record interface{}) error {
+		batch = append(batch, record.(*CrossTransactionIndexed).ToCrossTransaction())
+		if len(batch) >= migrateBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil && err != storm.ErrNotFound {
+		return count, ErrCtxDbFailure{"migrate ctx failed", err}
+	}
+	if err := flush(); err != nil {
+		return count, ErrCtxDbFailure{"migrate ctx failed", err}
+	}
+	log.Info("Migrate cross transactions", "chainID", dst.ChainID(), "count", count)
+	return count, nil
+}
+
+func migrateEvidences(src storm.Node, dst EvidenceDB) (int, error) {
+	var count int
+	err := src.Select().Each(new(EvidenceIndexed), func(record interface{}) error {
+		ev, err := record.(*EvidenceIndexed).ToEvidence()
+		if err != nil {
+			return err
+		}
+		if _, err := dst.Add(ev); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil && err != storm.ErrNotFound {
+		return count, ErrCtxDbFailure{"migrate evidence failed", err}
+	}
+	return count, nil
+}
@@ -1,25 +1,32 @@
 package db
 
 import (
+	"errors"
 	"math/big"
 	"sync"
 
 	"github.com/simplechain-org/go-simplechain/common"
 	"github.com/simplechain-org/go-simplechain/cross/core"
+	"github.com/simplechain-org/go-simplechain/crypto"
 	"github.com/simplechain-org/go-simplechain/ethdb"
+	"github.com/simplechain-org/go-simplechain/ethdb/memorydb"
 	"github.com/simplechain-org/go-simplechain/log"
 	"github.com/simplechain-org/go-simplechain/rlp"
 	"github.com/simplechain-org/go-simplechain/trie"
 )
 
 var (
-	FinishedRoot = []byte("_FINISHED_ROOT_")
+	FinishedRoot   = []byte("_FINISHED_ROOT_")
+	FinishedAnchor = []byte("_FINISHED_ANCHOR_")
+
+	ErrNotFinished = errors.New("ctx is not finished")
 )
 
 type TransactionLogs struct {
 	diskDB   ethdb.KeyValueStore
 	trieDB   *trie.Database
 	finished *trie.Trie
+	root     common.Hash // last committed root
 
 	lock sync.RWMutex
 }
@@ -36,7 +43,7 @@ func NewTransactionLogs(db ethdb.KeyValueStore) (*TransactionLogs, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &TransactionLogs{diskDB: db, trieDB: database, finished: finished}, nil
+	return &TransactionLogs{diskDB: db, trieDB: database, finished: finished, root: finished.Hash()}, nil
 }
 
 func (l *TransactionLogs) Get(chainID *big.Int) *TransactionLog {
@@ -102,5 +109,93 @@ func (l *TransactionLog) Commit() (common.Hash, error) {
 	if err := l.trieDB.Commit(root, false); err != nil {
 		return root, err
 	}
-	return root, l.diskDB.Put(FinishedRoot, root.Bytes())
+	if err := l.diskDB.Put(FinishedRoot, root.Bytes()); err != nil {
+		return root, err
+	}
+	l.root = root
+	return root, nil
+}
+
+// Root returns the last committed root of finished trie
+func (l *TransactionLogs) Root() common.Hash {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.root
+}
+
+// Prove returns the merkle proof of the finished ctx in the trie of committed root
+func (l *TransactionLog) Prove(root common.Hash, hash common.Hash) ([][]byte, error) {
+	finished, err := trie.New(root, l.trieDB)
+	if err != nil {
+		return nil, err
+	}
+	key := getKey(l.chainID, hash)
+	if enc, err := finished.TryGet(key); err != nil || len(enc) == 0 {
+		return nil, ErrNotFinished
+	}
+	var proof proofList
+	if err := finished.Prove(key, 0, &proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// AnchoredRoot returns the last finish root signed by a quorum of anchors of chain
+func (l *TransactionLog) AnchoredRoot() *core.FinishRoot {
+	enc, err := l.diskDB.Get(anchorKey(l.chainID))
+	if err != nil {
+		return nil
+	}
+	var root core.FinishRoot
+	if err := rlp.DecodeBytes(enc, &root); err != nil {
+		log.Warn("decode anchored root failed", "chainID", l.chainID, "error", err)
+		return nil
+	}
+	return &root
+}
+
+func (l *TransactionLog) SetAnchoredRoot(root *core.FinishRoot) error {
+	enc, err := rlp.EncodeToBytes(root)
+	if err != nil {
+		return err
+	}
+	return l.diskDB.Put(anchorKey(l.chainID), enc)
+}
+
+func anchorKey(chainID *big.Int) []byte {
+	return append(common.CopyBytes(FinishedAnchor), chainID.Bytes()...)
+}
+
+// VerifyFinishProof checks the proof of ctx against root, returns the finished ctx if it is valid
+func VerifyFinishProof(root common.Hash, chainID *big.Int, hash common.Hash, proof [][]byte) (*core.CrossTransactionWithSignatures, error) {
+	proofDB := memorydb.New()
+	for _, node := range proof {
+		if err := proofDB.Put(crypto.Keccak256(node), node); err != nil {
+			return nil, err
+		}
+	}
+	enc, _, err := trie.VerifyProof(root, getKey(chainID, hash), proofDB)
+	if err != nil {
+		return nil, err
+	}
+	if len(enc) == 0 {
+		return nil, ErrNotFinished
+	}
+	var ctx core.CrossTransactionWithSignatures
+	if err := rlp.DecodeBytes(enc, &ctx); err != nil {
+		return nil, err
+	}
+	return &ctx, nil
+}
+
+// proofList collects the trie nodes of a proof in order
+type proofList [][]byte
+
+func (n *proofList) Put(key []byte, value []byte) error {
+	*n = append(*n, value)
+	return nil
+}
+
+func (n *proofList) Delete(key []byte) error {
+	panic("not supported")
 }
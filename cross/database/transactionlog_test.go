@@ -6,6 +6,7 @@ import (
 
 	"github.com/simplechain-org/go-simplechain/common"
 	"github.com/simplechain-org/go-simplechain/cross/core"
+	"github.com/simplechain-org/go-simplechain/crypto"
 	"github.com/simplechain-org/go-simplechain/ethdb/memorydb"
 	"github.com/stretchr/testify/assert"
 )
@@ -42,3 +43,58 @@ func TestTransactionLog_AddFinish(t *testing.T) {
 		assert.False(t, l.IsFinish(common.BytesToHash([]byte("3"))))
 	}
 }
+
+func TestTransactionLog_Prove(t *testing.T) {
+	db := memorydb.New()
+	defer db.Close()
+
+	txLogs, err := NewTransactionLogs(db)
+	assert.NoError(t, err)
+	l := txLogs.Get(big.NewInt(1))
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, l.AddFinish(&core.CrossTransactionWithSignatures{
+			Data:     core.CtxDatas{CTxId: common.BigToHash(big.NewInt(int64(i)))},
+			Status:   core.CtxStatusFinished,
+			BlockNum: uint64(i),
+		}))
+	}
+	root, err := l.Commit()
+	assert.NoError(t, err)
+	assert.Equal(t, root, txLogs.Root())
+
+	id := common.BigToHash(big.NewInt(5))
+	proof, err := l.Prove(root, id)
+	assert.NoError(t, err)
+	ctx, err := VerifyFinishProof(root, big.NewInt(1), id, proof)
+	assert.NoError(t, err)
+	assert.Equal(t, id, ctx.ID())
+	assert.Equal(t, uint64(5), ctx.BlockNum)
+
+	_, err = VerifyFinishProof(root, big.NewInt(2), id, proof)
+	assert.Error(t, err, "proof of another chain")
+	_, err = l.Prove(root, common.BigToHash(big.NewInt(11)))
+	assert.Equal(t, ErrNotFinished, err)
+
+	// proof against the old root is still valid after new commit
+	assert.NoError(t, l.AddFinish(&core.CrossTransactionWithSignatures{
+		Data:   core.CtxDatas{CTxId: common.BigToHash(big.NewInt(11))},
+		Status: core.CtxStatusFinished,
+	}))
+	_, err = l.Commit()
+	assert.NoError(t, err)
+	proof, err = l.Prove(root, id)
+	assert.NoError(t, err)
+	_, err = VerifyFinishProof(root, big.NewInt(1), id, proof)
+	assert.NoError(t, err)
+
+	assert.Nil(t, l.AnchoredRoot())
+	key, _ := crypto.GenerateKey()
+	anchored := core.NewFinishRoot(big.NewInt(1), root, 10)
+	assert.NoError(t, anchored.Sign(func(hash []byte) ([]byte, error) { return crypto.Sign(hash, key) }))
+	assert.NoError(t, l.SetAnchoredRoot(anchored))
+	saved := l.AnchoredRoot()
+	assert.Equal(t, root, saved.Root)
+	signers, err := saved.Signers()
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Address{crypto.PubkeyToAddress(key.PublicKey)}, signers)
+}
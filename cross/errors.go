@@ -15,5 +15,6 @@ var (
 	ErrReorgCtx        = fmt.Errorf("[%w]: ctx is on sidechain", ErrVerifyCtx)
 	ErrInternal        = fmt.Errorf("[%w]: internal error", ErrVerifyCtx)
 	ErrRepetitionCtx   = fmt.Errorf("[%w]: repetition cross transaction", ErrVerifyCtx) // 合约重复接单
+	ErrInvalidBLSShare = fmt.Errorf("[%w]: invalid BLS signature share", ErrVerifyCtx)
 
 )
@@ -22,3 +22,10 @@ type ServiceContext struct {
 	Retriever     trigger.ChainRetriever
 	Executor      trigger.Executor
 }
+
+// ChainPair is the main chain paired with a remote chain
+type ChainPair struct {
+	Main   *ServiceContext
+	Sub    *ServiceContext
+	Legacy bool // the main/sub pair migrated from the legacy config, see Config.LegacyPair
+}
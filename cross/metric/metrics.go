@@ -0,0 +1,58 @@
+package metric
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/simplechain-org/go-simplechain/metrics"
+	"github.com/simplechain-org/go-simplechain/params"
+)
+
+// 跨链指标注册在metrics.DefaultRegistry中，开启--metrics后由/debug/metrics/prometheus导出，
+// 名称为cross/<chainID>/<name>
+
+func name(chainID uint64, path string) string {
+	return fmt.Sprintf("cross/%d/%s", chainID, path)
+}
+
+// StatusGauge is the number of ctxs in the status of chain store
+func StatusGauge(chainID uint64, status fmt.Stringer) metrics.Gauge {
+	return metrics.GetOrRegisterGauge(name(chainID, "ctx/"+status.String()), nil)
+}
+
+// PoolGauges are the number of ctxs in pending and queued of chain pool
+func PoolGauges(chainID uint64) (pending, queued metrics.Gauge) {
+	return metrics.GetOrRegisterGauge(name(chainID, "pool/pending"), nil),
+		metrics.GetOrRegisterGauge(name(chainID, "pool/queued"), nil)
+}
+
+// SignLatency is the milliseconds from a local ctx added to pool to its signatures collected
+func SignLatency(chainID uint64) metrics.Histogram {
+	return metrics.GetOrRegisterHistogram(name(chainID, "pool/sign"), nil, metrics.NewExpDecaySample(1028, 0.015))
+}
+
+// SyncLag is the number of blocks of chain store behind the peer syncing with
+func SyncLag(chainID uint64) metrics.Gauge {
+	return metrics.GetOrRegisterGauge(name(chainID, "sync/lag"), nil)
+}
+
+// SubmitFailure counts anchor transactions failed to be made or executed
+func SubmitFailure(chainID uint64) metrics.Counter {
+	return metrics.GetOrRegisterCounter(name(chainID, "executor/failure"), nil)
+}
+
+// AnchorBalance is the balance of anchor in ether
+func AnchorBalance(chainID uint64) metrics.GaugeFloat64 {
+	return metrics.GetOrRegisterGaugeFloat64(name(chainID, "executor/balance"), nil)
+}
+
+func UpdateBalance(g metrics.GaugeFloat64, balance *big.Int) {
+	ether, _ := new(big.Float).Quo(new(big.Float).SetInt(balance), big.NewFloat(params.Ether)).Float64()
+	g.Update(ether)
+}
+
+// reportCounter counts the abnormal events reported of chain
+func reportCounter(chainID uint64, msg string) metrics.Counter {
+	return metrics.GetOrRegisterCounter(name(chainID, "report/"+strings.Replace(msg, " ", "_", -1)), nil)
+}
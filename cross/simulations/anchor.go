@@ -0,0 +1,167 @@
+package simulations
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/crypto"
+	"github.com/simplechain-org/go-simplechain/ethclient"
+	"github.com/simplechain-org/go-simplechain/node"
+	"github.com/simplechain-org/go-simplechain/p2p/enode"
+	p2psim "github.com/simplechain-org/go-simplechain/p2p/simulations"
+	"github.com/simplechain-org/go-simplechain/p2p/simulations/adapters"
+
+	"github.com/simplechain-org/go-simplechain/cross"
+	"github.com/simplechain-org/go-simplechain/cross/backend"
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+	cdb "github.com/simplechain-org/go-simplechain/cross/database"
+	"github.com/simplechain-org/go-simplechain/cross/trigger/rpctrigger"
+	rpcexecutor "github.com/simplechain-org/go-simplechain/cross/trigger/rpctrigger/executor"
+	rpcretriever "github.com/simplechain-org/go-simplechain/cross/trigger/rpctrigger/retriever"
+	"github.com/simplechain-org/go-simplechain/cross/trigger/simpletrigger"
+	"github.com/simplechain-org/go-simplechain/cross/trigger/simpletrigger/subscriber"
+)
+
+const serviceName = "cross"
+
+// Anchor is an anchor node of the simulation network, it follows both chains over RPC
+// and its stores are in memory, so a restarted anchor syncs everything from its peers
+type Anchor struct {
+	Name    string
+	Address common.Address
+
+	key  *ecdsa.PrivateKey
+	node *p2psim.Node
+}
+
+// ID returns the p2p node id of the anchor
+func (a *Anchor) ID() enode.ID {
+	return a.node.ID()
+}
+
+// Up reports whether the anchor node is running
+func (a *Anchor) Up() bool {
+	return a.node.Up()
+}
+
+// Service returns the cross service of the running anchor, nil if the anchor is stopped
+func (a *Anchor) Service() *backend.CrossService {
+	sn, ok := a.node.Node.(*adapters.SimNode)
+	if !ok {
+		return nil
+	}
+	if srv, ok := sn.Service(serviceName).(*anchorService); ok && a.node.Up() {
+		return srv.CrossService
+	}
+	return nil
+}
+
+// connected reports whether the anchor has a p2p connection with the other node
+func (a *Anchor) connected(other enode.ID) bool {
+	sn, ok := a.node.Node.(*adapters.SimNode)
+	if !ok || !a.node.Up() {
+		return false
+	}
+	for _, peer := range sn.Server().Peers() {
+		if peer.ID() == other {
+			return true
+		}
+	}
+	return false
+}
+
+// anchorService closes the rpc clients of the chains when the cross service is stopped
+type anchorService struct {
+	*backend.CrossService
+	clients []*ethclient.Client
+}
+
+func (s *anchorService) Stop() error {
+	err := s.CrossService.Stop()
+	s.closeClients()
+	return err
+}
+
+func (s *anchorService) closeClients() {
+	for _, client := range s.clients {
+		client.Close()
+	}
+	s.clients = nil
+}
+
+// newService creates the cross service of the anchor node, it is called on every start of the node
+func (n *Network) newService(ctx *adapters.ServiceContext) (node.Service, error) {
+	anchor := n.anchorByName(ctx.Config.Name)
+	if anchor == nil {
+		return nil, ErrUnknownAnchor
+	}
+	config := cross.Config{
+		MainContract: n.Main.Contract,
+		SubContract:  n.Sub.Contract,
+		Signer:       anchor.Address,
+		Anchors:      n.anchorAddresses(),
+		ExpireNumber: n.config.ExpireNumber,
+		ExpireCheck:  expireCheck,
+		StoreEngine:  cdb.LevelDBEngine, // in-memory ctx store of the ephemeral node
+	}
+
+	srv := new(anchorService)
+	mainCtx, err := n.newServiceContext(srv, n.Main, anchor, config)
+	if err != nil {
+		srv.closeClients()
+		return nil, err
+	}
+	subCtx, err := n.newServiceContext(srv, n.Sub, anchor, config)
+	if err != nil {
+		srv.closeClients()
+		return nil, err
+	}
+	srv.CrossService, err = backend.NewCrossService(ctx.NodeContext, []cross.ChainPair{{Main: mainCtx, Sub: subCtx}}, config)
+	if err != nil {
+		srv.closeClients()
+		return nil, err
+	}
+	return srv, nil
+}
+
+func (n *Network) newServiceContext(srv *anchorService, chain *Chain, anchor *Anchor, config cross.Config) (*cross.ServiceContext, error) {
+	client := chain.Dial()
+	srv.clients = append(srv.clients, client)
+
+	protocol, err := rpctrigger.NewRPCChain(client, chain.ID, chain.Contract, "")
+	if err != nil {
+		return nil, err
+	}
+	exec, err := rpcexecutor.NewRPCExecutor(protocol, anchor.Address, keySigner{anchor.key}, "")
+	if err != nil {
+		return nil, err
+	}
+	finality := simpletrigger.NewDepthFinality(n.config.ConfirmDepth)
+	ctx := &cross.ServiceContext{
+		ProtocolChain: protocol,
+		Config:        &config,
+		Executor:      exec,
+		Subscriber:    subscriber.NewSimpleSubscriber(chain.Contract, protocol, "", finality),
+	}
+	ctx.Retriever = rpcretriever.NewRPCRetriever(protocol, ctx.Config, finality)
+	return ctx, nil
+}
+
+// keySigner signs for the anchor with its private key
+type keySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s keySigner) SignHash(hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, s.key)
+}
+
+func (s keySigner) SignCtx(ctx *cc.CrossTransaction, chainID *big.Int) (*cc.CrossTransaction, error) {
+	return cc.SignCtx(ctx, cc.MakeCtxSigner(chainID), s.SignHash)
+}
+
+func (s keySigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.NewEIP155Signer(chainID), s.key)
+}
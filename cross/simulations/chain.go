@@ -0,0 +1,190 @@
+// Package simulations runs a main chain, a sub chain and anchors of the cross chain service in one process,
+// so that cross transactions can be driven through scripted scenarios (reorgs, anchor churn, expiry and
+// network partitions) and the ctx status of every anchor store can be asserted deterministically.
+package simulations
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain/accounts/abi"
+	"github.com/simplechain-org/go-simplechain/accounts/abi/bind/backends"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/common/hexutil"
+	"github.com/simplechain-org/go-simplechain/consensus/ethash"
+	"github.com/simplechain-org/go-simplechain/core"
+	"github.com/simplechain-org/go-simplechain/core/rawdb"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/crypto"
+	"github.com/simplechain-org/go-simplechain/ethclient"
+	"github.com/simplechain-org/go-simplechain/ethdb"
+	"github.com/simplechain-org/go-simplechain/params"
+	"github.com/simplechain-org/go-simplechain/rpc"
+)
+
+const (
+	chainGasLimit  = 30000000
+	deployGasLimit = 10000000
+	callGasLimit   = 1000000
+)
+
+var (
+	ErrTransactionFailed = errors.New("transaction failed")
+	ErrInvalidReorg      = errors.New("reorg deeper than the chain")
+)
+
+// Chain is a simulated blockchain with the cross contract deployed, anchors follow it over in-process JSON-RPC
+type Chain struct {
+	ID       uint64
+	Contract common.Address
+
+	db      ethdb.Database
+	backend *backends.SimulatedBackend
+	server  *rpc.Server
+	abi     abi.ABI
+	signer  types.Signer
+}
+
+// NewChain creates a simulated chain of the chain id, accounts in alloc are funded at genesis
+func NewChain(chainID uint64, alloc core.GenesisAlloc) (*Chain, error) {
+	data, err := hexutil.Decode(params.CrossDemoAbi)
+	if err != nil {
+		return nil, err
+	}
+	crossAbi, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	config := *params.AllScryptProtocolChanges
+	config.ChainID = new(big.Int).SetUint64(chainID)
+
+	db := rawdb.NewMemoryDatabase()
+	c := &Chain{
+		ID:      chainID,
+		db:      db,
+		backend: backends.NewSimulatedBackendWithConfig(db, &config, alloc, chainGasLimit),
+		server:  rpc.NewServer(),
+		abi:     crossAbi,
+		signer:  types.NewEIP155Signer(config.ChainID),
+	}
+	if err := c.server.RegisterName("eth", &ethService{db: db, backend: c.backend}); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Dial returns a new client of the chain, which should be closed by the caller
+func (c *Chain) Dial() *ethclient.Client {
+	return ethclient.NewClient(rpc.DialInProc(c.server))
+}
+
+// Commit seals the pending transactions into a new block
+func (c *Chain) Commit() {
+	c.backend.Commit()
+}
+
+// Head returns the current block number
+func (c *Chain) Head() uint64 {
+	return c.backend.Blockchain().CurrentBlock().NumberU64()
+}
+
+// Reorg replaces the latest depth blocks by length empty blocks of another miner,
+// length should be greater than depth to make the fork canonical
+func (c *Chain) Reorg(depth, length int) error {
+	chain := c.backend.Blockchain()
+	head := chain.CurrentBlock().NumberU64()
+	if uint64(depth) > head {
+		return ErrInvalidReorg
+	}
+	parent := chain.GetBlockByNumber(head - uint64(depth))
+	blocks, _ := core.GenerateChain(chain.Config(), parent, ethash.NewFaker(), c.db, length, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{1})
+	})
+	if _, err := chain.InsertChain(blocks); err != nil {
+		return err
+	}
+	c.backend.Rollback() // rebuild pending block on the new head
+	return nil
+}
+
+// Deploy creates the cross contract by the owner and seals it
+func (c *Chain) Deploy(owner *ecdsa.PrivateKey, code []byte) error {
+	from := crypto.PubkeyToAddress(owner.PublicKey)
+	nonce, err := c.backend.PendingNonceAt(context.Background(), from)
+	if err != nil {
+		return err
+	}
+	tx, err := types.SignTx(types.NewContractCreation(nonce, new(big.Int), deployGasLimit, big.NewInt(1), code), c.signer, owner)
+	if err != nil {
+		return err
+	}
+	if _, err := c.send(tx); err != nil {
+		return err
+	}
+	c.Contract = crypto.CreateAddress(from, nonce)
+	return nil
+}
+
+// Transact calls the method of the cross contract by key with value, the transaction is sealed
+// in a new block and its receipt is returned
+func (c *Chain) Transact(key *ecdsa.PrivateKey, value *big.Int, method string, args ...interface{}) (*types.Receipt, error) {
+	input, err := c.abi.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := c.backend.PendingNonceAt(context.Background(), crypto.PubkeyToAddress(key.PublicKey))
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+	tx, err := types.SignTx(types.NewTransaction(nonce, c.Contract, value, callGasLimit, big.NewInt(1), input), c.signer, key)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(tx)
+}
+
+// Call calls the constant method of the cross contract at the latest block
+func (c *Chain) Call(method string, args ...interface{}) ([]byte, error) {
+	input, err := c.abi.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	return c.backend.CallContract(context.Background(), callMsg(c.Contract, input), nil)
+}
+
+// Balance returns the balance of the account at the latest block
+func (c *Chain) Balance(account common.Address) (*big.Int, error) {
+	return c.backend.BalanceAt(context.Background(), account, nil)
+}
+
+// Event returns the topic of the cross contract event
+func (c *Chain) Event(name string) common.Hash {
+	return c.abi.Events[name].ID()
+}
+
+func (c *Chain) send(tx *types.Transaction) (*types.Receipt, error) {
+	if err := c.backend.SendTransaction(context.Background(), tx); err != nil {
+		return nil, err
+	}
+	c.backend.Commit()
+	receipt, err := c.backend.TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return receipt, ErrTransactionFailed
+	}
+	return receipt, nil
+}
+
+func (c *Chain) Close() {
+	c.server.Stop()
+	c.backend.Close()
+}
@@ -0,0 +1,173 @@
+package simulations
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/core"
+	"github.com/simplechain-org/go-simplechain/crypto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRemoteChainID = 512
+
+var testRelayFee = big.NewInt(1e16)
+
+// recept is the receipt of a ctx finished by anchors in the cross contract
+type recept struct {
+	TxId   common.Hash
+	TxHash common.Hash
+	From   common.Address
+	To     common.Address
+}
+
+// testContract is the cross contract deployed in a chain without anchor nodes, anchors are driven by keys
+type testContract struct {
+	*Chain
+	owner   *ecdsa.PrivateKey
+	maker   *ecdsa.PrivateKey
+	anchors []*ecdsa.PrivateKey
+}
+
+func newTestContract(t *testing.T) *testContract {
+	data, err := ioutil.ReadFile("../contract/crossdemo/crossDemo.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &testContract{owner: deriveKey("owner"), maker: deriveKey("maker")}
+	alloc := core.GenesisAlloc{
+		crypto.PubkeyToAddress(c.owner.PublicKey): {Balance: initialBalance},
+		crypto.PubkeyToAddress(c.maker.PublicKey): {Balance: initialBalance},
+	}
+	var addresses []common.Address
+	for i := 0; i < 3; i++ {
+		key := deriveKey(fmt.Sprintf("anchor%d", i))
+		c.anchors = append(c.anchors, key)
+		addresses = append(addresses, crypto.PubkeyToAddress(key.PublicKey))
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = core.GenesisAccount{Balance: initialBalance}
+	}
+	if c.Chain, err = NewChain(1024, alloc); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Deploy(c.owner, common.FromHex(strings.TrimSpace(string(data)))); err != nil {
+		t.Fatal(err)
+	}
+	if fee, err := c.Call("getRelayFee", big.NewInt(testRemoteChainID), common.Address{}); err != nil || len(fee) == 0 {
+		t.Fatal(ErrOutdatedContract)
+	}
+	if _, err := c.Transact(c.owner, nil, "chainRegister", big.NewInt(testRemoteChainID), maxCtxValue, uint8(2), addresses); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// makerStart makes a ctx paying testRelayFee and returns its txId
+func (c *testContract) makerStart(t *testing.T) common.Hash {
+	receipt, err := c.Transact(c.maker, new(big.Int).Add(testValue, testRelayFee), "makerStart", big.NewInt(testRemoteChainID),
+		testDestValue, common.Address{}, []byte{}, testRelayFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, log := range receipt.Logs {
+		if len(log.Topics) > 1 && log.Topics[0] == c.Event("MakerTx") {
+			return log.Topics[1]
+		}
+	}
+	t.Fatal(ErrUnknownCtx)
+	return common.Hash{}
+}
+
+func (c *testContract) relayFee(t *testing.T, anchor int) *big.Int {
+	ret, err := c.Call("getRelayFee", big.NewInt(testRemoteChainID), crypto.PubkeyToAddress(c.anchors[anchor].PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return new(big.Int).SetBytes(ret)
+}
+
+func (c *testContract) balance(t *testing.T, account common.Address) *big.Int {
+	balance, err := c.Balance(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return balance
+}
+
+func (c *testContract) partialFinish(anchor int, txID, txHash common.Hash, fillValue *big.Int) error {
+	rtx := &recept{TxId: txID, TxHash: txHash, From: crypto.PubkeyToAddress(c.maker.PublicKey), To: common.HexToAddress("0x7a")}
+	_, err := c.Transact(c.anchors[anchor], nil, "makerPartialFinish", rtx, big.NewInt(testRemoteChainID), fillValue)
+	return err
+}
+
+func TestContract_PartialFinishRelayFee(t *testing.T) {
+	c := newTestContract(t)
+	defer c.Close()
+
+	id := c.makerStart(t)
+	fill := new(big.Int).Div(testDestValue, big.NewInt(2))
+	assert.NoError(t, c.partialFinish(0, id, common.HexToHash("0x01"), fill))
+	assert.Zero(t, c.relayFee(t, 0).Sign(), "relay fee is credited before signConfirmCount")
+
+	// half of the fee is split by the signers reaching signConfirmCount
+	assert.NoError(t, c.partialFinish(1, id, common.HexToHash("0x01"), fill))
+	share := new(big.Int).Div(testRelayFee, big.NewInt(4))
+	assert.Equal(t, share, c.relayFee(t, 0))
+	assert.Equal(t, share, c.relayFee(t, 1))
+
+	// signatures after signConfirmCount earn nothing
+	assert.NoError(t, c.partialFinish(2, id, common.HexToHash("0x01"), fill))
+	assert.Zero(t, c.relayFee(t, 2).Sign())
+}
+
+func TestContract_CancelAfterPartialSign(t *testing.T) {
+	c := newTestContract(t)
+	defer c.Close()
+
+	// fills of different txHash are signed by single anchors and never reach signConfirmCount
+	id := c.makerStart(t)
+	fill := new(big.Int).Div(testDestValue, big.NewInt(2))
+	assert.NoError(t, c.partialFinish(0, id, common.HexToHash("0x01"), fill))
+	assert.NoError(t, c.partialFinish(1, id, common.HexToHash("0x02"), fill))
+	assert.Zero(t, c.relayFee(t, 0).Sign())
+	assert.Zero(t, c.relayFee(t, 1).Sign())
+
+	// the maker is refunded the value and the whole fee, nothing is left in the contract
+	maker := crypto.PubkeyToAddress(c.maker.PublicKey)
+	before := c.balance(t, maker)
+	for i := 0; i < 2; i++ {
+		_, err := c.Transact(c.anchors[i], nil, "makerCancel", id, big.NewInt(testRemoteChainID))
+		assert.NoError(t, err)
+	}
+	refund := new(big.Int).Sub(c.balance(t, maker), before)
+	assert.Equal(t, new(big.Int).Add(testValue, testRelayFee), refund)
+	assert.Zero(t, c.balance(t, c.Contract).Sign())
+}
+
+func TestContract_CancelPartiallyFilled(t *testing.T) {
+	c := newTestContract(t)
+	defer c.Close()
+
+	// half of the order is filled and settled before expired
+	id := c.makerStart(t)
+	fill := new(big.Int).Div(testDestValue, big.NewInt(2))
+	assert.NoError(t, c.partialFinish(0, id, common.HexToHash("0x01"), fill))
+	assert.NoError(t, c.partialFinish(1, id, common.HexToHash("0x01"), fill))
+
+	// the maker is refunded the value not filled and the fee not paid
+	maker := crypto.PubkeyToAddress(c.maker.PublicKey)
+	before := c.balance(t, maker)
+	for i := 0; i < 2; i++ {
+		_, err := c.Transact(c.anchors[i], nil, "makerCancel", id, big.NewInt(testRemoteChainID))
+		assert.NoError(t, err)
+	}
+	half := func(v *big.Int) *big.Int { return new(big.Int).Div(v, big.NewInt(2)) }
+	refund := new(big.Int).Sub(c.balance(t, maker), before)
+	assert.Equal(t, new(big.Int).Add(half(testValue), half(testRelayFee)), refund)
+	assert.Equal(t, half(testRelayFee), c.balance(t, c.Contract), "paid fee is left for anchors to claim")
+}
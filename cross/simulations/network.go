@@ -0,0 +1,414 @@
+package simulations
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/core"
+	"github.com/simplechain-org/go-simplechain/crypto"
+	"github.com/simplechain-org/go-simplechain/p2p/enode"
+	p2psim "github.com/simplechain-org/go-simplechain/p2p/simulations"
+	"github.com/simplechain-org/go-simplechain/p2p/simulations/adapters"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+)
+
+const (
+	mineInterval   = 100 * time.Millisecond // time for anchors to handle a new block
+	connectTimeout = 10 * time.Second
+	expireCheck    = 1 // seconds between anchors checking expired ctxs
+)
+
+var (
+	ErrOutdatedContract = errors.New("cross contract code is older than crossdemo.sol")
+	ErrUnknownAnchor    = errors.New("unknown anchor")
+	ErrUnknownCtx       = errors.New("ctx is not stored by any running anchor")
+	ErrTimeout          = errors.New("simulation timeout")
+)
+
+var (
+	initialBalance = new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil)
+	maxCtxValue    = new(big.Int).Exp(big.NewInt(10), big.NewInt(21), nil)
+)
+
+// Config is the setup of a simulation network
+type Config struct {
+	MainChainID      uint64
+	SubChainID       uint64
+	Anchors          int    // number of anchors registered in both chains
+	SignConfirmCount uint8  // signatures of anchors to take or finish a ctx
+	ConfirmDepth     uint64 // blocks to confirm a block of both chains
+	ExpireNumber     uint64 // blocks before an untaken ctx is cancelled, 0 means never
+	Code             []byte // creation code of the cross contract
+}
+
+// Network runs the main chain, the sub chain and anchors which are connected by in-memory p2p pipes.
+// Keys of the owner, maker, taker and anchors are derived from fixed seeds, so every run of a
+// scenario makes the same ctxs.
+type Network struct {
+	Main    *Chain
+	Sub     *Chain
+	Anchors []*Anchor
+
+	config Config
+	owner  *ecdsa.PrivateKey
+	maker  *ecdsa.PrivateKey
+	taker  *ecdsa.PrivateKey
+
+	net     *p2psim.Network
+	blocked map[[2]int]bool // anchor pairs which are separated by a partition
+}
+
+// order is the ctx taken by taker in the cross contract
+type order struct {
+	Value            *big.Int
+	TxId             common.Hash
+	TxHash           common.Hash
+	From             common.Address
+	To               common.Address
+	BlockHash        common.Hash
+	DestinationValue *big.Int
+	Data             []byte
+	V                []*big.Int
+	R                [][32]byte
+	S                [][32]byte
+}
+
+// deriveKey returns the private key of a seed
+func deriveKey(seed string) *ecdsa.PrivateKey {
+	key, err := crypto.ToECDSA(crypto.Keccak256([]byte(seed)))
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// NewNetwork deploys the cross contract in both chains, registers anchors and starts the anchor nodes
+// fully connected, ErrOutdatedContract is returned if the contract code does not match the ABI
+func NewNetwork(config Config) (n *Network, err error) {
+	n = &Network{
+		config:  config,
+		owner:   deriveKey("owner"),
+		maker:   deriveKey("maker"),
+		taker:   deriveKey("taker"),
+		blocked: make(map[[2]int]bool),
+	}
+	alloc := core.GenesisAlloc{
+		crypto.PubkeyToAddress(n.owner.PublicKey): {Balance: initialBalance},
+		crypto.PubkeyToAddress(n.maker.PublicKey): {Balance: initialBalance},
+		crypto.PubkeyToAddress(n.taker.PublicKey): {Balance: initialBalance},
+	}
+	for i := 0; i < config.Anchors; i++ {
+		key := deriveKey(fmt.Sprintf("anchor%d", i))
+		n.Anchors = append(n.Anchors, &Anchor{
+			Name:    fmt.Sprintf("anchor%d", i),
+			Address: crypto.PubkeyToAddress(key.PublicKey),
+			key:     key,
+		})
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = core.GenesisAccount{Balance: initialBalance}
+	}
+
+	defer func() {
+		if err != nil {
+			n.Shutdown()
+		}
+	}()
+	if n.Main, err = NewChain(config.MainChainID, alloc); err != nil {
+		return n, err
+	}
+	if n.Sub, err = NewChain(config.SubChainID, alloc); err != nil {
+		return n, err
+	}
+	if err = n.deploy(n.Main, n.Sub); err != nil {
+		return n, err
+	}
+	if err = n.deploy(n.Sub, n.Main); err != nil {
+		return n, err
+	}
+
+	// anchors answer sync requests from their message loops, so connections need the buffers
+	// of loopback TCP, two anchors writing to each other over an unbuffered pipe deadlock
+	n.net = p2psim.NewNetwork(adapters.NewTCPAdapter(map[string]adapters.ServiceFunc{serviceName: n.newService}),
+		&p2psim.NetworkConfig{DefaultService: serviceName})
+	for i, anchor := range n.Anchors {
+		key := deriveKey(fmt.Sprintf("node%d", i))
+		if anchor.node, err = n.net.NewNodeWithConfig(&adapters.NodeConfig{
+			ID:         enode.PubkeyToIDV4(&key.PublicKey),
+			PrivateKey: key,
+			Name:       anchor.Name,
+			Services:   []string{serviceName},
+		}); err != nil {
+			return n, err
+		}
+		if err = n.net.Start(anchor.ID()); err != nil {
+			return n, err
+		}
+	}
+	return n, n.connectAll()
+}
+
+// deploy creates the cross contract in the chain and registers the remote chain with anchors
+func (n *Network) deploy(chain, remote *Chain) error {
+	if err := chain.Deploy(n.owner, n.config.Code); err != nil {
+		return err
+	}
+	// getRelayFee is the latest method of crossdemo.sol, outdated code returns nothing
+	fee, err := chain.Call("getRelayFee", new(big.Int).SetUint64(remote.ID), common.Address{})
+	if err != nil || len(fee) == 0 {
+		return ErrOutdatedContract
+	}
+	_, err = chain.Transact(n.owner, nil, "chainRegister", new(big.Int).SetUint64(remote.ID), maxCtxValue,
+		n.config.SignConfirmCount, n.anchorAddresses())
+	return err
+}
+
+func (n *Network) anchorByName(name string) *Anchor {
+	for _, anchor := range n.Anchors {
+		if anchor.Name == name {
+			return anchor
+		}
+	}
+	return nil
+}
+
+func (n *Network) anchorAddresses() []common.Address {
+	addresses := make([]common.Address, 0, len(n.Anchors))
+	for _, anchor := range n.Anchors {
+		addresses = append(addresses, anchor.Address)
+	}
+	return addresses
+}
+
+// Maker makes a ctx in the main chain which sells value for destValue of the sub chain
+func (n *Network) Maker(value, destValue *big.Int) (common.Hash, error) {
+	receipt, err := n.Main.Transact(n.maker, value, "makerStart", new(big.Int).SetUint64(n.Sub.ID), destValue,
+		common.Address{}, []byte{}, new(big.Int))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	for _, log := range receipt.Logs {
+		if log.Address == n.Main.Contract && len(log.Topics) > 1 && log.Topics[0] == n.Main.Event("MakerTx") {
+			return log.Topics[1], nil
+		}
+	}
+	return common.Hash{}, ErrUnknownCtx
+}
+
+// Taker takes the ctx in the sub chain with signatures stored by a running anchor
+func (n *Network) Taker(ctxID common.Hash) error {
+	ctx := n.Ctx(ctxID)
+	if ctx == nil {
+		return ErrUnknownCtx
+	}
+	ord := order{
+		Value:            ctx.Data.Value,
+		TxId:             ctx.Data.CTxId,
+		TxHash:           ctx.Data.TxHash,
+		From:             ctx.Data.From,
+		To:               ctx.Data.To,
+		BlockHash:        ctx.Data.BlockHash,
+		DestinationValue: ctx.Data.DestinationValue,
+		Data:             ctx.Data.Input,
+		V:                ctx.Data.V,
+	}
+	for i := range ctx.Data.R {
+		var r, s [32]byte
+		copy(r[:], common.LeftPadBytes(ctx.Data.R[i].Bytes(), 32))
+		copy(s[:], common.LeftPadBytes(ctx.Data.S[i].Bytes(), 32))
+		ord.R, ord.S = append(ord.R, r), append(ord.S, s)
+	}
+	_, err := n.Sub.Transact(n.taker, ctx.Data.DestinationValue, "taker", &ord, new(big.Int).SetUint64(n.Main.ID))
+	return err
+}
+
+// Ctx returns the ctx made in the main chain from the first running anchor which stores it
+func (n *Network) Ctx(ctxID common.Hash) *cc.CrossTransactionWithSignatures {
+	for _, anchor := range n.Anchors {
+		if srv := anchor.Service(); srv != nil {
+			if ctx := srv.GetCtx(new(big.Int).SetUint64(n.Main.ID), ctxID); ctx != nil {
+				return ctx
+			}
+		}
+	}
+	return nil
+}
+
+// Status returns the status of the ctx in the store of every running anchor which knows it
+func (n *Network) Status(ctxID common.Hash) map[string]cc.CtxStatus {
+	statuses := make(map[string]cc.CtxStatus)
+	for _, anchor := range n.Anchors {
+		if srv := anchor.Service(); srv != nil {
+			if status, ok := srv.CtxStatus(new(big.Int).SetUint64(n.Main.ID), ctxID); ok {
+				statuses[anchor.Name] = status
+			}
+		}
+	}
+	return statuses
+}
+
+// hasStatus reports whether every running anchor stores the ctx in status
+func (n *Network) hasStatus(ctxID common.Hash, status cc.CtxStatus) bool {
+	var running int
+	for _, anchor := range n.Anchors {
+		if anchor.Up() {
+			running++
+		}
+	}
+	statuses := n.Status(ctxID)
+	if running == 0 || len(statuses) != running {
+		return false
+	}
+	for _, s := range statuses {
+		if s != status {
+			return false
+		}
+	}
+	return true
+}
+
+// Mine seals blocks in both chains, one block of each chain in every interval
+func (n *Network) Mine(blocks int) {
+	for i := 0; i < blocks; i++ {
+		n.Main.Commit()
+		n.Sub.Commit()
+		time.Sleep(mineInterval)
+	}
+}
+
+// MineUntil seals blocks in both chains until cond is satisfied
+func (n *Network) MineUntil(timeout time.Duration, cond func() bool) error {
+	for deadline := time.Now().Add(timeout); !cond(); {
+		if time.Now().After(deadline) {
+			return ErrTimeout
+		}
+		n.Mine(1)
+	}
+	return nil
+}
+
+// MineUntilStatus seals blocks until every running anchor stores the ctx in status
+func (n *Network) MineUntilStatus(ctxID common.Hash, status cc.CtxStatus, timeout time.Duration) error {
+	return n.MineUntil(timeout, func() bool { return n.hasStatus(ctxID, status) })
+}
+
+// WaitStatus waits without new blocks until every running anchor stores the ctx in status
+func (n *Network) WaitStatus(ctxID common.Hash, status cc.CtxStatus, timeout time.Duration) error {
+	for deadline := time.Now().Add(timeout); !n.hasStatus(ctxID, status); {
+		if time.Now().After(deadline) {
+			return ErrTimeout
+		}
+		time.Sleep(mineInterval)
+	}
+	return nil
+}
+
+// StopAnchor stops the anchor node, its stores are dropped
+func (n *Network) StopAnchor(i int) error {
+	return n.net.Stop(n.Anchors[i].ID())
+}
+
+// StartAnchor starts the stopped anchor with empty stores and connects it with reachable anchors
+func (n *Network) StartAnchor(i int) error {
+	if err := n.net.Start(n.Anchors[i].ID()); err != nil {
+		return err
+	}
+	return n.connectAll()
+}
+
+// Partition separates anchors into groups, anchors are disconnected from anchors of other groups
+// and anchors in no group are isolated
+func (n *Network) Partition(groups ...[]int) error {
+	group := make(map[int]int)
+	for g, anchors := range groups {
+		for _, i := range anchors {
+			group[i] = g + 1
+		}
+	}
+	for i := range n.Anchors {
+		for j := i + 1; j < len(n.Anchors); j++ {
+			if gi, gj := group[i], group[j]; gi == 0 || gi != gj {
+				n.blocked[[2]int{i, j}] = true
+				if err := n.disconnect(i, j); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Heal removes partitions and reconnects all running anchors
+func (n *Network) Heal() error {
+	n.blocked = make(map[[2]int]bool)
+	return n.connectAll()
+}
+
+// connectAll connects every pair of running anchors which is not partitioned
+func (n *Network) connectAll() error {
+	for i := range n.Anchors {
+		for j := i + 1; j < len(n.Anchors); j++ {
+			if !n.Anchors[i].Up() || !n.Anchors[j].Up() || n.blocked[[2]int{i, j}] {
+				continue
+			}
+			if err := n.connect(i, j); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// connect adds the j-th anchor as a peer of the i-th anchor and waits for the connection,
+// the anchors take turns to dial since a node does not redial a peer it dialed recently
+func (n *Network) connect(i, j int) error {
+	one, other := n.Anchors[i], n.Anchors[j]
+	for deadline := time.Now().Add(connectTimeout); !one.connected(other.ID()); {
+		if time.Now().After(deadline) {
+			return ErrTimeout
+		}
+		client, err := one.node.Client()
+		if err != nil {
+			return err
+		}
+		if err := client.Call(nil, "admin_addPeer", string(other.node.Addr())); err != nil {
+			return err
+		}
+		time.Sleep(p2psim.DialBanTimeout)
+		one, other = other, one
+	}
+	return nil
+}
+
+// disconnect removes the peers of both anchors and waits until they are disconnected
+func (n *Network) disconnect(i, j int) error {
+	one, other := n.Anchors[i], n.Anchors[j]
+	for deadline := time.Now().Add(connectTimeout); one.connected(other.ID()) || other.connected(one.ID()); {
+		if time.Now().After(deadline) {
+			return ErrTimeout
+		}
+		for _, pair := range [][2]*Anchor{{one, other}, {other, one}} {
+			if client, err := pair[0].node.Client(); err == nil {
+				client.Call(nil, "admin_removePeer", string(pair[1].node.Addr()))
+			}
+		}
+		time.Sleep(p2psim.DialBanTimeout)
+	}
+	return nil
+}
+
+// Shutdown stops anchors and chains
+func (n *Network) Shutdown() {
+	if n.net != nil {
+		n.net.Shutdown()
+	}
+	if n.Main != nil {
+		n.Main.Close()
+	}
+	if n.Sub != nil {
+		n.Sub.Close()
+	}
+}
@@ -0,0 +1,155 @@
+package simulations
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain"
+	"github.com/simplechain-org/go-simplechain/accounts/abi/bind/backends"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/common/hexutil"
+	"github.com/simplechain-org/go-simplechain/core"
+	"github.com/simplechain-org/go-simplechain/core/rawdb"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/eth/filters"
+	"github.com/simplechain-org/go-simplechain/ethdb"
+	"github.com/simplechain-org/go-simplechain/rlp"
+	"github.com/simplechain-org/go-simplechain/rpc"
+)
+
+// ethService serves the simulated backend as eth namespace of JSON-RPC,
+// only the methods used by rpctrigger are implemented
+type ethService struct {
+	db      ethdb.Database
+	backend *backends.SimulatedBackend
+}
+
+type callArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Data     hexutil.Bytes   `json:"data"`
+}
+
+func (args callArgs) toMessage() simplechain.CallMsg {
+	return simplechain.CallMsg{
+		From:     args.From,
+		To:       args.To,
+		Gas:      uint64(args.Gas),
+		GasPrice: (*big.Int)(args.GasPrice),
+		Value:    (*big.Int)(args.Value),
+		Data:     args.Data,
+	}
+}
+
+func callMsg(to common.Address, data []byte) simplechain.CallMsg {
+	return simplechain.CallMsg{To: &to, Data: data}
+}
+
+func (s *ethService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(s.backend.Blockchain().Config().ChainID)
+}
+
+func (s *ethService) Syncing() (bool, error) {
+	return false, nil
+}
+
+func (s *ethService) GetBlockByNumber(number rpc.BlockNumber, fullTx bool) (*types.Header, error) {
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return s.backend.Blockchain().CurrentHeader(), nil
+	}
+	return s.backend.Blockchain().GetHeaderByNumber(uint64(number)), nil
+}
+
+func (s *ethService) GetBlockByHash(hash common.Hash, fullTx bool) (*types.Header, error) {
+	return s.backend.Blockchain().GetHeaderByHash(hash), nil
+}
+
+func (s *ethService) GetTransactionByHash(hash common.Hash) (map[string]interface{}, error) {
+	tx, blockHash, number, _ := rawdb.ReadTransaction(s.db, hash)
+	if tx == nil {
+		return nil, nil
+	}
+	enc, err := json.Marshal(tx)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(enc, &fields); err != nil {
+		return nil, err
+	}
+	fields["blockHash"] = blockHash
+	fields["blockNumber"] = hexutil.Uint64(number)
+	return fields, nil
+}
+
+func (s *ethService) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	return s.backend.TransactionReceipt(ctx, hash)
+}
+
+func (s *ethService) GetLogs(ctx context.Context, crit filters.FilterCriteria) ([]types.Log, error) {
+	return s.backend.FilterLogs(ctx, simplechain.FilterQuery(crit))
+}
+
+func (s *ethService) Call(ctx context.Context, args callArgs, number rpc.BlockNumber) (hexutil.Bytes, error) {
+	return s.backend.CallContract(ctx, args.toMessage(), nil)
+}
+
+func (s *ethService) EstimateGas(ctx context.Context, args callArgs) (hexutil.Uint64, error) {
+	gas, err := s.backend.EstimateGas(ctx, args.toMessage())
+	return hexutil.Uint64(gas), err
+}
+
+func (s *ethService) GetBalance(ctx context.Context, address common.Address, number rpc.BlockNumber) (*hexutil.Big, error) {
+	balance, err := s.backend.BalanceAt(ctx, address, nil)
+	return (*hexutil.Big)(balance), err
+}
+
+func (s *ethService) GetTransactionCount(ctx context.Context, address common.Address, number rpc.BlockNumber) (hexutil.Uint64, error) {
+	if number == rpc.PendingBlockNumber {
+		nonce, err := s.backend.PendingNonceAt(ctx, address)
+		return hexutil.Uint64(nonce), err
+	}
+	nonce, err := s.backend.NonceAt(ctx, address, nil)
+	return hexutil.Uint64(nonce), err
+}
+
+func (s *ethService) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+	price, err := s.backend.SuggestGasPrice(ctx)
+	return (*hexutil.Big)(price), err
+}
+
+func (s *ethService) SendRawTransaction(ctx context.Context, encodedTx hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), s.backend.SendTransaction(ctx, tx)
+}
+
+func (s *ethService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+	heads := make(chan core.ChainHeadEvent, 16)
+	headSub := s.backend.Blockchain().SubscribeChainHeadEvent(heads)
+	go func() {
+		defer headSub.Unsubscribe()
+		for {
+			select {
+			case h := <-heads:
+				notifier.Notify(rpcSub.ID, h.Block.Header())
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
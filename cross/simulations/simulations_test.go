@@ -0,0 +1,145 @@
+package simulations
+
+import (
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/simplechain-org/go-simplechain/common"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testConfirmDepth = 3
+	statusTimeout    = 30 * time.Second
+)
+
+var (
+	testValue     = big.NewInt(1e18)
+	testDestValue = big.NewInt(2e18)
+)
+
+func newTestNetwork(t *testing.T, expireNumber uint64) *Network {
+	data, err := ioutil.ReadFile("../contract/crossdemo/crossDemo.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := NewNetwork(Config{
+		MainChainID:      1024,
+		SubChainID:       512,
+		Anchors:          3,
+		SignConfirmCount: 2,
+		ConfirmDepth:     testConfirmDepth,
+		ExpireNumber:     expireNumber,
+		Code:             common.FromHex(strings.TrimSpace(string(data))),
+	})
+	if err == ErrOutdatedContract {
+		t.Fatal("crossDemo.bin is outdated, run go generate in cross/contract/crossdemo")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// assertStatus asserts every anchor stores the ctx in status
+func assertStatus(t *testing.T, n *Network, ctxID common.Hash, status cc.CtxStatus) {
+	statuses := n.Status(ctxID)
+	assert.Len(t, statuses, len(n.Anchors))
+	for name, s := range statuses {
+		assert.Equal(t, status.String(), s.String(), name)
+	}
+}
+
+func makeWaitingCtx(t *testing.T, n *Network) common.Hash {
+	id, err := n.Maker(testValue, testDestValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.MineUntilStatus(id, cc.CtxStatusWaiting, statusTimeout); err != nil {
+		t.Fatalf("wait ctx signed failed: %v, status: %v", err, n.Status(id))
+	}
+	return id
+}
+
+func TestNetwork_Finish(t *testing.T) {
+	n := newTestNetwork(t, 0)
+	defer n.Shutdown()
+
+	id := makeWaitingCtx(t, n)
+	assert.NoError(t, n.Taker(id))
+	assert.NoError(t, n.MineUntilStatus(id, cc.CtxStatusFinished, statusTimeout))
+	assertStatus(t, n, id, cc.CtxStatusFinished)
+}
+
+func TestNetwork_TakerReorg(t *testing.T) {
+	n := newTestNetwork(t, 0)
+	defer n.Shutdown()
+
+	id := makeWaitingCtx(t, n)
+	assert.NoError(t, n.Taker(id))
+	assert.NoError(t, n.WaitStatus(id, cc.CtxStatusExecuting, statusTimeout))
+
+	// the block of taker is replaced before confirmed
+	assert.NoError(t, n.Sub.Reorg(1, 2))
+	assert.NoError(t, n.WaitStatus(id, cc.CtxStatusWaiting, statusTimeout))
+	n.Mine(testConfirmDepth + 1)
+	assertStatus(t, n, id, cc.CtxStatusWaiting)
+
+	// the ctx can be taken again
+	assert.NoError(t, n.Taker(id))
+	assert.NoError(t, n.MineUntilStatus(id, cc.CtxStatusFinished, statusTimeout))
+	assertStatus(t, n, id, cc.CtxStatusFinished)
+}
+
+func TestNetwork_AnchorChurn(t *testing.T) {
+	n := newTestNetwork(t, 0)
+	defer n.Shutdown()
+
+	id := makeWaitingCtx(t, n)
+	assert.NoError(t, n.StopAnchor(2))
+
+	// the remaining anchors still reach signConfirmCount
+	assert.NoError(t, n.Taker(id))
+	assert.NoError(t, n.MineUntilStatus(id, cc.CtxStatusFinished, statusTimeout))
+
+	// the restarted anchor syncs the ctx from peers
+	assert.NoError(t, n.StartAnchor(2))
+	assert.NoError(t, n.MineUntilStatus(id, cc.CtxStatusFinished, statusTimeout))
+	assertStatus(t, n, id, cc.CtxStatusFinished)
+}
+
+func TestNetwork_Partition(t *testing.T) {
+	n := newTestNetwork(t, 0)
+	defer n.Shutdown()
+
+	assert.NoError(t, n.Partition([]int{0}, []int{1}, []int{2}))
+	id, err := n.Maker(testValue, testDestValue)
+	assert.NoError(t, err)
+
+	// every anchor signs alone and no ctx reaches signConfirmCount
+	assert.NoError(t, n.MineUntilStatus(id, cc.CtxStatusPending, statusTimeout))
+	n.Mine(testConfirmDepth + 1)
+	assertStatus(t, n, id, cc.CtxStatusPending)
+
+	// signatures are exchanged by pending sync after healed
+	assert.NoError(t, n.Heal())
+	assert.NoError(t, n.MineUntilStatus(id, cc.CtxStatusWaiting, statusTimeout))
+	assert.NoError(t, n.Taker(id))
+	assert.NoError(t, n.MineUntilStatus(id, cc.CtxStatusFinished, statusTimeout))
+	assertStatus(t, n, id, cc.CtxStatusFinished)
+}
+
+func TestNetwork_Expire(t *testing.T) {
+	n := newTestNetwork(t, 10)
+	defer n.Shutdown()
+
+	id := makeWaitingCtx(t, n)
+	assert.NoError(t, n.MineUntilStatus(id, cc.CtxStatusCancelled, statusTimeout))
+	assertStatus(t, n, id, cc.CtxStatusCancelled)
+}
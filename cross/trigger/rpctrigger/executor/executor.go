@@ -0,0 +1,475 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/simplechain-org/go-simplechain"
+	"github.com/simplechain-org/go-simplechain/accounts/abi"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/common/hexutil"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/log"
+	"github.com/simplechain-org/go-simplechain/params"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+	"github.com/simplechain-org/go-simplechain/cross/metric"
+	"github.com/simplechain-org/go-simplechain/cross/trigger"
+	"github.com/simplechain-org/go-simplechain/cross/trigger/rpctrigger"
+	simpleexecutor "github.com/simplechain-org/go-simplechain/cross/trigger/simpletrigger/executor"
+)
+
+const (
+	maxFinishGasLimit = 250000
+	requestTimeout    = 10 * time.Second
+	promoteInterval   = 30 * time.Second
+)
+
+// RPCExecutor sends anchor transactions of cross contract to the chain followed over RPC,
+// transactions are signed by the anchor signer of local chain and sent by eth_sendRawTransaction
+type RPCExecutor struct {
+	anchor common.Address
+	signer trigger.AnchorSigner
+
+	chain  *rpctrigger.RPCChain
+	client rpctrigger.Client
+
+	contract    common.Address
+	contractABI abi.ABI
+
+	queue  *simpleexecutor.TxQueue
+	ledger *simpleexecutor.Ledger
+	mu     sync.Mutex // protects nonce of anchor
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+	log    log.Logger
+}
+
+func NewRPCExecutor(chain *rpctrigger.RPCChain, anchor common.Address, signer trigger.AnchorSigner, journal string) (*RPCExecutor, error) {
+	logger := log.New("module", "rpcexecutor", "chainID", chain.ChainID())
+	data, err := hexutil.Decode(params.CrossDemoAbi)
+	if err != nil {
+		logger.Error("Parse crossABI", "err", err)
+		return nil, err
+	}
+	abi, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		logger.Error("Parse crossABI", "err", err)
+		return nil, err
+	}
+
+	return &RPCExecutor{
+		anchor:      anchor,
+		signer:      signer,
+		chain:       chain,
+		client:      chain.Client(),
+		contract:    chain.Contract(),
+		contractABI: abi,
+		queue:       simpleexecutor.NewTxQueue(journal),
+		ledger:      simpleexecutor.NewLedger(simpleexecutor.LedgerPath(journal)),
+		stopCh:      make(chan struct{}),
+		log:         logger,
+	}, nil
+}
+
+func (exe *RPCExecutor) Start() {
+	if err := exe.queue.Load(); err != nil {
+		exe.log.Warn("Failed to rotate executor journal", "err", err)
+	}
+	if err := exe.ledger.Load(); err != nil {
+		exe.log.Warn("Failed to load executor ledger", "err", err)
+	}
+	exe.wg.Add(1)
+	go exe.loop()
+}
+
+func (exe *RPCExecutor) loop() {
+	defer exe.wg.Done()
+	promote := time.NewTicker(promoteInterval)
+	defer promote.Stop()
+	for {
+		select {
+		case <-promote.C:
+			exe.promoteTransaction()
+
+		case <-exe.stopCh:
+			return
+		}
+	}
+}
+
+func (exe *RPCExecutor) Stop() {
+	close(exe.stopCh)
+	exe.wg.Wait()
+	exe.queue.Close()
+}
+
+// Queue returns the journaled transactions of anchor
+func (exe *RPCExecutor) Queue() []*cc.AnchorTransaction {
+	return exe.queue.List()
+}
+
+// Profit returns the gas spent and relay fees earned by the finished transactions of anchor
+func (exe *RPCExecutor) Profit() *cc.AnchorProfit {
+	return exe.ledger.Profit()
+}
+
+// RelayFee returns the relay fee of ctxs with the remote chain, which is credited to anchor but not claimed yet
+func (exe *RPCExecutor) RelayFee(remoteChainID *big.Int) (*big.Int, error) {
+	data, err := exe.contractABI.Pack("getRelayFee", remoteChainID, exe.anchor)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	res, err := exe.client.CallContract(ctx, simplechain.CallMsg{From: exe.anchor, To: &exe.contract, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(res), nil
+}
+
+// ClaimRelayFee withdraws the relay fee credited by ctxs with the remote chain, it returns the claiming fee,
+// or zero if the fee is not worth the gas or a claiming is pending
+func (exe *RPCExecutor) ClaimRelayFee(remoteChainID *big.Int) (*big.Int, error) {
+	fee, err := exe.RelayFee(remoteChainID)
+	if err != nil || fee.Sign() == 0 {
+		return new(big.Int), err
+	}
+	claim := cc.NewClaimTransaction(remoteChainID, exe.anchor, fee)
+	data, err := claim.ConstructData(exe.contractABI)
+	if err != nil {
+		return nil, err
+	}
+	for _, tx := range exe.queue.Pending() {
+		if bytes.Equal(tx.Data, data) {
+			return new(big.Int), nil
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	gasPrice, err := exe.suggestPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if fee.Cmp(new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(simpleexecutor.GasLimitOf(claim)))) <= 0 {
+		return new(big.Int), nil
+	}
+	exe.submit([]common.Hash{claim.ID()}, []contractCall{claim})
+	return fee, nil
+}
+
+func (exe *RPCExecutor) SignHash(hash []byte) ([]byte, error) {
+	return exe.signer.SignHash(hash)
+}
+
+// CanSignHash reports whether the anchor signer of local chain signs raw hashes
+func (exe *RPCExecutor) CanSignHash() bool {
+	if signer, ok := exe.signer.(trigger.HashSigner); ok {
+		return signer.CanSignHash()
+	}
+	return true
+}
+
+func (exe *RPCExecutor) SignCtx(ctx *cc.CrossTransaction, chainID *big.Int) (*cc.CrossTransaction, error) {
+	return exe.signer.SignCtx(ctx, chainID)
+}
+
+func (exe *RPCExecutor) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return exe.signer.SignTx(tx, chainID)
+}
+
+// contractCall is a transaction of the cross contract which is sent by anchor
+type contractCall interface {
+	ConstructData(crossContract abi.ABI) ([]byte, error)
+}
+
+// SubmitTransaction finishes receipts in destination chain, receipts taken entirely are finished
+// in batches, and the ones already finished are left out before batching
+func (exe *RPCExecutor) SubmitTransaction(rtxs []*cc.ReceptTransaction) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	var (
+		ids       []common.Hash
+		calls     []contractCall
+		batchable []*cc.ReceptTransaction
+	)
+	for _, rtx := range rtxs {
+		if rtx.DestinationId.Cmp(exe.chain.ChainID()) != 0 {
+			continue
+		}
+		if !rtx.Batchable() {
+			ids = append(ids, rtx.CTxId)
+			calls = append(calls, rtx)
+			continue
+		}
+		data, err := rtx.ConstructData(exe.contractABI)
+		if err != nil {
+			exe.log.Error("ConstructData", "id", rtx.CTxId, "err", err)
+			continue
+		}
+		if !exe.checkTransaction(ctx, maxFinishGasLimit, data) {
+			exe.log.Debug("already finish the cross Transaction", "id", rtx.CTxId)
+			continue
+		}
+		batchable = append(batchable, rtx)
+	}
+	for _, batch := range simpleexecutor.BatchRecepts(batchable) {
+		ids = append(ids, batch.ID())
+		calls = append(calls, batch)
+	}
+	exe.submit(ids, calls)
+}
+
+func (exe *RPCExecutor) SubmitDelivery(txs []*cc.DeliverTransaction) {
+	var (
+		ids   []common.Hash
+		calls []contractCall
+	)
+	for _, tx := range txs {
+		if tx.Ctx.DestinationId().Cmp(exe.chain.ChainID()) == 0 {
+			ids = append(ids, tx.ID())
+			calls = append(calls, tx)
+		}
+	}
+	exe.submit(ids, calls)
+}
+
+func (exe *RPCExecutor) SubmitEvidence(evs []*cc.Evidence) {
+	var (
+		ids   []common.Hash
+		calls []contractCall
+	)
+	for _, ev := range evs {
+		if ev.Kind == cc.EvidenceDoubleSign && ev.DestinationId().Cmp(exe.chain.ChainID()) == 0 {
+			ids = append(ids, ev.ID())
+			calls = append(calls, ev)
+		}
+	}
+	exe.submit(ids, calls)
+}
+
+func (exe *RPCExecutor) SubmitCancel(ctxs []*cc.CancelTransaction) {
+	var (
+		ids   []common.Hash
+		calls []contractCall
+	)
+	for _, ctx := range ctxs {
+		if ctx.ChainId.Cmp(exe.chain.ChainID()) == 0 {
+			ids = append(ids, ctx.CTxId)
+			calls = append(calls, ctx)
+		}
+	}
+	exe.submit(ids, calls)
+}
+
+func (exe *RPCExecutor) submit(ids []common.Hash, calls []contractCall) {
+	if len(calls) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	exe.mu.Lock()
+	defer exe.mu.Unlock()
+
+	nonce, err := exe.client.PendingNonceAt(ctx, exe.anchor)
+	if err != nil {
+		exe.log.Warn("get anchor nonce failed", "error", err)
+		return
+	}
+	nonce = exe.queue.NextNonce(nonce)
+	gasPrice, err := exe.suggestPrice(ctx)
+	if err != nil {
+		exe.log.Warn("suggest gas price failed", "error", err)
+		return
+	}
+	exe.checkBalance(ctx, ids, gasPrice)
+
+	for i, call := range calls {
+		data, err := call.ConstructData(exe.contractABI)
+		if err != nil {
+			exe.log.Error("ConstructData", "id", ids[i], "err", err)
+			continue
+		}
+		gasLimit := simpleexecutor.GasLimitOf(call)
+		if !exe.checkTransaction(ctx, gasLimit, data) {
+			exe.log.Debug("already finish the cross Transaction", "id", ids[i])
+			continue
+		}
+		tx, err := exe.signTransaction(nonce, gasLimit, gasPrice, data)
+		if err != nil {
+			exe.log.Warn("sign transaction failed", "id", ids[i], "err", err)
+			continue
+		}
+		// journal before sending, a failed sending is retried by promoting
+		exe.queue.Put(&cc.AnchorTransaction{
+			CTxId:    ids[i],
+			Nonce:    nonce,
+			GasPrice: gasPrice,
+			GasLimit: gasLimit,
+			Data:     data,
+			TxHash:   tx.Hash(),
+			Status:   cc.AnchorTxPending,
+			Time:     uint64(time.Now().Unix()),
+			Batch:    simpleexecutor.BatchOf(call),
+		})
+		if err := exe.client.SendTransaction(ctx, tx); err != nil {
+			exe.log.Warn("send transaction failed", "id", ids[i], "err", err)
+		}
+		nonce++
+	}
+}
+
+func (exe *RPCExecutor) suggestPrice(ctx context.Context) (*big.Int, error) {
+	gasPrice, err := exe.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if gasPrice.Cmp(simpleexecutor.MaxGasPrice) > 0 {
+		gasPrice = new(big.Int).Set(simpleexecutor.MaxGasPrice)
+	}
+	return gasPrice, nil
+}
+
+func (exe *RPCExecutor) checkBalance(ctx context.Context, ids []common.Hash, gasPrice *big.Int) {
+	required := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(maxFinishGasLimit*uint64(len(ids))))
+	if balance, err := exe.client.BalanceAt(ctx, exe.anchor, nil); err != nil || balance.Cmp(required) < 0 {
+		exe.log.Warn("insufficient balance for finishing", "count", len(ids),
+			"error", err, "balance", balance, "price", gasPrice)
+		metric.Report(exe.chain.ChainID().Uint64(), "insufficient balance", "count", len(ids))
+	}
+}
+
+// checkTransaction returns false if the transaction would fail (e.g. already finished by others)
+func (exe *RPCExecutor) checkTransaction(ctx context.Context, gasLimit uint64, data []byte) bool {
+	_, err := exe.client.EstimateGas(ctx, simplechain.CallMsg{
+		From: exe.anchor,
+		To:   &exe.contract,
+		Gas:  gasLimit,
+		Data: data,
+	})
+	return err == nil
+}
+
+func (exe *RPCExecutor) signTransaction(nonce, gasLimit uint64, gasPrice *big.Int, data []byte) (*types.Transaction, error) {
+	tx := types.NewTransaction(nonce, exe.contract, big.NewInt(0), gasLimit, gasPrice, data)
+	return exe.signer.SignTx(tx, exe.chain.ChainID())
+}
+
+// promoteTransaction finishes the queued transactions whose nonce is confirmed, resends the dropped ones
+// with fresh nonces and the ones unknown by the remote node, and replaces the stuck ones with a higher gas price
+func (exe *RPCExecutor) promoteTransaction() {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	exe.mu.Lock()
+	defer exe.mu.Unlock()
+
+	pending := exe.queue.Pending()
+	if len(pending) == 0 {
+		return
+	}
+	confirmed, err := exe.client.NonceAt(ctx, exe.anchor, nil)
+	if err != nil {
+		exe.log.Warn("get anchor nonce failed", "error", err)
+		return
+	}
+	getReceipt := func(hash common.Hash) *types.Receipt {
+		receipt, _ := exe.client.TransactionReceipt(ctx, hash)
+		return receipt
+	}
+
+	var (
+		finished, promoted int
+		packed, dropped    []*cc.AnchorTransaction
+		now                = time.Now()
+	)
+	for _, tx := range pending {
+		if tx.Nonce < confirmed {
+			if receipt := simpleexecutor.FinishAnchorTx(tx, getReceipt); receipt != nil {
+				simpleexecutor.AccountAnchorTx(tx, receipt, exe.gasPrice(ctx, tx.TxHash, tx.GasPrice), exe.contract, exe.anchor)
+				packed = append(packed, tx)
+				for _, id := range simpleexecutor.FailedBatchItems(receipt, exe.contract) {
+					exe.log.Warn("batch finish skipped", "id", id.String(), "tx", tx.TxHash.String())
+				}
+			} else {
+				dropped = append(dropped, tx)
+			}
+			exe.queue.Put(tx)
+			finished++
+			continue
+		}
+		gasPrice := tx.GasPrice
+		if known, _, err := exe.client.TransactionByHash(ctx, tx.TxHash); err == nil && known != nil {
+			// stuck in remote txpool, replace it with a higher price
+			if now.Sub(time.Unix(int64(tx.Time), 0)) < promoteInterval {
+				continue
+			}
+			if gasPrice = simpleexecutor.BumpGasPrice(tx.GasPrice); gasPrice == nil {
+				exe.log.Info("reach max gas price, keep waiting", "nonce", tx.Nonce, "tx", tx.TxHash.String())
+				continue
+			}
+		}
+		newTx, err := exe.signTransaction(tx.Nonce, tx.GasLimit, gasPrice, tx.Data)
+		if err != nil {
+			exe.log.Info("promoteTransaction", "nonce", tx.Nonce, "err", err)
+			continue
+		}
+		if newTx.Hash() != tx.TxHash {
+			tx.Replaced = append(tx.Replaced, tx.TxHash)
+			tx.TxHash = newTx.Hash()
+		}
+		tx.GasPrice, tx.Time = gasPrice, uint64(now.Unix())
+		exe.queue.Put(tx)
+		if err := exe.client.SendTransaction(ctx, newTx); err != nil {
+			exe.log.Info("promoteTransaction", "nonce", tx.Nonce, "err", err)
+			continue
+		}
+		promoted++
+	}
+	// the nonce of a dropped tx is used by another transaction of anchor, resend it unless the ctx is finished by others
+	var resent []*cc.AnchorTransaction
+	if len(dropped) > 0 {
+		if nonce, err := exe.client.PendingNonceAt(ctx, exe.anchor); err != nil {
+			exe.log.Warn("get anchor nonce failed", "error", err)
+		} else {
+			resent = exe.queue.ResendDropped(dropped, nonce, func(tx *cc.AnchorTransaction) (common.Hash, error) {
+				if !exe.checkTransaction(ctx, tx.GasLimit, tx.Data) {
+					return common.Hash{}, simpleexecutor.ErrCtxFinished
+				}
+				newTx, err := exe.signTransaction(tx.Nonce, tx.GasLimit, tx.GasPrice, tx.Data)
+				if err != nil {
+					return common.Hash{}, err
+				}
+				// a failed sending is retried by promoting as the tx is queued
+				if err := exe.client.SendTransaction(ctx, newTx); err != nil {
+					exe.log.Info("promoteTransaction", "nonce", tx.Nonce, "err", err)
+				}
+				return newTx.Hash(), nil
+			})
+		}
+	}
+	exe.log.Info("promoteTransaction", "finished", finished, "resent", len(resent), "len", promoted)
+	if err := exe.ledger.Add(packed...); err != nil {
+		exe.log.Warn("Failed to save executor ledger", "err", err)
+	}
+	if finished > 0 {
+		if err := exe.queue.Rotate(); err != nil {
+			exe.log.Warn("Failed to rotate executor journal", "err", err)
+		}
+	}
+}
+
+// gasPrice returns the gas price of the packed transaction, or the given price if it is unknown by the remote node
+func (exe *RPCExecutor) gasPrice(ctx context.Context, hash common.Hash, price *big.Int) *big.Int {
+	if tx, _, err := exe.client.TransactionByHash(ctx, hash); err == nil && tx != nil {
+		return tx.GasPrice()
+	}
+	return price
+}
@@ -0,0 +1,192 @@
+package retriever
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/log"
+	"github.com/simplechain-org/go-simplechain/params"
+
+	"github.com/simplechain-org/go-simplechain/cross"
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+	"github.com/simplechain-org/go-simplechain/cross/trigger"
+	"github.com/simplechain-org/go-simplechain/cross/trigger/rpctrigger"
+	"github.com/simplechain-org/go-simplechain/cross/trigger/simpletrigger"
+	"github.com/simplechain-org/go-simplechain/cross/trigger/simpletrigger/retriever"
+)
+
+const (
+	minRequireSignature = 2
+	expireNumber        = -1 //pending rtx expired after block num (-1 if never expired)
+)
+
+// RPCRetriever retrieves and validates ctxs of the chain followed over RPC,
+// the cross contract is queried by eth_call at the latest block
+type RPCRetriever struct {
+	chain            *rpctrigger.RPCChain
+	anchors          map[uint64]*retriever.AnchorSet // chainID => anchorSet
+	requireSignature int
+	config           *cross.Config
+	finality         simpletrigger.Finality
+
+	mu     sync.RWMutex
+	logger log.Logger
+}
+
+func NewRPCRetriever(chain *rpctrigger.RPCChain, config *cross.Config, finality simpletrigger.Finality) trigger.ChainRetriever {
+	return &RPCRetriever{
+		chain:            chain,
+		anchors:          make(map[uint64]*retriever.AnchorSet),
+		requireSignature: minRequireSignature,
+		config:           config,
+		finality:         finality,
+		logger:           log.New("X-module", "rpcretriever", "chainID", chain.ChainID()),
+	}
+}
+
+func (r *RPCRetriever) CanAcceptTxs() bool {
+	return r.chain.CanAcceptTxs()
+}
+
+func (r *RPCRetriever) ConfirmedDepth() uint64 {
+	return r.finality.ConfirmedDepth()
+}
+
+func (r *RPCRetriever) CurrentBlockNumber() uint64 {
+	return r.chain.CurrentBlockNumber()
+}
+
+func (r *RPCRetriever) GetTransactionTimeOnChain(tx trigger.Transaction) uint64 {
+	if header := r.chain.GetHeaderByHash(tx.BlockHash()); header != nil {
+		return header.Time
+	}
+	return 0
+}
+
+func (r *RPCRetriever) GetTransactionNumberOnChain(tx trigger.Transaction) uint64 {
+	if header := r.chain.GetHeaderByHash(tx.BlockHash()); header != nil {
+		return header.Number.Uint64()
+	}
+	return r.chain.CurrentBlockNumber()
+}
+
+func (r *RPCRetriever) GetConfirmedTransactionNumberOnChain(tx trigger.Transaction) uint64 {
+	if header := r.chain.GetHeaderByHash(tx.BlockHash()); header != nil {
+		return header.Number.Uint64() + r.finality.ConfirmedDepth()
+	}
+	return r.chain.CurrentBlockNumber()
+}
+
+func (r *RPCRetriever) IsLocalCtx(ctx trigger.Transaction) bool {
+	return r.chain.ChainID().Cmp(ctx.ChainId()) == 0
+}
+
+func (r *RPCRetriever) IsRemoteCtx(ctx trigger.Transaction) bool {
+	return r.chain.ChainID().Cmp(ctx.DestinationId()) == 0
+}
+
+func (r *RPCRetriever) RequireSignatures() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.requireSignature
+}
+
+func (r *RPCRetriever) ExpireNumber() int {
+	if r.config != nil && r.config.ExpireNumber > 0 {
+		return int(r.config.ExpireNumber)
+	}
+	return expireNumber
+}
+
+func (r *RPCRetriever) VerifyExpire(ctx *cc.CrossTransaction) error {
+	expire := r.ExpireNumber()
+	if expire < 0 {
+		return nil
+	}
+	if current, number := r.CurrentBlockNumber(), r.GetTransactionNumberOnChain(ctx); current > number && current-number > uint64(expire) {
+		r.logger.Debug("ctx is already expired", "ctxID", ctx.ID().String())
+		return cross.ErrExpiredCtx
+	}
+	return nil
+}
+
+func (r *RPCRetriever) VerifySigner(ctx *cc.CrossTransaction, signChain, validChain *big.Int) (common.Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	anchorSet, ok := r.anchors[validChain.Uint64()]
+	if !ok {
+		if err := r.queryAnchors(validChain.Uint64()); err != nil {
+			return common.Address{}, err
+		}
+		anchorSet = r.anchors[validChain.Uint64()]
+	}
+	signer, ok := anchorSet.IsAnchorSignedCtx(ctx, cc.NewEIP155CtxSigner(signChain))
+	if !ok {
+		r.logger.Warn("invalid signature", "anchors", anchorSet.String(), "ctxID", ctx.ID().String(), "signer", signer.String())
+		return signer, cross.ErrInvalidSignCtx
+	}
+	return signer, nil
+}
+
+// send message to verify ctx in the cross contract
+// (must exist makerTx in source-chain, do not took by others in destination-chain)
+func (r *RPCRetriever) VerifyContract(cws trigger.Transaction) error {
+	paddedCtxId := common.LeftPadBytes(cws.ID().Bytes(), 32) //CtxId
+	if r.IsLocalCtx(cws) {
+		res, err := r.chain.CallContract(packCall(params.GetMakerTxFn, paddedCtxId, common.LeftPadBytes(cws.DestinationId().Bytes(), 32)))
+		if err != nil {
+			r.logger.Warn("call getMakerTx failed", "error", err)
+			return cross.ErrInternal
+		}
+		if new(big.Int).SetBytes(res).Sign() == 0 { // error if makerTx is not existed in source-chain
+			return cross.ErrRepetitionCtx
+		}
+
+	} else if r.IsRemoteCtx(cws) {
+		res, err := r.chain.CallContract(packCall(params.GetTakerTxFn, paddedCtxId, common.LeftPadBytes(cws.ChainId().Bytes(), 32)))
+		if err != nil {
+			r.logger.Warn("call getTakerTx failed", "error", err)
+			return cross.ErrInternal
+		}
+		if new(big.Int).SetBytes(res).Sign() != 0 { // error if takerTx is already taken in destination-chain
+			return cross.ErrRepetitionCtx
+		}
+	}
+	return nil
+}
+
+func (r *RPCRetriever) UpdateAnchors(info *cc.RemoteChainInfo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.queryAnchors(info.RemoteChainId); err != nil && err != cross.ErrInvalidSignCtx {
+		return err
+	}
+	return nil
+}
+
+// queryAnchors loads anchors of remoteChainId from cross contract, should be called with lock held
+func (r *RPCRetriever) queryAnchors(remoteChainId uint64) error {
+	res, err := r.chain.CallContract(packCall(params.GetAnchorFn, common.LeftPadBytes(new(big.Int).SetUint64(remoteChainId).Bytes(), 32)))
+	if err != nil {
+		r.logger.Warn("call getAnchor failed", "error", err)
+		return cross.ErrInternal
+	}
+	anchors, signedCount := retriever.UnpackAnchors(res)
+	if len(anchors) == 0 {
+		r.logger.Warn("empty anchors in current state", "remoteChainId", remoteChainId)
+		return cross.ErrInvalidSignCtx
+	}
+	r.config.Anchors = anchors
+	r.requireSignature = signedCount
+	r.anchors[remoteChainId] = retriever.NewAnchorSet(anchors)
+	return nil
+}
+
+func packCall(function []byte, inputs ...[]byte) []byte {
+	data := append([]byte{}, function...)
+	for _, input := range inputs {
+		data = append(data, input...)
+	}
+	return data
+}
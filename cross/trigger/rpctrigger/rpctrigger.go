@@ -0,0 +1,556 @@
+package rpctrigger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/simplechain-org/go-simplechain"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/ethclient"
+	"github.com/simplechain-org/go-simplechain/log"
+	"github.com/simplechain-org/go-simplechain/params"
+	"github.com/simplechain-org/go-simplechain/rpc"
+
+	"github.com/simplechain-org/go-simplechain/cross/trigger"
+)
+
+const (
+	requestTimeout   = 10 * time.Second
+	resubscribeDelay = 5 * time.Second
+	headChanSize     = 16
+	maxReorgDepth    = 1024 // max blocks to trace back while reorg or catching up
+	keepHeaderNumber = 256  // recent headers kept to serve the subscriber
+	maxReplayRange   = 1000 // max blocks of a ranged log filter while replaying missed blocks
+)
+
+var ErrInvalidChain = errors.New("rpc chain is not the configured chain")
+
+// Client is the JSON-RPC client of a remote chain, *ethclient.Client implements it
+type Client interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	SyncProgress(ctx context.Context) (*simplechain.SyncProgress, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (simplechain.Subscription, error)
+	FilterLogs(ctx context.Context, q simplechain.FilterQuery) ([]types.Log, error)
+	CallContract(ctx context.Context, msg simplechain.CallMsg, blockNumber *big.Int) ([]byte, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, msg simplechain.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+}
+
+// crossSubscriber receives cross contract logs of the followed chain, it's implemented by SimpleSubscriber
+type crossSubscriber interface {
+	StoreCrossContractLog(blockNumber uint64, hash common.Hash, logs []*types.Log)
+	NotifyBlockReorg(blockNumber *big.Int, deletedLogs [][]*types.Log, rebirthLogs [][]*types.Log)
+	Stop()
+}
+
+// RPCChain follows a remote chain over websocket JSON-RPC, it takes place of the in-process blockchain:
+// new heads are subscribed, cross contract logs of each block are fetched and fed to the cross subscriber.
+type RPCChain struct {
+	client      Client
+	chainID     *big.Int
+	genesis     common.Hash
+	contract    common.Address
+	chainConfig *params.ChainConfig
+
+	subscriber crossSubscriber
+
+	current   *types.Header
+	headers   map[uint64]*types.Header     // recent canonical headers
+	logs      map[common.Hash][]*types.Log // cross contract logs of recent canonical blocks
+	processed uint64                       // number of the last block whose logs are fed to the subscriber
+	mu        sync.RWMutex
+
+	checkpoint string // file saving the processed number, the missed blocks are replayed after restarting
+
+	syncing int32 // remote chain is syncing (atomic)
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+	log  log.Logger
+}
+
+// Dial connects the chain with websocket url (e.g. ws://127.0.0.1:8546)
+func Dial(url string, chainID uint64, contract common.Address, checkpoint string) (*RPCChain, error) {
+	client, err := ethclient.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+	return NewRPCChain(client, chainID, contract, checkpoint)
+}
+
+// NewRPCChain creates the chain followed by client, the processed number is saved at checkpoint,
+// the chain starts from the remote head without replaying if checkpoint is empty
+func NewRPCChain(client Client, chainID uint64, contract common.Address, checkpoint string) (*RPCChain, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	id, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if id.Uint64() != chainID {
+		return nil, ErrInvalidChain
+	}
+	genesis, err := client.HeaderByNumber(ctx, common.Big0)
+	if err != nil {
+		return nil, err
+	}
+	return &RPCChain{
+		client:      client,
+		chainID:     id,
+		genesis:     genesis.Hash(),
+		contract:    contract,
+		chainConfig: &params.ChainConfig{ChainID: id},
+		headers:     make(map[uint64]*types.Header),
+		logs:        make(map[common.Hash][]*types.Log),
+		checkpoint:  checkpoint,
+		quit:        make(chan struct{}),
+		log:         log.New("X-module", "rpcchain", "chainID", id),
+	}, nil
+}
+
+func (c *RPCChain) Client() Client           { return c.client }
+func (c *RPCChain) Contract() common.Address { return c.contract }
+func (c *RPCChain) ChainID() *big.Int        { return c.chainID }
+func (c *RPCChain) GenesisHash() common.Hash { return c.genesis }
+func (c *RPCChain) RegisterAPIs(apis []rpc.API) {
+	c.log.Debug("apis of rpc chain are not served", "count", len(apis))
+}
+
+func (c *RPCChain) Start() error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	head, err := c.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	processed, ok, err := c.loadCheckpoint()
+	if err != nil {
+		c.log.Warn("Failed to load rpc chain checkpoint", "error", err)
+	}
+	if ok && processed < head.Number.Uint64() {
+		c.processed = processed
+		if err := c.catchUp(head); err != nil {
+			return err
+		}
+	} else {
+		c.setHead(head, nil)
+		c.saveCheckpoint(head.Number.Uint64())
+	}
+
+	heads := make(chan *types.Header, headChanSize)
+	sub, err := c.client.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		return err
+	}
+	c.wg.Add(1)
+	go c.loop(heads, sub)
+	return nil
+}
+
+func (c *RPCChain) Stop() {
+	close(c.quit)
+	c.wg.Wait()
+	if c.subscriber != nil {
+		c.subscriber.Stop()
+	}
+}
+
+func (c *RPCChain) loop(heads chan *types.Header, sub simplechain.Subscription) {
+	defer c.wg.Done()
+	defer func() { sub.Unsubscribe() }()
+
+	for {
+		select {
+		case head := <-heads:
+			if err := c.processHead(head); err != nil {
+				c.log.Warn("process new head failed", "number", head.Number, "hash", head.Hash(), "error", err)
+			}
+
+		case err := <-sub.Err():
+			c.log.Warn("new head subscription is broken", "error", err)
+			if sub = c.subscribe(heads); sub == nil {
+				return
+			}
+			if err := c.resume(); err != nil {
+				c.log.Warn("catch up missed blocks failed", "error", err)
+			}
+
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// subscribe subscribes new heads until succeed, return nil if chain is stopped
+func (c *RPCChain) subscribe(heads chan *types.Header) simplechain.Subscription {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		sub, err := c.client.SubscribeNewHead(ctx, heads)
+		cancel()
+		if err == nil {
+			return sub
+		}
+		c.log.Warn("subscribe new head failed", "error", err)
+		select {
+		case <-time.After(resubscribeDelay):
+		case <-c.quit:
+			return nil
+		}
+	}
+}
+
+// resume catches up the blocks produced while the subscription is broken, the missed blocks are replayed
+// with ranged log filters if the followed head is still canonical, otherwise they are traced back as a reorg
+func (c *RPCChain) resume() error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	head, err := c.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	current := c.CurrentHeader()
+	if head.Number.Uint64() <= current.Number.Uint64()+1 {
+		return c.processHead(head)
+	}
+	canonical, err := c.client.HeaderByNumber(ctx, current.Number)
+	if err != nil {
+		return err
+	}
+	if canonical.Hash() != current.Hash() {
+		return c.processHead(head)
+	}
+	return c.catchUp(head)
+}
+
+// processHead links head to the followed chain, logs of reorged blocks are notified as deleted,
+// logs of new blocks (including missing ones) are stored by number ascending
+func (c *RPCChain) processHead(head *types.Header) error {
+	if current := c.CurrentHeader(); current != nil && head.Number.Uint64() > current.Number.Uint64()+maxReorgDepth {
+		return c.catchUp(head) // too far to trace back
+	}
+	var newChain []*types.Header // reverse order (higher -> lower)
+	for h := head; ; {
+		if known := c.getHeader(h.Number.Uint64()); known != nil && known.Hash() == h.Hash() {
+			break // already followed
+		}
+		newChain = append(newChain, h)
+		number := h.Number.Uint64()
+		if number == 0 || len(newChain) >= maxReorgDepth {
+			break
+		}
+		parent := c.getHeader(number - 1)
+		if parent != nil && parent.Hash() == h.ParentHash {
+			break // linked to the followed chain
+		}
+		if parent == nil && number-1 < c.lowestNumber() {
+			break // older than the kept headers, can't trace back anymore
+		}
+		var err error
+		if h, err = c.headerByHash(h.ParentHash); err != nil {
+			return err
+		}
+	}
+	if len(newChain) == 0 {
+		return nil
+	}
+
+	// notify deleted logs of the reorged blocks
+	ancestor := newChain[len(newChain)-1].Number.Uint64() - 1
+	if deletedLogs := c.truncate(ancestor); len(deletedLogs) > 0 && c.subscriber != nil {
+		c.subscriber.NotifyBlockReorg(new(big.Int).SetUint64(ancestor), deletedLogs, nil)
+	}
+
+	for i := len(newChain) - 1; i >= 0; i-- {
+		h := newChain[i]
+		logs, err := c.contractLogs(h.Hash())
+		if err != nil {
+			return err
+		}
+		c.setHead(h, logs)
+		if c.subscriber != nil {
+			c.subscriber.StoreCrossContractLog(h.Number.Uint64(), h.Hash(), logs)
+		}
+	}
+	c.saveCheckpoint(head.Number.Uint64())
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	if progress, err := c.client.SyncProgress(ctx); err == nil {
+		if progress != nil {
+			atomic.StoreInt32(&c.syncing, 1)
+		} else {
+			atomic.StoreInt32(&c.syncing, 0)
+		}
+	}
+	return nil
+}
+
+// catchUp replays logs of the blocks after the processed one up to the parent of head with ranged log filters,
+// and restarts following from head. Headers of the replayed blocks are not kept, so their reorg is not notified.
+func (c *RPCChain) catchUp(head *types.Header) error {
+	c.mu.RLock()
+	from := c.processed + 1
+	c.mu.RUnlock()
+	if number := head.Number.Uint64(); number > from {
+		if err := c.replay(from, number-1); err != nil {
+			return err
+		}
+	}
+	logs, err := c.contractLogs(head.Hash())
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.current = nil
+	c.headers = make(map[uint64]*types.Header)
+	c.logs = make(map[common.Hash][]*types.Log)
+	c.mu.Unlock()
+
+	c.setHead(head, logs)
+	if c.subscriber != nil {
+		c.subscriber.StoreCrossContractLog(head.Number.Uint64(), head.Hash(), logs)
+	}
+	c.saveCheckpoint(head.Number.Uint64())
+	return nil
+}
+
+// replay feeds cross contract logs of blocks in [from, to] to the subscriber by ranged log filters,
+// blocks without logs are skipped
+func (c *RPCChain) replay(from, to uint64) error {
+	for start := from; start <= to; start += maxReplayRange {
+		end := start + maxReplayRange - 1
+		if end > to {
+			end = to
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		logs, err := c.client.FilterLogs(ctx, simplechain.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []common.Address{c.contract},
+		})
+		cancel()
+		if err != nil {
+			return err
+		}
+		// logs are ordered by block, feed them block by block
+		for i := 0; i < len(logs); {
+			blockLogs := []*types.Log{&logs[i]}
+			j := i + 1
+			for ; j < len(logs) && logs[j].BlockHash == logs[i].BlockHash; j++ {
+				blockLogs = append(blockLogs, &logs[j])
+			}
+			if c.subscriber != nil {
+				c.subscriber.StoreCrossContractLog(logs[i].BlockNumber, logs[i].BlockHash, blockLogs)
+			}
+			i = j
+		}
+		c.log.Info("Replayed missed blocks", "from", start, "to", end, "logs", len(logs))
+		c.saveCheckpoint(end)
+	}
+	return nil
+}
+
+// rpcCheckpoint is the content of the checkpoint file
+type rpcCheckpoint struct {
+	Processed uint64 `json:"processed"`
+}
+
+// loadCheckpoint reads the saved processed number, ok is false if it's never saved
+func (c *RPCChain) loadCheckpoint() (number uint64, ok bool, err error) {
+	if c.checkpoint == "" {
+		return 0, false, nil
+	}
+	data, err := ioutil.ReadFile(c.checkpoint)
+	if os.IsNotExist(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	var cp rpcCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return 0, false, err
+	}
+	return cp.Processed, true, nil
+}
+
+// saveCheckpoint records number as processed, and saves it to the checkpoint file
+func (c *RPCChain) saveCheckpoint(number uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.processed = number
+	if c.checkpoint == "" {
+		return
+	}
+	data, err := json.Marshal(rpcCheckpoint{Processed: number})
+	if err == nil {
+		if err = ioutil.WriteFile(c.checkpoint+".new", data, 0644); err == nil {
+			err = os.Rename(c.checkpoint+".new", c.checkpoint)
+		}
+	}
+	if err != nil {
+		c.log.Warn("Failed to save rpc chain checkpoint", "number", number, "error", err)
+	}
+}
+
+func (c *RPCChain) headerByHash(hash common.Hash) (*types.Header, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	return c.client.HeaderByHash(ctx, hash)
+}
+
+// contractLogs fetches cross contract logs of the block
+func (c *RPCChain) contractLogs(hash common.Hash) ([]*types.Log, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	logs, err := c.client.FilterLogs(ctx, simplechain.FilterQuery{BlockHash: &hash, Addresses: []common.Address{c.contract}})
+	if err != nil {
+		return nil, err
+	}
+	blockLogs := make([]*types.Log, 0, len(logs))
+	for i := range logs {
+		blockLogs = append(blockLogs, &logs[i])
+	}
+	return blockLogs, nil
+}
+
+func (c *RPCChain) setHead(head *types.Header, logs []*types.Log) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	number := head.Number.Uint64()
+	c.current = head
+	c.headers[number] = head
+	c.logs[head.Hash()] = logs
+	if number >= keepHeaderNumber {
+		if old, ok := c.headers[number-keepHeaderNumber]; ok {
+			delete(c.logs, old.Hash())
+			delete(c.headers, number-keepHeaderNumber)
+		}
+	}
+}
+
+// truncate removes followed headers above number, and returns their logs (higher -> lower)
+func (c *RPCChain) truncate(number uint64) (deletedLogs [][]*types.Log) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current == nil {
+		return nil
+	}
+	for n := c.current.Number.Uint64(); n > number; n-- {
+		if h, ok := c.headers[n]; ok {
+			if logs := c.logs[h.Hash()]; len(logs) > 0 {
+				deletedLogs = append(deletedLogs, logs)
+			}
+			delete(c.logs, h.Hash())
+			delete(c.headers, n)
+		}
+	}
+	if parent, ok := c.headers[number]; ok {
+		c.current = parent
+	}
+	return deletedLogs
+}
+
+func (c *RPCChain) getHeader(number uint64) *types.Header {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.headers[number]
+}
+
+func (c *RPCChain) lowestNumber() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.current == nil || c.current.Number.Uint64() < keepHeaderNumber {
+		return 0
+	}
+	return c.current.Number.Uint64() - keepHeaderNumber + 1
+}
+
+// CanAcceptTxs returns false if the remote chain is syncing
+func (c *RPCChain) CanAcceptTxs() bool {
+	return c.CurrentHeader() != nil && atomic.LoadInt32(&c.syncing) == 0
+}
+
+func (c *RPCChain) CurrentHeader() *types.Header {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.current
+}
+
+func (c *RPCChain) CurrentBlockNumber() uint64 {
+	if head := c.CurrentHeader(); head != nil {
+		return head.Number.Uint64()
+	}
+	return 0
+}
+
+func (c *RPCChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	header, err := c.headerByHash(hash)
+	if err != nil {
+		return nil
+	}
+	return header
+}
+
+// CallContract executes a message call of cross contract at the latest block
+func (c *RPCChain) CallContract(data []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	return c.client.CallContract(ctx, simplechain.CallMsg{To: &c.contract, Data: data}, nil)
+}
+
+// GetHeaderByNumber implements chainRetriever of the subscriber
+func (c *RPCChain) GetHeaderByNumber(number uint64) *types.Header {
+	if header := c.getHeader(number); header != nil {
+		return header
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	header, err := c.client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return nil
+	}
+	return header
+}
+
+// GetTransactionByTxHash implements chainRetriever of the subscriber
+func (c *RPCChain) GetTransactionByTxHash(hash common.Hash) (*types.Transaction, common.Hash, uint64) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	receipt, err := c.client.TransactionReceipt(ctx, hash)
+	if err != nil || receipt == nil {
+		return nil, common.Hash{}, 0
+	}
+	tx, pending, err := c.client.TransactionByHash(ctx, hash)
+	if err != nil || pending {
+		return nil, common.Hash{}, 0
+	}
+	return tx, receipt.BlockHash, receipt.BlockNumber.Uint64()
+}
+
+// GetChainConfig implements chainRetriever of the subscriber, only chainID is available
+func (c *RPCChain) GetChainConfig() *params.ChainConfig {
+	return c.chainConfig
+}
+
+// SetCrossSubscriber implements chainRetriever of the subscriber
+func (c *RPCChain) SetCrossSubscriber(s trigger.Subscriber) {
+	c.subscriber = s.(crossSubscriber) // panic if failed
+}
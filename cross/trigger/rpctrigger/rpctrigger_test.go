@@ -0,0 +1,382 @@
+package rpctrigger_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/simplechain-org/go-simplechain"
+	"github.com/simplechain-org/go-simplechain/accounts/abi/bind/backends"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/common/hexutil"
+	"github.com/simplechain-org/go-simplechain/core"
+	"github.com/simplechain-org/go-simplechain/core/rawdb"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/crypto"
+	"github.com/simplechain-org/go-simplechain/eth/filters"
+	"github.com/simplechain-org/go-simplechain/ethclient"
+	"github.com/simplechain-org/go-simplechain/ethdb"
+	"github.com/simplechain-org/go-simplechain/event"
+	"github.com/simplechain-org/go-simplechain/rlp"
+	"github.com/simplechain-org/go-simplechain/rpc"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+	"github.com/simplechain-org/go-simplechain/cross/trigger/rpctrigger"
+	"github.com/simplechain-org/go-simplechain/cross/trigger/rpctrigger/executor"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	anchorKey   = mustGenerateKey()
+	anchorAddr  = crypto.PubkeyToAddress(anchorKey.PublicKey)
+	testBalance = big.NewInt(1e18)
+
+	// this code generates a log
+	logCode = common.Hex2Bytes("60606040525b7f24ec1d3ff24c2f6ff210738839dbc339cd45a5294d85c79361016243157aae7b60405180905060405180910390a15b600a8060416000396000f360606040526008565b00")
+)
+
+func mustGenerateKey() *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// simService serves the simulated backend as eth namespace of JSON-RPC
+type simService struct {
+	db      ethdb.Database
+	backend *backends.SimulatedBackend
+}
+
+type callArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Data     hexutil.Bytes   `json:"data"`
+}
+
+func (args callArgs) toMessage() simplechain.CallMsg {
+	return simplechain.CallMsg{
+		From:     args.From,
+		To:       args.To,
+		Gas:      uint64(args.Gas),
+		GasPrice: (*big.Int)(args.GasPrice),
+		Value:    (*big.Int)(args.Value),
+		Data:     args.Data,
+	}
+}
+
+func (s *simService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(s.backend.Blockchain().Config().ChainID)
+}
+
+func (s *simService) Syncing() (bool, error) {
+	return false, nil
+}
+
+func (s *simService) GetBlockByNumber(number rpc.BlockNumber, fullTx bool) (*types.Header, error) {
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return s.backend.Blockchain().CurrentHeader(), nil
+	}
+	return s.backend.Blockchain().GetHeaderByNumber(uint64(number)), nil
+}
+
+func (s *simService) GetBlockByHash(hash common.Hash, fullTx bool) (*types.Header, error) {
+	return s.backend.Blockchain().GetHeaderByHash(hash), nil
+}
+
+func (s *simService) GetTransactionByHash(hash common.Hash) (map[string]interface{}, error) {
+	tx, blockHash, number, _ := rawdb.ReadTransaction(s.db, hash)
+	if tx == nil {
+		return nil, nil
+	}
+	enc, err := json.Marshal(tx)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(enc, &fields); err != nil {
+		return nil, err
+	}
+	fields["blockHash"] = blockHash
+	fields["blockNumber"] = hexutil.Uint64(number)
+	return fields, nil
+}
+
+func (s *simService) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	return s.backend.TransactionReceipt(ctx, hash)
+}
+
+func (s *simService) GetLogs(ctx context.Context, crit filters.FilterCriteria) ([]types.Log, error) {
+	return s.backend.FilterLogs(ctx, simplechain.FilterQuery(crit))
+}
+
+func (s *simService) Call(ctx context.Context, args callArgs, number rpc.BlockNumber) (hexutil.Bytes, error) {
+	return s.backend.CallContract(ctx, args.toMessage(), nil)
+}
+
+func (s *simService) EstimateGas(ctx context.Context, args callArgs) (hexutil.Uint64, error) {
+	gas, err := s.backend.EstimateGas(ctx, args.toMessage())
+	return hexutil.Uint64(gas), err
+}
+
+func (s *simService) GetBalance(ctx context.Context, address common.Address, number rpc.BlockNumber) (*hexutil.Big, error) {
+	balance, err := s.backend.BalanceAt(ctx, address, nil)
+	return (*hexutil.Big)(balance), err
+}
+
+func (s *simService) GetTransactionCount(ctx context.Context, address common.Address, number rpc.BlockNumber) (hexutil.Uint64, error) {
+	if number == rpc.PendingBlockNumber {
+		nonce, err := s.backend.PendingNonceAt(ctx, address)
+		return hexutil.Uint64(nonce), err
+	}
+	nonce, err := s.backend.NonceAt(ctx, address, nil)
+	return hexutil.Uint64(nonce), err
+}
+
+func (s *simService) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+	price, err := s.backend.SuggestGasPrice(ctx)
+	return (*hexutil.Big)(price), err
+}
+
+func (s *simService) SendRawTransaction(ctx context.Context, encodedTx hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), s.backend.SendTransaction(ctx, tx)
+}
+
+func (s *simService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+	heads := make(chan core.ChainHeadEvent, 16)
+	headSub := s.backend.Blockchain().SubscribeChainHeadEvent(heads)
+	go func() {
+		defer headSub.Unsubscribe()
+		for {
+			select {
+			case h := <-heads:
+				notifier.Notify(rpcSub.ID, h.Block.Header())
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// newSimulatedClient serves a simulated backend over in-process JSON-RPC
+func newSimulatedClient(t *testing.T) (*backends.SimulatedBackend, *ethclient.Client, func()) {
+	db := rawdb.NewMemoryDatabase()
+	sim := backends.NewSimulatedBackendWithDatabase(db, core.GenesisAlloc{
+		testAddr:   {Balance: testBalance},
+		anchorAddr: {Balance: testBalance},
+	}, 10000000)
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &simService{db: db, backend: sim}); err != nil {
+		t.Fatal(err)
+	}
+	client := ethclient.NewClient(rpc.DialInProc(server))
+	return sim, client, func() {
+		client.Close()
+		server.Stop()
+		sim.Close()
+	}
+}
+
+type storedLogs struct {
+	number uint64
+	hash   common.Hash
+	logs   []*types.Log
+}
+
+// testSubscriber records logs fed by the rpc chain
+type testSubscriber struct {
+	stored []storedLogs
+	mu     sync.Mutex
+}
+
+func (s *testSubscriber) StoreCrossContractLog(number uint64, hash common.Hash, logs []*types.Log) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stored = append(s.stored, storedLogs{number: number, hash: hash, logs: logs})
+}
+
+func (s *testSubscriber) NotifyBlockReorg(*big.Int, [][]*types.Log, [][]*types.Log) {}
+
+func (s *testSubscriber) SubscribeBlockEvent(chan<- cc.CrossBlockEvent) event.Subscription {
+	return nil
+}
+
+func (s *testSubscriber) Stop() {}
+
+func (s *testSubscriber) waitStored(t *testing.T, count int) []storedLogs {
+	for timeout := time.After(5 * time.Second); ; {
+		s.mu.Lock()
+		stored := append([]storedLogs{}, s.stored...)
+		s.mu.Unlock()
+		if len(stored) >= count {
+			return stored
+		}
+		select {
+		case <-timeout:
+			t.Fatalf("stored blocks timeout, want %d, got %d", count, len(stored))
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestRPCChain_Follow(t *testing.T) {
+	sim, client, closeFn := newSimulatedClient(t)
+	defer closeFn()
+
+	contract := crypto.CreateAddress(testAddr, 0)
+	chainID := sim.Blockchain().Config().ChainID
+
+	_, err := rpctrigger.NewRPCChain(client, chainID.Uint64()+1, contract, "")
+	assert.Equal(t, rpctrigger.ErrInvalidChain, err)
+
+	chain, err := rpctrigger.NewRPCChain(client, chainID.Uint64(), contract, "")
+	assert.NoError(t, err)
+	assert.Equal(t, sim.Blockchain().Genesis().Hash(), chain.GenesisHash())
+
+	subscriber := new(testSubscriber)
+	chain.SetCrossSubscriber(subscriber)
+	assert.NoError(t, chain.Start())
+	defer chain.Stop()
+
+	signer := types.NewEIP155Signer(chainID)
+	tx, err := types.SignTx(types.NewContractCreation(0, new(big.Int), 1000000, new(big.Int), logCode), signer, testKey)
+	assert.NoError(t, err)
+	assert.NoError(t, sim.SendTransaction(context.Background(), tx))
+	sim.Commit()
+	sim.Commit()
+
+	stored := subscriber.waitStored(t, 2)
+	assert.Equal(t, uint64(1), stored[0].number)
+	assert.Equal(t, 1, len(stored[0].logs))
+	assert.Equal(t, contract, stored[0].logs[0].Address)
+	assert.Equal(t, uint64(2), stored[1].number)
+	assert.Equal(t, 0, len(stored[1].logs))
+
+	assert.Equal(t, uint64(2), chain.CurrentBlockNumber())
+	assert.True(t, chain.CanAcceptTxs())
+	assert.Equal(t, stored[0].hash, chain.GetHeaderByNumber(1).Hash())
+
+	found, blockHash, number := chain.GetTransactionByTxHash(tx.Hash())
+	assert.NotNil(t, found)
+	assert.Equal(t, stored[0].hash, blockHash)
+	assert.Equal(t, uint64(1), number)
+}
+
+func TestRPCChain_Replay(t *testing.T) {
+	sim, client, closeFn := newSimulatedClient(t)
+	defer closeFn()
+
+	dir, err := ioutil.TempDir("", "rpctrigger")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	checkpoint := filepath.Join(dir, "checkpoint.json")
+
+	contract := crypto.CreateAddress(testAddr, 0)
+	chainID := sim.Blockchain().Config().ChainID
+
+	chain, err := rpctrigger.NewRPCChain(client, chainID.Uint64(), contract, checkpoint)
+	assert.NoError(t, err)
+	chain.SetCrossSubscriber(new(testSubscriber))
+	assert.NoError(t, chain.Start())
+	chain.Stop()
+
+	// blocks produced while the node is down
+	signer := types.NewEIP155Signer(chainID)
+	tx, err := types.SignTx(types.NewContractCreation(0, new(big.Int), 1000000, new(big.Int), logCode), signer, testKey)
+	assert.NoError(t, err)
+	assert.NoError(t, sim.SendTransaction(context.Background(), tx))
+	sim.Commit()
+	sim.Commit()
+	sim.Commit()
+
+	chain, err = rpctrigger.NewRPCChain(client, chainID.Uint64(), contract, checkpoint)
+	assert.NoError(t, err)
+	subscriber := new(testSubscriber)
+	chain.SetCrossSubscriber(subscriber)
+	assert.NoError(t, chain.Start())
+	defer chain.Stop()
+
+	stored := subscriber.waitStored(t, 2)
+	assert.Equal(t, uint64(1), stored[0].number)
+	assert.Equal(t, 1, len(stored[0].logs))
+	assert.Equal(t, sim.Blockchain().GetHeaderByNumber(1).Hash(), stored[0].hash)
+	assert.Equal(t, uint64(3), stored[1].number)
+	assert.Equal(t, uint64(3), chain.CurrentBlockNumber())
+
+	sim.Commit()
+	stored = subscriber.waitStored(t, 3)
+	assert.Equal(t, uint64(4), stored[2].number)
+}
+
+// keySigner signs for anchor with its private key
+type keySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s keySigner) SignHash(hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, s.key)
+}
+
+func (s keySigner) SignCtx(ctx *cc.CrossTransaction, chainID *big.Int) (*cc.CrossTransaction, error) {
+	return cc.SignCtx(ctx, cc.MakeCtxSigner(chainID), s.SignHash)
+}
+
+func (s keySigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.NewEIP155Signer(chainID), s.key)
+}
+
+func TestRPCExecutor_SubmitTransaction(t *testing.T) {
+	sim, client, closeFn := newSimulatedClient(t)
+	defer closeFn()
+
+	chainID := sim.Blockchain().Config().ChainID
+	chain, err := rpctrigger.NewRPCChain(client, chainID.Uint64(), common.HexToAddress("0xaa"), "")
+	assert.NoError(t, err)
+
+	exe, err := executor.NewRPCExecutor(chain, anchorAddr, keySigner{anchorKey}, "")
+	assert.NoError(t, err)
+
+	id := common.HexToHash("0b2aa4c82a3b0187a087e030a26b71fc1a49e74d3776ae8e03876ea9153abbca")
+	exe.SubmitTransaction([]*cc.ReceptTransaction{
+		cc.NewReceptTransaction(id, common.Hash{}, testAddr, testAddr, chainID, big.NewInt(1)),
+		cc.NewReceptTransaction(id, common.Hash{}, testAddr, testAddr, big.NewInt(1), chainID), // not for this chain
+	})
+	sim.Commit()
+
+	nonce, err := client.NonceAt(context.Background(), anchorAddr, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), nonce)
+
+	queue := exe.Queue()
+	assert.Len(t, queue, 1)
+	assert.Equal(t, id, queue[0].CTxId)
+	assert.Equal(t, uint64(0), queue[0].Nonce)
+	assert.Equal(t, cc.AnchorTxPending, queue[0].Status)
+}
@@ -0,0 +1,57 @@
+package executor
+
+import (
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/params"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+)
+
+const maxBatchGasLimit = 2000000
+
+// MaxBatchSize is the max number of receipts finished by one makerFinishBatch transaction
+const MaxBatchSize = maxBatchGasLimit / maxFinishGasLimit
+
+// BatchRecepts groups the batchable receipts by chain in order, every batch has at most MaxBatchSize receipts
+func BatchRecepts(rtxs []*cc.ReceptTransaction) []*cc.ReceptBatch {
+	var (
+		batches []*cc.ReceptBatch
+		last    = make(map[uint64]*cc.ReceptBatch) // chainID => the latest batch of chain
+	)
+	for _, rtx := range rtxs {
+		chainID := rtx.ChainId.Uint64()
+		batch, ok := last[chainID]
+		if !ok || len(batch.Recepts) >= MaxBatchSize {
+			batch = cc.NewReceptBatch(rtx.ChainId)
+			last[chainID] = batch
+			batches = append(batches, batch)
+		}
+		batch.Recepts = append(batch.Recepts, rtx)
+	}
+	return batches
+}
+
+// BatchOf returns ctx IDs finished by the call, or nil if the call is not a batch of receipts
+func BatchOf(call interface{}) []common.Hash {
+	if batch, ok := call.(*cc.ReceptBatch); ok && len(batch.Recepts) > 1 {
+		return batch.IDs()
+	}
+	return nil
+}
+
+// FailedBatchItems returns ctx IDs skipped by the makerFinishBatch transaction of receipt,
+// which are reported by MakerFinishResult logs of the cross contract
+func FailedBatchItems(receipt *types.Receipt, contract common.Address) []common.Hash {
+	var failed []common.Hash
+	for _, l := range receipt.Logs {
+		if l.Address != contract || len(l.Topics) < 3 || l.Topics[0] != params.MakerFinishResultTopic || len(l.Data) < 64 {
+			continue
+		}
+		// MakerFinishResult(txId, anchor, remoteChainId, success)
+		if l.Data[63] == 0 {
+			failed = append(failed, l.Topics[1])
+		}
+	}
+	return failed
+}
@@ -0,0 +1,96 @@
+package executor
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/accounts/abi"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/common/hexutil"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/crypto"
+	"github.com/simplechain-org/go-simplechain/params"
+	"github.com/simplechain-org/go-simplechain/rlp"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newRecept(id uint64, chainID int64) *cc.ReceptTransaction {
+	return cc.NewReceptTransaction(common.BigToHash(new(big.Int).SetUint64(id)), common.Hash{},
+		common.Address{}, common.HexToAddress("0x01"), big.NewInt(chainID), big.NewInt(chainID))
+}
+
+func TestBatchRecepts(t *testing.T) {
+	var rtxs []*cc.ReceptTransaction
+	for i := 0; i < MaxBatchSize+1; i++ {
+		rtxs = append(rtxs, newRecept(uint64(i), 512))
+	}
+	rtxs = append(rtxs, newRecept(100, 1024))
+
+	batches := BatchRecepts(rtxs)
+	assert.Len(t, batches, 3)
+	assert.Len(t, batches[0].Recepts, MaxBatchSize)
+	assert.Equal(t, rtxs[0].CTxId, batches[0].ID())
+	assert.Equal(t, rtxs[MaxBatchSize].CTxId, batches[1].ID())
+	assert.Equal(t, int64(1024), batches[2].ChainId.Int64())
+
+	assert.Equal(t, uint64(maxFinishGasLimit*MaxBatchSize), GasLimitOf(batches[0]))
+	assert.LessOrEqual(t, GasLimitOf(batches[0]), uint64(maxBatchGasLimit))
+	assert.Equal(t, batches[0].IDs(), BatchOf(batches[0]))
+	assert.Nil(t, BatchOf(batches[1]))
+
+	data, err := hexutil.Decode(params.CrossDemoAbi)
+	assert.NoError(t, err)
+	crossABI, err := abi.JSON(bytes.NewReader(data))
+	assert.NoError(t, err)
+
+	// makerFinishBatch for receipts, makerFinish for a single one
+	input, err := batches[0].ConstructData(crossABI)
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256([]byte("makerFinishBatch((bytes32,bytes32,address,address)[],uint256)"))[:4], input[:4])
+	input, err = batches[1].ConstructData(crossABI)
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256([]byte("makerFinish((bytes32,bytes32,address,address),uint256)"))[:4], input[:4])
+}
+
+func TestFailedBatchItems(t *testing.T) {
+	contract, target := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+	resultLog := func(address common.Address, id uint64, success bool) *types.Log {
+		data := common.LeftPadBytes(big.NewInt(512).Bytes(), 32)
+		if success {
+			data = append(data, common.LeftPadBytes([]byte{1}, 32)...)
+		} else {
+			data = append(data, make([]byte, 32)...)
+		}
+		return &types.Log{
+			Address: address,
+			Topics:  []common.Hash{params.MakerFinishResultTopic, common.BigToHash(new(big.Int).SetUint64(id)), {}},
+			Data:    data,
+		}
+	}
+	failed := FailedBatchItems(&types.Receipt{Logs: []*types.Log{
+		resultLog(contract, 1, true),
+		resultLog(contract, 2, false),
+		resultLog(target, 3, false), // not emitted by the cross contract
+	}}, contract)
+	assert.Equal(t, []common.Hash{common.BigToHash(big.NewInt(2))}, failed)
+}
+
+func TestAnchorTx_BatchEncode(t *testing.T) {
+	tx := newAnchorTx(0)
+	enc, err := rlp.EncodeToBytes(tx)
+	assert.NoError(t, err)
+	dec, err := decodeAnchorTx(enc)
+	assert.NoError(t, err)
+	assert.Empty(t, dec.Batch)
+
+	tx.Batch = []common.Hash{tx.CTxId, common.HexToHash("0x02")}
+	enc, err = rlp.EncodeToBytes(tx)
+	assert.NoError(t, err)
+	dec, err = decodeAnchorTx(enc)
+	assert.NoError(t, err)
+	assert.Equal(t, tx.Batch, dec.Batch)
+}
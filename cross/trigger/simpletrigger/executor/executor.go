@@ -4,18 +4,18 @@ import (
 	"bytes"
 	"context"
 	"math/big"
-	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/simplechain-org/go-simplechain/accounts"
 	"github.com/simplechain-org/go-simplechain/accounts/abi"
+	"github.com/simplechain-org/go-simplechain/accounts/external"
 	"github.com/simplechain-org/go-simplechain/common"
 	"github.com/simplechain-org/go-simplechain/common/hexutil"
-	"github.com/simplechain-org/go-simplechain/core"
 	"github.com/simplechain-org/go-simplechain/core/types"
 	"github.com/simplechain-org/go-simplechain/eth"
 	"github.com/simplechain-org/go-simplechain/log"
+	"github.com/simplechain-org/go-simplechain/metrics"
 	"github.com/simplechain-org/go-simplechain/params"
 
 	cc "github.com/simplechain-org/go-simplechain/cross/core"
@@ -23,7 +23,11 @@ import (
 	"github.com/simplechain-org/go-simplechain/cross/trigger/simpletrigger"
 )
 
-const maxFinishGasLimit = 250000
+const (
+	maxFinishGasLimit  = 250000
+	maxDeliverGasLimit = 1000000 // delivery leaves gas for the call of target contract
+	promoteInterval    = 30 * time.Second
+)
 
 //var MaxGasPrice = big.NewInt(100e9)
 
@@ -44,12 +48,19 @@ type SimpleExecutor struct {
 	contract    common.Address
 	contractABI abi.ABI
 
+	queue  *TxQueue
+	ledger *Ledger
+	mu     sync.Mutex // protects nonce of anchor
+
+	failure metrics.Counter
+	balance metrics.GaugeFloat64
+
 	stopCh chan struct{}
 	wg     sync.WaitGroup
 	log    log.Logger
 }
 
-func NewSimpleExecutor(chain simpletrigger.SimpleChain, anchor common.Address, contract common.Address) (
+func NewSimpleExecutor(chain simpletrigger.SimpleChain, anchor common.Address, contract common.Address, journal string) (
 	*SimpleExecutor, error) {
 	logger := log.New("module", "executor", "chainID", chain.ChainConfig().ChainID)
 	data, err := hexutil.Decode(params.CrossDemoAbi)
@@ -71,24 +82,35 @@ func NewSimpleExecutor(chain simpletrigger.SimpleChain, anchor common.Address, c
 		gasHelper:   NewGasHelper(chain.BlockChain(), chain),
 		contract:    contract,
 		contractABI: abi,
+		queue:       NewTxQueue(journal),
+		ledger:      NewLedger(LedgerPath(journal)),
+		failure:     metric.SubmitFailure(chain.ChainConfig().ChainID.Uint64()),
+		balance:     metric.AnchorBalance(chain.ChainConfig().ChainID.Uint64()),
 		stopCh:      make(chan struct{}),
 		log:         logger,
 	}, nil
 }
 
 func (exe *SimpleExecutor) Start() {
+	if err := exe.queue.Load(); err != nil {
+		exe.log.Warn("Failed to rotate executor journal", "err", err)
+	}
+	if err := exe.ledger.Load(); err != nil {
+		exe.log.Warn("Failed to load executor ledger", "err", err)
+	}
 	exe.wg.Add(1)
 	go exe.loop()
 }
 
 func (exe *SimpleExecutor) loop() {
 	defer exe.wg.Done()
-	expire := time.NewTicker(30 * time.Second)
+	expire := time.NewTicker(promoteInterval)
 	defer expire.Stop()
 	for {
 		select {
 		case <-expire.C:
 			exe.promoteTransaction()
+			exe.updateBalance()
 
 		case <-exe.stopCh:
 			return
@@ -96,23 +118,220 @@ func (exe *SimpleExecutor) loop() {
 	}
 }
 
+func (exe *SimpleExecutor) updateBalance() {
+	if balance, err := exe.gasHelper.GetBalance(exe.anchor); err == nil && balance != nil {
+		metric.UpdateBalance(exe.balance, balance)
+	}
+}
+
 func (exe *SimpleExecutor) Stop() {
 	close(exe.stopCh)
 	exe.wg.Wait()
+	exe.queue.Close()
+}
+
+// Queue returns the journaled transactions of anchor
+func (exe *SimpleExecutor) Queue() []*cc.AnchorTransaction {
+	return exe.queue.List()
+}
+
+// Profit returns the gas spent and relay fees earned by the finished transactions of anchor
+func (exe *SimpleExecutor) Profit() *cc.AnchorProfit {
+	return exe.ledger.Profit()
+}
+
+// RelayFee returns the relay fee of ctxs with the remote chain, which is credited to anchor but not claimed yet
+func (exe *SimpleExecutor) RelayFee(remoteChainID *big.Int) (*big.Int, error) {
+	data, err := exe.contractABI.Pack("getRelayFee", remoteChainID, exe.anchor)
+	if err != nil {
+		return nil, err
+	}
+	res, err := exe.gasHelper.call(context.Background(), CallArgs{From: exe.anchor, To: &exe.contract, Data: data})
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(res), nil
+}
+
+// ClaimRelayFee withdraws the relay fee credited by ctxs with the remote chain, it returns the claiming fee,
+// or zero if the fee is not worth the gas or a claiming is pending
+func (exe *SimpleExecutor) ClaimRelayFee(remoteChainID *big.Int) (*big.Int, error) {
+	fee, err := exe.RelayFee(remoteChainID)
+	if err != nil || fee.Sign() == 0 {
+		return new(big.Int), err
+	}
+	claim := cc.NewClaimTransaction(remoteChainID, exe.anchor, fee)
+	data, err := claim.ConstructData(exe.contractABI)
+	if err != nil {
+		return nil, err
+	}
+	for _, tx := range exe.queue.Pending() {
+		if bytes.Equal(tx.Data, data) {
+			return new(big.Int), nil
+		}
+	}
+	gasPrice, err := exe.gpo.SuggestPrice(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if fee.Cmp(new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(GasLimitOf(claim)))) <= 0 {
+		return new(big.Int), nil
+	}
+	exe.submit([]common.Hash{claim.ID()}, []contractCall{claim})
+	return fee, nil
 }
 
 func (exe *SimpleExecutor) SignHash(hash []byte) ([]byte, error) {
+	account, wallet, err := exe.wallet()
+	if err != nil {
+		return nil, err
+	}
+	return wallet.SignHash(account, hash)
+}
+
+// CanSignHash reports whether the wallet of anchor signs raw hashes, the external signer refuses them
+func (exe *SimpleExecutor) CanSignHash() bool {
+	_, wallet, err := exe.wallet()
+	if err != nil {
+		return false
+	}
+	_, ext := wallet.(*external.ExternalSigner)
+	return !ext
+}
+
+// SignCtx signs the ctx made in chain chainID, the external signer is requested with the ctx content
+// for approval by its rules, other wallets sign the ctx hash directly
+func (exe *SimpleExecutor) SignCtx(ctx *cc.CrossTransaction, chainID *big.Int) (*cc.CrossTransaction, error) {
+	account, wallet, err := exe.wallet()
+	if err != nil {
+		return nil, err
+	}
+	signer := cc.MakeCtxSigner(chainID)
+	if ext, ok := wallet.(*external.ExternalSigner); ok {
+		sig, err := ext.SignCrossTransaction(account, ctx, chainID)
+		if err != nil {
+			return nil, err
+		}
+		return ctx.WithSignature(signer, sig)
+	}
+	return cc.SignCtx(ctx, signer, func(hash []byte) ([]byte, error) {
+		return wallet.SignHash(account, hash)
+	})
+}
+
+// SignTx signs the anchor transaction of chain chainID
+func (exe *SimpleExecutor) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	account, wallet, err := exe.wallet()
+	if err != nil {
+		return nil, err
+	}
+	return wallet.SignTx(account, tx, chainID)
+}
+
+func (exe *SimpleExecutor) wallet() (accounts.Account, accounts.Wallet, error) {
 	account := accounts.Account{Address: exe.anchor}
 	wallet, err := exe.chain.AccountManager().Find(account)
 	if err != nil {
 		exe.log.Error("account not found ", "address", exe.anchor)
-		return nil, err
+		return account, nil, err
 	}
-	return wallet.SignHash(account, hash)
+	return account, wallet, nil
 }
 
+// contractCall is a transaction of the cross contract which is sent by anchor
+type contractCall interface {
+	ConstructData(crossContract abi.ABI) ([]byte, error)
+}
+
+// SubmitTransaction finishes receipts in destination chain, receipts taken entirely are finished
+// in batches, and the ones already finished are left out before batching
 func (exe *SimpleExecutor) SubmitTransaction(rtxs []*cc.ReceptTransaction) {
-	txs, err := exe.getTxForLockOut(rtxs)
+	var (
+		ids       []common.Hash
+		calls     []contractCall
+		batchable []*cc.ReceptTransaction
+	)
+	for _, rtx := range rtxs {
+		if rtx.DestinationId.Uint64() != exe.pm.NetworkId() {
+			continue
+		}
+		if !rtx.Batchable() {
+			ids = append(ids, rtx.CTxId)
+			calls = append(calls, rtx)
+			continue
+		}
+		if exe.finishable(rtx) {
+			batchable = append(batchable, rtx)
+		}
+	}
+	for _, batch := range BatchRecepts(batchable) {
+		ids = append(ids, batch.ID())
+		calls = append(calls, batch)
+	}
+	exe.submit(ids, calls)
+}
+
+// finishable returns false if makerFinish of the receipt would fail (e.g. already finished by anchor)
+func (exe *SimpleExecutor) finishable(rtx *cc.ReceptTransaction) bool {
+	data, err := rtx.ConstructData(exe.contractABI)
+	if err != nil {
+		exe.log.Error("ConstructData", "id", rtx.CTxId, "err", err)
+		return false
+	}
+	if ok, _ := exe.checkTransaction(exe.anchor, exe.contract, 0, maxFinishGasLimit, new(big.Int), data); !ok {
+		exe.log.Debug("already finish the cross Transaction", "id", rtx.CTxId)
+		return false
+	}
+	return true
+}
+
+// SubmitDelivery delivers signed message ctxs to target contracts in destination chain
+func (exe *SimpleExecutor) SubmitDelivery(txs []*cc.DeliverTransaction) {
+	var (
+		ids   []common.Hash
+		calls []contractCall
+	)
+	for _, tx := range txs {
+		if tx.Ctx.DestinationId().Uint64() == exe.pm.NetworkId() {
+			ids = append(ids, tx.ID())
+			calls = append(calls, tx)
+		}
+	}
+	exe.submit(ids, calls)
+}
+
+// SubmitEvidence reports double signing anchors to the cross contract of destination chain
+func (exe *SimpleExecutor) SubmitEvidence(evs []*cc.Evidence) {
+	var (
+		ids   []common.Hash
+		calls []contractCall
+	)
+	for _, ev := range evs {
+		if ev.Kind == cc.EvidenceDoubleSign && ev.DestinationId().Uint64() == exe.pm.NetworkId() {
+			ids = append(ids, ev.ID())
+			calls = append(calls, ev)
+		}
+	}
+	exe.submit(ids, calls)
+}
+
+// SubmitCancel cancels taking of expired ctxs in destination chain, or refunds maker in source chain
+func (exe *SimpleExecutor) SubmitCancel(ctxs []*cc.CancelTransaction) {
+	var (
+		ids   []common.Hash
+		calls []contractCall
+	)
+	for _, ctx := range ctxs {
+		if ctx.ChainId.Uint64() == exe.pm.NetworkId() {
+			ids = append(ids, ctx.CTxId)
+			calls = append(calls, ctx)
+		}
+	}
+	exe.submit(ids, calls)
+}
+
+func (exe *SimpleExecutor) submit(ids []common.Hash, calls []contractCall) {
+	txs, err := exe.getTxForLockOut(ids, calls)
 	if err != nil {
 		exe.log.Error("GetTxForLockOut", "err", err)
 	}
@@ -121,43 +340,57 @@ func (exe *SimpleExecutor) SubmitTransaction(rtxs []*cc.ReceptTransaction) {
 	}
 }
 
-func (exe *SimpleExecutor) getTxForLockOut(rwss []*cc.ReceptTransaction) ([]*types.Transaction, error) {
+func (exe *SimpleExecutor) getTxForLockOut(ids []common.Hash, calls []contractCall) ([]*types.Transaction, error) {
 	var err error
 	var count uint64
 	var param *TranParam
 	var tx *types.Transaction
 	var txs []*types.Transaction
 
-	nonce := exe.pm.GetNonce(exe.anchor)
+	exe.mu.Lock()
+	defer exe.mu.Unlock()
+
+	nonce := exe.queue.NextNonce(exe.pm.GetNonce(exe.anchor))
 	tokenAddress := exe.contract
 
-	for _, rws := range rwss {
-		if rws.DestinationId.Uint64() == exe.pm.NetworkId() {
-			param, err = exe.createTransaction(rws)
-			if err != nil {
-				exe.log.Warn("getTxForLockOut CreateTransaction", "id", rws.CTxId, "err", err)
-				continue
-			}
-			if ok, _ := exe.checkTransaction(exe.anchor, tokenAddress, nonce+count, param.gasLimit, param.gasPrice,
-				param.data); !ok {
-				exe.log.Debug("already finish the cross Transaction", "id", rws.CTxId)
-				continue
-			}
+	for i, call := range calls {
+		param, err = exe.createTransaction(ids[i], call)
+		if err != nil {
+			exe.log.Warn("getTxForLockOut CreateTransaction", "id", ids[i], "err", err)
+			exe.failure.Inc(1)
+			continue
+		}
+		if ok, _ := exe.checkTransaction(exe.anchor, tokenAddress, nonce+count, param.gasLimit, param.gasPrice,
+			param.data); !ok {
+			exe.log.Debug("already finish the cross Transaction", "id", ids[i])
+			continue
+		}
 
-			tx, err = newSignedTransaction(nonce+count, tokenAddress, param.gasLimit, param.gasPrice, param.data, exe.pm.NetworkId(), exe.SignHash)
-			if err != nil {
-				exe.log.Warn("GetTxForLockOut newSignedTransaction", "id", rws.CTxId, "err", err)
-				return nil, err
-			}
-			txs = append(txs, tx)
-			count++
+		tx, err = newSignedTransaction(nonce+count, tokenAddress, param.gasLimit, param.gasPrice, param.data, exe.pm.NetworkId(), exe.SignTx)
+		if err != nil {
+			exe.log.Warn("GetTxForLockOut newSignedTransaction", "id", ids[i], "err", err)
+			exe.failure.Inc(1)
+			return nil, err
 		}
+		exe.queue.Put(&cc.AnchorTransaction{
+			CTxId:    ids[i],
+			Nonce:    nonce + count,
+			GasPrice: param.gasPrice,
+			GasLimit: param.gasLimit,
+			Data:     param.data,
+			TxHash:   tx.Hash(),
+			Status:   cc.AnchorTxPending,
+			Time:     uint64(time.Now().Unix()),
+			Batch:    BatchOf(call),
+		})
+		txs = append(txs, tx)
+		count++
 	}
 
 	return txs, nil
 }
 
-func (exe *SimpleExecutor) createTransaction(rws *cc.ReceptTransaction) (*TranParam, error) {
+func (exe *SimpleExecutor) createTransaction(id common.Hash, call contractCall) (*TranParam, error) {
 	gasPrice, err := exe.gpo.SuggestPrice(context.Background())
 	if err != nil {
 		return nil, err
@@ -165,20 +398,31 @@ func (exe *SimpleExecutor) createTransaction(rws *cc.ReceptTransaction) (*TranPa
 	if gasPrice.Cmp(eth.DefaultConfig.Miner.GasPrice) < 0 {
 		gasPrice.Set(eth.DefaultConfig.Miner.GasPrice)
 	}
-	data, err := rws.ConstructData(exe.contractABI)
+	data, err := call.ConstructData(exe.contractABI)
 	if err != nil {
 		exe.log.Error("ConstructData", "err", err)
 		return nil, err
 	}
 	if balance, err := exe.gasHelper.GetBalance(exe.anchor); err != nil || balance == nil ||
 		balance.Cmp(new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(maxFinishGasLimit))) < 0 {
-		log.Warn("insufficient balance for finishing", "ctxID", rws.CTxId.String(),
-			"chainID", rws.ChainId, "error", err, "balance", balance, "price", gasPrice)
+		log.Warn("insufficient balance for finishing", "ctxID", id.String(),
+			"chainID", exe.pm.NetworkId(), "error", err, "balance", balance, "price", gasPrice)
 		metric.Report(exe.gasHelper.chain.ChainConfig().ChainID.Uint64(), "insufficient balance",
-			"ctxID", rws.CTxId.String())
+			"ctxID", id.String())
 	}
 
-	return &TranParam{gasLimit: maxFinishGasLimit, gasPrice: gasPrice, data: data}, nil
+	return &TranParam{gasLimit: GasLimitOf(call), gasPrice: gasPrice, data: data}, nil
+}
+
+// GasLimitOf returns the gas limit of an anchor transaction
+func GasLimitOf(call interface{}) uint64 {
+	switch call := call.(type) {
+	case *cc.DeliverTransaction:
+		return maxDeliverGasLimit
+	case *cc.ReceptBatch:
+		return maxFinishGasLimit * uint64(len(call.Recepts))
+	}
+	return maxFinishGasLimit
 }
 
 func (exe *SimpleExecutor) checkTransaction(address, tokenAddress common.Address, nonce, gasLimit uint64,
@@ -195,58 +439,107 @@ func (exe *SimpleExecutor) checkTransaction(address, tokenAddress common.Address
 }
 
 func newSignedTransaction(nonce uint64, to common.Address, gasLimit uint64, gasPrice *big.Int,
-	data []byte, networkId uint64, signHash cc.SignHash) (*types.Transaction, error) {
+	data []byte, networkId uint64, signTx signTxFn) (*types.Transaction, error) {
 	tx := types.NewTransaction(nonce, to, big.NewInt(0), gasLimit, gasPrice, data)
-	signer := types.NewEIP155Signer(big.NewInt(int64(networkId)))
-	txHash := signer.Hash(tx)
-	signature, err := signHash(txHash.Bytes())
-	if err != nil {
-		return nil, err
+	return signTx(tx, big.NewInt(int64(networkId)))
+}
+
+type signTxFn func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+
+// promoteTransaction finishes the queued transactions whose nonce is consumed, resends the dropped ones with
+// fresh nonces, re-adds the ones evicted from txpool, and replaces the stuck ones with a higher gas price
+func (exe *SimpleExecutor) promoteTransaction() {
+	exe.mu.Lock()
+	defer exe.mu.Unlock()
+
+	pending := exe.queue.Pending()
+	if len(pending) == 0 {
+		return
 	}
-	signedTx, err := tx.WithSignature(signer, signature)
+	nonce, err := exe.gasHelper.GetNonce(exe.anchor)
 	if err != nil {
-		return nil, err
+		exe.log.Warn("get anchor nonce failed", "error", err)
+		return
+	}
+	inPool := make(map[common.Hash]bool)
+	if txs, err := exe.pm.Pending(); err == nil {
+		for _, tx := range txs[exe.anchor] {
+			inPool[tx.Hash()] = true
+		}
 	}
-	return signedTx, nil
-}
 
-func (exe *SimpleExecutor) promoteTransaction() {
-	if pending, err := exe.pm.Pending(); err == nil {
-		if txs, ok := pending[exe.anchor]; ok {
-			var count uint64
-			var newTxs []*types.Transaction
-			var nonceBegin uint64
-			for _, v := range txs {
-				if count < core.DefaultTxPoolConfig.AccountSlots {
-					if count == 0 {
-						nonceBegin = v.Nonce()
-					}
-					if ok, _ := exe.checkTransaction(exe.anchor, *v.To(), nonceBegin+count, v.Gas(),
-						v.GasPrice(), v.Data()); !ok {
-						exe.log.Debug("already finish the cross Transaction", "tx", v.Hash())
-						continue
-					}
-					gasPrice := new(big.Int).Div(new(big.Int).Mul(
-						v.GasPrice(), big.NewInt(100+int64(core.DefaultTxPoolConfig.PriceBump))), big.NewInt(100))
-
-					if gasPrice.Cmp(MaxGasPrice) > 0 {
-						exe.log.Info("overflow max gas price, set to max", "tx", v.Hash().String())
-						gasPrice = new(big.Int).Sub(MaxGasPrice, big.NewInt(rand.Int63n(1e9)))
-					}
-
-					tx, err := newSignedTransaction(nonceBegin+count, *v.To(), v.Gas(), gasPrice, v.Data(), exe.pm.NetworkId(), exe.SignHash)
-					if err != nil {
-						exe.log.Info("promoteTransaction", "err", err)
-					}
-
-					newTxs = append(newTxs, tx)
-					count++
-				} else {
-					break
+	var (
+		finished int
+		packed   []*cc.AnchorTransaction
+		dropped  []*cc.AnchorTransaction
+		newTxs   []*types.Transaction
+		now      = time.Now()
+	)
+	for _, tx := range pending {
+		if tx.Nonce < nonce {
+			if receipt := FinishAnchorTx(tx, exe.gasHelper.GetReceipt); receipt != nil {
+				AccountAnchorTx(tx, receipt, exe.gasHelper.GetGasPrice(tx.TxHash, tx.GasPrice), exe.contract, exe.anchor)
+				packed = append(packed, tx)
+				for _, id := range FailedBatchItems(receipt, exe.contract) {
+					exe.log.Warn("batch finish skipped", "id", id.String(), "tx", tx.TxHash.String())
+					exe.failure.Inc(1)
 				}
+			} else {
+				dropped = append(dropped, tx)
 			}
-			exe.log.Info("promoteTransaction", "len", len(newTxs))
-			exe.pm.AddLocals(newTxs)
+			if tx.Status != cc.AnchorTxSucceeded {
+				exe.failure.Inc(1)
+			}
+			exe.queue.Put(tx)
+			finished++
+			continue
+		}
+		gasPrice := tx.GasPrice
+		if inPool[tx.TxHash] { // stuck in txpool, replace it with a higher price
+			if now.Sub(time.Unix(int64(tx.Time), 0)) < promoteInterval {
+				continue
+			}
+			if gasPrice = BumpGasPrice(tx.GasPrice); gasPrice == nil {
+				exe.log.Info("reach max gas price, keep waiting", "nonce", tx.Nonce, "tx", tx.TxHash.String())
+				continue
+			}
+		}
+		newTx, err := newSignedTransaction(tx.Nonce, exe.contract, tx.GasLimit, gasPrice, tx.Data, exe.pm.NetworkId(), exe.SignTx)
+		if err != nil {
+			exe.log.Info("promoteTransaction", "nonce", tx.Nonce, "err", err)
+			continue
+		}
+		if newTx.Hash() != tx.TxHash {
+			tx.Replaced = append(tx.Replaced, tx.TxHash)
+			tx.TxHash = newTx.Hash()
+		}
+		tx.GasPrice, tx.Time = gasPrice, uint64(now.Unix())
+		exe.queue.Put(tx)
+		newTxs = append(newTxs, newTx)
+	}
+	// the nonce of a dropped tx is used by another transaction of anchor, resend it unless the ctx is finished by others
+	resent := exe.queue.ResendDropped(dropped, exe.pm.GetNonce(exe.anchor), func(tx *cc.AnchorTransaction) (common.Hash, error) {
+		if ok, _ := exe.checkTransaction(exe.anchor, exe.contract, tx.Nonce, tx.GasLimit, tx.GasPrice, tx.Data); !ok {
+			return common.Hash{}, ErrCtxFinished
+		}
+		newTx, err := newSignedTransaction(tx.Nonce, exe.contract, tx.GasLimit, tx.GasPrice, tx.Data, exe.pm.NetworkId(), exe.SignTx)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		newTxs = append(newTxs, newTx)
+		return newTx.Hash(), nil
+	})
+
+	exe.log.Info("promoteTransaction", "finished", finished, "resent", len(resent), "len", len(newTxs))
+	if len(newTxs) > 0 {
+		exe.pm.AddLocals(newTxs)
+	}
+	if err := exe.ledger.Add(packed...); err != nil {
+		exe.log.Warn("Failed to save executor ledger", "err", err)
+	}
+	if finished > 0 {
+		if err := exe.queue.Rotate(); err != nil {
+			exe.log.Warn("Failed to rotate executor journal", "err", err)
 		}
 	}
 }
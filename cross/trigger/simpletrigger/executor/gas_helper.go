@@ -2,6 +2,7 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"math/big"
 	"time"
 
@@ -21,6 +22,8 @@ import (
 var (
 	defaultGasPrice = big.NewInt(params.GWei)
 	MaxGasPrice     = big.NewInt(500 * params.GWei)
+
+	errCallFailed = errors.New("contract call failed")
 )
 
 type CallArgs struct {
@@ -114,3 +117,35 @@ func (this *GasHelper) checkExec(ctx context.Context, args CallArgs) (bool, erro
 	}
 	return true, nil
 }
+
+// call executes a read-only call at the latest block and returns the output
+func (this *GasHelper) call(ctx context.Context, args CallArgs) ([]byte, error) {
+	res, _, failed, err := this.doCall(ctx, args, rpc.LatestBlockNumber, vm.Config{}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if failed {
+		return nil, errCallFailed
+	}
+	return res, nil
+}
+
+func (h *GasHelper) GetNonce(addr common.Address) (uint64, error) {
+	state, err := h.blockchain.State()
+	if err != nil {
+		return 0, err
+	}
+	return state.GetNonce(addr), nil
+}
+
+func (h *GasHelper) GetReceipt(hash common.Hash) *types.Receipt {
+	return h.blockchain.GetReceiptsByTxHash(hash)
+}
+
+// GetGasPrice returns the gas price of the packed tx, or price if the tx is not found
+func (h *GasHelper) GetGasPrice(hash common.Hash, price *big.Int) *big.Int {
+	if tx, _, _ := h.blockchain.GetTransactionByTxHash(hash); tx != nil {
+		return tx.GasPrice()
+	}
+	return price
+}
@@ -0,0 +1,81 @@
+package executor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+)
+
+// Ledger accumulates the profit and loss of finished anchor transactions, it is saved beside
+// the executor journal so that the totals survive rotating finished transactions out of the queue.
+type Ledger struct {
+	path   string
+	profit *cc.AnchorProfit
+	mu     sync.RWMutex
+}
+
+// LedgerPath returns the ledger path of an executor journal, empty if the journal is empty
+func LedgerPath(journal string) string {
+	if journal == "" {
+		return ""
+	}
+	return strings.TrimSuffix(journal, ".rlp") + "_ledger.json"
+}
+
+// NewLedger creates a ledger saved at path, the ledger is in-memory only if path is empty
+func NewLedger(path string) *Ledger {
+	return &Ledger{path: path, profit: cc.NewAnchorProfit()}
+}
+
+// Load reads the saved totals, a missing file is an empty ledger
+func (l *Ledger) Load() error {
+	if l.path == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	profit := cc.NewAnchorProfit()
+	if err := json.Unmarshal(data, profit); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.profit = profit
+	return nil
+}
+
+// Add accounts the finished transactions and saves the totals
+func (l *Ledger) Add(txs ...*cc.AnchorTransaction) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, tx := range txs {
+		l.profit.Add(tx)
+	}
+	if l.path == "" || len(txs) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(l.profit)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(l.path+".new", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(l.path+".new", l.path)
+}
+
+// Profit returns a copy of the totals
+func (l *Ledger) Profit() *cc.AnchorProfit {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.profit.Copy()
+}
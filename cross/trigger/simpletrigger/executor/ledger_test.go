@@ -0,0 +1,42 @@
+package executor
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "executor-ledger")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := LedgerPath(filepath.Join(dir, "executor.rlp"))
+	assert.Equal(t, filepath.Join(dir, "executor_ledger.json"), path)
+
+	l := NewLedger(path)
+	assert.NoError(t, l.Load())
+	first, second := newAnchorTx(0), newAnchorTx(1)
+	first.GasUsed, first.GasCost, first.RelayFee = 100, big.NewInt(1000), big.NewInt(3000)
+	second.GasUsed, second.GasCost, second.Claimed = 50, big.NewInt(500), big.NewInt(3000)
+	assert.NoError(t, l.Add(first, second))
+
+	// totals are kept after reloading
+	l = NewLedger(path)
+	assert.NoError(t, l.Load())
+	profit := l.Profit()
+	assert.Equal(t, uint64(2), profit.Txs)
+	assert.Equal(t, uint64(150), profit.GasUsed)
+	assert.Equal(t, big.NewInt(1500), profit.GasCost)
+	assert.Equal(t, big.NewInt(3000), profit.RelayFee)
+	assert.Equal(t, big.NewInt(3000), profit.Claimed)
+	assert.Equal(t, big.NewInt(1500), profit.Profit())
+
+	// the copy is not affected by later transactions
+	assert.NoError(t, l.Add(first))
+	assert.Equal(t, big.NewInt(1500), profit.GasCost)
+	assert.Equal(t, big.NewInt(2500), l.Profit().GasCost)
+}
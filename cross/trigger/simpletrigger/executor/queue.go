@@ -0,0 +1,260 @@
+package executor
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/core"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/log"
+	"github.com/simplechain-org/go-simplechain/params"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+)
+
+const maxFinishedTxs = 256 // finished transactions kept in queue for querying
+
+// ErrCtxFinished is returned by resending a dropped transaction whose call would fail now,
+// e.g. the ctx is finished by another anchor
+var ErrCtxFinished = errors.New("ctx is finished by others")
+
+// TxQueue records every transaction submitted by anchor until its nonce is consumed on chain,
+// the queue is journaled to disk so that the unpacked transactions survive node restarts and txpool evictions.
+type TxQueue struct {
+	txs     map[uint64]*cc.AnchorTransaction // nonce => anchor tx
+	journal *queueJournal
+	mu      sync.RWMutex
+}
+
+// NewTxQueue creates a queue journaled at path, the queue is in-memory only if path is empty
+func NewTxQueue(path string) *TxQueue {
+	q := &TxQueue{txs: make(map[uint64]*cc.AnchorTransaction)}
+	if path != "" {
+		q.journal = newQueueJournal(path)
+	}
+	return q
+}
+
+// Load restores the queue from journal, and opens the journal for writing
+func (q *TxQueue) Load() error {
+	if q.journal == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.journal.load(func(tx *cc.AnchorTransaction) { q.txs[tx.Nonce] = tx }); err != nil {
+		log.Warn("Failed to load executor journal", "err", err)
+	}
+	return q.rotate()
+}
+
+// Put adds or updates the transaction of tx.Nonce
+func (q *TxQueue) Put(tx *cc.AnchorTransaction) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.txs[tx.Nonce] = copyAnchorTx(tx)
+	if q.journal != nil {
+		if err := q.journal.insert(tx); err != nil {
+			log.Warn("Failed to journal anchor transaction", "nonce", tx.Nonce, "err", err)
+		}
+	}
+}
+
+// NextNonce returns the nonce for a new transaction, which is not less than the given pool nonce
+func (q *TxQueue) NextNonce(poolNonce uint64) uint64 {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	nonce := poolNonce
+	for n, tx := range q.txs {
+		if !tx.Finished() && n >= nonce {
+			nonce = n + 1
+		}
+	}
+	return nonce
+}
+
+// ResendDropped queues the call data of dropped transactions again with fresh nonces from poolNonce,
+// each one is signed and sent by resend which returns its hash. The ones failed to resend are left dropped.
+func (q *TxQueue) ResendDropped(dropped []*cc.AnchorTransaction, poolNonce uint64,
+	resend func(tx *cc.AnchorTransaction) (common.Hash, error)) []*cc.AnchorTransaction {
+	var (
+		nonce  = q.NextNonce(poolNonce)
+		resent []*cc.AnchorTransaction
+	)
+	for _, tx := range dropped {
+		next := &cc.AnchorTransaction{
+			CTxId:    tx.CTxId,
+			Nonce:    nonce,
+			GasPrice: tx.GasPrice,
+			GasLimit: tx.GasLimit,
+			Data:     tx.Data,
+			Status:   cc.AnchorTxPending,
+			Time:     uint64(time.Now().Unix()),
+			Batch:    tx.Batch,
+		}
+		hash, err := resend(next)
+		if err != nil {
+			log.Info("Dropped anchor transaction is not resent", "ctxId", tx.CTxId.String(), "nonce", tx.Nonce, "err", err)
+			continue
+		}
+		next.TxHash = hash
+		q.Put(next)
+		resent = append(resent, next)
+		nonce++
+	}
+	return resent
+}
+
+// Pending returns the unfinished transactions sorted by nonce
+func (q *TxQueue) Pending() []*cc.AnchorTransaction {
+	return q.list(true)
+}
+
+// List returns all transactions in queue sorted by nonce
+func (q *TxQueue) List() []*cc.AnchorTransaction {
+	return q.list(false)
+}
+
+func (q *TxQueue) list(pendingOnly bool) []*cc.AnchorTransaction {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	txs := make([]*cc.AnchorTransaction, 0, len(q.txs))
+	for _, tx := range q.txs {
+		if !pendingOnly || !tx.Finished() {
+			txs = append(txs, copyAnchorTx(tx))
+		}
+	}
+	sort.Slice(txs, func(i, j int) bool { return txs[i].Nonce < txs[j].Nonce })
+	return txs
+}
+
+// Rotate drops the oldest finished transactions and regenerates the journal
+func (q *TxQueue) Rotate() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.rotate()
+}
+
+func (q *TxQueue) rotate() error {
+	var finished []uint64
+	for n, tx := range q.txs {
+		if tx.Finished() {
+			finished = append(finished, n)
+		}
+	}
+	if len(finished) > maxFinishedTxs {
+		sort.Slice(finished, func(i, j int) bool { return finished[i] < finished[j] })
+		for _, n := range finished[:len(finished)-maxFinishedTxs] {
+			delete(q.txs, n)
+		}
+	}
+	if q.journal == nil {
+		return nil
+	}
+	txs := make([]*cc.AnchorTransaction, 0, len(q.txs))
+	for _, tx := range q.txs {
+		txs = append(txs, tx)
+	}
+	sort.Slice(txs, func(i, j int) bool { return txs[i].Nonce < txs[j].Nonce })
+	return q.journal.rotate(txs)
+}
+
+func (q *TxQueue) Close() error {
+	if q.journal == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.journal.close()
+}
+
+func copyAnchorTx(tx *cc.AnchorTransaction) *cc.AnchorTransaction {
+	cpy := *tx
+	if tx.GasPrice != nil {
+		cpy.GasPrice = new(big.Int).Set(tx.GasPrice)
+	}
+	cpy.Replaced = append([]common.Hash{}, tx.Replaced...)
+	if tx.Batch != nil {
+		cpy.Batch = append([]common.Hash{}, tx.Batch...)
+	}
+	if tx.GasCost != nil {
+		cpy.GasCost = new(big.Int).Set(tx.GasCost)
+	}
+	if tx.RelayFee != nil {
+		cpy.RelayFee = new(big.Int).Set(tx.RelayFee)
+	}
+	if tx.Claimed != nil {
+		cpy.Claimed = new(big.Int).Set(tx.Claimed)
+	}
+	return &cpy
+}
+
+// FinishAnchorTx resolves the status of tx whose nonce is consumed, by receipt of any hash sent with the nonce,
+// it returns the receipt of the packed one, or nil if the tx is dropped
+func FinishAnchorTx(tx *cc.AnchorTransaction, getReceipt func(common.Hash) *types.Receipt) *types.Receipt {
+	hashes := tx.Hashes()
+	for i, hash := range hashes {
+		receipt := getReceipt(hash)
+		if receipt == nil {
+			continue
+		}
+		if receipt.Status == types.ReceiptStatusSuccessful {
+			tx.Status = cc.AnchorTxSucceeded
+		} else {
+			tx.Status = cc.AnchorTxFailed
+		}
+		if receipt.BlockNumber != nil {
+			tx.BlockNumber = receipt.BlockNumber.Uint64()
+		}
+		// the packed one becomes the tx hash, others are replaced
+		tx.TxHash = hash
+		tx.Replaced = append(append([]common.Hash{}, hashes[i+1:]...), hashes[:i]...)
+		return receipt
+	}
+	tx.Status = cc.AnchorTxDropped
+	return nil
+}
+
+// AccountAnchorTx accounts the gas fee paid for the packed tx at the price, and relay fees
+// credited to or claimed by anchor in logs of the cross contract in its receipt
+func AccountAnchorTx(tx *cc.AnchorTransaction, receipt *types.Receipt, price *big.Int, contract, anchor common.Address) {
+	tx.GasUsed = receipt.GasUsed
+	tx.GasCost = new(big.Int).Mul(price, new(big.Int).SetUint64(receipt.GasUsed))
+	tx.RelayFee, tx.Claimed = new(big.Int), new(big.Int)
+	for _, l := range receipt.Logs {
+		// logs are also emitted by target contracts of message deliveries
+		if l.Address != contract || len(l.Topics) == 0 || len(l.Data) < 64 {
+			continue
+		}
+		// RelayFee(txId, anchor, remoteChainId, fee) and ClaimRelayFee(remoteChainId, anchor, fee) have the fee at the same offset,
+		// a partial fill credits every signer in the tx reaching signConfirmCount
+		switch l.Topics[0] {
+		case params.RelayFeeTopic:
+			if len(l.Topics) > 2 && l.Topics[2] == anchor.Hash() {
+				tx.RelayFee.Add(tx.RelayFee, new(big.Int).SetBytes(l.Data[32:64]))
+			}
+		case params.ClaimRelayFeeTopic:
+			if len(l.Topics) > 1 && l.Topics[1] == anchor.Hash() {
+				tx.Claimed.Add(tx.Claimed, new(big.Int).SetBytes(l.Data[32:64]))
+			}
+		}
+	}
+}
+
+// BumpGasPrice raises the gas price by txpool PriceBump percent up to MaxGasPrice,
+// it returns nil if the price already reaches MaxGasPrice
+func BumpGasPrice(price *big.Int) *big.Int {
+	if price.Cmp(MaxGasPrice) >= 0 {
+		return nil
+	}
+	bumped := new(big.Int).Div(new(big.Int).Mul(
+		price, big.NewInt(100+int64(core.DefaultTxPoolConfig.PriceBump))), big.NewInt(100))
+	if bumped.Cmp(MaxGasPrice) > 0 {
+		bumped.Set(MaxGasPrice)
+	}
+	return bumped
+}
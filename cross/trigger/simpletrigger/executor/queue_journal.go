@@ -0,0 +1,170 @@
+package executor
+
+import (
+	"errors"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/log"
+	"github.com/simplechain-org/go-simplechain/rlp"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+)
+
+// errNoActiveJournal is returned if a transaction is attempted to be inserted
+// into the journal, but no such file is currently open.
+var errNoActiveJournal = errors.New("no active journal")
+
+// devNull is a WriteCloser that just discards anything written into it.
+type devNull struct{}
+
+func (*devNull) Write(p []byte) (n int, err error) { return len(p), nil }
+func (*devNull) Close() error                      { return nil }
+
+// queueJournal is a rotating log of anchor transactions, every update of a transaction
+// is appended, so the last record of a nonce wins on loading.
+type queueJournal struct {
+	path   string         // Filesystem path to store the transactions at
+	writer io.WriteCloser // Output stream to write new transactions into
+}
+
+func newQueueJournal(path string) *queueJournal {
+	return &queueJournal{
+		path: path,
+	}
+}
+
+// load parses a journal dump from disk, loading its contents into the specified queue.
+func (journal *queueJournal) load(add func(*cc.AnchorTransaction)) error {
+	// Skip the parsing if the journal file doesn't exist at all
+	if _, err := os.Stat(journal.path); os.IsNotExist(err) {
+		return nil
+	}
+	input, err := os.Open(journal.path)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	// Temporarily discard any journal additions (don't double add on load)
+	journal.writer = new(devNull)
+	defer func() { journal.writer = nil }()
+
+	stream := rlp.NewStream(input, 0)
+	var (
+		failure error
+		total   int
+	)
+	for {
+		var raw rlp.RawValue
+		if err = stream.Decode(&raw); err != nil {
+			if err != io.EOF {
+				failure = err
+			}
+			break
+		}
+		tx, err := decodeAnchorTx(raw)
+		if err != nil {
+			failure = err
+			break
+		}
+		total++
+		add(tx)
+	}
+	log.Info("Loaded local executor journal", "records", total)
+	return failure
+}
+
+// legacyAnchorTx is the journal record of anchor transactions without gas accounting
+type legacyAnchorTx struct {
+	CTxId       common.Hash
+	Nonce       uint64
+	GasPrice    *big.Int
+	GasLimit    uint64
+	Data        []byte
+	TxHash      common.Hash
+	Replaced    []common.Hash
+	Status      cc.AnchorTxStatus
+	BlockNumber uint64
+	Time        uint64
+}
+
+// decodeAnchorTx decodes a journal record, records written before gas accounting are also accepted
+func decodeAnchorTx(raw []byte) (*cc.AnchorTransaction, error) {
+	tx := new(cc.AnchorTransaction)
+	if err := rlp.DecodeBytes(raw, tx); err == nil {
+		return tx, nil
+	}
+	var legacy legacyAnchorTx
+	if err := rlp.DecodeBytes(raw, &legacy); err != nil {
+		return nil, err
+	}
+	return &cc.AnchorTransaction{
+		CTxId:       legacy.CTxId,
+		Nonce:       legacy.Nonce,
+		GasPrice:    legacy.GasPrice,
+		GasLimit:    legacy.GasLimit,
+		Data:        legacy.Data,
+		TxHash:      legacy.TxHash,
+		Replaced:    legacy.Replaced,
+		Status:      legacy.Status,
+		BlockNumber: legacy.BlockNumber,
+		Time:        legacy.Time,
+	}, nil
+}
+
+// insert adds the specified transaction to the local disk journal.
+func (journal *queueJournal) insert(tx *cc.AnchorTransaction) error {
+	if journal.writer == nil {
+		return errNoActiveJournal
+	}
+	return rlp.Encode(journal.writer, tx)
+}
+
+// rotate regenerates the journal based on the current contents of the queue.
+func (journal *queueJournal) rotate(txs []*cc.AnchorTransaction) error {
+	// Close the current journal (if any is open)
+	if journal.writer != nil {
+		if err := journal.writer.Close(); err != nil {
+			return err
+		}
+		journal.writer = nil
+	}
+	// Generate a new journal with the contents of the current queue
+	replacement, err := os.OpenFile(journal.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	for _, tx := range txs {
+		if err = rlp.Encode(replacement, tx); err != nil {
+			replacement.Close()
+			return err
+		}
+	}
+	replacement.Close()
+
+	// Replace the live journal with the newly generated one
+	if err = os.Rename(journal.path+".new", journal.path); err != nil {
+		return err
+	}
+	sink, err := os.OpenFile(journal.path, os.O_WRONLY|os.O_APPEND, 0755)
+	if err != nil {
+		return err
+	}
+	journal.writer = sink
+	log.Debug("Regenerated executor journal", "transactions", len(txs))
+
+	return nil
+}
+
+// close flushes the journal contents to disk and closes the file.
+func (journal *queueJournal) close() error {
+	var err error
+	if journal.writer != nil {
+		err = journal.writer.Close()
+		journal.writer = nil
+	}
+	return err
+}
@@ -0,0 +1,180 @@
+package executor
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/params"
+	"github.com/simplechain-org/go-simplechain/rlp"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newAnchorTx(nonce uint64) *cc.AnchorTransaction {
+	return &cc.AnchorTransaction{
+		CTxId:    common.BigToHash(new(big.Int).SetUint64(nonce + 1)),
+		Nonce:    nonce,
+		GasPrice: big.NewInt(1e9),
+		GasLimit: maxFinishGasLimit,
+		Data:     []byte{0x01},
+		TxHash:   common.BigToHash(new(big.Int).SetUint64(nonce + 100)),
+	}
+}
+
+func TestTxQueue_Journal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "executor-queue")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "executor.rlp")
+
+	q := NewTxQueue(path)
+	assert.NoError(t, q.Load())
+	for i := uint64(0); i < 3; i++ {
+		q.Put(newAnchorTx(i))
+	}
+	assert.Equal(t, uint64(3), q.NextNonce(1))
+	assert.Equal(t, uint64(5), q.NextNonce(5))
+
+	// the last record of a nonce wins after reloading
+	tx := newAnchorTx(0)
+	tx.Replaced = append(tx.Replaced, tx.TxHash)
+	tx.TxHash = common.HexToHash("0xff")
+	tx.Status = cc.AnchorTxSucceeded
+	q.Put(tx)
+	assert.NoError(t, q.Close())
+
+	q = NewTxQueue(path)
+	assert.NoError(t, q.Load())
+	defer q.Close()
+	all := q.List()
+	assert.Len(t, all, 3)
+	assert.Equal(t, common.HexToHash("0xff"), all[0].TxHash)
+	assert.Equal(t, cc.AnchorTxSucceeded, all[0].Status)
+	assert.Len(t, all[0].Replaced, 1)
+	assert.Len(t, q.Pending(), 2)
+	assert.Equal(t, uint64(1), q.Pending()[0].Nonce)
+}
+
+func TestTxQueue_Rotate(t *testing.T) {
+	q := NewTxQueue("")
+	for i := uint64(0); i < maxFinishedTxs+10; i++ {
+		tx := newAnchorTx(i)
+		tx.Status = cc.AnchorTxSucceeded
+		q.Put(tx)
+	}
+	q.Put(newAnchorTx(maxFinishedTxs + 10))
+	assert.NoError(t, q.Rotate())
+	all := q.List()
+	assert.Len(t, all, maxFinishedTxs+1)
+	assert.Equal(t, uint64(10), all[0].Nonce)
+	assert.Len(t, q.Pending(), 1)
+}
+
+func TestFinishAnchorTx(t *testing.T) {
+	tx := newAnchorTx(0)
+	packed := tx.TxHash
+	tx.Replaced = []common.Hash{packed}
+	tx.TxHash = common.HexToHash("0xff")
+
+	FinishAnchorTx(tx, func(hash common.Hash) *types.Receipt {
+		if hash == packed {
+			return &types.Receipt{Status: types.ReceiptStatusFailed, BlockNumber: big.NewInt(7)}
+		}
+		return nil
+	})
+	assert.Equal(t, cc.AnchorTxFailed, tx.Status)
+	assert.Equal(t, packed, tx.TxHash)
+	assert.Equal(t, []common.Hash{common.HexToHash("0xff")}, tx.Replaced)
+	assert.Equal(t, uint64(7), tx.BlockNumber)
+
+	tx = newAnchorTx(1)
+	FinishAnchorTx(tx, func(common.Hash) *types.Receipt { return nil })
+	assert.Equal(t, cc.AnchorTxDropped, tx.Status)
+}
+
+func TestTxQueue_ResendDropped(t *testing.T) {
+	q := NewTxQueue("")
+	dropped, finished := newAnchorTx(0), newAnchorTx(1)
+	dropped.Batch = []common.Hash{dropped.CTxId, common.HexToHash("0xb2")}
+	for _, tx := range []*cc.AnchorTransaction{dropped, finished} {
+		tx.Status = cc.AnchorTxDropped
+		q.Put(tx)
+	}
+	q.Put(newAnchorTx(2))
+
+	resent := q.ResendDropped([]*cc.AnchorTransaction{dropped, finished}, 1, func(tx *cc.AnchorTransaction) (common.Hash, error) {
+		if tx.CTxId == finished.CTxId {
+			return common.Hash{}, ErrCtxFinished
+		}
+		return common.HexToHash("0xff"), nil
+	})
+	assert.Len(t, resent, 1)
+	assert.Equal(t, uint64(3), resent[0].Nonce)
+
+	pending := q.Pending()
+	assert.Len(t, pending, 2)
+	assert.Equal(t, dropped.CTxId, pending[1].CTxId)
+	assert.Equal(t, dropped.Data, pending[1].Data)
+	assert.Equal(t, dropped.Batch, pending[1].Batch)
+	assert.Equal(t, common.HexToHash("0xff"), pending[1].TxHash)
+	assert.Len(t, q.List(), 4)
+}
+
+func TestAccountAnchorTx(t *testing.T) {
+	contract, target := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+	anchor, other := common.HexToAddress("0xa1"), common.HexToAddress("0xa2")
+	feeLog := func(address common.Address, topic common.Hash, fee int64, indexed ...common.Hash) *types.Log {
+		data := append(common.LeftPadBytes(big.NewInt(512).Bytes(), 32), common.LeftPadBytes(big.NewInt(fee).Bytes(), 32)...)
+		return &types.Log{Address: address, Topics: append([]common.Hash{topic}, indexed...), Data: data}
+	}
+	txID := common.HexToHash("0x1d")
+	tx := newAnchorTx(0)
+	AccountAnchorTx(tx, &types.Receipt{
+		GasUsed: 21000,
+		Logs: []*types.Log{
+			feeLog(contract, params.RelayFeeTopic, 100, txID, anchor.Hash()),
+			feeLog(contract, params.RelayFeeTopic, 100, txID, other.Hash()), // credited to another signer
+			feeLog(target, params.RelayFeeTopic, 1000, txID, anchor.Hash()), // forged by the target of a message
+			feeLog(contract, params.ClaimRelayFeeTopic, 300, anchor.Hash()),
+		},
+	}, big.NewInt(2e9), contract, anchor)
+	assert.Equal(t, uint64(21000), tx.GasUsed)
+	assert.Equal(t, big.NewInt(42e12), tx.GasCost)
+	assert.Equal(t, big.NewInt(100), tx.RelayFee)
+	assert.Equal(t, big.NewInt(300), tx.Claimed)
+}
+
+func TestTxQueue_LegacyJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "executor-queue")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "executor.rlp")
+
+	tx := newAnchorTx(0)
+	legacy := legacyAnchorTx{CTxId: tx.CTxId, Nonce: tx.Nonce, GasPrice: tx.GasPrice, GasLimit: tx.GasLimit,
+		Data: tx.Data, TxHash: tx.TxHash, Status: cc.AnchorTxSucceeded}
+	enc, err := rlp.EncodeToBytes(&legacy)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(path, enc, 0644))
+
+	q := NewTxQueue(path)
+	assert.NoError(t, q.Load())
+	defer q.Close()
+	all := q.List()
+	assert.Len(t, all, 1)
+	assert.Equal(t, tx.TxHash, all[0].TxHash)
+	assert.Equal(t, cc.AnchorTxSucceeded, all[0].Status)
+}
+
+func TestBumpGasPrice(t *testing.T) {
+	assert.Equal(t, big.NewInt(11e8), BumpGasPrice(big.NewInt(1e9)))
+	assert.Equal(t, MaxGasPrice, BumpGasPrice(new(big.Int).Sub(MaxGasPrice, big.NewInt(1))))
+	assert.Nil(t, BumpGasPrice(MaxGasPrice))
+}
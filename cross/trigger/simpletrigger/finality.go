@@ -0,0 +1,86 @@
+package simpletrigger
+
+import (
+	"github.com/simplechain-org/go-simplechain/consensus/dpos"
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/log"
+	"github.com/simplechain-org/go-simplechain/params"
+)
+
+type HeaderReader interface {
+	GetHeaderByNumber(number uint64) *types.Header
+}
+
+// Finality decides when blocks of a chain are confirmed, logs of the cross contract are handled as
+// confirmed cross transactions only after their blocks are confirmed
+type Finality interface {
+	// ConfirmedDepth returns the max blocks from the head to its confirmed block
+	ConfirmedDepth() uint64
+	// ConfirmedNumber returns the highest confirmed block number when head is the current block number,
+	// false if no block is confirmed yet
+	ConfirmedNumber(chain HeaderReader, head uint64) (uint64, bool)
+}
+
+// NewFinality returns the finality of the chain by its consensus engine:
+// blocks of Istanbul and Raft chains are final once they are sealed,
+// DPoS chains follow the confirmations of signers, and PoW (or Clique) chains wait for depth blocks
+func NewFinality(config *params.ChainConfig, depth uint64) Finality {
+	switch {
+	case config == nil:
+		return NewDepthFinality(depth)
+	case config.Istanbul != nil, config.Raft:
+		return NewDepthFinality(0)
+	case config.DPoS != nil:
+		// signers confirm blocks in the latest 2/3 loop, see dpos.Snapshot.getLastConfirmedBlockNumber
+		if config.DPoS.MaxSignerCount > 0 {
+			depth = config.DPoS.MaxSignerCount * 2 / 3
+		}
+		return &dposFinality{maxDepth: depth}
+	default:
+		return NewDepthFinality(depth)
+	}
+}
+
+type depthFinality struct {
+	depth uint64
+}
+
+// NewDepthFinality returns the finality that blocks are confirmed after depth blocks
+func NewDepthFinality(depth uint64) Finality {
+	return &depthFinality{depth: depth}
+}
+
+func (f *depthFinality) ConfirmedDepth() uint64 {
+	return f.depth
+}
+
+func (f *depthFinality) ConfirmedNumber(_ HeaderReader, head uint64) (uint64, bool) {
+	if head < f.depth {
+		return 0, false
+	}
+	return head - f.depth, true
+}
+
+// dposFinality reads the confirmed block number recorded in header extra by the DPoS engine,
+// blocks are confirmed after maxDepth blocks if the header is not available
+type dposFinality struct {
+	maxDepth uint64
+}
+
+func (f *dposFinality) ConfirmedDepth() uint64 {
+	return f.maxDepth
+}
+
+func (f *dposFinality) ConfirmedNumber(chain HeaderReader, head uint64) (uint64, bool) {
+	if header := chain.GetHeaderByNumber(head); header != nil {
+		number, err := dpos.ConfirmedBlockNumber(header)
+		if err == nil && number <= head {
+			return number, true
+		}
+		log.Debug("Failed to read confirmed number of dpos header", "number", head, "error", err)
+	}
+	if head < f.maxDepth {
+		return 0, false
+	}
+	return head - f.maxDepth, true
+}
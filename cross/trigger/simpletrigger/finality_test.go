@@ -0,0 +1,46 @@
+package simpletrigger
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/core/types"
+	"github.com/simplechain-org/go-simplechain/params"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type headerChain map[uint64]*types.Header
+
+func (c headerChain) GetHeaderByNumber(number uint64) *types.Header { return c[number] }
+
+func TestNewFinality(t *testing.T) {
+	chain := headerChain{}
+
+	pow := NewFinality(params.TestChainConfig, 12)
+	assert.Equal(t, uint64(12), pow.ConfirmedDepth())
+	_, ok := pow.ConfirmedNumber(chain, 11)
+	assert.False(t, ok)
+	number, ok := pow.ConfirmedNumber(chain, 20)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(8), number)
+
+	for _, config := range []*params.ChainConfig{
+		{ChainID: big.NewInt(1), Istanbul: new(params.IstanbulConfig)},
+		{ChainID: big.NewInt(1), Raft: true},
+	} {
+		bft := NewFinality(config, 12)
+		assert.Equal(t, uint64(0), bft.ConfirmedDepth())
+		number, ok := bft.ConfirmedNumber(chain, 20)
+		assert.True(t, ok)
+		assert.Equal(t, uint64(20), number)
+	}
+
+	// dpos header without extra falls back to the max depth of 2/3 signers
+	dpos := NewFinality(params.AllDPoSProtocolChanges, 12)
+	assert.Equal(t, uint64(14), dpos.ConfirmedDepth())
+	chain[20] = &types.Header{Number: big.NewInt(20)}
+	number, ok = dpos.ConfirmedNumber(chain, 20)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(6), number)
+}
@@ -55,6 +55,11 @@ func QueryAnchor(config *params.ChainConfig, bc core.ChainContext, statedb *stat
 	if err != nil {
 		log.Info("QueryAnchor apply getAnchor transaction failed", "err", err)
 	}
+	return UnpackAnchors(res)
+}
+
+// UnpackAnchors parses the result of getAnchor in cross contract
+func UnpackAnchors(res []byte) ([]common.Address, int) {
 	var anchors []common.Address
 	if len(res) > 64 {
 		signConfirmCount := new(big.Int).SetBytes(res[common.HashLength : common.HashLength*2]).Uint64()
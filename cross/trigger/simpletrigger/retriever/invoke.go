@@ -18,11 +18,12 @@ import (
 )
 
 type ChainInvoke struct {
-	bc simpletrigger.BlockChain
+	bc       simpletrigger.BlockChain
+	finality simpletrigger.Finality
 }
 
-func NewChainInvoke(chain simpletrigger.BlockChain) *ChainInvoke {
-	return &ChainInvoke{bc: chain}
+func NewChainInvoke(chain simpletrigger.BlockChain, finality simpletrigger.Finality) *ChainInvoke {
+	return &ChainInvoke{bc: chain, finality: finality}
 }
 
 func (c ChainInvoke) CurrentBlockNumber() uint64 {
@@ -42,7 +43,7 @@ func (c ChainInvoke) GetTransactionNumberOnChain(tx trigger.Transaction) uint64
 
 func (c ChainInvoke) GetConfirmedTransactionNumberOnChain(tx trigger.Transaction) uint64 {
 	if num := c.bc.GetBlockNumber(tx.BlockHash()); num != nil {
-		return *num + uint64(simpletrigger.DefaultConfirmDepth)
+		return *num + c.finality.ConfirmedDepth()
 	}
 	//TODO return current for invisible block?
 	return c.bc.CurrentBlock().NumberU64()
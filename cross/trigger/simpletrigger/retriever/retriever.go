@@ -16,10 +16,10 @@ type SimpleRetriever struct {
 }
 
 func NewSimpleRetriever(bc simpletrigger.BlockChain, pm simpletrigger.ProtocolManager, contract common.Address,
-	config *cross.Config, chainConfig *params.ChainConfig) trigger.ChainRetriever {
+	config *cross.Config, chainConfig *params.ChainConfig, finality simpletrigger.Finality) trigger.ChainRetriever {
 	r := new(SimpleRetriever)
 	r.pm = pm
-	r.ChainInvoke = NewChainInvoke(bc)
+	r.ChainInvoke = NewChainInvoke(bc, finality)
 	r.SimpleValidator = NewSimpleValidator(contract, bc, config, chainConfig)
 	r.SimpleValidator.SimpleRetriever = r
 	return r
@@ -30,5 +30,5 @@ func (s *SimpleRetriever) CanAcceptTxs() bool {
 }
 
 func (s *SimpleRetriever) ConfirmedDepth() uint64 {
-	return uint64(simpletrigger.DefaultConfirmDepth)
+	return s.finality.ConfirmedDepth()
 }
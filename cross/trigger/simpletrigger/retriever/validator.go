@@ -63,6 +63,9 @@ func (v *SimpleValidator) RequireSignatures() int {
 }
 
 func (v *SimpleValidator) ExpireNumber() int {
+	if v.config != nil && v.config.ExpireNumber > 0 {
+		return int(v.config.ExpireNumber)
+	}
 	return expireNumber
 }
 
@@ -27,12 +27,17 @@ type SimpleSubscriber struct {
 	reorgHook    func(number *big.Int, deletedLogs, rebirthLogs [][]*types.Log)
 }
 
-func NewSimpleSubscriber(contract common.Address, chain chainRetriever, journalPath string) *SimpleSubscriber {
+// NewSimpleSubscriber creates a subscriber of the cross contract logs, blocks are confirmed
+// after DefaultConfirmDepth blocks if finality is nil
+func NewSimpleSubscriber(contract common.Address, chain chainRetriever, journalPath string, finality simpletrigger.Finality) *SimpleSubscriber {
+	if finality == nil {
+		finality = simpletrigger.NewDepthFinality(uint64(simpletrigger.DefaultConfirmDepth))
+	}
 	s := &SimpleSubscriber{
 		contract: contract,
 		unconfirmedBlockLogs: unconfirmedBlockLogs{
-			chain: chain,
-			depth: uint(simpletrigger.DefaultConfirmDepth),
+			chain:    chain,
+			finality: finality,
 		},
 	}
 	if journalPath != "" {
@@ -70,6 +75,7 @@ func (t *SimpleSubscriber) StoreCrossContractLog(blockNumber uint64, hash common
 	if logs != nil {
 		var takers []*cc.CrossTransactionModifier
 		var finishes []*cc.CrossTransactionModifier
+		var cancels []*cc.CrossTransactionModifier
 		var updates []*cc.RemoteChainInfo
 		for _, v := range logs {
 			if t.contract == v.Address && len(v.Topics) > 0 {
@@ -88,6 +94,42 @@ func (t *SimpleSubscriber) StoreCrossContractLog(blockNumber uint64, hash common
 						unconfirmedLogs = append(unconfirmedLogs, v)
 					}
 
+				case params.TakerPartialTopic:
+					if len(v.Topics) >= 3 && len(v.Data) >= common.HashLength*5 {
+						fill := decodeTakerPartial(v)
+						status := cc.CtxStatusPartialFilled
+						if fill.filled.Cmp(fill.destValue) >= 0 {
+							status = cc.CtxStatusExecuting
+						}
+						takers = append(takers, &cc.CrossTransactionModifier{
+							ID:     v.Topics[1],
+							Type:   cc.Remote,
+							Status: status,
+							Filled: fill.filled,
+						})
+						unconfirmedLogs = append(unconfirmedLogs, v)
+					}
+
+				case params.MessageDeliveredTopic:
+					if len(v.Topics) >= 3 && len(v.Data) >= common.HashLength*3 {
+						takers = append(takers, &cc.CrossTransactionModifier{
+							ID:     v.Topics[1],
+							Type:   cc.Remote,
+							Status: cc.CtxStatusDelivering,
+						})
+						unconfirmedLogs = append(unconfirmedLogs, v)
+					}
+
+				case params.MessageReceiptTopic:
+					if len(v.Topics) >= 3 {
+						finishes = append(finishes, &cc.CrossTransactionModifier{
+							ID:            v.Topics[1],
+							AtBlockNumber: v.BlockNumber,
+							Status:        cc.CtxStatusReceipting,
+						})
+						unconfirmedLogs = append(unconfirmedLogs, v)
+					}
+
 				case params.MakerFinishTopic:
 					if len(v.Topics) >= 3 {
 						finishes = append(finishes, &cc.CrossTransactionModifier{
@@ -98,6 +140,21 @@ func (t *SimpleSubscriber) StoreCrossContractLog(blockNumber uint64, hash common
 						unconfirmedLogs = append(unconfirmedLogs, v)
 					}
 
+				case params.TakerCancelTopic:
+					if len(v.Topics) >= 3 && len(v.Data) >= common.HashLength {
+						cancels = append(cancels, &cc.CrossTransactionModifier{
+							ID:     v.Topics[1],
+							Type:   cc.Remote,
+							Status: cc.CtxStatusCancelling,
+						})
+						unconfirmedLogs = append(unconfirmedLogs, v)
+					}
+
+				case params.MakerCancelTopic:
+					if len(v.Topics) >= 3 {
+						unconfirmedLogs = append(unconfirmedLogs, v)
+					}
+
 				case params.AddAnchorsTopic, params.RemoveAnchorsTopic, params.UpdateAnchorTopic:
 					updates = append(updates,
 						&cc.RemoteChainInfo{
@@ -110,6 +167,7 @@ func (t *SimpleSubscriber) StoreCrossContractLog(blockNumber uint64, hash common
 
 		currentEvent.NewTaker.Takers = append(currentEvent.NewTaker.Takers, takers...)
 		currentEvent.NewFinish.Finishes = append(currentEvent.NewFinish.Finishes, finishes...)
+		currentEvent.NewCancel.Cancels = append(currentEvent.NewCancel.Cancels, cancels...)
 		currentEvent.NewAnchor.ChainInfo = append(currentEvent.NewAnchor.ChainInfo, updates...)
 	}
 
@@ -137,6 +195,40 @@ func (t *SimpleSubscriber) NotifyBlockReorg(number *big.Int, deletedLogs [][]*ty
 						})
 					}
 
+				case params.TakerPartialTopic: // reorg partialFilled|executing -> partialFilled|waiting
+					if len(l.Topics) >= 3 && len(l.Data) >= common.HashLength*5 {
+						fill := decodeTakerPartial(l)
+						filled := new(big.Int).Sub(fill.filled, fill.fillValue)
+						status := cc.CtxStatusPartialFilled
+						if filled.Sign() <= 0 {
+							status = cc.CtxStatusWaiting
+						}
+						reorgEvent.ReorgTaker.Takers = append(reorgEvent.ReorgTaker.Takers, &cc.CrossTransactionModifier{
+							ID:     l.Topics[1],
+							Status: status,
+							Type:   cc.Reorg,
+							Filled: filled,
+						})
+					}
+
+				case params.MessageDeliveredTopic: // reorg delivering -> waiting
+					if len(l.Topics) >= 3 {
+						reorgEvent.ReorgTaker.Takers = append(reorgEvent.ReorgTaker.Takers, &cc.CrossTransactionModifier{
+							ID:     l.Topics[1],
+							Status: cc.CtxStatusWaiting,
+							Type:   cc.Reorg,
+						})
+					}
+
+				case params.MessageReceiptTopic: // reorg receipting -> delivered
+					if len(l.Topics) >= 3 {
+						reorgEvent.ReorgFinish.Finishes = append(reorgEvent.ReorgFinish.Finishes, &cc.CrossTransactionModifier{
+							ID:     l.Topics[1],
+							Status: cc.CtxStatusDelivered,
+							Type:   cc.Reorg,
+						})
+					}
+
 				case params.MakerFinishTopic: // reorg executing finishing -> executed
 					if len(l.Topics) >= 3 {
 						reorgEvent.ReorgFinish.Finishes = append(reorgEvent.ReorgFinish.Finishes, &cc.CrossTransactionModifier{
@@ -145,6 +237,15 @@ func (t *SimpleSubscriber) NotifyBlockReorg(number *big.Int, deletedLogs [][]*ty
 							Type:   cc.Reorg,
 						})
 					}
+
+				case params.TakerCancelTopic: // reorg cancelling -> waiting
+					if len(l.Topics) >= 3 {
+						reorgEvent.ReorgCancel.Cancels = append(reorgEvent.ReorgCancel.Cancels, &cc.CrossTransactionModifier{
+							ID:     l.Topics[1],
+							Status: cc.CtxStatusWaiting,
+							Type:   cc.Reorg,
+						})
+					}
 				}
 			}
 		}
@@ -11,6 +11,7 @@ import (
 	"github.com/simplechain-org/go-simplechain/core/types"
 	"github.com/simplechain-org/go-simplechain/core/vm"
 	cc "github.com/simplechain-org/go-simplechain/cross/core"
+	"github.com/simplechain-org/go-simplechain/cross/trigger/simpletrigger"
 	"github.com/simplechain-org/go-simplechain/crypto"
 	"github.com/simplechain-org/go-simplechain/params"
 
@@ -41,8 +42,7 @@ func TestSimpleSubscriber_NotifyBlockReorg(t *testing.T) {
 	blockchain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
 	defer blockchain.Stop()
 
-	subscriber := NewSimpleSubscriber(common.Address{}, blockchain, "")
-	subscriber.depth = 4
+	subscriber := NewSimpleSubscriber(common.Address{}, blockchain, "", simpletrigger.NewDepthFinality(4))
 
 	chain, _ := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 4, func(i int, gen *core.BlockGen) {
 		if i == 1 {
@@ -133,3 +133,70 @@ func TestSimpleSubscriber_NotifyBlockReorg(t *testing.T) {
 	assert.Equal(t, 0, len(shifts[3]))
 	assert.Equal(t, 1, len(shifts[4]))
 }
+
+func TestSimpleSubscriber_TakerPartial(t *testing.T) {
+	contract := common.HexToAddress("0xcc")
+	subscriber := NewSimpleSubscriber(contract, newNoopChainRetriever(), "", nil)
+	ch := make(chan cc.CrossBlockEvent, 3)
+	sub := subscriber.SubscribeBlockEvent(ch)
+	defer sub.Unsubscribe()
+
+	ctxID := common.HexToHash("0x01")
+	partial := func(number uint64, fillValue, filled int64) *types.Log {
+		data := make([]byte, common.HashLength*5)
+		copy(data[:common.HashLength], common.LeftPadBytes(big.NewInt(1).Bytes(), 32))
+		copy(data[common.HashLength*2:], common.LeftPadBytes(big.NewInt(fillValue).Bytes(), 32))
+		copy(data[common.HashLength*3:], common.LeftPadBytes(big.NewInt(filled).Bytes(), 32))
+		copy(data[common.HashLength*4:], common.LeftPadBytes(big.NewInt(100).Bytes(), 32))
+		return &types.Log{Address: contract, Topics: []common.Hash{params.TakerPartialTopic, ctxID, common.HexToHash("0x02")},
+			Data: data, BlockNumber: number}
+	}
+
+	subscriber.StoreCrossContractLog(1, common.HexToHash("0x11"), []*types.Log{partial(1, 30, 30)})
+	ev := <-ch
+	assert.Equal(t, 1, len(ev.NewTaker.Takers))
+	assert.Equal(t, cc.CtxStatusPartialFilled, ev.NewTaker.Takers[0].Status)
+	assert.Equal(t, big.NewInt(30), ev.NewTaker.Takers[0].Filled)
+
+	last := partial(2, 70, 100)
+	subscriber.StoreCrossContractLog(2, common.HexToHash("0x12"), []*types.Log{last})
+	ev = <-ch
+	assert.Equal(t, cc.CtxStatusExecuting, ev.NewTaker.Takers[0].Status, "filled entirely")
+	assert.Equal(t, big.NewInt(100), ev.NewTaker.Takers[0].Filled)
+
+	subscriber.NotifyBlockReorg(big.NewInt(2), [][]*types.Log{{last}}, nil)
+	ev = <-ch
+	assert.Equal(t, 1, len(ev.ReorgTaker.Takers))
+	assert.Equal(t, cc.CtxStatusPartialFilled, ev.ReorgTaker.Takers[0].Status)
+	assert.Equal(t, big.NewInt(30), ev.ReorgTaker.Takers[0].Filled)
+}
+
+func TestSimpleSubscriber_Message(t *testing.T) {
+	contract := common.HexToAddress("0xcc")
+	subscriber := NewSimpleSubscriber(contract, newNoopChainRetriever(), "", nil)
+	ch := make(chan cc.CrossBlockEvent, 3)
+	sub := subscriber.SubscribeBlockEvent(ch)
+	defer sub.Unsubscribe()
+
+	ctxID := common.HexToHash("0x01")
+	delivered := &types.Log{Address: contract, Topics: []common.Hash{params.MessageDeliveredTopic, ctxID, common.HexToHash("0x02")},
+		Data: make([]byte, common.HashLength*3), BlockNumber: 1}
+	subscriber.StoreCrossContractLog(1, common.HexToHash("0x11"), []*types.Log{delivered})
+	ev := <-ch
+	assert.Equal(t, 1, len(ev.NewTaker.Takers))
+	assert.Equal(t, cc.CtxStatusDelivering, ev.NewTaker.Takers[0].Status)
+	assert.Equal(t, cc.Remote, ev.NewTaker.Takers[0].Type)
+
+	subscriber.NotifyBlockReorg(big.NewInt(1), [][]*types.Log{{delivered}}, nil)
+	ev = <-ch
+	assert.Equal(t, 1, len(ev.ReorgTaker.Takers))
+	assert.Equal(t, cc.CtxStatusWaiting, ev.ReorgTaker.Takers[0].Status)
+
+	receipt := &types.Log{Address: contract, Topics: []common.Hash{params.MessageReceiptTopic, ctxID, common.HexToHash("0x03")},
+		Data: make([]byte, common.HashLength), BlockNumber: 2}
+	subscriber.StoreCrossContractLog(2, common.HexToHash("0x12"), []*types.Log{receipt})
+	ev = <-ch
+	assert.Equal(t, 1, len(ev.NewFinish.Finishes))
+	assert.Equal(t, cc.CtxStatusReceipting, ev.NewFinish.Finishes[0].Status)
+	assert.Equal(t, uint64(2), ev.NewFinish.Finishes[0].AtBlockNumber)
+}
@@ -12,6 +12,7 @@ import (
 
 	cc "github.com/simplechain-org/go-simplechain/cross/core"
 	"github.com/simplechain-org/go-simplechain/cross/trigger"
+	"github.com/simplechain-org/go-simplechain/cross/trigger/simpletrigger"
 )
 
 type chainRetriever interface {
@@ -30,10 +31,10 @@ type unconfirmedBlockLog struct {
 }
 
 type unconfirmedBlockLogs struct {
-	chain  chainRetriever // Blockchain to verify canonical status through
-	depth  uint           // Depth after which to discard previous blocks
-	blocks *ring.Ring     // Block infos to allow canonical chain cross checks
-	lock   sync.RWMutex   // Protects the fields from concurrent access
+	chain    chainRetriever         // Blockchain to verify canonical status through
+	finality simpletrigger.Finality // Finality after which to discard previous blocks
+	blocks   *ring.Ring             // Block infos to allow canonical chain cross checks
+	lock     sync.RWMutex           // Protects the fields from concurrent access
 }
 
 func (t *SimpleSubscriber) add(index uint64, hash common.Hash, blockLogs []*types.Log) {
@@ -57,25 +58,31 @@ func (t *SimpleSubscriber) add(index uint64, hash common.Hash, blockLogs []*type
 
 // Insert adds a new block to the set of trigger ones.
 func (t *SimpleSubscriber) insert(index uint64, hash common.Hash, blockLogs []*types.Log, currentEvent *cc.CrossBlockEvent) {
-	// If a new block was mined locally, shift out any old enough blocks
-	t.shift(index, currentEvent)
-
 	// add unconfirmedBlockLog into unconfirmedBlockLogs
 	t.add(index, hash, blockLogs)
+
+	// If a new block was mined locally, shift out any old enough blocks,
+	// the new block itself is shifted out if the chain has instant finality
+	t.shift(index, currentEvent)
 }
 
-// Shift drops all trigger blocks from the set which exceed the trigger sets depth
-// allowance, checking them against the canonical chain for inclusion or staleness
+// Shift drops all trigger blocks from the set which are confirmed by the finality
+// of the chain, checking them against the canonical chain for inclusion or staleness
 // report.
 func (t *SimpleSubscriber) shift(height uint64, currentEvent *cc.CrossBlockEvent) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
+	confirmed, ok := t.finality.ConfirmedNumber(t.chain, height)
+
 loop:
 	for t.blocks != nil {
 		// Retrieve the next trigger block and abort if too fresh
 		next := t.blocks.Value.(*unconfirmedBlockLog)
-		// Block seems to exceed depth allowance, check for canonical status
+		if !ok || next.index > confirmed { // not confirmed yet
+			break loop
+		}
+		// Block is confirmed, check for canonical status
 		header := t.chain.GetHeaderByNumber(next.index)
 		switch {
 		case header == nil:
@@ -84,9 +91,6 @@ loop:
 		case header.Hash() != next.hash:
 			log.Info("⑂ block became a side fork", "number", next.index, "hash", next.hash)
 
-		case next.index+uint64(t.depth) > height: // not confirmed yet
-			break loop
-
 		default:
 			if t.shiftLogHook != nil {
 				t.shiftLogHook(next.index, next.hash, next.logs)
@@ -95,30 +99,18 @@ loop:
 				var ctxs []*cc.CrossTransaction
 				var rtxs []*cc.ReceptTransaction
 				var finishModifiers []*cc.CrossTransactionModifier
+				var cancels []*cc.CancelTransaction
+				var refundModifiers []*cc.CrossTransactionModifier
 				for _, v := range next.logs {
 					tx, blockHash, blockNumber := t.chain.GetTransactionByTxHash(v.TxHash)
 					if tx != nil && blockHash == v.BlockHash && blockNumber == v.BlockNumber &&
 						t.contract == v.Address && len(v.Topics) >= 3 {
 
 						switch {
-						case params.MakerTopic == v.Topics[0] && len(v.Data) >= common.HashLength*6:
-							var from common.Address
-							var to common.Address
-							copy(from[:], v.Topics[2][common.HashLength-common.AddressLength:])
-							copy(to[:], v.Data[common.HashLength-common.AddressLength:common.HashLength])
-							ctxId := v.Topics[1]
-							count := common.BytesToHash(v.Data[common.HashLength*5 : common.HashLength*6]).Big().Int64()
-							ctxs = append(ctxs,
-								cc.NewCrossTransaction(
-									common.BytesToHash(v.Data[common.HashLength*2:common.HashLength*3]).Big(),
-									common.BytesToHash(v.Data[common.HashLength*3:common.HashLength*4]).Big(),
-									common.BytesToHash(v.Data[common.HashLength:common.HashLength*2]).Big(),
-									ctxId,
-									v.TxHash,
-									v.BlockHash,
-									from,
-									to,
-									v.Data[common.HashLength*6:common.HashLength*6+count]))
+						case params.MakerTopic == v.Topics[0]:
+							if ctx := DecodeMakerLog(v); ctx != nil {
+								ctxs = append(ctxs, ctx)
+							}
 
 						case params.TakerTopic == v.Topics[0] && len(v.Data) >= common.HashLength*4:
 							var to, from common.Address
@@ -128,30 +120,71 @@ loop:
 							rtxs = append(rtxs, cc.NewReceptTransaction(ctxId, v.TxHash, from, to,
 								common.BytesToHash(v.Data[:common.HashLength]).Big(), t.chain.GetChainConfig().ChainID))
 
+						case params.TakerPartialTopic == v.Topics[0] && len(v.Data) >= common.HashLength*5:
+							// every partial fill is settled by makerPartialFinish in the remote chain
+							fill := decodeTakerPartial(v)
+							rtx := cc.NewReceptTransaction(v.Topics[1], v.TxHash, fill.from, fill.to,
+								fill.remoteChainID, t.chain.GetChainConfig().ChainID)
+							rtx.FillValue = fill.fillValue
+							rtxs = append(rtxs, rtx)
+
+						case params.MessageDeliveredTopic == v.Topics[0] && len(v.Data) >= common.HashLength*3:
+							// delivery is confirmed, record the receipt in the remote chain
+							rtx := cc.NewReceptTransaction(v.Topics[1], v.TxHash,
+								common.BytesToAddress(v.Data[common.HashLength*2-common.AddressLength:common.HashLength*2]),
+								common.BytesToAddress(v.Topics[2][common.HashLength-common.AddressLength:]),
+								common.BytesToHash(v.Data[:common.HashLength]).Big(), t.chain.GetChainConfig().ChainID)
+							rtx.Kind = cc.CtxKindMessage
+							rtx.Success = common.BytesToHash(v.Data[common.HashLength*2:common.HashLength*3]).Big().Sign() != 0
+							rtxs = append(rtxs, rtx)
+
+						case params.MessageReceiptTopic == v.Topics[0]:
+							finishModifiers = append(finishModifiers, &cc.CrossTransactionModifier{
+								ID:            v.Topics[1],
+								AtBlockNumber: height,
+								Status:        cc.CtxStatusReceipted,
+							})
+
 						case params.MakerFinishTopic == v.Topics[0]:
 							finishModifiers = append(finishModifiers, &cc.CrossTransactionModifier{
 								ID:            v.Topics[1],
-								AtBlockNumber: v.BlockNumber + uint64(t.depth),
+								AtBlockNumber: height,
 								Status:        cc.CtxStatusFinished,
 							})
+
+						case params.TakerCancelTopic == v.Topics[0] && len(v.Data) >= common.HashLength:
+							// taking is cancelled, refund maker in the remote chain
+							cancels = append(cancels, cc.NewCancelTransaction(v.Topics[1],
+								common.BytesToHash(v.Data[:common.HashLength]).Big(), t.chain.GetChainConfig().ChainID, true))
+
+						case params.MakerCancelTopic == v.Topics[0]:
+							refundModifiers = append(refundModifiers, &cc.CrossTransactionModifier{
+								ID:            v.Topics[1],
+								AtBlockNumber: height,
+								Status:        cc.CtxStatusCancelled,
+							})
 						}
 					}
 				}
 
-				confirmNumber := header.Number.Uint64() + uint64(t.depth) // make a confirmed number
+				confirmNumber := height // blocks are confirmed at the current height
 
 				// add confirmed logs into current block event
 				if currentEvent != nil && currentEvent.Number.Uint64() == confirmNumber {
 					currentEvent.ConfirmedMaker.Txs = append(currentEvent.ConfirmedMaker.Txs, ctxs...)
 					currentEvent.ConfirmedTaker.Txs = append(currentEvent.ConfirmedTaker.Txs, rtxs...)
 					currentEvent.ConfirmedFinish.Finishes = append(currentEvent.ConfirmedFinish.Finishes, finishModifiers...)
+					currentEvent.ConfirmedCancel.Txs = append(currentEvent.ConfirmedCancel.Txs, cancels...)
+					currentEvent.ConfirmedRefund.Refunds = append(currentEvent.ConfirmedRefund.Refunds, refundModifiers...)
 
-				} else if len(ctxs)|len(rtxs)|len(finishModifiers) > 0 {
+				} else if len(ctxs)|len(rtxs)|len(finishModifiers)|len(cancels)|len(refundModifiers) > 0 {
 					t.crossBlockSend(cc.CrossBlockEvent{
 						Number:          new(big.Int).SetUint64(confirmNumber),
 						ConfirmedMaker:  cc.ConfirmedMakerEvent{Txs: ctxs},
 						ConfirmedTaker:  cc.ConfirmedTakerEvent{Txs: rtxs},
 						ConfirmedFinish: cc.ConfirmedFinishEvent{Finishes: finishModifiers},
+						ConfirmedCancel: cc.ConfirmedCancelEvent{Txs: cancels},
+						ConfirmedRefund: cc.ConfirmedRefundEvent{Refunds: refundModifiers},
 					})
 				}
 			}
@@ -167,3 +200,51 @@ loop:
 	}
 
 }
+
+// takerPartial is a TakerPartial log of the cross contract
+type takerPartial struct {
+	to, from      common.Address
+	remoteChainID *big.Int
+	fillValue     *big.Int // destination value filled by the log
+	filled        *big.Int // destination value filled in total
+	destValue     *big.Int
+}
+
+func decodeTakerPartial(v *types.Log) *takerPartial {
+	word := func(i int) *big.Int {
+		return common.BytesToHash(v.Data[common.HashLength*i : common.HashLength*(i+1)]).Big()
+	}
+	return &takerPartial{
+		to:            common.BytesToAddress(v.Topics[2][common.HashLength-common.AddressLength:]),
+		from:          common.BytesToAddress(v.Data[common.HashLength*2-common.AddressLength : common.HashLength*2]),
+		remoteChainID: word(0),
+		fillValue:     word(2),
+		filled:        word(3),
+		destValue:     word(4),
+	}
+}
+
+// DecodeMakerLog decodes the ctx made by a MakerTx log of the cross contract, nil if the log is malformed
+func DecodeMakerLog(v *types.Log) *cc.CrossTransaction {
+	if len(v.Topics) < 3 || len(v.Data) < common.HashLength*6 {
+		return nil
+	}
+	var from common.Address
+	var to common.Address
+	copy(from[:], v.Topics[2][common.HashLength-common.AddressLength:])
+	copy(to[:], v.Data[common.HashLength-common.AddressLength:common.HashLength])
+	count := common.BytesToHash(v.Data[common.HashLength*5 : common.HashLength*6]).Big()
+	if !count.IsInt64() || int64(len(v.Data)) < common.HashLength*6+count.Int64() {
+		return nil
+	}
+	return cc.NewCrossTransaction(
+		common.BytesToHash(v.Data[common.HashLength*2:common.HashLength*3]).Big(),
+		common.BytesToHash(v.Data[common.HashLength*3:common.HashLength*4]).Big(),
+		common.BytesToHash(v.Data[common.HashLength:common.HashLength*2]).Big(),
+		v.Topics[1],
+		v.TxHash,
+		v.BlockHash,
+		from,
+		to,
+		v.Data[common.HashLength*6:common.HashLength*6+count.Int64()])
+}
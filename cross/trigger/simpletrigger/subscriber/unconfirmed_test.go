@@ -54,7 +54,7 @@ func TestUnconfirmedInsertBounds(t *testing.T) {
 	simpletrigger.DefaultConfirmDepth = 12
 	limit := simpletrigger.DefaultConfirmDepth
 
-	pool := NewSimpleSubscriber(common.Address{}, newNoopChainRetriever(), "")
+	pool := NewSimpleSubscriber(common.Address{}, newNoopChainRetriever(), "", nil)
 	for depth := uint64(0); depth < 2*uint64(limit); depth++ {
 		// Insert multiple blocks for the same level just to stress it
 		for i := 0; i < int(depth); i++ {
@@ -78,7 +78,7 @@ func TestUnconfirmedShifts(t *testing.T) {
 	limit, start := uint(12), uint64(25)
 
 	chain := newNoopChainRetriever()
-	pool := NewSimpleSubscriber(common.Address{}, chain, "")
+	pool := NewSimpleSubscriber(common.Address{}, chain, "", nil)
 	for depth := start; depth < start+uint64(limit); depth++ {
 		header := types.Header{
 			ParentHash: [32]byte{byte(depth)},
@@ -108,3 +108,25 @@ func TestUnconfirmedShifts(t *testing.T) {
 		t.Errorf("unconfirmed count mismatch: have %d, want %d", n, 0)
 	}
 }
+
+// Tests that blocks of chains with instant finality are shifted out as soon as they are inserted.
+func TestUnconfirmedInstantFinality(t *testing.T) {
+	chain := newNoopChainRetriever()
+	pool := NewSimpleSubscriber(common.Address{}, chain, "", simpletrigger.NewDepthFinality(0))
+
+	var shifts []uint64
+	pool.shiftLogHook = func(number uint64, hash common.Hash, confirmedLogs []*types.Log) {
+		shifts = append(shifts, number)
+	}
+	for number := uint64(1); number <= 3; number++ {
+		header := types.Header{Number: new(big.Int).SetUint64(number)}
+		chain.insert(&header)
+		pool.StoreCrossContractLog(number, header.Hash(), nil)
+		if len(shifts) != int(number) || shifts[number-1] != number {
+			t.Errorf("block %d not confirmed instantly, shifts: %v", number, shifts)
+		}
+		if pool.blocks != nil {
+			t.Errorf("unconfirmed count mismatch: have %d, want %d", pool.blocks.Len(), 0)
+		}
+	}
+}
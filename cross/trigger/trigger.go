@@ -4,6 +4,7 @@ import (
 	"math/big"
 
 	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/core/types"
 	"github.com/simplechain-org/go-simplechain/cross/core"
 	"github.com/simplechain-org/go-simplechain/event"
 )
@@ -16,10 +17,41 @@ type Subscriber interface {
 type Executor interface {
 	SignHash([]byte) ([]byte, error)
 	SubmitTransaction([]*core.ReceptTransaction)
+	SubmitCancel([]*core.CancelTransaction)
+	SubmitDelivery([]*core.DeliverTransaction)
+	SubmitEvidence([]*core.Evidence)
 	Start()
 	Stop()
 }
 
+// QueuedExecutor journals submitted transactions of anchor until they are packed
+type QueuedExecutor interface {
+	Executor
+	Queue() []*core.AnchorTransaction
+}
+
+// AccountedExecutor accounts the gas spent by anchor, and claims the relay fees paid by makers
+type AccountedExecutor interface {
+	QueuedExecutor
+	Profit() *core.AnchorProfit
+	RelayFee(remoteChainID *big.Int) (*big.Int, error)
+	ClaimRelayFee(remoteChainID *big.Int) (*big.Int, error)
+}
+
+// AnchorSigner signs ctxs and transactions of anchor, the signature is requested from the wallet of anchor
+// so that the anchor key can be held by an external signer (e.g. clef) which doesn't sign raw hashes
+type AnchorSigner interface {
+	SignHash([]byte) ([]byte, error)
+	SignCtx(ctx *core.CrossTransaction, chainID *big.Int) (*core.CrossTransaction, error)
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// HashSigner reports whether the anchor signer signs raw hashes (e.g. finish roots),
+// which are refused by external signers
+type HashSigner interface {
+	CanSignHash() bool
+}
+
 type Validator interface {
 	VerifyExpire(ctx *core.CrossTransaction) error
 	VerifyContract(cws Transaction) error
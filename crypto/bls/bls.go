@@ -0,0 +1,157 @@
+// Package bls implements BLS signatures over the bn256 curve, signatures are points of G1
+// and public keys are points of G2, so that a signature can be verified on chain by the
+// bigModExp and bn256Pairing precompiled contracts.
+package bls
+
+import (
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/crypto"
+	bn256 "github.com/simplechain-org/go-simplechain/crypto/bn256/cloudflare"
+)
+
+const (
+	SecretKeyLength = 32
+	PublicKeyLength = 128
+	SignatureLength = 64
+)
+
+var (
+	ErrInvalidSecretKey = errors.New("bls: invalid secret key")
+	ErrInvalidPublicKey = errors.New("bls: invalid public key")
+	ErrInvalidSignature = errors.New("bls: invalid signature")
+)
+
+var (
+	g2 = new(bn256.G2).ScalarBaseMult(big.NewInt(1)) // generator of G2
+
+	// sqrtExponent is (P+1)/4, P ≡ 3 mod 4 so that beta^((P+1)/4) is a square root of beta
+	sqrtExponent = new(big.Int).Rsh(new(big.Int).Add(bn256.P, big.NewInt(1)), 2)
+	curveB       = big.NewInt(3)
+)
+
+type SecretKey struct {
+	x *big.Int
+}
+
+type PublicKey struct {
+	p *bn256.G2
+}
+
+type Signature struct {
+	p *bn256.G1
+}
+
+// GenerateKey generates a random secret key
+func GenerateKey(r io.Reader) (*SecretKey, error) {
+	x, _, err := bn256.RandomG1(r)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretKey{x: x}, nil
+}
+
+// NewSecretKey decodes a 32 bytes big-endian secret key
+func NewSecretKey(b []byte) (*SecretKey, error) {
+	if len(b) != SecretKeyLength {
+		return nil, ErrInvalidSecretKey
+	}
+	x := new(big.Int).SetBytes(b)
+	if x.Sign() == 0 || x.Cmp(bn256.Order) >= 0 {
+		return nil, ErrInvalidSecretKey
+	}
+	return &SecretKey{x: x}, nil
+}
+
+func (sk *SecretKey) Bytes() []byte {
+	return common.LeftPadBytes(sk.x.Bytes(), SecretKeyLength)
+}
+
+func (sk *SecretKey) PublicKey() *PublicKey {
+	return &PublicKey{p: new(bn256.G2).ScalarBaseMult(sk.x)}
+}
+
+// Sign signs the hash, the signature is sk*H(hash)
+func (sk *SecretKey) Sign(hash []byte) *Signature {
+	return &Signature{p: new(bn256.G1).ScalarMult(HashToPoint(hash), sk.x)}
+}
+
+// NewPublicKey decodes a public key in the layout of bn256Pairing precompile (x.imag, x.real, y.imag, y.real)
+func NewPublicKey(b []byte) (*PublicKey, error) {
+	if len(b) != PublicKeyLength {
+		return nil, ErrInvalidPublicKey
+	}
+	p := new(bn256.G2)
+	if _, err := p.Unmarshal(b); err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	return &PublicKey{p: p}, nil
+}
+
+func (pk *PublicKey) Bytes() []byte {
+	return pk.p.Marshal()
+}
+
+// NewSignature decodes a signature in the layout of bn256Pairing precompile (x, y)
+func NewSignature(b []byte) (*Signature, error) {
+	if len(b) != SignatureLength {
+		return nil, ErrInvalidSignature
+	}
+	p := new(bn256.G1)
+	if _, err := p.Unmarshal(b); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	return &Signature{p: p}, nil
+}
+
+func (sig *Signature) Bytes() []byte {
+	return sig.p.Marshal()
+}
+
+// Verify checks e(sig, g2) == e(H(hash), pk)
+func Verify(pk *PublicKey, hash []byte, sig *Signature) bool {
+	h := new(bn256.G1).Neg(HashToPoint(hash))
+	return bn256.PairingCheck([]*bn256.G1{sig.p, h}, []*bn256.G2{g2, pk.p})
+}
+
+// AggregateSignatures sums signatures, the aggregation is verified by the sum of public keys
+func AggregateSignatures(sigs ...*Signature) *Signature {
+	sum := new(bn256.G1).ScalarBaseMult(new(big.Int))
+	for _, sig := range sigs {
+		sum.Add(sum, sig.p)
+	}
+	return &Signature{p: sum}
+}
+
+func AggregatePublicKeys(pks ...*PublicKey) *PublicKey {
+	sum := new(bn256.G2).ScalarBaseMult(new(big.Int))
+	for _, pk := range pks {
+		sum.Add(sum, pk.p)
+	}
+	return &PublicKey{p: sum}
+}
+
+// HashToPoint maps hash to G1 by try-and-increment:
+// x = keccak256(hash || uint256(counter)) mod P, until x^3+3 is a square.
+// The cross contract computes the same point with the bigModExp precompile.
+func HashToPoint(hash []byte) *bn256.G1 {
+	for counter := int64(0); ; counter++ {
+		x := new(big.Int).SetBytes(crypto.Keccak256(hash, common.LeftPadBytes(big.NewInt(counter).Bytes(), 32)))
+		x.Mod(x, bn256.P)
+
+		beta := new(big.Int).Exp(x, big.NewInt(3), bn256.P)
+		beta.Add(beta, curveB).Mod(beta, bn256.P)
+		y := new(big.Int).Exp(beta, sqrtExponent, bn256.P)
+		if new(big.Int).Exp(y, big.NewInt(2), bn256.P).Cmp(beta) != 0 {
+			continue
+		}
+		p := new(bn256.G1)
+		if _, err := p.Unmarshal(append(common.LeftPadBytes(x.Bytes(), 32), common.LeftPadBytes(y.Bytes(), 32)...)); err != nil {
+			continue
+		}
+		return p
+	}
+}
@@ -0,0 +1,173 @@
+package bls
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/core/vm"
+	"github.com/simplechain-org/go-simplechain/crypto"
+	bn256 "github.com/simplechain-org/go-simplechain/crypto/bn256/cloudflare"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	sk, err := GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	pk := sk.PublicKey()
+	hash := crypto.Keccak256([]byte("ctx"))
+
+	sig := sk.Sign(hash)
+	assert.True(t, Verify(pk, hash, sig))
+	assert.False(t, Verify(pk, crypto.Keccak256([]byte("other")), sig))
+
+	// encoding round trip
+	sk2, err := NewSecretKey(sk.Bytes())
+	assert.NoError(t, err)
+	pk2, err := NewPublicKey(pk.Bytes())
+	assert.NoError(t, err)
+	sig2, err := NewSignature(sig.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, sk.Bytes(), sk2.Bytes())
+	assert.True(t, Verify(pk2, hash, sig2))
+
+	_, err = NewSignature(make([]byte, 10))
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestAggregate(t *testing.T) {
+	hash := crypto.Keccak256([]byte("ctx"))
+	var (
+		pks  []*PublicKey
+		sigs []*Signature
+	)
+	for i := 0; i < 3; i++ {
+		sk, err := GenerateKey(rand.Reader)
+		assert.NoError(t, err)
+		pks = append(pks, sk.PublicKey())
+		sigs = append(sigs, sk.Sign(hash))
+	}
+	assert.True(t, Verify(AggregatePublicKeys(pks...), hash, AggregateSignatures(sigs...)))
+	assert.False(t, Verify(AggregatePublicKeys(pks[:2]...), hash, AggregateSignatures(sigs...)))
+}
+
+func TestThreshold(t *testing.T) {
+	shares, err := GenerateShares(rand.Reader, 3, 5)
+	assert.NoError(t, err)
+	master := shares[0].Master
+	hash := crypto.Keccak256([]byte("ctx"))
+
+	partial := make(map[uint64]*Signature)
+	for _, share := range shares[1:3] {
+		partial[share.Index] = share.Sign(hash)
+	}
+	_, err = RecoverSignature(partial, 3)
+	assert.Equal(t, ErrNotEnoughShares, err)
+
+	partial[shares[4].Index] = shares[4].Sign(hash)
+	sig, err := RecoverSignature(partial, 3)
+	assert.NoError(t, err)
+	assert.True(t, Verify(master, hash, sig))
+
+	// any subset recovers the same signature
+	other := map[uint64]*Signature{1: shares[0].Sign(hash), 2: shares[1].Sign(hash), 4: shares[3].Sign(hash)}
+	sig2, err := RecoverSignature(other, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, sig.Bytes(), sig2.Bytes())
+
+	_, err = GenerateShares(rand.Reader, 6, 5)
+	assert.Equal(t, ErrInvalidThreshold, err)
+}
+
+func TestRecoverVerified(t *testing.T) {
+	shares, err := GenerateShares(rand.Reader, 3, 5)
+	assert.NoError(t, err)
+	master := shares[0].Master
+	hash := crypto.Keccak256([]byte("ctx"))
+
+	sigs := make(map[uint64]*Signature)
+	for _, share := range shares {
+		sigs[share.Index] = share.Sign(hash)
+		assert.NoError(t, shares[0].VerifyShare(share.Index, hash, sigs[share.Index]))
+	}
+	// a forged share of the lowest index fails the share verification and the first subset
+	forged := shares[1].Sign(hash)
+	assert.Equal(t, ErrInvalidShare, shares[0].VerifyShare(1, hash, forged))
+	assert.Equal(t, ErrInvalidShareIndex, shares[0].VerifyShare(6, hash, forged))
+	sigs[1] = forged
+
+	sig, err := RecoverSignature(sigs, 3)
+	assert.NoError(t, err)
+	assert.False(t, Verify(master, hash, sig))
+
+	sig, err = RecoverVerified(sigs, 3, master, hash)
+	assert.NoError(t, err)
+	assert.True(t, Verify(master, hash, sig))
+
+	// not enough valid shares
+	other := shares[4].Sign(crypto.Keccak256([]byte("other")))
+	sigs[2], sigs[3] = other, other
+	_, err = RecoverVerified(sigs, 3, master, hash)
+	assert.Equal(t, ErrNoValidSubset, err)
+}
+
+func TestKeyShareFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bls")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	shares, err := GenerateShares(rand.Reader, 2, 3)
+	assert.NoError(t, err)
+	anchors := []common.Address{common.HexToAddress("0xa1"), common.HexToAddress("0xa2"), common.HexToAddress("0xa3")}
+	shares[1].Anchors = anchors
+	file := filepath.Join(dir, "share.json")
+	assert.NoError(t, SaveKeyShare(file, shares[1]))
+
+	loaded, err := LoadKeyShare(file)
+	assert.NoError(t, err)
+	assert.Equal(t, shares[1].Index, loaded.Index)
+	assert.Equal(t, shares[1].Threshold, loaded.Threshold)
+	assert.Equal(t, shares[1].Secret.Bytes(), loaded.Secret.Bytes())
+	assert.Equal(t, shares[1].Master.Bytes(), loaded.Master.Bytes())
+	assert.Len(t, loaded.Publics, 3)
+	assert.Equal(t, shares[2].Secret.PublicKey().Bytes(), loaded.Publics[2].Bytes())
+	assert.Equal(t, anchors, loaded.Anchors)
+	anchor, ok := loaded.AnchorOf(2)
+	assert.True(t, ok)
+	assert.Equal(t, anchors[1], anchor)
+
+	// the public key of the share must match its secret
+	shares[2].Publics = shares[2].Publics[:1]
+	assert.NoError(t, SaveKeyShare(file, shares[2]))
+	_, err = LoadKeyShare(file)
+	assert.Equal(t, ErrInvalidPublics, err)
+}
+
+// the pairing check of the cross contract: e(sig, g2) * e(-H(hash), pk) == 1
+func TestVerifyByPrecompile(t *testing.T) {
+	sk, err := GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	hash := crypto.Keccak256([]byte("ctx"))
+
+	pairing := func(sig *Signature) []byte {
+		h := HashToPoint(hash).Marshal()
+		y := new(big.Int).SetBytes(h[32:])
+		negY := new(big.Int).Sub(bn256.P, y)
+		var input []byte
+		input = append(input, sig.Bytes()...)
+		input = append(input, g2.Marshal()...)
+		input = append(input, h[:32]...)
+		input = append(input, common.LeftPadBytes(negY.Bytes(), 32)...)
+		input = append(input, sk.PublicKey().Bytes()...)
+		out, err := vm.PrecompiledContractsByzantium[common.BytesToAddress([]byte{8})].Run(input)
+		assert.NoError(t, err)
+		return out
+	}
+	assert.Equal(t, common.LeftPadBytes([]byte{1}, 32), pairing(sk.Sign(hash)))
+	assert.Equal(t, make([]byte, 32), pairing(sk.Sign(crypto.Keccak256([]byte("other")))))
+}
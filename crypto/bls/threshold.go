@@ -0,0 +1,270 @@
+package bls
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"sort"
+
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/common/hexutil"
+	bn256 "github.com/simplechain-org/go-simplechain/crypto/bn256/cloudflare"
+)
+
+// maxRecoverAttempts is the max subsets of shares tried by RecoverVerified
+const maxRecoverAttempts = 128
+
+var (
+	ErrInvalidThreshold  = errors.New("bls: invalid threshold")
+	ErrNotEnoughShares   = errors.New("bls: not enough signature shares")
+	ErrInvalidShareIndex = errors.New("bls: invalid share index")
+	ErrInvalidShare      = errors.New("bls: invalid signature share")
+	ErrInvalidPublics    = errors.New("bls: public keys mismatch shares")
+	ErrInvalidAnchors    = errors.New("bls: anchors mismatch shares")
+	ErrNoValidSubset     = errors.New("bls: no subset of shares recovers a valid signature")
+)
+
+// KeyShare is a (threshold, n) share of the master key, any threshold of signature shares
+// can be recovered into a signature verified by the master public key.
+type KeyShare struct {
+	Index     uint64           // index of the share, starts from 1
+	Threshold int              // number of shares required to recover a signature
+	Secret    *SecretKey       // secret share
+	Master    *PublicKey       // public key of master secret
+	Publics   []*PublicKey     // public keys of every share, share i is verified by Publics[i-1]
+	Anchors   []common.Address // signers holding every share, share i is held by Anchors[i-1], empty if unbound
+}
+
+type keyShareJSON struct {
+	Index     uint64           `json:"index"`
+	Threshold int              `json:"threshold"`
+	Secret    hexutil.Bytes    `json:"secret"`
+	Master    hexutil.Bytes    `json:"master"`
+	Publics   []hexutil.Bytes  `json:"publics"`
+	Anchors   []common.Address `json:"anchors,omitempty"`
+}
+
+func (s *KeyShare) MarshalJSON() ([]byte, error) {
+	publics := make([]hexutil.Bytes, len(s.Publics))
+	for i, pk := range s.Publics {
+		publics[i] = pk.Bytes()
+	}
+	return json.Marshal(&keyShareJSON{
+		Index:     s.Index,
+		Threshold: s.Threshold,
+		Secret:    s.Secret.Bytes(),
+		Master:    s.Master.Bytes(),
+		Publics:   publics,
+		Anchors:   s.Anchors,
+	})
+}
+
+func (s *KeyShare) UnmarshalJSON(input []byte) error {
+	var dec keyShareJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.Index == 0 {
+		return ErrInvalidShareIndex
+	}
+	if dec.Threshold <= 0 {
+		return ErrInvalidThreshold
+	}
+	secret, err := NewSecretKey(dec.Secret)
+	if err != nil {
+		return err
+	}
+	master, err := NewPublicKey(dec.Master)
+	if err != nil {
+		return err
+	}
+	if len(dec.Publics) < dec.Threshold || uint64(len(dec.Publics)) < dec.Index {
+		return ErrInvalidPublics
+	}
+	publics := make([]*PublicKey, len(dec.Publics))
+	for i, enc := range dec.Publics {
+		if publics[i], err = NewPublicKey(enc); err != nil {
+			return err
+		}
+	}
+	if !bytes.Equal(publics[dec.Index-1].Bytes(), secret.PublicKey().Bytes()) {
+		return ErrInvalidPublics
+	}
+	if len(dec.Anchors) > 0 && len(dec.Anchors) != len(publics) {
+		return ErrInvalidAnchors
+	}
+	s.Index, s.Threshold, s.Secret, s.Master = dec.Index, dec.Threshold, secret, master
+	s.Publics, s.Anchors = publics, dec.Anchors
+	return nil
+}
+
+// Sign signs the hash with the secret share
+func (s *KeyShare) Sign(hash []byte) *Signature {
+	return s.Secret.Sign(hash)
+}
+
+// VerifyShare verifies the signature share of index by the public key of the share
+func (s *KeyShare) VerifyShare(index uint64, hash []byte, sig *Signature) error {
+	if index == 0 || index > uint64(len(s.Publics)) {
+		return ErrInvalidShareIndex
+	}
+	if !Verify(s.Publics[index-1], hash, sig) {
+		return ErrInvalidShare
+	}
+	return nil
+}
+
+// AnchorOf returns the signer holding the share of index, false if shares are not bound to anchors
+func (s *KeyShare) AnchorOf(index uint64) (common.Address, bool) {
+	if index == 0 || index > uint64(len(s.Anchors)) {
+		return common.Address{}, false
+	}
+	return s.Anchors[index-1], true
+}
+
+// LoadKeyShare reads a json encoded key share from file
+func LoadKeyShare(file string) (*KeyShare, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	share := new(KeyShare)
+	if err := json.Unmarshal(data, share); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// SaveKeyShare writes the key share to file with restrictive permissions
+func SaveKeyShare(file string, share *KeyShare) error {
+	data, err := json.MarshalIndent(share, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0600)
+}
+
+// GenerateShares deals n shares of a random master key by a polynomial of degree threshold-1,
+// share i is the polynomial evaluated at i (i starts from 1). Every share carries the public keys
+// of all shares, so that signature shares of other holders can be verified one by one.
+func GenerateShares(r io.Reader, threshold, n int) ([]*KeyShare, error) {
+	if threshold <= 0 || threshold > n {
+		return nil, ErrInvalidThreshold
+	}
+	coefficients := make([]*big.Int, threshold)
+	for i := range coefficients {
+		sk, err := GenerateKey(r)
+		if err != nil {
+			return nil, err
+		}
+		coefficients[i] = sk.x
+	}
+	master := (&SecretKey{x: coefficients[0]}).PublicKey()
+
+	shares := make([]*KeyShare, n)
+	publics := make([]*PublicKey, n)
+	for i := range shares {
+		index := big.NewInt(int64(i + 1))
+		// Horner's method
+		y := new(big.Int)
+		for j := threshold - 1; j >= 0; j-- {
+			y.Mul(y, index).Add(y, coefficients[j]).Mod(y, bn256.Order)
+		}
+		secret := &SecretKey{x: y}
+		publics[i] = secret.PublicKey()
+		shares[i] = &KeyShare{Index: uint64(i + 1), Threshold: threshold, Secret: secret, Master: master, Publics: publics}
+	}
+	return shares, nil
+}
+
+// RecoverSignature interpolates the master signature from threshold signature shares keyed by share index,
+// the shares of the lowest indexes are used
+func RecoverSignature(shares map[uint64]*Signature, threshold int) (*Signature, error) {
+	indexes, err := shareIndexes(shares, threshold)
+	if err != nil {
+		return nil, err
+	}
+	return interpolate(shares, indexes[:threshold]), nil
+}
+
+// RecoverVerified recovers the master signature of hash from signature shares keyed by share index,
+// if the signature of a subset fails verification by master (e.g. a share is forged), other subsets
+// of threshold shares are tried before giving up
+func RecoverVerified(shares map[uint64]*Signature, threshold int, master *PublicKey, hash []byte) (*Signature, error) {
+	indexes, err := shareIndexes(shares, threshold)
+	if err != nil {
+		return nil, err
+	}
+	// positions in indexes of the subset, in lexicographic order of combinations
+	subset := make([]int, threshold)
+	for i := range subset {
+		subset[i] = i
+	}
+	picked := make([]uint64, threshold)
+	for attempt := 0; attempt < maxRecoverAttempts; attempt++ {
+		for i, pos := range subset {
+			picked[i] = indexes[pos]
+		}
+		if sig := interpolate(shares, picked); Verify(master, hash, sig) {
+			return sig, nil
+		}
+		// next combination: increase the rightmost position which has room and reset the following ones
+		i := threshold - 1
+		for i >= 0 && subset[i] == len(indexes)-threshold+i {
+			i--
+		}
+		if i < 0 {
+			break
+		}
+		subset[i]++
+		for j := i + 1; j < threshold; j++ {
+			subset[j] = subset[j-1] + 1
+		}
+	}
+	return nil, ErrNoValidSubset
+}
+
+// shareIndexes returns the share indexes in ascending order
+func shareIndexes(shares map[uint64]*Signature, threshold int) ([]uint64, error) {
+	if threshold <= 0 {
+		return nil, ErrInvalidThreshold
+	}
+	if len(shares) < threshold {
+		return nil, ErrNotEnoughShares
+	}
+	indexes := make([]uint64, 0, len(shares))
+	for index := range shares {
+		if index == 0 {
+			return nil, ErrInvalidShareIndex
+		}
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	return indexes, nil
+}
+
+// interpolate evaluates the polynomial of signature shares at 0
+func interpolate(shares map[uint64]*Signature, indexes []uint64) *Signature {
+	sum := new(bn256.G1).ScalarBaseMult(new(big.Int))
+	for i := range indexes {
+		xi := new(big.Int).SetUint64(indexes[i])
+		// lagrange coefficient at 0: Π xj/(xj-xi)
+		num, den := big.NewInt(1), big.NewInt(1)
+		for j := range indexes {
+			if i == j {
+				continue
+			}
+			xj := new(big.Int).SetUint64(indexes[j])
+			num.Mul(num, xj).Mod(num, bn256.Order)
+			diff := new(big.Int).Sub(xj, xi)
+			den.Mul(den, diff.Mod(diff, bn256.Order)).Mod(den, bn256.Order)
+		}
+		lambda := num.Mul(num, new(big.Int).ModInverse(den, bn256.Order))
+		lambda.Mod(lambda, bn256.Order)
+		sum.Add(sum, new(bn256.G1).ScalarMult(shares[indexes[i]].p, lambda))
+	}
+	return &Signature{p: sum}
+}
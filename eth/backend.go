@@ -310,6 +310,9 @@ func CreateConsensusEngine(ctx *node.ServiceContext, chainConfig *params.ChainCo
 			config.Istanbul.Epoch = chainConfig.Istanbul.Epoch
 		}
 		config.Istanbul.ProposerPolicy = istanbul.ProposerPolicy(chainConfig.Istanbul.ProposerPolicy)
+		config.Istanbul.QBFTBlock = chainConfig.Istanbul.QBFTBlock
+		config.Istanbul.ValidatorContract = chainConfig.Istanbul.ValidatorContract
+		config.Istanbul.ValidatorPerBlock = chainConfig.Istanbul.ValidatorPerBlock
 		return istanbulBackend.New(&config.Istanbul, ctx.NodeKey(), db)
 	}
 
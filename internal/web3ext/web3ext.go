@@ -83,6 +83,11 @@ web3._extend({
 				call: 'cross_ctxTakerByPage',
 				params: 3,
 		}),
+		new web3._extend.Method({
+				name: 'getCtxRefundableByPage',
+				call: 'cross_ctxRefundableByPage',
+				params: 3,
+		}),
 		new web3._extend.Method({
 				name: 'getCtxStats',
 				call: 'cross_ctxStats',
@@ -93,6 +98,11 @@ web3._extend({
 				call: 'cross_poolStats',
 				params: 0,
 		}),
+		new web3._extend.Method({
+				name: 'getOrderBook',
+				call: 'cross_orderBook',
+				params: 1,
+		}),
 		new web3._extend.Method({
 			name: 'setStoreDelay',
 			call: 'cross_setStoreDelay',
@@ -113,6 +123,11 @@ web3._extend({
 			call: 'cross_syncStore',
 			params: 0,
 		}),
+		new web3._extend.Method({
+			name: 'claimRelayFee',
+			call: 'cross_claimRelayFee',
+			params: 1,
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -131,6 +146,14 @@ web3._extend({
 			name: 'anchors',
 			getter: 'cross_anchors'
 		}),
+		new web3._extend.Property({
+			name: 'executorQueue',
+			getter: 'cross_executorQueue'
+		}),
+		new web3._extend.Property({
+			name: 'profit',
+			getter: 'cross_profit'
+		}),
 	]
 });
 `
@@ -296,6 +319,16 @@ web3._extend({
                        call: 'raft_removePeer',
                        params: 1
                }),
+               new web3._extend.Method({
+                       name: 'addLearner',
+                       call: 'raft_addLearner',
+                       params: 1
+               }),
+               new web3._extend.Method({
+                       name: 'promoteToPeer',
+                       call: 'raft_promoteToPeer',
+                       params: 1
+               }),
                new web3._extend.Property({
                        name: 'leader',
                        getter: 'raft_leader'
@@ -209,8 +209,11 @@ func (c *CliqueConfig) String() string {
 
 // IstanbulConfig is the consensus engine configs for Istanbul based sealing.
 type IstanbulConfig struct {
-	Epoch          uint64 `json:"epoch"`  // Epoch length to reset votes and checkpoint
-	ProposerPolicy uint64 `json:"policy"` // The policy for proposer selection
+	Epoch             uint64          `json:"epoch"`                       // Epoch length to reset votes and checkpoint
+	ProposerPolicy    uint64          `json:"policy"`                      // The policy for proposer selection
+	QBFTBlock         *big.Int        `json:"qbftBlock,omitempty"`         // QBFT switch block (nil = no fork, 0 = already on QBFT)
+	ValidatorContract *common.Address `json:"validatorContract,omitempty"` // Validator management contract (nil = validators voted by headers)
+	ValidatorPerBlock bool            `json:"validatorPerBlock,omitempty"` // Whether validators are read from the contract every block instead of each epoch
 }
 
 type RaftConfig struct {
@@ -222,6 +225,11 @@ func (c *IstanbulConfig) String() string {
 	return "istanbul"
 }
 
+// IsQBFT returns whether num is either equal to the QBFT fork block or greater.
+func (c *IstanbulConfig) IsQBFT(num *big.Int) bool {
+	return isForked(c.QBFTBlock, num)
+}
+
 type GenesisAccount struct {
 	Balance string `json:"balance"`
 }
@@ -242,6 +250,9 @@ type DPoSConfig struct {
 	PBFTEnable       bool                       `json:"pbft"`             //
 	VoterReward      bool                       `json:"voterReward"`
 	LightConfig      *DPoSLightConfig           `json:"lightConfig,omitempty"`
+	UnbondingLoops   uint64                     `json:"unbondingLoops,omitempty"`  // Loop count for the stake of devote to unlock
+	BondingBlock     *big.Int                   `json:"bondingBlock,omitempty"`    // Vote stake is locked from the balance of voter since the block, instead of following the balance (nil = never)
+	GovernanceBlock  *big.Int                   `json:"governanceBlock,omitempty"` // Governance contract replaces "dpos:1:event:*" payloads since the block (nil = never)
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -249,6 +260,16 @@ func (a *DPoSConfig) String() string {
 	return "dpos"
 }
 
+// IsBonding returns whether num is either equal to the bonding fork block or greater.
+func (a *DPoSConfig) IsBonding(num *big.Int) bool {
+	return isForked(a.BondingBlock, num)
+}
+
+// IsGovernance returns whether num is either equal to the governance fork block or greater.
+func (a *DPoSConfig) IsGovernance(num *big.Int) bool {
+	return isForked(a.GovernanceBlock, num)
+}
+
 // ScryptConfig is the consensus engine configs for proof-of-work based sealing.
 type ScryptConfig struct{}
 
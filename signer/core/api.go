@@ -41,9 +41,9 @@ const (
 	// numberOfAccountsToDerive For hardware wallets, the number of accounts to derive
 	numberOfAccountsToDerive = 10
 	// ExternalAPIVersion -- see extapi_changelog.md
-	ExternalAPIVersion = "6.0.0"
+	ExternalAPIVersion = "6.1.0"
 	// InternalAPIVersion -- see intapi_changelog.md
-	InternalAPIVersion = "7.0.0"
+	InternalAPIVersion = "7.1.0"
 )
 
 // ExternalAPI defines the external API through which signing requests are made.
@@ -58,6 +58,8 @@ type ExternalAPI interface {
 	SignData(ctx context.Context, contentType string, addr common.MixedcaseAddress, data interface{}) (hexutil.Bytes, error)
 	// SignTypedData - request to sign the given structured data (plus prefix)
 	SignTypedData(ctx context.Context, addr common.MixedcaseAddress, data TypedData) (hexutil.Bytes, error)
+	// SignCrossTransaction - request to sign the cross chain transaction by an anchor
+	SignCrossTransaction(ctx context.Context, anchor common.MixedcaseAddress, args SendCtxArgs) (hexutil.Bytes, error)
 	// EcRecover - recover public key from given message and signature
 	EcRecover(ctx context.Context, data hexutil.Bytes, sig hexutil.Bytes) (common.Address, error)
 	// Version info about the APIs
@@ -71,6 +73,8 @@ type UIClientAPI interface {
 	ApproveTx(request *SignTxRequest) (SignTxResponse, error)
 	// ApproveSignData prompt the user for confirmation to request to sign data
 	ApproveSignData(request *SignDataRequest) (SignDataResponse, error)
+	// ApproveCtx prompt the user for confirmation to request to sign a cross chain transaction
+	ApproveCtx(request *SignCtxRequest) (SignCtxResponse, error)
 	// ApproveListing prompt the user for confirmation to list accounts
 	// the list of accounts to list can be modified by the UI
 	ApproveListing(request *ListRequest) (ListResponse, error)
@@ -240,6 +244,16 @@ type (
 	SignDataResponse struct {
 		Approved bool `json:"approved"`
 	}
+	// SignCtxRequest contains info about a cross chain transaction to sign by an anchor
+	SignCtxRequest struct {
+		Anchor      common.MixedcaseAddress `json:"anchor"`
+		Transaction SendCtxArgs             `json:"transaction"`
+		Hash        hexutil.Bytes           `json:"hash"`
+		Meta        Metadata                `json:"meta"`
+	}
+	SignCtxResponse struct {
+		Approved bool `json:"approved"`
+	}
 	NewAccountRequest struct {
 		Meta Metadata `json:"meta"`
 	}
@@ -544,8 +558,12 @@ func (api *SignerAPI) SignTransaction(ctx context.Context, args SendTxArgs, meth
 	if err != nil {
 		return nil, err
 	}
+	chainID := api.chainID
+	if result.Transaction.ChainID != nil {
+		chainID = (*big.Int)(result.Transaction.ChainID)
+	}
 	// The one to sign is the one that was returned from the UI
-	signedTx, err := wallet.SignTxWithPassphrase(acc, pw, unsignedTx, api.chainID)
+	signedTx, err := wallet.SignTxWithPassphrase(acc, pw, unsignedTx, chainID)
 	if err != nil {
 		api.UI.ShowError(err.Error())
 		return nil, err
@@ -75,6 +75,11 @@ func (ui *headlessUi) ApproveSignData(request *core.SignDataRequest) (core.SignD
 	return core.SignDataResponse{approved}, nil
 }
 
+func (ui *headlessUi) ApproveCtx(request *core.SignCtxRequest) (core.SignCtxResponse, error) {
+	approved := (<-ui.approveCh == "Y")
+	return core.SignCtxResponse{approved}, nil
+}
+
 func (ui *headlessUi) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
 	approval := <-ui.approveCh
 	//fmt.Printf("approval %s\n", approval)
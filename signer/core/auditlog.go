@@ -76,6 +76,14 @@ func (l *AuditLogger) SignTypedData(ctx context.Context, addr common.MixedcaseAd
 	return b, e
 }
 
+func (l *AuditLogger) SignCrossTransaction(ctx context.Context, anchor common.MixedcaseAddress, args SendCtxArgs) (hexutil.Bytes, error) {
+	l.log.Info("SignCrossTransaction", "type", "request", "metadata", MetadataFromContext(ctx).String(),
+		"anchor", anchor.String(), "ctx", args.String())
+	b, e := l.api.SignCrossTransaction(ctx, anchor, args)
+	l.log.Info("SignCrossTransaction", "type", "response", "data", common.Bytes2Hex(b), "error", e)
+	return b, e
+}
+
 func (l *AuditLogger) EcRecover(ctx context.Context, data hexutil.Bytes, sig hexutil.Bytes) (common.Address, error) {
 	l.log.Info("EcRecover", "type", "request", "metadata", MetadataFromContext(ctx).String(),
 		"data", common.Bytes2Hex(data), "sig", common.Bytes2Hex(sig))
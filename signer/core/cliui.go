@@ -110,6 +110,9 @@ func (ui *CommandlineUI) ApproveTx(request *SignTxRequest) (SignTxResponse, erro
 	fmt.Printf("gas:      %v (%v)\n", request.Transaction.Gas, uint64(request.Transaction.Gas))
 	fmt.Printf("gasprice: %v wei\n", request.Transaction.GasPrice.ToInt())
 	fmt.Printf("nonce:    %v (%v)\n", request.Transaction.Nonce, uint64(request.Transaction.Nonce))
+	if chainID := request.Transaction.ChainID; chainID != nil {
+		fmt.Printf("chainId:  %v\n", chainID.ToInt())
+	}
 	if request.Transaction.Data != nil {
 		d := *request.Transaction.Data
 		if len(d) > 0 {
@@ -155,6 +158,31 @@ func (ui *CommandlineUI) ApproveSignData(request *SignDataRequest) (SignDataResp
 	return SignDataResponse{true}, nil
 }
 
+// ApproveCtx prompt the user for confirmation to request to sign a cross chain transaction
+func (ui *CommandlineUI) ApproveCtx(request *SignCtxRequest) (SignCtxResponse, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	fmt.Printf("--------- Cross transaction request-------------\n")
+	fmt.Printf("anchor:   %s\n", request.Anchor.String())
+	fmt.Printf("ctxId:    %s\n", request.Transaction.CtxId.String())
+	fmt.Printf("txHash:   %s\n", request.Transaction.TxHash.String())
+	fmt.Printf("from:     %s\n", request.Transaction.From.String())
+	fmt.Printf("to:       %s\n", request.Transaction.To.String())
+	fmt.Printf("value:    %v wei (chain %v)\n", request.Transaction.Value.ToInt(), request.Transaction.ChainId.ToInt())
+	fmt.Printf("charge:   %v wei (chain %v)\n", request.Transaction.DestinationValue.ToInt(), request.Transaction.DestinationId.ToInt())
+	if len(request.Transaction.Input) > 0 {
+		fmt.Printf("input:    %v\n", hexutil.Encode(request.Transaction.Input))
+	}
+	fmt.Printf("sign hash:  %v\n", request.Hash)
+	fmt.Printf("-------------------------------------------\n")
+	showMetadata(request.Meta)
+	if !ui.confirm() {
+		return SignCtxResponse{false}, nil
+	}
+	return SignCtxResponse{true}, nil
+}
+
 // ApproveListing prompt the user for confirmation to list accounts
 // the list of accounts to list can be modified by the UI
 func (ui *CommandlineUI) ApproveListing(request *ListRequest) (ListResponse, error) {
@@ -0,0 +1,69 @@
+// Copyright 2020 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/simplechain-org/go-simplechain/accounts"
+	"github.com/simplechain-org/go-simplechain/common"
+	"github.com/simplechain-org/go-simplechain/common/hexutil"
+	"github.com/simplechain-org/go-simplechain/crypto"
+
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
+)
+
+// SignCrossTransaction signs a cross chain transaction with the anchor account. The request is
+// approved by the UI (or the rules) with the value and destination chain of the ctx before signing.
+//
+// The signature is in the [R || S || V] format where V is 0 or 1, the same as signing the hash directly.
+func (api *SignerAPI) SignCrossTransaction(ctx context.Context, anchor common.MixedcaseAddress, args SendCtxArgs) (hexutil.Bytes, error) {
+	data := cc.CtxSigningData(args.toCrossTransaction())
+	req := &SignCtxRequest{
+		Anchor:      anchor,
+		Transaction: args,
+		Hash:        crypto.Keccak256(data),
+		Meta:        MetadataFromContext(ctx),
+	}
+	// We make the request prior to looking up if we actually have the account, to prevent
+	// account-enumeration via the API
+	res, err := api.UI.ApproveCtx(req)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Approved {
+		return nil, ErrRequestDenied
+	}
+	account := accounts.Account{Address: anchor.Address()}
+	wallet, err := api.am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	pw, err := api.lookupOrQueryPassword(account.Address,
+		"Password for signing",
+		fmt.Sprintf("Please enter password for signing cross transaction with account %s", account.Address.Hex()))
+	if err != nil {
+		return nil, err
+	}
+	signature, err := wallet.SignDataWithPassphrase(account, pw, accounts.MimetypeCrossTransaction, data)
+	if err != nil {
+		api.UI.ShowError(err.Error())
+		return nil, err
+	}
+	return signature, nil
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/simplechain-org/go-simplechain/common"
 	"github.com/simplechain-org/go-simplechain/common/hexutil"
 	"github.com/simplechain-org/go-simplechain/core/types"
+	cc "github.com/simplechain-org/go-simplechain/cross/core"
 )
 
 type ValidationInfo struct {
@@ -76,6 +77,9 @@ type SendTxArgs struct {
 	// We accept "data" and "input" for backwards-compatibility reasons.
 	Data  *hexutil.Bytes `json:"data"`
 	Input *hexutil.Bytes `json:"input,omitempty"`
+	// ChainID is the chain to sign for, the chain of clef is used if nil.
+	// Anchors of cross chains send transactions to more than one chain.
+	ChainID *hexutil.Big `json:"chainId,omitempty"`
 }
 
 func (args SendTxArgs) String() string {
@@ -98,3 +102,30 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 	}
 	return types.NewTransaction(uint64(args.Nonce), args.To.Address(), (*big.Int)(&args.Value), (uint64)(args.Gas), (*big.Int)(&args.GasPrice), input)
 }
+
+// SendCtxArgs represents a cross chain transaction made in a chain, which is signed by anchors
+type SendCtxArgs struct {
+	CtxId            common.Hash             `json:"ctxId"`
+	TxHash           common.Hash             `json:"txHash"`
+	BlockHash        common.Hash             `json:"blockHash"`
+	From             common.MixedcaseAddress `json:"from"`
+	To               common.MixedcaseAddress `json:"to"`
+	Value            hexutil.Big             `json:"value"`
+	ChainId          hexutil.Big             `json:"chainId"` // chain in which the ctx is made
+	DestinationId    hexutil.Big             `json:"destinationId"`
+	DestinationValue hexutil.Big             `json:"destinationValue"`
+	Input            hexutil.Bytes           `json:"input"`
+}
+
+func (args SendCtxArgs) String() string {
+	s, err := json.Marshal(args)
+	if err == nil {
+		return string(s)
+	}
+	return err.Error()
+}
+
+func (args *SendCtxArgs) toCrossTransaction() *cc.CrossTransaction {
+	return cc.NewCrossTransaction((*big.Int)(&args.Value), (*big.Int)(&args.DestinationValue), (*big.Int)(&args.DestinationId),
+		args.CtxId, args.TxHash, args.BlockHash, args.From.Address(), args.To.Address(), args.Input)
+}
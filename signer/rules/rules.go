@@ -185,6 +185,19 @@ func (r *rulesetUI) ApproveSignData(request *core.SignDataRequest) (core.SignDat
 	return core.SignDataResponse{Approved: false}, err
 }
 
+func (r *rulesetUI) ApproveCtx(request *core.SignCtxRequest) (core.SignCtxResponse, error) {
+	jsonreq, err := json.Marshal(request)
+	approved, err := r.checkApproval("ApproveCtx", jsonreq, err)
+	if err != nil {
+		log.Info("Rule-based approval error, going to manual", "error", err)
+		return r.next.ApproveCtx(request)
+	}
+	if approved {
+		return core.SignCtxResponse{Approved: true}, nil
+	}
+	return core.SignCtxResponse{Approved: false}, err
+}
+
 // OnInputRequired not handled by rules
 func (r *rulesetUI) OnInputRequired(info core.UserInputRequest) (core.UserInputResponse, error) {
 	return r.next.OnInputRequired(info)
@@ -91,6 +91,10 @@ func (alwaysDenyUI) ApproveSignData(request *core.SignDataRequest) (core.SignDat
 	return core.SignDataResponse{Approved: false}, nil
 }
 
+func (alwaysDenyUI) ApproveCtx(request *core.SignCtxRequest) (core.SignCtxResponse, error) {
+	return core.SignCtxResponse{Approved: false}, nil
+}
+
 func (alwaysDenyUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
 	return core.ListResponse{Accounts: nil}, nil
 }
@@ -217,6 +221,11 @@ func (d *dummyUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataR
 	return core.SignDataResponse{}, core.ErrRequestDenied
 }
 
+func (d *dummyUI) ApproveCtx(request *core.SignCtxRequest) (core.SignCtxResponse, error) {
+	d.calls = append(d.calls, "ApproveCtx")
+	return core.SignCtxResponse{}, core.ErrRequestDenied
+}
+
 func (d *dummyUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
 	d.calls = append(d.calls, "ApproveListing")
 	return core.ListResponse{}, core.ErrRequestDenied
@@ -257,6 +266,7 @@ func TestForwarding(t *testing.T) {
 	}
 	r.ApproveSignData(nil)
 	r.ApproveTx(nil)
+	r.ApproveCtx(nil)
 	r.ApproveNewAccount(nil)
 	r.ApproveListing(nil)
 	r.ShowError("test")
@@ -265,7 +275,7 @@ func TestForwarding(t *testing.T) {
 	//This one is not forwarded
 	r.OnApprovedTx(ethapi.SignTransactionResult{})
 
-	expCalls := 6
+	expCalls := 7
 	if len(ui.calls) != expCalls {
 
 		t.Errorf("Expected %d forwarded calls, got %d: %s", expCalls, len(ui.calls), strings.Join(ui.calls, ","))
@@ -521,6 +531,11 @@ func (d *dontCallMe) ApproveSignData(request *core.SignDataRequest) (core.SignDa
 	return core.SignDataResponse{}, core.ErrRequestDenied
 }
 
+func (d *dontCallMe) ApproveCtx(request *core.SignCtxRequest) (core.SignCtxResponse, error) {
+	d.t.Fatalf("Did not expect next-handler to be called")
+	return core.SignCtxResponse{}, core.ErrRequestDenied
+}
+
 func (d *dontCallMe) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
 	d.t.Fatalf("Did not expect next-handler to be called")
 	return core.ListResponse{}, core.ErrRequestDenied
@@ -625,3 +640,56 @@ function ApproveSignData(r){
 		t.Fatalf("Expected approved")
 	}
 }
+
+func dummyCtx(value int64, destination int64) *core.SignCtxRequest {
+	anchor, _ := mixAddr("0x694267f14675d7e1b9494fd8d72fefe1755710fa")
+	from, _ := mixAddr("0x000000000000000000000000000000000000dead")
+	return &core.SignCtxRequest{
+		Anchor: *anchor,
+		Transaction: core.SendCtxArgs{
+			From:             *from,
+			To:               *from,
+			Value:            hexutil.Big(*big.NewInt(value)),
+			ChainId:          hexutil.Big(*big.NewInt(1)),
+			DestinationId:    hexutil.Big(*big.NewInt(destination)),
+			DestinationValue: hexutil.Big(*big.NewInt(value)),
+		},
+		Meta: core.Metadata{Remote: "remoteip", Local: "localip", Scheme: "inproc"},
+	}
+}
+
+func TestSignCtx(t *testing.T) {
+
+	js := `
+	function big(str){
+		if(str.slice(0,2) == "0x"){ return new BigNumber(str.slice(2),16)}
+		return new BigNumber(str)
+	}
+	function ApproveCtx(r){
+		var tx = r.transaction
+		if(big(tx.destinationId).eq(512) && big(tx.value).lte(1000)){
+			return "Approve"
+		}
+		return "Reject"
+	}`
+	r, err := initRuleEngine(js)
+	if err != nil {
+		t.Fatalf("Couldn't create evaluator %v", err)
+	}
+	for i, tt := range []struct {
+		value, destination int64
+		approved           bool
+	}{
+		{1000, 512, true},
+		{1001, 512, false},
+		{1000, 513, false},
+	} {
+		resp, err := r.ApproveCtx(dummyCtx(tt.value, tt.destination))
+		if err != nil {
+			t.Fatalf("test %d: unexpected error %v", i, err)
+		}
+		if resp.Approved != tt.approved {
+			t.Errorf("test %d: expected approved=%v, got %v", i, tt.approved, resp.Approved)
+		}
+	}
+}